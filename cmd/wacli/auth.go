@@ -20,8 +20,9 @@ func newAuthCmd(flags *rootFlags) *cobra.Command {
 	var downloadMedia bool
 
 	cmd := &cobra.Command{
-		Use:   "auth",
-		Short: "Authenticate with WhatsApp (QR) and bootstrap sync",
+		Use:     "auth",
+		Aliases: []string{"qr"}, // the command new users look for first
+		Short:   "Authenticate with WhatsApp (QR) and bootstrap sync",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer stop()
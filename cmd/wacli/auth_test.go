@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQRIsAuthAlias(t *testing.T) {
+	var flags rootFlags
+	root := newRootCmd(&flags)
+
+	cmd, rest, err := root.Find([]string{"qr", "--follow"})
+	if err != nil {
+		t.Fatalf("find qr: %v", err)
+	}
+	if cmd.Name() != "auth" || cmd.Parent() != root {
+		t.Fatalf("qr resolved to %q, want auth", cmd.CommandPath())
+	}
+	if err := cmd.ParseFlags(rest); err != nil {
+		t.Fatalf("qr does not take auth's flags: %v", err)
+	}
+	if follow, _ := cmd.Flags().GetBool("follow"); !follow {
+		t.Fatalf("--follow not parsed")
+	}
+
+	var buf bytes.Buffer
+	root = newRootCmd(&flags)
+	root.SetOut(&buf)
+	root.SetArgs([]string{"qr", "--help"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("qr --help: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Authenticate with WhatsApp (QR)") || !strings.Contains(buf.String(), "Aliases:") {
+		t.Fatalf("qr --help did not show auth:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/store"
+)
+
+var benchWords = []string{
+	"meeting", "lunch", "invoice", "tomorrow", "photo", "call", "deadline", "weekend",
+	"project", "coffee", "flight", "dinner", "report", "birthday", "budget", "train",
+}
+
+type benchOptions struct {
+	Messages int
+	Chats    int
+	Searches int
+}
+
+type benchReport struct {
+	Messages        int     `json:"messages"`
+	Chats           int     `json:"chats"`
+	InsertSeconds   float64 `json:"insert_seconds"`
+	MessagesPerSec  float64 `json:"messages_per_second"`
+	DBSizeBytes     int64   `json:"db_size_bytes"`
+	FTSEnabled      bool    `json:"fts_enabled"`
+	Searches        int     `json:"searches,omitempty"`
+	SearchP50Millis float64 `json:"search_p50_ms,omitempty"`
+	SearchP95Millis float64 `json:"search_p95_ms,omitempty"`
+	SearchP99Millis float64 `json:"search_p99_ms,omitempty"`
+}
+
+func newBenchCmd(flags *rootFlags) *cobra.Command {
+	opts := benchOptions{Searches: 200}
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Benchmark the message store against a temporary database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			rep, err := runBench(ctx, opts)
+			if err != nil {
+				return err
+			}
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, rep)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "MESSAGES\t%d (%d chats)\n", rep.Messages, rep.Chats)
+			fmt.Fprintf(w, "INSERT\t%.2fs (%.0f msg/s)\n", rep.InsertSeconds, rep.MessagesPerSec)
+			fmt.Fprintf(w, "DB SIZE\t%d bytes\n", rep.DBSizeBytes)
+			fmt.Fprintf(w, "FTS5\t%v\n", rep.FTSEnabled)
+			if rep.Searches > 0 {
+				fmt.Fprintf(w, "SEARCH\tp50 %.2fms  p95 %.2fms  p99 %.2fms (%d queries)\n", rep.SearchP50Millis, rep.SearchP95Millis, rep.SearchP99Millis, rep.Searches)
+			} else {
+				fmt.Fprintf(w, "SEARCH\tskipped (FTS5 not available)\n")
+			}
+			_ = w.Flush()
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&opts.Messages, "messages", 100000, "number of synthetic messages to insert")
+	cmd.Flags().IntVar(&opts.Chats, "chats", 1000, "number of synthetic chats")
+	return cmd
+}
+
+func runBench(ctx context.Context, opts benchOptions) (benchReport, error) {
+	if opts.Messages <= 0 || opts.Chats <= 0 {
+		return benchReport{}, fmt.Errorf("--messages and --chats must be positive")
+	}
+
+	dir, err := os.MkdirTemp("", "wacli-bench-")
+	if err != nil {
+		return benchReport{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bench.db")
+	db, err := store.Open(store.DefaultStoreOptions(path))
+	if err != nil {
+		return benchReport{}, err
+	}
+	defer db.Close()
+
+	rep := benchReport{Messages: opts.Messages, Chats: opts.Chats, FTSEnabled: db.HasFTS()}
+	base := time.Now().Add(-time.Duration(opts.Messages) * time.Second)
+
+	start := time.Now()
+	for i := 0; i < opts.Chats; i++ {
+		if _, _, err := db.UpsertChat(ctx, benchChatJID(i), "dm", fmt.Sprintf("Bench %d", i), "", time.Time{}); err != nil {
+			return benchReport{}, err
+		}
+	}
+	for i := 0; i < opts.Messages; i++ {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return benchReport{}, err
+			}
+		}
+		chat := benchChatJID(i % opts.Chats)
+		if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID:    chat,
+			MsgID:      fmt.Sprintf("bench-%d", i),
+			SenderJID:  chat,
+			SenderName: fmt.Sprintf("Bench %d", i%opts.Chats),
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+			Text:       benchText(i),
+		}); err != nil {
+			return benchReport{}, err
+		}
+	}
+	elapsed := time.Since(start)
+	rep.InsertSeconds = elapsed.Seconds()
+	if elapsed > 0 {
+		rep.MessagesPerSec = float64(opts.Messages) / elapsed.Seconds()
+	}
+
+	if rep.FTSEnabled && opts.Searches > 0 {
+		latencies := make([]time.Duration, 0, opts.Searches)
+		for i := 0; i < opts.Searches; i++ {
+			q := benchWords[i%len(benchWords)]
+			t0 := time.Now()
+			if _, err := db.SearchMessages(ctx, store.SearchMessagesParams{Query: q, Limit: 50}); err != nil {
+				return benchReport{}, err
+			}
+			latencies = append(latencies, time.Since(t0))
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		rep.Searches = len(latencies)
+		rep.SearchP50Millis = percentileMillis(latencies, 50)
+		rep.SearchP95Millis = percentileMillis(latencies, 95)
+		rep.SearchP99Millis = percentileMillis(latencies, 99)
+	}
+
+	if err := db.Close(); err != nil {
+		return benchReport{}, err
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if st, err := os.Stat(path + suffix); err == nil {
+			rep.DBSizeBytes += st.Size()
+		}
+	}
+	return rep, nil
+}
+
+func benchChatJID(i int) string {
+	return fmt.Sprintf("1555%07d@s.whatsapp.net", i)
+}
+
+func benchText(i int) string {
+	n := len(benchWords)
+	return fmt.Sprintf("%s about the %s %s #%d", benchWords[i%n], benchWords[(i/n)%n], benchWords[(i*7)%n], i)
+}
+
+// percentileMillis returns the p-th percentile of sorted durations in ms.
+func percentileMillis(sorted []time.Duration, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (len(sorted)*p + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	return float64(sorted[idx]) / float64(time.Millisecond)
+}
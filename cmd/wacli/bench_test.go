@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunBench(t *testing.T) {
+	rep, err := runBench(context.Background(), benchOptions{Messages: 50, Chats: 5, Searches: 10})
+	if err != nil {
+		t.Fatalf("runBench: %v", err)
+	}
+	if rep.Messages != 50 || rep.Chats != 5 {
+		t.Fatalf("unexpected counts: %+v", rep)
+	}
+	if rep.MessagesPerSec <= 0 || rep.DBSizeBytes <= 0 {
+		t.Fatalf("expected throughput and size to be reported: %+v", rep)
+	}
+	if rep.FTSEnabled && rep.Searches != 10 {
+		t.Fatalf("expected 10 searches with FTS, got %d", rep.Searches)
+	}
+}
+
+func TestPercentileMillis(t *testing.T) {
+	var ds []time.Duration
+	for i := 1; i <= 100; i++ {
+		ds = append(ds, time.Duration(i)*time.Millisecond)
+	}
+	if got := percentileMillis(ds, 50); got != 50 {
+		t.Fatalf("p50: got %v", got)
+	}
+	if got := percentileMillis(ds, 99); got != 99 {
+		t.Fatalf("p99: got %v", got)
+	}
+}
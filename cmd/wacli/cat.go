@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// errCatLimit stops EachMessage once --limit messages are printed.
+var errCatLimit = errors.New("limit reached")
+
+type catOptions struct {
+	chatJID string
+	asJSON  bool
+	noMedia bool
+	limit   int // 0 = all
+}
+
+type catMessageJSON struct {
+	ChatJID    string `json:"chat_jid"`
+	MsgID      string `json:"msg_id"`
+	Timestamp  string `json:"timestamp"`
+	SenderJID  string `json:"sender_jid,omitempty"`
+	SenderName string `json:"sender_name"`
+	FromMe     bool   `json:"from_me"`
+	Text       string `json:"text"`
+	MediaType  string `json:"media_type,omitempty"`
+}
+
+func newCatCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var format string
+	var noMedia bool
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "cat",
+		Short: "Print a chat's stored messages, oldest first",
+		Long: `Print every stored message of a chat in chronological order, one per
+line, for reading or piping into grep:
+
+  [2024-01-15 14:23:01] Alice: Hello there
+
+Times are local. Line breaks inside a message are shown as ⏎. With
+--format json, each line is a JSON object instead.
+
+Example:
+  wacli cat --chat 14155552671 | grep -i invoice`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if chat == "" {
+				return fmt.Errorf("--chat is required")
+			}
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unsupported format %q (use text or json)", format)
+			}
+			if limit < 0 {
+				return fmt.Errorf("--limit must not be negative")
+			}
+			chatJID, err := wa.ParseChatJID(chat)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			_, err = catMessages(ctx, a.DB(), os.Stdout, catOptions{
+				chatJID: chatJID.String(),
+				asJSON:  format == "json" || flags.asJSON,
+				noMedia: noMedia,
+				limit:   limit,
+			})
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "chat phone number or JID")
+	cmd.Flags().StringVar(&format, "format", "text", "text or json (newline-delimited)")
+	cmd.Flags().BoolVar(&noMedia, "no-media", false, "skip media messages")
+	cmd.Flags().IntVar(&limit, "limit", 0, "stop after N messages (0 = all)")
+	return cmd
+}
+
+// catMessages writes the messages of opts.chatJID to w, oldest first, and
+// returns how many it wrote.
+func catMessages(ctx context.Context, db *store.DB, w io.Writer, opts catOptions) (int, error) {
+	enc := json.NewEncoder(w)
+	n := 0
+	err := db.EachMessage(ctx, opts.chatJID, func(m store.Message) error {
+		if opts.noMedia && m.MediaType != "" {
+			return nil
+		}
+		if opts.limit > 0 && n >= opts.limit {
+			return errCatLimit
+		}
+		n++
+		return writeCatMessage(w, enc, m, catText(m), opts.asJSON, false)
+	})
+	if errors.Is(err, errCatLimit) {
+		err = nil
+	}
+	return n, err
+}
+
+// catText is the text cat shows for m; non-text messages fall back to
+// their display text ("Sent image", ...).
+func catText(m store.Message) string {
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.DisplayText
+}
+
+// writeCatMessage writes m with text as one line, or as JSON via enc. The
+// line names the chat too when withChat is set.
+func writeCatMessage(w io.Writer, enc *json.Encoder, m store.Message, text string, asJSON, withChat bool) error {
+	if asJSON {
+		return enc.Encode(catMessageJSON{
+			ChatJID:    m.ChatJID,
+			MsgID:      m.MsgID,
+			Timestamp:  m.Timestamp.UTC().Format(time.RFC3339),
+			SenderJID:  m.SenderJID,
+			SenderName: catSender(m),
+			FromMe:     m.FromMe,
+			Text:       text,
+			MediaType:  m.MediaType,
+		})
+	}
+	from := catSender(m)
+	if withChat {
+		chat := m.ChatName
+		if chat == "" {
+			chat = m.ChatJID
+		}
+		from = chat + " / " + from
+	}
+	_, err := fmt.Fprintf(w, "[%s] %s: %s\n",
+		m.Timestamp.Local().Format("2006-01-02 15:04:05"),
+		from,
+		strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", " ⏎ "),
+	)
+	return err
+}
+
+func catSender(m store.Message) string {
+	switch {
+	case m.FromMe:
+		return "me"
+	case m.SenderName != "":
+		return m.SenderName
+	case m.SenderJID != "":
+		return m.SenderJID
+	default:
+		return m.ChatJID
+	}
+}
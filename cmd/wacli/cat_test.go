@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestCatMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "15551234567@s.whatsapp.net"
+	base := time.Date(2024, 1, 15, 14, 23, 1, 0, time.Local)
+	seedChat(t, db, chat, "dm", "Alice", base)
+	// Inserted out of order; cat sorts by time.
+	for _, p := range []store.UpsertMessageParams{
+		{MsgID: "m3", Timestamp: base.Add(2 * time.Minute), FromMe: true, Text: "two\nlines"},
+		{MsgID: "m1", Timestamp: base, SenderJID: chat, SenderName: "Alice", Text: "Hello there"},
+		{MsgID: "m2", Timestamp: base.Add(time.Minute), SenderJID: chat, MediaType: "image", DisplayText: "Sent image"},
+	} {
+		p.ChatJID = chat
+		if _, _, err := db.UpsertMessage(ctx, p); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := catMessages(ctx, db, &buf, catOptions{chatJID: chat})
+	if err != nil || n != 3 {
+		t.Fatalf("catMessages: %d, %v", n, err)
+	}
+	want := "[2024-01-15 14:23:01] Alice: Hello there\n" +
+		"[2024-01-15 14:24:01] " + chat + ": Sent image\n" +
+		"[2024-01-15 14:25:01] me: two ⏎ lines\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if n, err := catMessages(ctx, db, &buf, catOptions{chatJID: chat, noMedia: true, limit: 1}); err != nil || n != 1 {
+		t.Fatalf("catMessages limit: %d, %v", n, err)
+	}
+	if buf.String() != "[2024-01-15 14:23:01] Alice: Hello there\n" {
+		t.Fatalf("limit output = %q", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := catMessages(ctx, db, &buf, catOptions{chatJID: chat, asJSON: true, noMedia: true}); err != nil {
+		t.Fatalf("catMessages json: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d: %s", len(lines), buf.String())
+	}
+	var last catMessageJSON
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if last.MsgID != "m3" || !last.FromMe || last.Text != "two\nlines" || last.SenderName != "me" {
+		t.Fatalf("last = %+v", last)
+	}
+}
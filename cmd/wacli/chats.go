@@ -37,7 +37,7 @@ func newChatsListCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			chats, err := a.DB().ListChats(query, limit)
+			chats, err := a.DB().ListChats(ctx, query, limit)
 			if err != nil {
 				return err
 			}
@@ -52,7 +52,7 @@ func newChatsListCmd(flags *rootFlags) *cobra.Command {
 				if name == "" {
 					name = c.JID
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Kind, truncate(name, 28), c.JID, c.LastMessageTS.Local().Format("2006-01-02 15:04:05"))
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Kind, out.Truncate(name, 28), c.JID, c.LastMessageTS.Local().Format("2006-01-02 15:04:05"))
 			}
 			_ = w.Flush()
 			return nil
@@ -81,7 +81,7 @@ func newChatsShowCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			c, err := a.DB().GetChat(jid)
+			c, err := a.DB().GetChat(ctx, jid)
 			if err != nil {
 				return err
 			}
@@ -89,6 +89,12 @@ func newChatsShowCmd(flags *rootFlags) *cobra.Command {
 				return out.WriteJSON(os.Stdout, c)
 			}
 			fmt.Fprintf(os.Stdout, "JID: %s\nKind: %s\nName: %s\nLast: %s\n", c.JID, c.Kind, c.Name, c.LastMessageTS.Local().Format(time.RFC3339))
+			if c.ParticipantsCount != nil {
+				fmt.Fprintf(os.Stdout, "Participants: %d\n", *c.ParticipantsCount)
+			}
+			if c.Description != "" {
+				fmt.Fprintf(os.Stdout, "Description: %s\n", c.Description)
+			}
 			return nil
 		},
 	}
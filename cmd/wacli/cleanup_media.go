@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/store"
+)
+
+type cleanupMediaOptions struct {
+	mediaDir  string // only files under it are deleted
+	olderThan time.Time
+	dryRun    bool
+	asJSON    bool
+}
+
+type cleanupMediaFile struct {
+	Chat    string `json:"chat"`
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+	Bytes   int64  `json:"bytes"`
+	Missing bool   `json:"missing,omitempty"` // already gone from disk
+	Kept    bool   `json:"kept,omitempty"`    // outside the media dir; only forgotten
+}
+
+type cleanupMediaResult struct {
+	DryRun  bool               `json:"dry_run"`
+	Deleted int                `json:"deleted"`
+	Missing int                `json:"missing"`
+	Kept    int                `json:"kept"`
+	Bytes   int64              `json:"bytes"`
+	Files   []cleanupMediaFile `json:"files"`
+}
+
+func newCleanupMediaCmd(flags *rootFlags) *cobra.Command {
+	var olderThan string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "cleanup-media",
+		Short: "Delete downloaded media files older than a given age",
+		Long: `Delete media files downloaded more than --older-than ago and forget their
+local paths; the media can still be downloaded again later.
+
+--older-than takes days (30d) or a Go duration (12h). Only files in the
+store's media dir are deleted; files saved elsewhere (media download
+--output) and files already missing are just forgotten. --dry-run only lists
+what would be done.
+
+Examples:
+  wacli cleanup-media --older-than 30d --dry-run
+  wacli cleanup-media --older-than 90d`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return fmt.Errorf("invalid --older-than: %w", err)
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+			a, lk, err := newApp(ctx, flags, !dryRun, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			return cleanupMedia(ctx, a.DB(), os.Stdout, cleanupMediaOptions{
+				mediaDir:  filepath.Join(a.StoreDir(), "media"),
+				olderThan: time.Now().Add(-age),
+				dryRun:    dryRun,
+				asJSON:    flags.asJSON,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "minimum age of the download, e.g. 30d or 12h")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the files without deleting them")
+	_ = cmd.MarkFlagRequired("older-than")
+	return cmd
+}
+
+// parseAge parses a positive age given in days ("30d") or as a Go duration.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number of days", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q must be positive", s)
+	}
+	return d, nil
+}
+
+// cleanupMedia deletes media downloaded before opts.olderThan and clears
+// their local paths, then reports the counts to w.
+func cleanupMedia(ctx context.Context, db *store.DB, w io.Writer, opts cleanupMediaOptions) error {
+	infos, err := db.ListMediaMetadata(ctx, opts.olderThan)
+	if err != nil {
+		return err
+	}
+
+	res := cleanupMediaResult{DryRun: opts.dryRun, Files: []cleanupMediaFile{}}
+	for _, info := range infos {
+		f := cleanupMediaFile{Chat: info.ChatJID, ID: info.MsgID, Path: info.LocalPath}
+		st, err := os.Lstat(info.LocalPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			f.Missing = true
+		case err != nil:
+			return err
+		case !st.Mode().IsRegular():
+			// Never delete anything but a file we wrote.
+			continue
+		case !pathWithin(info.LocalPath, opts.mediaDir):
+			f.Kept = true
+		default:
+			f.Bytes = st.Size()
+		}
+
+		if !opts.dryRun {
+			if !f.Missing && !f.Kept {
+				if err := os.Remove(info.LocalPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+			}
+			if err := db.ClearMediaDownloaded(ctx, info.ChatJID, info.MsgID); err != nil {
+				return err
+			}
+		}
+		switch {
+		case f.Missing:
+			res.Missing++
+		case f.Kept:
+			res.Kept++
+		default:
+			res.Deleted++
+			res.Bytes += f.Bytes
+		}
+		res.Files = append(res.Files, f)
+	}
+
+	if opts.asJSON {
+		return out.WriteJSON(w, res)
+	}
+	verb := "Deleted"
+	if opts.dryRun {
+		verb = "Would delete"
+		for _, f := range res.Files {
+			switch {
+			case f.Missing:
+				fmt.Fprintf(w, "%s (missing)\n", f.Path)
+			case f.Kept:
+				fmt.Fprintf(w, "%s (outside the media dir, kept)\n", f.Path)
+			default:
+				fmt.Fprintf(w, "%s (%d bytes)\n", f.Path, f.Bytes)
+			}
+		}
+	}
+	fmt.Fprintf(w, "%s %d files, %d bytes", verb, res.Deleted, res.Bytes)
+	if res.Missing > 0 {
+		fmt.Fprintf(w, " (%d already missing)", res.Missing)
+	}
+	if res.Kept > 0 {
+		forgot := "forgot"
+		if opts.dryRun {
+			forgot = "would forget"
+		}
+		fmt.Fprintf(w, "; %s %d files outside the media dir", forgot, res.Kept)
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+// pathWithin reports whether path resolves to a location inside dir, after
+// following symlinks in both.
+func pathWithin(path, dir string) bool {
+	resolve := func(p string) (string, bool) {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "", false
+		}
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			return real, true
+		}
+		if real, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+			return filepath.Join(real, filepath.Base(abs)), true
+		}
+		return abs, true
+	}
+	p, ok := resolve(path)
+	if !ok {
+		return false
+	}
+	d, ok := resolve(dir)
+	if !ok {
+		return false
+	}
+	rel, err := filepath.Rel(d, p)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestCleanupMedia(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	mediaDir := t.TempDir()
+	userDir := t.TempDir() // e.g. media download --output ~/Documents
+	chat := "15551234567@s.whatsapp.net"
+	now := time.Now()
+	seedChat(t, db, chat, "dm", "Alice", now)
+	add := func(dir, id string, size int, downloaded time.Time, onDisk bool) string {
+		t.Helper()
+		path := filepath.Join(dir, id+".jpg")
+		if onDisk {
+			if err := os.WriteFile(path, make([]byte, size), 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+		if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID: chat, MsgID: id, SenderJID: chat, Timestamp: downloaded, MediaType: "image",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+		if err := db.MarkMediaDownloaded(ctx, chat, id, path, downloaded); err != nil {
+			t.Fatalf("MarkMediaDownloaded: %v", err)
+		}
+		return path
+	}
+	old1 := add(mediaDir, "old1", 100, now.Add(-40*24*time.Hour), true)
+	old2 := add(mediaDir, "old2", 50, now.Add(-31*24*time.Hour), true)
+	add(mediaDir, "gone", 0, now.Add(-60*24*time.Hour), false)
+	recent := add(mediaDir, "recent", 10, now.Add(-24*time.Hour), true)
+	outside := add(userDir, "outside", 70, now.Add(-90*24*time.Hour), true)
+
+	opts := cleanupMediaOptions{mediaDir: mediaDir, olderThan: now.Add(-30 * 24 * time.Hour), dryRun: true}
+	var buf bytes.Buffer
+	if err := cleanupMedia(ctx, db, &buf, opts); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	for _, want := range []string{old1 + " (100 bytes)", old2 + " (50 bytes)", "(missing)", outside + " (outside the media dir, kept)",
+		"Would delete 2 files, 150 bytes (1 already missing); would forget 1 files outside the media dir"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("dry run output missing %q:\n%s", want, buf.String())
+		}
+	}
+	if _, err := os.Stat(old1); err != nil {
+		t.Fatalf("dry run removed a file: %v", err)
+	}
+
+	buf.Reset()
+	opts.dryRun = false
+	if err := cleanupMedia(ctx, db, &buf, opts); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if got := buf.String(); got != "Deleted 2 files, 150 bytes (1 already missing); forgot 1 files outside the media dir\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+	for _, p := range []string{old1, old2} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s still exists (err %v)", p, err)
+		}
+	}
+	for _, p := range []string{recent, outside} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s removed: %v", p, err)
+		}
+	}
+	for _, id := range []string{"old1", "old2", "gone", "outside"} {
+		info, err := db.GetMediaDownloadInfo(ctx, chat, id)
+		if err != nil {
+			t.Fatalf("GetMediaDownloadInfo: %v", err)
+		}
+		if info.LocalPath != "" || !info.DownloadedAt.IsZero() {
+			t.Errorf("%s still marked downloaded: %+v", id, info)
+		}
+	}
+	if info, _ := db.GetMediaDownloadInfo(ctx, chat, "recent"); info.LocalPath != recent {
+		t.Errorf("recent lost its local path: %q", info.LocalPath)
+	}
+
+	// Nothing left to clean.
+	buf.Reset()
+	if err := cleanupMedia(ctx, db, &buf, opts); err != nil {
+		t.Fatalf("second cleanup: %v", err)
+	}
+	if got := buf.String(); got != "Deleted 0 files, 0 bytes\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "12h": 12 * time.Hour, " 1d ": 24 * time.Hour} {
+		if got, err := parseAge(in); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-1h", "xd", "30"} {
+		if _, err := parseAge(in); err == nil {
+			t.Errorf("parseAge(%q): expected an error", in)
+		}
+	}
+}
+
+func TestPathWithin(t *testing.T) {
+	mediaDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(mediaDir, "link")); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+	for path, want := range map[string]bool{
+		filepath.Join(mediaDir, "chat", "a.jpg"):   true,
+		mediaDir:                                   false,
+		filepath.Join(mediaDir, "..", "a.jpg"):     false,
+		filepath.Join(outside, "a.jpg"):            false,
+		filepath.Join(mediaDir, "link", "a.jpg"):   false,
+		filepath.Join(mediaDir, "..link", "a.jpg"): true,
+	} {
+		if got := pathWithin(path, mediaDir); got != want {
+			t.Errorf("pathWithin(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/steipete/wacli/internal/config"
+	"github.com/steipete/wacli/internal/logging"
+)
+
+// configPathEnv names the config file when --config is not given.
+const configPathEnv = "WACLI_CONFIG"
+
+// logLevelKey is the one config key that is not a flag; WACLI_LOG overrides it.
+const logLevelKey = "log_level"
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the YAML config file",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "init",
+		Short: "Print a template config file",
+		Long: `Print a commented template config to stdout, e.g.
+  wacli config init > ~/.wacli/config.yaml
+  wacli --config ~/.wacli/config.yaml rpc --sync`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := fmt.Fprint(os.Stdout, config.Template)
+			return err
+		},
+	})
+	return cmd
+}
+
+// loadConfig applies the config file (from --config or WACLI_CONFIG) and
+// WACLI_<KEY> environment variables to cmd's flags.
+func loadConfig(cmd *cobra.Command, path string) error {
+	if !cmd.Flags().Changed("config") {
+		path = os.Getenv(configPathEnv)
+	}
+	values := map[string]string{}
+	if path != "" {
+		var err error
+		if values, err = config.LoadFile(path); err != nil {
+			return err
+		}
+		for _, key := range unknownConfigKeys(cmd.Root(), values) {
+			logging.Warn().Str("key", key).Str("config", path).Msg("ignoring unknown config key")
+		}
+	}
+	if level, ok := values[logLevelKey]; ok && os.Getenv("WACLI_LOG") == "" {
+		logging.SetLevel(level)
+	}
+	return applyConfig(cmd.Flags(), values, os.LookupEnv)
+}
+
+// applyConfig sets every flag in fs from, in order of precedence, its
+// WACLI_<KEY> environment variable, the command line, or the config values.
+func applyConfig(fs *pflag.FlagSet, values map[string]string, lookupEnv func(string) (string, bool)) error {
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Name == "config" || f.Name == "help" {
+			return
+		}
+		key := configKey(f.Name)
+		val, ok := lookupEnv(configEnv(key))
+		if !ok {
+			if f.Changed {
+				return
+			}
+			if val, ok = values[key]; !ok {
+				return
+			}
+		}
+		if err = setFlag(fs, f, val); err != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", val, key, err)
+		}
+	})
+	return err
+}
+
+// setFlag replaces f's value. Slice flags append on a second Set, so an
+// environment variable overriding a command-line slice replaces it instead.
+func setFlag(fs *pflag.FlagSet, f *pflag.Flag, val string) error {
+	if sv, ok := f.Value.(pflag.SliceValue); ok && f.Changed {
+		var items []string
+		if val != "" {
+			items = strings.Split(val, ",")
+		}
+		return sv.Replace(items)
+	}
+	return fs.Set(f.Name, val)
+}
+
+// unknownConfigKeys returns the keys that match no flag of any command.
+func unknownConfigKeys(root *cobra.Command, values map[string]string) []string {
+	known := map[string]bool{logLevelKey: true}
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		mark := func(f *pflag.Flag) { known[configKey(f.Name)] = true }
+		c.Flags().VisitAll(mark)
+		c.PersistentFlags().VisitAll(mark)
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+
+	var unknown []string
+	for k := range values {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func configKey(flagName string) string {
+	return strings.ReplaceAll(flagName, "-", "_")
+}
+
+func configEnv(key string) string {
+	return "WACLI_" + strings.ToUpper(key)
+}
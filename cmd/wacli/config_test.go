@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/steipete/wacli/internal/config"
+)
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		config map[string]string
+		env    map[string]string
+		want   string
+	}{
+		{name: "default", want: "localhost:5555"},
+		{name: "config", config: map[string]string{"addr": "cfg:1"}, want: "cfg:1"},
+		{name: "flag over config", args: []string{"--addr", "flag:1"}, config: map[string]string{"addr": "cfg:1"}, want: "flag:1"},
+		{name: "env over config", config: map[string]string{"addr": "cfg:1"}, env: map[string]string{"WACLI_ADDR": "env:1"}, want: "env:1"},
+		{name: "env over flag", args: []string{"--addr", "flag:1"}, config: map[string]string{"addr": "cfg:1"}, env: map[string]string{"WACLI_ADDR": "env:1"}, want: "env:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			addr := fs.String("addr", "localhost:5555", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			lookup := func(k string) (string, bool) { v, ok := tt.env[k]; return v, ok }
+			if err := applyConfig(fs, tt.config, lookup); err != nil {
+				t.Fatalf("applyConfig: %v", err)
+			}
+			if *addr != tt.want {
+				t.Fatalf("addr = %q, want %q", *addr, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyConfigTypedFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	timeout := fs.Duration("timeout", time.Minute, "")
+	asJSON := fs.Bool("json", false, "")
+	cidrs := fs.StringSlice("proxy-trusted-cidrs", nil, "")
+	if err := fs.Parse([]string{"--proxy-trusted-cidrs", "10.0.0.0/8"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	values := map[string]string{"timeout": "30s", "json": "true", "proxy_trusted_cidrs": "192.168.0.0/16"}
+	env := map[string]string{"WACLI_PROXY_TRUSTED_CIDRS": "172.16.0.0/12,127.0.0.0/8"}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	if err := applyConfig(fs, values, lookup); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	if *timeout != 30*time.Second || !*asJSON {
+		t.Fatalf("timeout = %v, json = %v", *timeout, *asJSON)
+	}
+	if want := []string{"172.16.0.0/12", "127.0.0.0/8"}; !reflect.DeepEqual(*cidrs, want) {
+		t.Fatalf("cidrs = %v, want %v", *cidrs, want)
+	}
+
+	fresh := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fresh.Bool("json", false, "")
+	if err := applyConfig(fresh, map[string]string{"json": "maybe"}, func(string) (string, bool) { return "", false }); err == nil {
+		t.Fatal("expected an error for an invalid bool")
+	}
+}
+
+func TestLoadConfigFileAndUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "addr: 0.0.0.0:8080\nlog_level: warn\nproxy_trusted_cidrs: [10.0.0.0/8, 172.16.0.0/12]\nendpoint_timeouts:\n  /send: 30s\n  /ping: 1s\nbogus: 1\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	values, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if values["proxy_trusted_cidrs"] != "10.0.0.0/8,172.16.0.0/12" || values["endpoint_timeouts"] != "/ping=1s,/send=30s" {
+		t.Fatalf("values = %v", values)
+	}
+
+	root := &cobra.Command{Use: "wacli"}
+	root.PersistentFlags().String("store", "", "")
+	rpcCmd := &cobra.Command{Use: "rpc"}
+	rpcCmd.Flags().String("addr", "", "")
+	rpcCmd.Flags().StringSlice("proxy-trusted-cidrs", nil, "")
+	rpcCmd.Flags().StringToString("endpoint-timeouts", nil, "")
+	root.AddCommand(rpcCmd)
+	if got := unknownConfigKeys(root, values); !reflect.DeepEqual(got, []string{"bogus"}) {
+		t.Fatalf("unknown keys = %v, want [bogus]", got)
+	}
+}
@@ -40,7 +40,7 @@ func newContactsSearchCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			cs, err := a.DB().SearchContacts(args[0], limit)
+			cs, err := a.DB().SearchContacts(ctx, args[0], limit)
 			if err != nil {
 				return err
 			}
@@ -53,9 +53,9 @@ func newContactsSearchCmd(flags *rootFlags) *cobra.Command {
 			fmt.Fprintln(w, "ALIAS\tNAME\tPHONE\tJID")
 			for _, c := range cs {
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
-					truncate(c.Alias, 18),
-					truncate(c.Name, 24),
-					truncate(c.Phone, 14),
+					out.Truncate(c.Alias, 18),
+					out.Truncate(c.Name, 24),
+					out.Truncate(c.Phone, 14),
 					c.JID,
 				)
 			}
@@ -85,7 +85,7 @@ func newContactsShowCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			c, err := a.DB().GetContact(jid)
+			c, err := a.DB().GetContact(ctx, jid)
 			if err != nil {
 				return err
 			}
@@ -138,7 +138,7 @@ func newContactsRefreshCmd(flags *rootFlags) *cobra.Command {
 
 			var count int
 			for jid, info := range cs {
-				_ = a.DB().UpsertContact(
+				_ = a.DB().UpsertContact(ctx,
 					jid.String(),
 					jid.User,
 					info.PushName,
@@ -180,7 +180,7 @@ func newContactsAliasCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			defer closeApp(a, lk)
-			if err := a.DB().SetAlias(jid, alias); err != nil {
+			if err := a.DB().SetAlias(ctx, jid, alias); err != nil {
 				return err
 			}
 			if flags.asJSON {
@@ -205,7 +205,7 @@ func newContactsAliasCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			defer closeApp(a, lk)
-			if err := a.DB().RemoveAlias(jid); err != nil {
+			if err := a.DB().RemoveAlias(ctx, jid); err != nil {
 				return err
 			}
 			if flags.asJSON {
@@ -242,7 +242,7 @@ func newContactsTagsCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			defer closeApp(a, lk)
-			if err := a.DB().AddTag(jid, tag); err != nil {
+			if err := a.DB().AddTag(ctx, jid, tag); err != nil {
 				return err
 			}
 			if flags.asJSON {
@@ -268,7 +268,7 @@ func newContactsTagsCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			defer closeApp(a, lk)
-			if err := a.DB().RemoveTag(jid, tag); err != nil {
+			if err := a.DB().RemoveTag(ctx, jid, tag); err != nil {
 				return err
 			}
 			if flags.asJSON {
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// countDayWindow is how far back --by day counts.
+const countDayWindow = 30 * 24 * time.Hour
+
+type countOptions struct {
+	chatJID string // empty = all chats
+	by      string // empty = one total
+	asJSON  bool
+	now     time.Time
+}
+
+type countJSON struct {
+	Key   string `json:"key"`
+	Name  string `json:"name,omitempty"`
+	Count int64  `json:"count"`
+}
+
+func newCountCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var by string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "count",
+		Short: "Count stored messages, optionally grouped",
+		Long: `Count the stored messages of all chats, or of one chat with --chat.
+
+Without --by, print the total. --by sender lists senders most active
+first; --by day covers the last 30 days, and --by week and month all time
+(weeks start on Monday, in local time).
+
+Examples:
+  wacli count
+  wacli count --chat 14155552671 --by sender
+  wacli count --by month --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch by {
+			case "", store.CountBySender, store.CountByDay, store.CountByWeek, store.CountByMonth:
+			default:
+				return fmt.Errorf("unsupported --by %q (use sender, day, week or month)", by)
+			}
+			if format != "table" && format != "json" {
+				return fmt.Errorf("unsupported format %q (use table or json)", format)
+			}
+			opts := countOptions{by: by, asJSON: format == "json" || flags.asJSON, now: time.Now()}
+			if chat != "" {
+				chatJID, err := wa.ParseChatJID(chat)
+				if err != nil {
+					return err
+				}
+				opts.chatJID = chatJID.String()
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+			return countMessages(ctx, a.DB(), os.Stdout, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "only this chat (phone number or JID)")
+	cmd.Flags().StringVar(&by, "by", "", "group by sender, day, week or month")
+	cmd.Flags().StringVar(&format, "format", "table", "table or json")
+	return cmd
+}
+
+// countMessages writes the total, or the grouped counts, to w.
+func countMessages(ctx context.Context, db *store.DB, w io.Writer, opts countOptions) error {
+	if opts.by == "" {
+		var total int64
+		var err error
+		if opts.chatJID != "" {
+			total, err = db.CountChatMessages(ctx, opts.chatJID)
+		} else {
+			total, err = db.CountMessages(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		if opts.asJSON {
+			return out.WriteJSON(w, map[string]int64{"total": total})
+		}
+		_, err = fmt.Fprintln(w, total)
+		return err
+	}
+
+	var since time.Time
+	if opts.by == store.CountByDay {
+		since = opts.now.Add(-countDayWindow)
+	}
+	counts, err := db.CountMessagesBy(ctx, opts.chatJID, opts.by, since)
+	if err != nil {
+		return err
+	}
+	if opts.asJSON {
+		rows := make([]countJSON, 0, len(counts))
+		for _, c := range counts {
+			key := c.Key
+			if opts.by == store.CountBySender && key == "" {
+				key = "me"
+			}
+			rows = append(rows, countJSON{Key: key, Name: c.Name, Count: c.Count})
+		}
+		return out.WriteJSON(w, rows)
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 4, 2, ' ', 0)
+	if opts.by == store.CountBySender {
+		fmt.Fprintln(tw, "SENDER\tJID\tCOUNT")
+		for _, c := range counts {
+			name, jid := c.Name, c.Key
+			if jid == "" {
+				name = "me"
+			} else if name == "" {
+				name = jid
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", out.Truncate(name, 28), jid, c.Count)
+		}
+	} else {
+		header := "DATE"
+		switch opts.by {
+		case store.CountByWeek:
+			header = "WEEK OF"
+		case store.CountByMonth:
+			header = "MONTH"
+		}
+		fmt.Fprintf(tw, "%s\tCOUNT\n", header)
+		for _, c := range counts {
+			fmt.Fprintf(tw, "%s\t%d\n", c.Key, c.Count)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestCountMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	group := "123@g.us"
+	alice := "15551234567@s.whatsapp.net"
+	bob := "15557654321@s.whatsapp.net"
+	// Midday, so local-time bucketing can't shift a message to another day.
+	wed := time.Date(2024, 1, 17, 12, 0, 0, 0, time.Local) // week of Mon Jan 15
+	seedChat(t, db, group, "group", "Team", wed)
+	seedChat(t, db, alice, "dm", "Alice", wed)
+	n := 0
+	add := func(chat, sender, name string, fromMe bool, ts time.Time) {
+		t.Helper()
+		n++
+		if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID: chat, MsgID: fmt.Sprintf("m%d", n), SenderJID: sender, SenderName: name,
+			FromMe: fromMe, Timestamp: ts, Text: "x",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	add(group, alice, "Alice", false, wed)
+	add(group, alice, "Alice", false, wed.AddDate(0, 0, 1))
+	add(group, alice, "Alice", false, wed.AddDate(0, 0, 5)) // Mon Jan 22
+	add(group, bob, "Bob", false, wed)
+	add(group, "", "", true, wed.AddDate(0, -1, 0))
+	add(alice, alice, "Alice", false, wed)
+
+	run := func(opts countOptions) string {
+		t.Helper()
+		opts.now = wed.AddDate(0, 0, 10)
+		var buf bytes.Buffer
+		if err := countMessages(ctx, db, &buf, opts); err != nil {
+			t.Fatalf("countMessages(%+v): %v", opts, err)
+		}
+		return buf.String()
+	}
+	decode := func(s string) []countJSON {
+		t.Helper()
+		var env struct{ Data []countJSON }
+		if err := json.Unmarshal([]byte(s), &env); err != nil {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+		return env.Data
+	}
+
+	if got := run(countOptions{}); got != "6\n" {
+		t.Fatalf("total = %q", got)
+	}
+	if got := run(countOptions{chatJID: group}); got != "5\n" {
+		t.Fatalf("group total = %q", got)
+	}
+
+	got := decode(run(countOptions{chatJID: group, by: store.CountBySender, asJSON: true}))
+	// Ties are ordered by JID, ours ('') first.
+	want := []countJSON{{Key: alice, Name: "Alice", Count: 3}, {Key: "me", Count: 1}, {Key: bob, Name: "Bob", Count: 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("by sender = %v, want %v", got, want)
+	}
+
+	// The message from a month earlier is outside the 30-day window.
+	got = decode(run(countOptions{chatJID: group, by: store.CountByDay, asJSON: true}))
+	want = []countJSON{{Key: "2024-01-17", Count: 2}, {Key: "2024-01-18", Count: 1}, {Key: "2024-01-22", Count: 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("by day = %v, want %v", got, want)
+	}
+
+	got = decode(run(countOptions{chatJID: group, by: store.CountByWeek, asJSON: true}))
+	want = []countJSON{{Key: "2023-12-11", Count: 1}, {Key: "2024-01-15", Count: 3}, {Key: "2024-01-22", Count: 1}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("by week = %v, want %v", got, want)
+	}
+
+	got = decode(run(countOptions{by: store.CountByMonth, asJSON: true}))
+	want = []countJSON{{Key: "2023-12", Count: 1}, {Key: "2024-01", Count: 5}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("by month = %v, want %v", got, want)
+	}
+
+	table := run(countOptions{chatJID: group, by: store.CountBySender})
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "SENDER") || !strings.HasPrefix(lines[1], "Alice") || !strings.HasSuffix(lines[1], " 3") || !strings.HasPrefix(lines[2], "me ") {
+		t.Fatalf("unexpected table:\n%s", table)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+)
+
+func newDBCmd(flags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Inspect the local message database",
+	}
+	cmd.AddCommand(newDBInfoCmd(flags))
+	return cmd
+}
+
+func newDBInfoCmd(flags *rootFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "info",
+		Short: "Show database path, size and search capabilities",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, false, true)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			type info struct {
+				Path       string `json:"path"`
+				SizeBytes  int64  `json:"size_bytes"`
+				Messages   int64  `json:"messages"`
+				FTSEnabled bool   `json:"fts_enabled"`
+			}
+
+			db := a.DB()
+			rep := info{Path: db.Path(), FTSEnabled: db.HasFTS()}
+			if st, err := os.Stat(rep.Path); err == nil {
+				rep.SizeBytes = st.Size()
+			}
+			if rep.Messages, err = db.CountMessages(ctx); err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, rep)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "PATH\t%s\n", rep.Path)
+			fmt.Fprintf(w, "SIZE\t%d bytes\n", rep.SizeBytes)
+			fmt.Fprintf(w, "MESSAGES\t%d\n", rep.Messages)
+			fmt.Fprintf(w, "FTS5\t%v\n", rep.FTSEnabled)
+			_ = w.Flush()
+			return nil
+		},
+	}
+}
@@ -77,7 +77,10 @@ func newDoctorCmd(flags *rootFlags) *cobra.Command {
 			}
 
 			if flags.asJSON {
-				return out.WriteJSON(os.Stdout, rep)
+				if err := out.WriteJSON(os.Stdout, rep); err != nil {
+					return err
+				}
+				return doctorFTSError(rep.FTSEnabled)
 			}
 
 			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
@@ -94,10 +97,18 @@ func newDoctorCmd(flags *rootFlags) *cobra.Command {
 			if rep.LockHeld {
 				fmt.Fprintln(os.Stdout, "\nTip: stop the running `wacli sync` before running write operations.")
 			}
-			return nil
+			return doctorFTSError(rep.FTSEnabled)
 		},
 	}
 
 	cmd.Flags().BoolVar(&connect, "connect", false, "try connecting to WhatsApp (requires store lock)")
 	return cmd
 }
+
+// doctorFTSError fails doctor, after its report, when the store has no FTS5.
+func doctorFTSError(ftsEnabled bool) error {
+	if ftsEnabled {
+		return nil
+	}
+	return fmt.Errorf("FTS5 is not available; search falls back to slower LIKE queries (rebuild with -tags sqlite_fts5)")
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDoctorFailsWithoutFTS(t *testing.T) {
+	hasFTS := openTestDB(t).HasFTS()
+
+	var flags rootFlags
+	root := newRootCmd(&flags)
+	root.SetArgs([]string{"--store", t.TempDir(), "--json", "doctor"})
+	err := root.Execute()
+	if hasFTS && err != nil {
+		t.Fatalf("doctor with FTS: %v", err)
+	}
+	if !hasFTS && (err == nil || !strings.Contains(err.Error(), "FTS5")) {
+		t.Fatalf("doctor without FTS: expected an FTS5 error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/export"
+)
+
+func newExportCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var format string
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export stored messages as CSV, NDJSON or plain text",
+		Long: `Export stored messages, oldest first, in the same formats as the RPC
+GET /export/messages endpoint:
+
+  csv     chat_jid,msg_id,sender_jid,timestamp,from_me,text
+  ndjson  one JSON object per line with the same fields
+  txt     [timestamp] sender: text
+
+Examples:
+  wacli export --chat 1234567890@s.whatsapp.net --output alice.csv
+  wacli export --format ndjson > messages.ndjson`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := export.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			var w io.Writer = os.Stdout
+			if output != "" && output != "-" {
+				file, err := os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+
+			if err := export.Messages(ctx, a.DB(), w, f, chat); err != nil {
+				return err
+			}
+			if file, ok := w.(*os.File); ok && file != os.Stdout {
+				if err := file.Close(); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Exported messages to %s\n", output)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "only export this chat JID (default: all chats)")
+	cmd.Flags().StringVar(&format, "format", export.FormatCSV, "csv, ndjson or txt")
+	cmd.Flags().StringVar(&output, "output", "", "write to this file instead of stdout")
+	return cmd
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	appPkg "github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newForwardHistoryCmd(flags *rootFlags) *cobra.Command {
+	var from string
+	var to string
+	var limit int
+	var delay time.Duration
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "forward-history",
+		Short: "Forward a chat's recent messages to another chat",
+		Long: `Forward the newest stored messages of one chat to another, oldest first.
+Text and media (reusing the original upload) are sent as forwarded
+messages; polls, locations and media whose keys were never synced are
+skipped.
+
+Example:
+  wacli forward-history --from 123456789@g.us --to 1234567890 --limit 20 --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if from == "" || to == "" {
+				return fmt.Errorf("--from and --to are required")
+			}
+			if limit <= 0 {
+				return fmt.Errorf("--limit must be positive")
+			}
+			fromJID, err := wa.ParseChatJID(from)
+			if err != nil {
+				return err
+			}
+			toJID, err := wa.ParseUserOrJID(to)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if !dryRun {
+				if err := a.EnsureAuthed(); err != nil {
+					return err
+				}
+				if err := a.Connect(ctx, false, nil); err != nil {
+					return err
+				}
+			}
+
+			res, err := a.ForwardHistory(ctx, appPkg.ForwardHistoryOptions{
+				From:   fromJID,
+				To:     toJID,
+				Limit:  limit,
+				Delay:  delay,
+				DryRun: dryRun,
+			})
+			if err != nil && res.Forwarded == 0 {
+				return err
+			}
+
+			if flags.asJSON {
+				if werr := out.WriteJSON(os.Stdout, map[string]any{
+					"from":      fromJID.String(),
+					"to":        toJID.String(),
+					"dry_run":   dryRun,
+					"forwarded": res.Forwarded,
+					"skipped":   res.Skipped,
+					"messages":  res.Messages,
+				}); werr != nil {
+					return werr
+				}
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "TIME\tID\tKIND\tTEXT\tRESULT\n")
+			for _, m := range res.Messages {
+				result := m.NewID
+				switch {
+				case m.Skipped != "":
+					result = "skipped: " + m.Skipped
+				case dryRun:
+					result = "would forward"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					m.Timestamp.Local().Format("2006-01-02 15:04:05"),
+					out.Truncate(m.SourceID, 14),
+					m.Kind,
+					out.Truncate(m.Text, 60),
+					result,
+				)
+			}
+			_ = w.Flush()
+			verb := "Forwarded"
+			if dryRun {
+				verb = "Would forward"
+			}
+			fmt.Fprintf(os.Stdout, "%s %d message(s) to %s, skipped %d\n", verb, res.Forwarded, toJID.String(), res.Skipped)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "source chat phone number or JID")
+	cmd.Flags().StringVar(&to, "to", "", "destination phone number or JID")
+	cmd.Flags().IntVar(&limit, "limit", 50, "forward the newest N messages")
+	cmd.Flags().DurationVar(&delay, "delay", 500*time.Millisecond, "pause between sends")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list what would be forwarded without sending")
+	return cmd
+}
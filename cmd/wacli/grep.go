@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// ANSI codes wrapped around matches when writing to a terminal.
+const (
+	grepMatchStart = "\x1b[1;31m"
+	grepMatchEnd   = "\x1b[0m"
+)
+
+type grepOptions struct {
+	chatJID   string // empty = every chat
+	re        *regexp.Regexp
+	invert    bool
+	asJSON    bool
+	highlight bool
+}
+
+func newGrepCmd(flags *rootFlags) *cobra.Command {
+	var pattern string
+	var chat string
+	var invert bool
+
+	cmd := &cobra.Command{
+		Use:   "grep",
+		Short: "Search stored messages with a Go regular expression",
+		Long: `Print stored messages whose text matches a Go regular expression
+(https://pkg.go.dev/regexp/syntax), oldest first, in the same format as
+wacli cat. Unlike wacli messages search, any regexp works; prefix it with
+(?i) to ignore case. Matches are highlighted when writing to a terminal.
+
+Examples:
+  wacli grep --pattern "urgent|ASAP"
+  wacli grep --pattern "(?i)invoice #\d+" --chat 14155552671`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pattern == "" {
+				return fmt.Errorf("--pattern is required")
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid --pattern: %w", err)
+			}
+			opts := grepOptions{re: re, invert: invert, asJSON: flags.asJSON, highlight: isTTY() && !flags.asJSON}
+			if chat != "" {
+				chatJID, err := wa.ParseChatJID(chat)
+				if err != nil {
+					return err
+				}
+				opts.chatJID = chatJID.String()
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			_, err = grepMessages(ctx, a.DB(), os.Stdout, opts)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&pattern, "pattern", "", "Go regular expression to match against message text")
+	cmd.Flags().StringVar(&chat, "chat", "", "only search this chat (phone number or JID)")
+	cmd.Flags().BoolVar(&invert, "invert-match", false, "print the messages that do not match")
+	return cmd
+}
+
+// grepMessages writes the messages matching opts.re (or, inverted, those
+// not matching) to w and returns how many it wrote.
+func grepMessages(ctx context.Context, db *store.DB, w io.Writer, opts grepOptions) (int, error) {
+	enc := json.NewEncoder(w)
+	n := 0
+	err := db.EachMessage(ctx, opts.chatJID, func(m store.Message) error {
+		text := catText(m)
+		matches := opts.re.FindAllStringIndex(text, -1)
+		if (len(matches) > 0) == opts.invert {
+			return nil
+		}
+		n++
+		if opts.highlight && !opts.invert {
+			text = highlightMatches(text, matches)
+		}
+		return writeCatMessage(w, enc, m, text, opts.asJSON, opts.chatJID == "")
+	})
+	return n, err
+}
+
+// highlightMatches wraps each [start, end) span of text in ANSI bold red.
+func highlightMatches(text string, matches [][]int) string {
+	var b []byte
+	last := 0
+	for _, m := range matches {
+		if m[0] == m[1] {
+			continue // empty match, nothing to show
+		}
+		b = append(b, text[last:m[0]]...)
+		b = append(b, grepMatchStart...)
+		b = append(b, text[m[0]:m[1]]...)
+		b = append(b, grepMatchEnd...)
+		last = m[1]
+	}
+	return string(append(b, text[last:]...))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestGrepMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "15551234567@s.whatsapp.net"
+	group := "120363000000000000@g.us"
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	seedChat(t, db, chat, "dm", "Alice", base)
+	seedChat(t, db, group, "group", "Team", base)
+	for i, p := range []store.UpsertMessageParams{
+		{ChatJID: chat, MsgID: "m1", Text: "URGENT: call me"},
+		{ChatJID: chat, MsgID: "m2", Text: "invoice #1234 attached"},
+		{ChatJID: chat, MsgID: "m3", Text: "price is $5.00 (approx.)"},
+		{ChatJID: group, MsgID: "g1", Text: "need this ASAP", SenderName: "Bob"},
+	} {
+		p.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if _, _, err := db.UpsertMessage(ctx, p); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	grep := func(pattern string, opts grepOptions) []string {
+		t.Helper()
+		opts.re = regexp.MustCompile(pattern)
+		var buf bytes.Buffer
+		n, err := grepMessages(ctx, db, &buf, opts)
+		if err != nil {
+			t.Fatalf("grepMessages(%q): %v", pattern, err)
+		}
+		out := strings.TrimSuffix(buf.String(), "\n")
+		if out == "" {
+			return nil
+		}
+		lines := strings.Split(out, "\n")
+		if len(lines) != n {
+			t.Fatalf("grepMessages(%q) reported %d, wrote %d lines", pattern, n, len(lines))
+		}
+		return lines
+	}
+
+	tests := []struct {
+		pattern string
+		opts    grepOptions
+		want    []string // message texts, in order
+	}{
+		{"urgent|ASAP", grepOptions{}, []string{"need this ASAP"}},
+		{"(?i)urgent|asap", grepOptions{}, []string{"URGENT: call me", "need this ASAP"}},
+		{`#\d{4}\b`, grepOptions{}, []string{"invoice #1234 attached"}},
+		{`\$5\.00 \(approx\.\)$`, grepOptions{}, []string{"price is $5.00 (approx.)"}},
+		{`^[a-z]`, grepOptions{chatJID: chat}, []string{"invoice #1234 attached", "price is $5.00 (approx.)"}},
+		{`(?i)urgent|asap`, grepOptions{invert: true}, []string{"invoice #1234 attached", "price is $5.00 (approx.)"}},
+		{`nomatch`, grepOptions{}, nil},
+	}
+	for _, tt := range tests {
+		lines := grep(tt.pattern, tt.opts)
+		if len(lines) != len(tt.want) {
+			t.Fatalf("%q: got %q, want %q", tt.pattern, lines, tt.want)
+		}
+		for i, line := range lines {
+			if !strings.HasSuffix(line, ": "+tt.want[i]) {
+				t.Fatalf("%q line %d = %q, want text %q", tt.pattern, i, line, tt.want[i])
+			}
+		}
+	}
+
+	// Every chat is searched by default, so lines name their chat.
+	if lines := grep("ASAP", grepOptions{}); lines[0] != "[2024-01-15 09:03:00] Team / Bob: need this ASAP" {
+		t.Fatalf("line = %q", lines[0])
+	}
+	lines := grep(`\d+`, grepOptions{chatJID: chat, highlight: true})
+	if want := "[2024-01-15 09:01:00] " + chat + ": invoice #" + grepMatchStart + "1234" + grepMatchEnd + " attached"; lines[0] != want {
+		t.Fatalf("highlighted = %q, want %q", lines[0], want)
+	}
+}
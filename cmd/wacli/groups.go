@@ -60,8 +60,8 @@ func newGroupsRefreshCmd(flags *rootFlags) *cobra.Command {
 				if g == nil {
 					continue
 				}
-				_ = persistGroupInfo(a.DB(), g)
-				_ = a.DB().UpsertChat(g.JID.String(), "group", g.GroupName.Name, time.Now())
+				_ = persistGroupInfo(ctx, a.DB(), g)
+				_, _, _ = a.DB().UpsertChat(ctx, g.JID.String(), "group", g.GroupName.Name, g.Topic, time.Now())
 			}
 
 			if flags.asJSON {
@@ -90,7 +90,7 @@ func newGroupsListCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			gs, err := a.DB().ListGroups(query, limit)
+			gs, err := a.DB().ListGroups(ctx, query, limit)
 			if err != nil {
 				return err
 			}
@@ -105,7 +105,7 @@ func newGroupsListCmd(flags *rootFlags) *cobra.Command {
 				if name == "" {
 					name = g.JID
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\n", truncate(name, 40), g.JID, g.CreatedAt.Local().Format("2006-01-02"))
+				fmt.Fprintf(w, "%s\t%s\t%s\n", out.Truncate(name, 40), g.JID, g.CreatedAt.Local().Format("2006-01-02"))
 			}
 			_ = w.Flush()
 			return nil
@@ -150,7 +150,7 @@ func newGroupsInfoCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			if info != nil {
-				_ = persistGroupInfo(a.DB(), info)
+				_ = persistGroupInfo(ctx, a.DB(), info)
 			}
 
 			if flags.asJSON {
@@ -205,7 +205,7 @@ func newGroupsRenameCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			if info, err := a.WA().GetGroupInfo(ctx, gjid); err == nil && info != nil {
-				_ = persistGroupInfo(a.DB(), info)
+				_ = persistGroupInfo(ctx, a.DB(), info)
 			}
 			if flags.asJSON {
 				return out.WriteJSON(os.Stdout, map[string]any{"jid": gjid.String(), "name": name})
@@ -275,7 +275,7 @@ func newGroupsParticipantsActionCmd(flags *rootFlags, action string) *cobra.Comm
 				return err
 			}
 			if info, err := a.WA().GetGroupInfo(ctx, gjid); err == nil && info != nil {
-				_ = persistGroupInfo(a.DB(), info)
+				_ = persistGroupInfo(ctx, a.DB(), info)
 			}
 
 			if flags.asJSON {
@@ -424,7 +424,7 @@ func newGroupsJoinCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 			if info, err := a.WA().GetGroupInfo(ctx, jid); err == nil && info != nil {
-				_ = persistGroupInfo(a.DB(), info)
+				_ = persistGroupInfo(ctx, a.DB(), info)
 			}
 			if flags.asJSON {
 				return out.WriteJSON(os.Stdout, map[string]any{"jid": jid.String(), "joined": true})
@@ -479,26 +479,20 @@ func newGroupsLeaveCmd(flags *rootFlags) *cobra.Command {
 	return cmd
 }
 
-func persistGroupInfo(db *store.DB, info *types.GroupInfo) error {
+func persistGroupInfo(ctx context.Context, db *store.DB, info *types.GroupInfo) error {
 	if info == nil {
 		return nil
 	}
-	if err := db.UpsertGroup(info.JID.String(), info.GroupName.Name, info.OwnerJID.String(), info.GroupCreated); err != nil {
+	if err := db.UpsertGroup(ctx, info.JID.String(), info.GroupName.Name, info.OwnerJID.String(), info.GroupCreated); err != nil {
 		return err
 	}
 	var ps []store.GroupParticipant
 	for _, p := range info.Participants {
-		role := "member"
-		if p.IsSuperAdmin {
-			role = "superadmin"
-		} else if p.IsAdmin {
-			role = "admin"
-		}
 		ps = append(ps, store.GroupParticipant{
 			GroupJID: info.JID.String(),
 			UserJID:  p.JID.String(),
-			Role:     role,
+			Role:     wa.ParticipantRole(p),
 		})
 	}
-	return db.ReplaceGroupParticipants(info.JID.String(), ps)
+	return db.ReplaceGroupParticipants(ctx, info.JID.String(), ps)
 }
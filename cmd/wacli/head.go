@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newHeadCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var lines int
+
+	cmd := &cobra.Command{
+		Use:   "head",
+		Short: "Print the oldest stored messages",
+		Long: `Print the first N stored messages of all chats, or of one chat with
+--chat, oldest first, in the same format as tail.
+
+Example:
+  wacli head --chat 14155552671 -n 50`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lines < 0 {
+				return fmt.Errorf("-n must not be negative")
+			}
+			opts := tailOptions{lines: lines, asJSON: flags.asJSON}
+			if chat != "" {
+				chatJID, err := wa.ParseChatJID(chat)
+				if err != nil {
+					return err
+				}
+				opts.chatJID = chatJID.String()
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+			return headMessages(ctx, a.DB(), os.Stdout, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "only this chat (phone number or JID)")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 20, "number of messages to print")
+	return cmd
+}
+
+// headMessages writes the opts.lines oldest messages to w, oldest first.
+func headMessages(ctx context.Context, db *store.DB, w io.Writer, opts tailOptions) error {
+	if opts.lines == 0 {
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	n := 0
+	err := db.EachMessage(ctx, opts.chatJID, func(m store.Message) error {
+		if err := writeCatMessage(w, enc, m, catText(m), opts.asJSON, opts.chatJID == ""); err != nil {
+			return err
+		}
+		if n++; n >= opts.lines {
+			return errCatLimit
+		}
+		return nil
+	})
+	if errors.Is(err, errCatLimit) {
+		err = nil
+	}
+	return err
+}
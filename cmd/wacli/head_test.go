@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestHeadMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "15551234567@s.whatsapp.net"
+	base := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	seedChat(t, db, chat, "dm", "Alice", base)
+	// Inserted newest first; head orders by time.
+	for i := 99; i >= 0; i-- {
+		if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     fmt.Sprintf("m%03d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			SenderJID: chat,
+			Text:      fmt.Sprintf("message %d", i),
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := headMessages(ctx, db, &buf, tailOptions{chatJID: chat, lines: 5, asJSON: true}); err != nil {
+		t.Fatalf("headMessages: %v", err)
+	}
+	dec := json.NewDecoder(&buf)
+	var got []string
+	for dec.More() {
+		var m catMessageJSON
+		if err := dec.Decode(&m); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got = append(got, m.MsgID)
+	}
+	want := []string{"m000", "m001", "m002", "m003", "m004"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	buf.Reset()
+	if err := headMessages(ctx, db, &buf, tailOptions{lines: 1}); err != nil {
+		t.Fatalf("headMessages: %v", err)
+	}
+	if want := "[" + base.Local().Format("2006-01-02 15:04:05") + "] Alice / 15551234567@s.whatsapp.net: message 0\n"; buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
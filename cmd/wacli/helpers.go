@@ -19,27 +19,25 @@ func parseTime(s string) (time.Time, error) {
 		return time.Time{}, fmt.Errorf("time is required")
 	}
 	if t, err := time.Parse(time.RFC3339, s); err == nil {
-		return t.UTC(), nil
+		return checkYear(t.UTC(), s)
 	}
 	// Full datetime: YYYY-MM-DD HH:MM:SS (UTC)
 	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.UTC); err == nil {
-		return t, nil
+		return checkYear(t, s)
 	}
 	// Date only: YYYY-MM-DD (UTC, midnight)
 	if t, err := time.ParseInLocation("2006-01-02", s, time.UTC); err == nil {
-		return t, nil
+		return checkYear(t, s)
 	}
 	return time.Time{}, fmt.Errorf("unsupported time format %q (use RFC3339, YYYY-MM-DD HH:MM:SS, or YYYY-MM-DD; all times UTC)", s)
 }
 
-func truncate(s string, max int) string {
-	s = strings.ReplaceAll(s, "\n", " ")
-	s = strings.TrimSpace(s)
-	if max <= 0 || len(s) <= max {
-		return s
-	}
-	if max <= 1 {
-		return s[:max]
+// checkYear rejects times outside years 1000-9999, which the layouts accept
+// (e.g. 0001-01-01, or an RFC3339 offset pushing 9999-12-31 into 10000) but
+// which don't round-trip through the DB's unix timestamps sensibly.
+func checkYear(t time.Time, input string) (time.Time, error) {
+	if y := t.Year(); y < 1000 || y > 9999 {
+		return time.Time{}, fmt.Errorf("time %q is out of range (years 1000-9999)", input)
 	}
-	return s[:max-1] + "…"
+	return t, nil
 }
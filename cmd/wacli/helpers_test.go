@@ -7,10 +7,10 @@ import (
 
 func TestParseTime(t *testing.T) {
 	tests := []struct {
-		input    string
-		wantErr  bool
-		checkFn  func(time.Time) bool
-		desc     string
+		input   string
+		wantErr bool
+		checkFn func(time.Time) bool
+		desc    string
 	}{
 		{
 			input:   "2026-02-07",
@@ -63,3 +63,29 @@ func TestParseTime(t *testing.T) {
 		})
 	}
 }
+
+func FuzzParseTime(f *testing.F) {
+	for _, seed := range []string{
+		"2026-02-07",
+		"2026-02-07 20:00:01",
+		"2026-02-07T20:00:01Z",
+		"invalid",
+		"",
+		"0001-01-01",
+		"9999-12-31T23:59:59-01:00",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := parseTime(s)
+		if err != nil {
+			return
+		}
+		if y := got.Year(); y < 1000 || y > 9999 {
+			t.Fatalf("parseTime(%q) = %v, year out of range", s, got)
+		}
+		if got.Location() != time.UTC {
+			t.Fatalf("parseTime(%q) = %v, expected UTC", s, got)
+		}
+	})
+}
@@ -22,17 +22,22 @@ func main() {
 
 func applyDeviceLabel() {
 	label := strings.TrimSpace(os.Getenv("WACLI_DEVICE_LABEL"))
+	manufacturer := strings.TrimSpace(os.Getenv("WACLI_DEVICE_MANUFACTURER"))
 	platformRaw := strings.TrimSpace(os.Getenv("WACLI_DEVICE_PLATFORM"))
 	if platformRaw != "" {
 		platform := parsePlatformType(platformRaw)
 		store.DeviceProps.PlatformType = platform.Enum()
 	}
-	if label == "" {
-		return
+	if label != "" {
+		store.SetOSInfo(label, [3]uint32{0, 1, 0})
+		store.BaseClientPayload.UserAgent.Device = proto.String(label)
+		if manufacturer == "" {
+			manufacturer = label
+		}
+	}
+	if manufacturer != "" {
+		store.BaseClientPayload.UserAgent.Manufacturer = proto.String(manufacturer)
 	}
-	store.SetOSInfo(label, [3]uint32{0, 1, 0})
-	store.BaseClientPayload.UserAgent.Device = proto.String(label)
-	store.BaseClientPayload.UserAgent.Manufacturer = proto.String(label)
 }
 
 func parsePlatformType(raw string) waCompanionReg.DeviceProps_PlatformType {
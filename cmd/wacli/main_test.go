@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/store"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParsePlatformType(t *testing.T) {
+	tests := []struct {
+		input string
+		want  waCompanionReg.DeviceProps_PlatformType
+	}{
+		{"CHROME", waCompanionReg.DeviceProps_CHROME},
+		{"FIREFOX", waCompanionReg.DeviceProps_FIREFOX},
+		{"SAFARI", waCompanionReg.DeviceProps_SAFARI},
+		{"DESKTOP", waCompanionReg.DeviceProps_DESKTOP},
+		{"chrome", waCompanionReg.DeviceProps_CHROME},
+		{" firefox ", waCompanionReg.DeviceProps_FIREFOX},
+		{"", waCompanionReg.DeviceProps_CHROME},
+		{"UNKNOWN_XYZ", waCompanionReg.DeviceProps_CHROME},
+	}
+	for _, tt := range tests {
+		if got := parsePlatformType(tt.input); got != tt.want {
+			t.Errorf("parsePlatformType(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDeviceLabelManufacturer(t *testing.T) {
+	ua := store.BaseClientPayload.UserAgent
+	origDevice, origManufacturer := ua.GetDevice(), ua.GetManufacturer()
+	origOS := store.DeviceProps.GetOs()
+	t.Cleanup(func() {
+		ua.Device = proto.String(origDevice)
+		ua.Manufacturer = proto.String(origManufacturer)
+		store.DeviceProps.Os = proto.String(origOS)
+	})
+
+	t.Setenv("WACLI_DEVICE_LABEL", "")
+	t.Setenv("WACLI_DEVICE_PLATFORM", "")
+	t.Setenv("WACLI_DEVICE_MANUFACTURER", "Acme")
+	applyDeviceLabel()
+	if got := ua.GetManufacturer(); got != "Acme" {
+		t.Errorf("manufacturer = %q, want Acme", got)
+	}
+	if got := ua.GetDevice(); got != origDevice {
+		t.Errorf("device changed to %q, want %q", got, origDevice)
+	}
+
+	t.Setenv("WACLI_DEVICE_MANUFACTURER", "")
+	t.Setenv("WACLI_DEVICE_LABEL", "wacli-test")
+	applyDeviceLabel()
+	if ua.GetDevice() != "wacli-test" || ua.GetManufacturer() != "wacli-test" {
+		t.Errorf("expected label for both fields, got device=%q manufacturer=%q", ua.GetDevice(), ua.GetManufacturer())
+	}
+}
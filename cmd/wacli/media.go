@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/app"
 	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/store"
 )
 
 func newMediaCmd(flags *rootFlags) *cobra.Command {
@@ -45,44 +47,29 @@ func newMediaDownloadCmd(flags *rootFlags) *cobra.Command {
 				return err
 			}
 
-			info, err := a.DB().GetMediaDownloadInfo(chat, id)
+			info, err := a.DB().GetMediaDownloadInfo(ctx, chat, id)
 			if err != nil {
 				return err
 			}
-			if info.MediaType == "" || info.DirectPath == "" || len(info.MediaKey) == 0 {
-				return fmt.Errorf("message has no downloadable media metadata (run `wacli sync` first)")
-			}
-
-			target, err := a.ResolveMediaOutputPath(info, outputPath)
-			if err != nil {
-				return err
-			}
-
-			if err := a.Connect(ctx, false, nil); err != nil {
-				return err
-			}
-
-			bytes, err := a.WA().DownloadMediaToFile(ctx, info.DirectPath, info.FileEncSHA256, info.FileSHA256, info.MediaKey, info.FileLength, info.MediaType, "", target)
+			info, bytes, err := downloadMedia(ctx, a, info, outputPath)
 			if err != nil {
 				return err
 			}
-			now := time.Now().UTC()
-			_ = a.DB().MarkMediaDownloaded(info.ChatJID, info.MsgID, target, now)
 
 			resp := map[string]any{
 				"chat":          info.ChatJID,
 				"id":            info.MsgID,
-				"path":          target,
+				"path":          info.LocalPath,
 				"bytes":         bytes,
 				"media_type":    info.MediaType,
 				"mime_type":     info.MimeType,
 				"downloaded":    true,
-				"downloaded_at": now.Format(time.RFC3339Nano),
+				"downloaded_at": info.DownloadedAt.Format(time.RFC3339Nano),
 			}
 			if flags.asJSON {
 				return out.WriteJSON(os.Stdout, resp)
 			}
-			fmt.Fprintf(os.Stdout, "%s (%d bytes)\n", target, bytes)
+			fmt.Fprintf(os.Stdout, "%s (%d bytes)\n", info.LocalPath, bytes)
 			return nil
 		},
 	}
@@ -94,3 +81,31 @@ func newMediaDownloadCmd(flags *rootFlags) *cobra.Command {
 	_ = cmd.MarkFlagRequired("id")
 	return cmd
 }
+
+// downloadMedia fetches a message's media to outputPath (empty = the store
+// media dir), records the download and returns the updated info. It
+// connects to WhatsApp; the caller must hold the store lock.
+func downloadMedia(ctx context.Context, a *app.App, info store.MediaDownloadInfo, outputPath string) (store.MediaDownloadInfo, int64, error) {
+	if info.MediaType == "" || info.DirectPath == "" || len(info.MediaKey) == 0 {
+		return info, 0, fmt.Errorf("message has no downloadable media metadata (run `wacli sync` first)")
+	}
+
+	target, err := a.ResolveMediaOutputPath(info, outputPath)
+	if err != nil {
+		return info, 0, err
+	}
+
+	if err := a.Connect(ctx, false, nil); err != nil {
+		return info, 0, err
+	}
+
+	bytes, err := a.WA().DownloadMediaToFile(ctx, info.DirectPath, info.FileEncSHA256, info.FileSHA256, info.MediaKey, info.FileLength, info.MediaType, "", target)
+	if err != nil {
+		return info, 0, err
+	}
+	now := time.Now().UTC()
+	_ = a.DB().MarkMediaDownloaded(ctx, info.ChatJID, info.MsgID, target, now)
+	info.LocalPath = target
+	info.DownloadedAt = now
+	return info, bytes, nil
+}
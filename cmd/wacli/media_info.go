@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+type mediaInfoJSON struct {
+	Chat         string `json:"chat"`
+	ChatName     string `json:"chat_name,omitempty"`
+	ID           string `json:"id"`
+	MediaType    string `json:"media_type"`
+	MimeType     string `json:"mime_type,omitempty"`
+	Filename     string `json:"filename,omitempty"`
+	FileLength   uint64 `json:"file_length"`
+	FileSHA256   string `json:"file_sha256,omitempty"`
+	DirectPath   string `json:"direct_path,omitempty"`
+	LocalPath    string `json:"local_path,omitempty"`
+	DownloadedAt string `json:"downloaded_at,omitempty"`
+	Caption      string `json:"caption,omitempty"`
+}
+
+func newMediaInfoCmd(flags *rootFlags) *cobra.Command {
+	var id string
+	var chat string
+
+	cmd := &cobra.Command{
+		Use:   "media-info",
+		Short: "Show stored metadata for a media message",
+		Long: `Show what the local store knows about a media message: type, MIME type,
+size, SHA-256, WhatsApp direct path, local path (once downloaded) and caption.
+
+WhatsApp can't be asked for a message's media by ID, so the metadata must
+have been synced. Pass --chat when an ID could appear in more than one chat.
+
+Examples:
+  wacli media-info --msg-id 3EB0C767D26A1D4F0E0B
+  wacli media-info --msg-id 3EB0C767D26A1D4F0E0B --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			info, err := findMediaInfo(ctx, a.DB(), chat, id)
+			if err != nil {
+				return err
+			}
+			return writeMediaInfo(os.Stdout, info, flags.asJSON)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "msg-id", "", "message ID")
+	cmd.Flags().StringVar(&chat, "chat", "", "chat of the message (phone number or JID)")
+	_ = cmd.MarkFlagRequired("msg-id")
+	return cmd
+}
+
+// findMediaInfo looks a message up by ID, within chat when it is given.
+func findMediaInfo(ctx context.Context, db *store.DB, chat, msgID string) (store.MediaDownloadInfo, error) {
+	var info store.MediaDownloadInfo
+	var err error
+	if chat != "" {
+		chatJID, perr := wa.ParseChatJID(chat)
+		if perr != nil {
+			return info, perr
+		}
+		info, err = db.GetMediaDownloadInfo(ctx, chatJID.String(), msgID)
+	} else {
+		info, err = db.GetMediaMetadata(ctx, msgID)
+	}
+	if store.IsNotFound(err) {
+		return info, fmt.Errorf("message %s not found (run `wacli sync` first)", msgID)
+	}
+	return info, err
+}
+
+// writeMediaInfo prints info as a key/value table, or as JSON. It fails
+// for messages without media.
+func writeMediaInfo(w io.Writer, info store.MediaDownloadInfo, asJSON bool) error {
+	if info.MediaType == "" {
+		return fmt.Errorf("message %s has no media", info.MsgID)
+	}
+	j := mediaInfoJSON{
+		Chat:       info.ChatJID,
+		ChatName:   info.ChatName,
+		ID:         info.MsgID,
+		MediaType:  info.MediaType,
+		MimeType:   info.MimeType,
+		Filename:   info.Filename,
+		FileLength: info.FileLength,
+		DirectPath: info.DirectPath,
+		LocalPath:  info.LocalPath,
+		Caption:    info.Caption,
+	}
+	if len(info.FileSHA256) > 0 {
+		j.FileSHA256 = hex.EncodeToString(info.FileSHA256)
+	}
+	if !info.DownloadedAt.IsZero() {
+		j.DownloadedAt = info.DownloadedAt.Format(time.RFC3339)
+	}
+	if asJSON {
+		return out.WriteJSON(w, j)
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 4, 2, ' ', 0)
+	row := func(k, v string) {
+		if v == "" {
+			v = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", k, v)
+	}
+	chatLabel := j.Chat
+	if j.ChatName != "" {
+		chatLabel = j.ChatName + " (" + j.Chat + ")"
+	}
+	row("CHAT", chatLabel)
+	row("ID", j.ID)
+	row("TYPE", j.MediaType)
+	row("MIME", j.MimeType)
+	row("FILENAME", j.Filename)
+	row("SIZE", fmt.Sprintf("%d bytes", j.FileLength))
+	row("SHA256", j.FileSHA256)
+	row("DIRECT PATH", j.DirectPath)
+	row("LOCAL PATH", j.LocalPath)
+	row("DOWNLOADED", j.DownloadedAt)
+	row("CAPTION", j.Caption)
+	return tw.Flush()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestWriteMediaInfo(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "15551234567@s.whatsapp.net"
+	sum := []byte{0xde, 0xad, 0xbe, 0xef}
+	seedChat(t, db, chat, "dm", "Alice", time.Now())
+	if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID: chat, MsgID: "img1", SenderJID: chat, Timestamp: time.Now(),
+		MediaType: "image", MediaCaption: "sunset", Filename: "sunset.jpg", MimeType: "image/jpeg",
+		DirectPath: "/v/t62.7118-24/abc", MediaKey: []byte{1}, FileSHA256: sum, FileLength: 2048,
+	}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	downloaded := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := db.MarkMediaDownloaded(ctx, chat, "img1", "/tmp/sunset.jpg", downloaded); err != nil {
+		t.Fatalf("MarkMediaDownloaded: %v", err)
+	}
+
+	info, err := db.GetMediaMetadata(ctx, "img1")
+	if err != nil {
+		t.Fatalf("GetMediaMetadata: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeMediaInfo(&buf, info, false); err != nil {
+		t.Fatalf("writeMediaInfo: %v", err)
+	}
+	for _, want := range []string{"Alice (" + chat + ")", "img1", "image", "image/jpeg", "sunset.jpg", "2048 bytes", hex.EncodeToString(sum), "/v/t62.7118-24/abc", "/tmp/sunset.jpg", "sunset"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := writeMediaInfo(&buf, info, true); err != nil {
+		t.Fatalf("writeMediaInfo json: %v", err)
+	}
+	var resp struct {
+		Data mediaInfoJSON `json:"data"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := mediaInfoJSON{
+		Chat: chat, ChatName: "Alice", ID: "img1", MediaType: "image", MimeType: "image/jpeg",
+		Filename: "sunset.jpg", FileLength: 2048, FileSHA256: "deadbeef", DirectPath: "/v/t62.7118-24/abc",
+		LocalPath: "/tmp/sunset.jpg", DownloadedAt: downloaded.Local().Format(time.RFC3339), Caption: "sunset",
+	}
+	if resp.Data != want {
+		t.Fatalf("json = %+v, want %+v", resp.Data, want)
+	}
+
+	if _, err := db.GetMediaMetadata(ctx, "missing"); !store.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chat, MsgID: "txt1", SenderJID: chat, Timestamp: time.Now(), Text: "hi"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	info, _ = db.GetMediaMetadata(ctx, "txt1")
+	if err := writeMediaInfo(&buf, info, false); err == nil {
+		t.Fatalf("expected an error for a message without media")
+	}
+}
@@ -27,9 +27,11 @@ func newMessagesCmd(flags *rootFlags) *cobra.Command {
 
 func newMessagesListCmd(flags *rootFlags) *cobra.Command {
 	var chat string
+	var from string
 	var limit int
 	var afterStr string
 	var beforeStr string
+	var msgTypes []string
 
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -61,11 +63,13 @@ func newMessagesListCmd(flags *rootFlags) *cobra.Command {
 				before = &t
 			}
 
-			msgs, err := a.DB().ListMessages(store.ListMessagesParams{
-				ChatJID: chat,
-				Limit:   limit,
-				After:   after,
-				Before:  before,
+			msgs, err := a.DB().ListMessages(ctx, store.ListMessagesParams{
+				ChatJID:    chat,
+				SenderJID:  from,
+				MediaTypes: msgTypes,
+				Limit:      limit,
+				After:      after,
+				Before:     before,
 			})
 			if err != nil {
 				return err
@@ -98,10 +102,10 @@ func newMessagesListCmd(flags *rootFlags) *cobra.Command {
 				}
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
 					m.Timestamp.Local().Format("2006-01-02 15:04:05"),
-					truncate(chatLabel, 24),
-					truncate(from, 18),
-					truncate(m.MsgID, 14),
-					truncate(text, 80),
+					out.Truncate(chatLabel, 24),
+					out.Truncate(from, 18),
+					out.Truncate(m.MsgID, 14),
+					out.Truncate(text, 80),
 				)
 			}
 			_ = w.Flush()
@@ -110,9 +114,11 @@ func newMessagesListCmd(flags *rootFlags) *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&chat, "chat", "", "chat JID")
+	cmd.Flags().StringVar(&from, "from", "", "sender JID")
 	cmd.Flags().IntVar(&limit, "limit", 50, "limit results")
 	cmd.Flags().StringVar(&afterStr, "after", "", "only messages after time (UTC: RFC3339, YYYY-MM-DD HH:MM:SS, or YYYY-MM-DD)")
 	cmd.Flags().StringVar(&beforeStr, "before", "", "only messages before time (UTC: RFC3339, YYYY-MM-DD HH:MM:SS, or YYYY-MM-DD)")
+	cmd.Flags().StringSliceVar(&msgTypes, "type", nil, "media type filter, comma-separated (image|video|audio|document)")
 	return cmd
 }
 
@@ -123,6 +129,7 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 	var afterStr string
 	var beforeStr string
 	var msgType string
+	var fuzzy bool
 
 	cmd := &cobra.Command{
 		Use:   "search <query>",
@@ -155,7 +162,7 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 				before = &t
 			}
 
-			msgs, err := a.DB().SearchMessages(store.SearchMessagesParams{
+			res, err := a.DB().SearchMessagesWithCount(ctx, store.SearchMessagesParams{
 				Query:   args[0],
 				ChatJID: chat,
 				From:    from,
@@ -163,15 +170,18 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 				After:   after,
 				Before:  before,
 				Type:    msgType,
+				Fuzzy:   fuzzy,
 			})
 			if err != nil {
 				return err
 			}
+			msgs := res.Messages
 
 			if flags.asJSON {
 				return out.WriteJSON(os.Stdout, map[string]any{
-					"messages": msgs,
-					"fts":      a.DB().HasFTS(),
+					"messages":    msgs,
+					"total_count": res.TotalCount,
+					"fts":         a.DB().HasFTS(),
 				})
 			}
 
@@ -195,10 +205,10 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 				}
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
 					m.Timestamp.Local().Format("2006-01-02 15:04:05"),
-					truncate(chatLabel, 24),
-					truncate(fromLabel, 18),
-					truncate(m.MsgID, 14),
-					truncate(match, 90),
+					out.Truncate(chatLabel, 24),
+					out.Truncate(fromLabel, 18),
+					out.Truncate(m.MsgID, 14),
+					out.Truncate(match, 90),
 				)
 			}
 			_ = w.Flush()
@@ -215,6 +225,7 @@ func newMessagesSearchCmd(flags *rootFlags) *cobra.Command {
 	cmd.Flags().StringVar(&afterStr, "after", "", "only messages after time (UTC: RFC3339, YYYY-MM-DD HH:MM:SS, or YYYY-MM-DD)")
 	cmd.Flags().StringVar(&beforeStr, "before", "", "only messages before time (UTC: RFC3339, YYYY-MM-DD HH:MM:SS, or YYYY-MM-DD)")
 	cmd.Flags().StringVar(&msgType, "type", "", "media type filter (image|video|audio|document)")
+	cmd.Flags().BoolVar(&fuzzy, "fuzzy", false, "also match sender names that sound like the query (Soundex; English names only)")
 	return cmd
 }
 
@@ -239,7 +250,7 @@ func newMessagesShowCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			m, err := a.DB().GetMessage(chat, id)
+			m, err := a.DB().GetMessage(ctx, chat, id)
 			if err != nil {
 				return err
 			}
@@ -295,7 +306,7 @@ func newMessagesContextCmd(flags *rootFlags) *cobra.Command {
 			}
 			defer closeApp(a, lk)
 
-			msgs, err := a.DB().MessageContext(chat, id, before, after)
+			msgs, err := a.DB().MessageContext(ctx, chat, id, before, after)
 			if err != nil {
 				return err
 			}
@@ -317,9 +328,9 @@ func newMessagesContextCmd(flags *rootFlags) *cobra.Command {
 				}
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
 					m.Timestamp.Local().Format("2006-01-02 15:04:05"),
-					truncate(from, 18),
-					truncate(m.MsgID, 14),
-					truncate(line, 100),
+					out.Truncate(from, 18),
+					out.Truncate(m.MsgID, 14),
+					out.Truncate(line, 100),
 				)
 			}
 			_ = w.Flush()
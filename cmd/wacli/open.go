@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/lock"
+	"github.com/steipete/wacli/internal/out"
+)
+
+// execCommand is exec.Command, swapped out in tests.
+var execCommand = exec.Command
+
+func newOpenCmd(flags *rootFlags) *cobra.Command {
+	var id string
+	var chat string
+
+	cmd := &cobra.Command{
+		Use:   "open",
+		Short: "Open a media message in the system viewer",
+		Long: `Open a media message with the default application: open on macOS,
+xdg-open on Linux and the file's associated program on Windows. The media
+is downloaded to the store media dir first unless it already is.
+
+Examples:
+  wacli open --msg-id 3EB0C767D26A1D4F0E0B
+  wacli open --chat 14155552671 --msg-id 3EB0C767D26A1D4F0E0B`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+			// Opening a file that is already on disk works while sync holds
+			// the store lock; only a download needs it.
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer func() { closeApp(a, lk) }() // lk is taken below if needed
+
+			info, err := findMediaInfo(ctx, a.DB(), chat, id)
+			if err != nil {
+				return err
+			}
+			if info.MediaType == "" {
+				return fmt.Errorf("message %s has no media", info.MsgID)
+			}
+			if !fileExists(info.LocalPath) {
+				if lk, err = lock.Acquire(a.StoreDir()); err != nil {
+					return err
+				}
+				if err := a.EnsureAuthed(); err != nil {
+					return err
+				}
+				if info, _, err = downloadMedia(ctx, a, info, ""); err != nil {
+					return err
+				}
+			}
+
+			if err := openFile(info.LocalPath); err != nil {
+				return err
+			}
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{"id": info.MsgID, "path": info.LocalPath})
+			}
+			fmt.Fprintln(os.Stdout, info.LocalPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "msg-id", "", "message ID")
+	cmd.Flags().StringVar(&chat, "chat", "", "chat of the message (phone number or JID)")
+	_ = cmd.MarkFlagRequired("msg-id")
+	return cmd
+}
+
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	st, err := os.Stat(path)
+	return err == nil && st.Mode().IsRegular()
+}
+
+// openCommand returns the command that opens path with goos's default
+// handler.
+func openCommand(goos, path string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{path}
+	case "windows":
+		// Not cmd /c start: cmd.exe would run &, ^ and % in a received
+		// file's name as shell syntax.
+		return "rundll32", []string{"url.dll,FileProtocolHandler", path}
+	default:
+		return "xdg-open", []string{path}
+	}
+}
+
+// openFile starts the default handler for path without waiting for it.
+func openFile(path string) error {
+	name, args := openCommand(runtime.GOOS, path)
+	if err := execCommand(name, args...).Start(); err != nil {
+		return fmt.Errorf("open %s with %s: %w", path, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	var gotName string
+	var gotArgs []string
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		gotName, gotArgs = name, args
+		// Runs this test binary with no tests, which exits at once.
+		return exec.Command(os.Args[0], "-test.run=^$")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	if err := openFile(path); err != nil {
+		t.Fatalf("openFile: %v", err)
+	}
+	wantName, wantArgs := openCommand(runtime.GOOS, path)
+	if gotName != wantName || !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Fatalf("ran %s %q, want %s %q", gotName, gotArgs, wantName, wantArgs)
+	}
+	if gotArgs[len(gotArgs)-1] != path {
+		t.Fatalf("file path not passed last: %q", gotArgs)
+	}
+}
+
+func TestOpenCommand(t *testing.T) {
+	path := "/tmp/a b.jpg"
+	for goos, want := range map[string][]string{
+		"darwin":  {"open", path},
+		"linux":   {"xdg-open", path},
+		"freebsd": {"xdg-open", path},
+		"windows": {"rundll32", "url.dll,FileProtocolHandler", path},
+	} {
+		name, args := openCommand(goos, path)
+		if got := append([]string{name}, args...); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", goos, got, want)
+		}
+	}
+
+	// A received file's name must reach the opener as one argument, never
+	// through a shell.
+	evil := `C:\wacli\media\a&calc&^%PATH%.pdf`
+	for _, goos := range []string{"darwin", "linux", "windows"} {
+		name, args := openCommand(goos, evil)
+		if name == "cmd" || name == "sh" || args[len(args)-1] != evil {
+			t.Errorf("%s: %s %q does not pass %q as a plain argument", goos, name, args, evil)
+		}
+	}
+}
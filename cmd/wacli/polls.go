@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+)
+
+func newPollsCmd(flags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "polls",
+		Short: "Inspect polls",
+	}
+	cmd.AddCommand(newPollsResultsCmd(flags))
+	return cmd
+}
+
+func newPollsResultsCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var id string
+
+	cmd := &cobra.Command{
+		Use:   "results",
+		Short: "Tally the votes on a poll (from votes seen during sync)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if id == "" {
+				return fmt.Errorf("--id is required")
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, false, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if chat == "" {
+				chat, err = a.DB().FindPollChat(ctx, id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("no poll with id %s", id)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			results, err := a.DB().PollResults(ctx, chat, id)
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				return fmt.Errorf("no poll with id %s in %s", id, chat)
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"chat":    chat,
+					"id":      id,
+					"results": results,
+				})
+			}
+			w := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "OPTION\tVOTES")
+			for _, r := range results {
+				fmt.Fprintf(w, "%s\t%d\n", out.Truncate(r.Name, 40), r.Count)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "chat JID (looked up from --id when omitted)")
+	cmd.Flags().StringVar(&id, "id", "", "poll message ID")
+	return cmd
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+)
+
+func newReplayCmd(flags *rootFlags) *cobra.Command {
+	var logFile string
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay a recorded event log into the local DB",
+		Long: `Replay newline-delimited JSON events (as written by ` + "`sync --event-log`" + `)
+through the sync handler, without connecting to WhatsApp. Useful for
+reproducing bugs against a scratch store:
+
+  wacli --store /tmp/wacli-repro replay --log-file events.jsonl`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if logFile == "" {
+				return fmt.Errorf("--log-file is required")
+			}
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			f, err := os.Open(logFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			a, lk, err := newApp(ctx, flags, true, true)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			res, err := a.Replay(ctx, f)
+			if err != nil {
+				return err
+			}
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"events":           res.Events,
+					"events_ignored":   res.Ignored,
+					"messages_stored":  res.MessagesStored,
+					"messages_updated": res.MessagesUpdated,
+					"messages_skipped": res.SkippedCount,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Replayed %d events (%d ignored). Messages stored: %d\n", res.Events, res.Ignored, res.MessagesStored)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&logFile, "log-file", "", "event log to replay (newline-delimited JSON)")
+	return cmd
+}
@@ -18,45 +18,71 @@ import (
 var version = "dev"
 
 type rootFlags struct {
-	storeDir string
-	asJSON   bool
-	timeout  time.Duration
+	storeDir   string
+	asJSON     bool
+	timeout    time.Duration
+	configPath string
 }
 
 func execute(args []string) error {
 	var flags rootFlags
+	rootCmd := newRootCmd(&flags)
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		_ = out.WriteError(os.Stderr, flags.asJSON, err)
+		return err
+	}
+	return nil
+}
 
+// newRootCmd builds the command tree around flags.
+func newRootCmd(flags *rootFlags) *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:           "wacli",
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		Version:       version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return loadConfig(cmd, flags.configPath)
+		},
 	}
 	rootCmd.SetVersionTemplate("wacli {{.Version}}\n")
 
 	rootCmd.PersistentFlags().StringVar(&flags.storeDir, "store", "", "store directory (default: ~/.wacli)")
 	rootCmd.PersistentFlags().BoolVar(&flags.asJSON, "json", false, "output JSON instead of human-readable text")
 	rootCmd.PersistentFlags().DurationVar(&flags.timeout, "timeout", 5*time.Minute, "command timeout (non-sync commands)")
+	rootCmd.PersistentFlags().StringVar(&flags.configPath, "config", "", "YAML config file (default: $"+configPathEnv+")")
 
 	rootCmd.AddCommand(newVersionCmd())
-	rootCmd.AddCommand(newDoctorCmd(&flags))
-	rootCmd.AddCommand(newAuthCmd(&flags))
-	rootCmd.AddCommand(newSyncCmd(&flags))
-	rootCmd.AddCommand(newMessagesCmd(&flags))
-	rootCmd.AddCommand(newSendCmd(&flags))
-	rootCmd.AddCommand(newMediaCmd(&flags))
-	rootCmd.AddCommand(newContactsCmd(&flags))
-	rootCmd.AddCommand(newChatsCmd(&flags))
-	rootCmd.AddCommand(newGroupsCmd(&flags))
-	rootCmd.AddCommand(newHistoryCmd(&flags))
-	rootCmd.AddCommand(newRPCCmd(&flags))
-
-	rootCmd.SetArgs(args)
-	if err := rootCmd.Execute(); err != nil {
-		_ = out.WriteError(os.Stderr, flags.asJSON, err)
-		return err
-	}
-	return nil
+	rootCmd.AddCommand(newDoctorCmd(flags))
+	rootCmd.AddCommand(newAuthCmd(flags))
+	rootCmd.AddCommand(newSyncCmd(flags))
+	rootCmd.AddCommand(newMessagesCmd(flags))
+	rootCmd.AddCommand(newCatCmd(flags))
+	rootCmd.AddCommand(newGrepCmd(flags))
+	rootCmd.AddCommand(newTailCmd(flags))
+	rootCmd.AddCommand(newHeadCmd(flags))
+	rootCmd.AddCommand(newCountCmd(flags))
+	rootCmd.AddCommand(newExportCmd(flags))
+	rootCmd.AddCommand(newSendCmd(flags))
+	rootCmd.AddCommand(newForwardHistoryCmd(flags))
+	rootCmd.AddCommand(newPollsCmd(flags))
+	rootCmd.AddCommand(newStatusBroadcastCmd(flags))
+	rootCmd.AddCommand(newMediaCmd(flags))
+	rootCmd.AddCommand(newMediaInfoCmd(flags))
+	rootCmd.AddCommand(newCleanupMediaCmd(flags))
+	rootCmd.AddCommand(newOpenCmd(flags))
+	rootCmd.AddCommand(newContactsCmd(flags))
+	rootCmd.AddCommand(newChatsCmd(flags))
+	rootCmd.AddCommand(newGroupsCmd(flags))
+	rootCmd.AddCommand(newHistoryCmd(flags))
+	rootCmd.AddCommand(newRPCCmd(flags))
+	rootCmd.AddCommand(newDBCmd(flags))
+	rootCmd.AddCommand(newBenchCmd(flags))
+	rootCmd.AddCommand(newSimulateCmd(flags))
+	rootCmd.AddCommand(newReplayCmd(flags))
+	rootCmd.AddCommand(newConfigCmd())
+	return rootCmd
 }
 
 func newApp(ctx context.Context, flags *rootFlags, needLock bool, allowUnauthed bool) (*app.App, *lock.Lock, error) {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -13,7 +14,10 @@ import (
 	"github.com/steipete/wacli/internal/logging"
 	"github.com/steipete/wacli/internal/out"
 	"github.com/steipete/wacli/internal/rpc"
+	"github.com/steipete/wacli/internal/store"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
 )
 
 func newRPCCmd(flags *rootFlags) *cobra.Command {
@@ -23,6 +27,18 @@ func newRPCCmd(flags *rootFlags) *cobra.Command {
 	var downloadMedia bool
 	var refreshContacts bool
 	var refreshGroups bool
+	var trustedCIDRs []string
+	var healthAddr string
+	var metricsAddr string
+	var endpointTimeouts map[string]string
+	var webhookURL string
+	var webhookSecret string
+	var groupMembersTTL time.Duration
+	var tlsSelfSigned bool
+	var tlsOpts tlsFlags
+	var authToken string
+	var rateLimit float64
+	var rateBurst int
 
 	cmd := &cobra.Command{
 		Use:   "rpc",
@@ -32,12 +48,25 @@ and sending messages via HTTP requests. Can run with or without
 active sync.
 
 Endpoints:
-  GET  /status    - Server status
-  GET  /chats     - List chats
-  GET  /messages  - Get messages (requires chat_jid param)
-  POST /search    - Search messages
-  POST /send      - Send a message
-  GET  /ping      - Health check
+  GET  /status        - Server status
+  GET  /chats         - List chats
+  GET  /contacts      - List contacts (synced with --refresh-contacts)
+  GET  /messages      - Get messages (requires chat_jid param)
+  POST /search        - Search messages
+  GET  /export/messages - Stream messages as csv, ndjson or txt (format param)
+  POST /send          - Send a message (or schedule it with send_at)
+  POST /send-batch    - Send up to 100 text messages, 3 at a time
+  GET  /scheduled     - Pending scheduled messages
+  DELETE /scheduled/{id} - Cancel a scheduled message
+  POST /react         - React to a message (empty reaction removes it)
+  GET  /reactions     - Reaction counts for a message
+  POST /mark-read     - Send read receipts for messages
+  GET  /unread-counts - Unread incoming messages per chat
+  POST /typing        - Show or clear the typing indicator
+  GET  /group-members - Members of a group (requires jid param)
+  GET  /events        - Server-Sent Events stream (types param filters)
+  GET  /metrics       - Prometheus metrics (unless --metrics-addr is set)
+  GET  /ping          - Health check
 
 Examples:
   # Start RPC server only (queries existing DB)
@@ -47,7 +76,32 @@ Examples:
   wacli rpc --sync
 
   # Use custom port
-  wacli rpc --addr localhost:8080`,
+  wacli rpc --addr localhost:8080
+
+  # Listen on a Unix domain socket
+  wacli rpc --addr /run/wacli.sock
+
+  # Behind a reverse proxy on the local network
+  wacli rpc --proxy-trusted-cidrs 10.0.0.0/8,172.16.0.0/12
+
+  # Serve only /health and /ready on a separate port
+  wacli rpc --healthcheck-addr :9090
+
+  # Serve Prometheus metrics on a separate port instead of /metrics
+  wacli rpc --metrics-addr :9091
+
+  # HTTPS for local development (pin the printed fingerprint)
+  wacli rpc --rpc-tls-auto-self-signed
+
+  # HTTPS with your own certificate, or one from Let's Encrypt
+  wacli rpc --addr :8443 --tls-cert cert.pem --tls-key key.pem
+  wacli rpc --addr :443 --tls-acme-domain wacli.example.com
+
+  # Require "Authorization: Bearer <token>" on every request but /ping
+  wacli rpc --addr :5555 --auth-token "$(openssl rand -hex 32)"
+
+  # POST every new message to a webhook, signed with a shared secret
+  wacli rpc --sync --webhook-url https://example.com/hook --webhook-secret s3cret`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log := logging.WithComponent("rpc")
 			log.Info().
@@ -55,6 +109,15 @@ Examples:
 				Bool("sync", enableSync).
 				Msg("starting RPC command")
 
+			trustedProxies, err := rpc.ParseCIDRs(trustedCIDRs)
+			if err != nil {
+				return err
+			}
+			timeouts, err := rpc.ParseTimeouts(endpointTimeouts)
+			if err != nil {
+				return err
+			}
+
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer stop()
 
@@ -67,10 +130,23 @@ Examples:
 			defer closeApp(a, lk)
 
 			// Create RPC server
-			rpcServer, err := rpc.New(rpc.Options{
-				Addr: addr,
-				DB:   a.DB(),
-			})
+			opts := rpc.Options{
+				Addr:            addr,
+				DB:              a.DB(),
+				TrustedProxies:  trustedProxies,
+				HealthAddr:      healthAddr,
+				MetricsAddr:     metricsAddr,
+				Timeouts:        timeouts,
+				WebhookURL:      webhookURL,
+				WebhookSecret:   webhookSecret,
+				GroupMembersTTL: groupMembersTTL,
+				TLSSelfSigned:   tlsSelfSigned,
+				AuthToken:       authToken,
+				RateLimit:       rateLimit,
+				RateBurst:       rateBurst,
+			}
+			tlsOpts.apply(&opts, a.StoreDir())
+			rpcServer, err := rpc.New(opts)
 			if err != nil {
 				return fmt.Errorf("create rpc server: %w", err)
 			}
@@ -85,10 +161,12 @@ Examples:
 				_ = rpcServer.Stop(shutdownCtx)
 			}()
 
-			if rpcServer.IsUnixSocket() {
-				fmt.Fprintf(os.Stderr, "RPC server listening on %s\n", addr)
-			} else {
-				fmt.Fprintf(os.Stderr, "RPC server listening on http://%s\n", addr)
+			printRPCListening(rpcServer, addr)
+			if rpcServer.HealthAddr() != "" {
+				fmt.Fprintf(os.Stderr, "Health checks on http://%s\n", rpcServer.HealthAddr())
+			}
+			if rpcServer.MetricsAddr() != "" {
+				fmt.Fprintf(os.Stderr, "Metrics on http://%s/metrics\n", rpcServer.MetricsAddr())
 			}
 
 			// If sync is enabled, connect and run sync
@@ -100,7 +178,7 @@ Examples:
 				// Set WA client for RPC server after connection
 				afterConnect := func(ctx context.Context) error {
 					if wa := a.WA(); wa != nil {
-						rpcServer.SetWA(&waWrapper{wa: wa})
+						rpcServer.SetWA(&waWrapper{wa: wa, app: a})
 					}
 					rpcServer.SetSyncRunning(true)
 					return nil
@@ -115,6 +193,7 @@ Examples:
 					RefreshContacts: refreshContacts,
 					RefreshGroups:   refreshGroups,
 					IdleExit:        idleExit,
+					OnReconnecting:  rpcServer.SetReconnecting,
 				})
 				rpcServer.SetSyncRunning(false)
 				if err != nil {
@@ -124,7 +203,7 @@ Examples:
 
 				if flags.asJSON {
 					return out.WriteJSON(os.Stdout, map[string]any{
-						"rpc_addr":        addr,
+						"rpc_addr":        rpcServer.Addr(),
 						"synced":          true,
 						"messages_stored": res.MessagesStored,
 					})
@@ -138,7 +217,7 @@ Examples:
 
 				if flags.asJSON {
 					return out.WriteJSON(os.Stdout, map[string]any{
-						"rpc_addr": addr,
+						"rpc_addr": rpcServer.Addr(),
 						"stopped":  true,
 					})
 				}
@@ -148,29 +227,158 @@ Examples:
 		},
 	}
 
-	cmd.Flags().StringVar(&addr, "addr", "localhost:5555", "RPC server listen address")
+	cmd.Flags().StringVar(&addr, "addr", "localhost:5555", "RPC server listen address (host:port or Unix socket path)")
 	cmd.Flags().BoolVar(&enableSync, "sync", false, "run sync alongside RPC server")
 	cmd.Flags().DurationVar(&idleExit, "idle-exit", 0, "exit after being idle (0 = never)")
 	cmd.Flags().BoolVar(&downloadMedia, "download-media", false, "download media in background during sync")
 	cmd.Flags().BoolVar(&refreshContacts, "refresh-contacts", false, "refresh contacts from session store")
-	cmd.Flags().BoolVar(&refreshGroups, "refresh-groups", false, "refresh joined groups")
+	cmd.Flags().BoolVar(&refreshGroups, "refresh-groups", false, "refresh joined groups and their members (pre-warms GET /group-members)")
+	cmd.Flags().BoolVar(&tlsSelfSigned, "rpc-tls-auto-self-signed", false, "serve HTTPS with a self-signed certificate generated at startup (fingerprint printed to stderr)")
+	cmd.Flags().DurationVar(&groupMembersTTL, "group-members-ttl", time.Hour, "how long GET /group-members serves stored members before refetching (negative = always refetch)")
+	cmd.Flags().StringVar(&healthAddr, "healthcheck-addr", "", "separate listen address serving only GET /health and GET /ready")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "serve GET /metrics on this separate listen address instead of the RPC address")
+	cmd.Flags().StringToStringVar(&endpointTimeouts, "endpoint-timeouts", nil, "per-endpoint request deadlines, e.g. /send=30s,/ping=1s (408 when exceeded)")
+	cmd.Flags().StringSliceVar(&trustedCIDRs, "proxy-trusted-cidrs", nil, "comma-separated CIDRs of reverse proxies whose X-Forwarded-* headers are trusted")
+	addTLSFlags(cmd, &tlsOpts)
+	addAuthTokenFlag(cmd, &authToken)
+	addRateLimitFlags(cmd, &rateLimit, &rateBurst)
+	addWebhookFlags(cmd, &webhookURL, &webhookSecret)
 
 	return cmd
 }
 
 // waWrapper adapts the app.WAClient to rpc.WAClient interface.
 type waWrapper struct {
-	wa appPkg.WAClient
+	wa  appPkg.WAClient
+	app *appPkg.App
 }
 
 func (w *waWrapper) IsConnected() bool {
 	return w.wa != nil && w.wa.IsConnected()
 }
 
+func (w *waWrapper) HealthCheck(ctx context.Context) error {
+	if w.wa == nil {
+		return fmt.Errorf("whatsapp client not initialized")
+	}
+	return w.wa.Ping(ctx)
+}
+
 func (w *waWrapper) SendText(ctx context.Context, to types.JID, text string) (types.MessageID, error) {
 	return w.wa.SendText(ctx, to, text)
 }
 
+func (w *waWrapper) SendTextWithReply(ctx context.Context, to types.JID, text, quotedMsgID, quotedChatJID string) (types.MessageID, error) {
+	return sendTextReply(ctx, w.app, to, text, quotedMsgID, quotedChatJID)
+}
+
+func (w *waWrapper) SendImage(ctx context.Context, to types.JID, caption string, data []byte) (types.MessageID, error) {
+	return sendImageData(ctx, w.wa, to, caption, data)
+}
+
+func (w *waWrapper) SendDocument(ctx context.Context, to types.JID, filename, mimeType string, data []byte) (types.MessageID, error) {
+	return sendDocumentData(ctx, w.wa, to, filename, mimeType, data)
+}
+
+func (w *waWrapper) SendLocation(ctx context.Context, to types.JID, lat, lng float64, name string) (types.MessageID, error) {
+	return sendLocation(ctx, w.wa, to, lat, lng, name)
+}
+
+func (w *waWrapper) SendReaction(ctx context.Context, chat types.JID, msgID types.MessageID, reaction string) error {
+	return w.app.SendReaction(ctx, chat, msgID, reaction)
+}
+
+func (w *waWrapper) MarkRead(ctx context.Context, chat types.JID, msgIDs []types.MessageID) error {
+	return w.app.MarkRead(ctx, chat, msgIDs)
+}
+
+func (w *waWrapper) SendChatPresence(ctx context.Context, chat types.JID, state string) error {
+	return w.wa.SendChatPresence(ctx, chat, types.ChatPresence(state))
+}
+
+func (w *waWrapper) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error) {
+	return w.wa.GetGroupInfo(ctx, jid)
+}
+
 func (w *waWrapper) ResolveChatName(ctx context.Context, chat types.JID, pushName string) string {
 	return w.wa.ResolveChatName(ctx, chat, pushName)
 }
+
+func (w *waWrapper) Subscribe(ch chan<- store.Message)   { w.app.Subscribe(ch) }
+func (w *waWrapper) Unsubscribe(ch chan<- store.Message) { w.app.Unsubscribe(ch) }
+
+// printRPCListening reports where rpcServer listens, and the certificate
+// fingerprint to pin when it generated one.
+func printRPCListening(rpcServer *rpc.Server, addr string) {
+	if rpcServer.IsUnixSocket() {
+		fmt.Fprintf(os.Stderr, "RPC server listening on %s\n", addr)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "RPC server listening on %s://%s\n", rpcServer.Scheme(), rpcServer.Addr())
+	if fp := rpcServer.TLSFingerprint(); fp != "" {
+		fmt.Fprintf(os.Stderr, "Self-signed certificate SHA-256 fingerprint: %s\n", fp)
+	}
+}
+
+// addWebhookFlags registers the webhook flags shared by rpc and sync.
+func addWebhookFlags(cmd *cobra.Command, url, secret *string) {
+	cmd.Flags().StringVar(url, "webhook-url", "", "POST every new message as JSON to this URL")
+	cmd.Flags().StringVar(secret, "webhook-secret", "", "sign webhook bodies with HMAC-SHA256 in the "+rpc.SignatureHeader+" header")
+}
+
+// sendTextReply implements rpc.WAClient.SendTextWithReply for both wrappers.
+func sendTextReply(ctx context.Context, a *appPkg.App, to types.JID, text, quotedMsgID, quotedChatJID string) (types.MessageID, error) {
+	quotedChat := to
+	if quotedChatJID != "" {
+		var err error
+		if quotedChat, err = types.ParseJID(quotedChatJID); err != nil {
+			return "", err
+		}
+	}
+	return a.SendTextReply(ctx, to, text, quotedChat, quotedMsgID)
+}
+
+// sendLocation sends a location pin at lat, lng, labelled name if set.
+func sendLocation(ctx context.Context, c appPkg.WAClient, to types.JID, lat, lng float64, name string) (types.MessageID, error) {
+	loc := &waProto.LocationMessage{
+		DegreesLatitude:  proto.Float64(lat),
+		DegreesLongitude: proto.Float64(lng),
+	}
+	if name != "" {
+		loc.Name = proto.String(name)
+	}
+	return c.SendProtoMessage(ctx, to, &waProto.Message{LocationMessage: loc})
+}
+
+func addAuthTokenFlag(cmd *cobra.Command, token *string) {
+	cmd.Flags().StringVar(token, "auth-token", "", "require this bearer token (Authorization header or ?token=) on every RPC request except /ping; use at least 32 random bytes")
+}
+
+func addRateLimitFlags(cmd *cobra.Command, limit *float64, burst *int) {
+	cmd.Flags().Float64Var(limit, "rate-limit", 10, "RPC requests per second allowed per client IP; excess requests get 429 (negative = unlimited)")
+	cmd.Flags().IntVar(burst, "rate-burst", 20, "RPC requests a client IP may burst above --rate-limit")
+}
+
+// tlsFlags holds the certificate flags shared by rpc and sync.
+type tlsFlags struct {
+	certFile   string
+	keyFile    string
+	acmeDomain string
+}
+
+func addTLSFlags(cmd *cobra.Command, f *tlsFlags) {
+	cmd.Flags().StringVar(&f.certFile, "tls-cert", "", "serve the RPC server over HTTPS with this PEM certificate (requires --tls-key)")
+	cmd.Flags().StringVar(&f.keyFile, "tls-key", "", "PEM private key for --tls-cert")
+	cmd.Flags().StringVar(&f.acmeDomain, "tls-acme-domain", "", "serve HTTPS with a Let's Encrypt certificate for this domain (must be reachable on port 443; cached in the store directory)")
+}
+
+// apply copies the flags into opts; ACME certificates are cached next to
+// the database in storeDir.
+func (f tlsFlags) apply(opts *rpc.Options, storeDir string) {
+	opts.TLSCertFile = f.certFile
+	opts.TLSKeyFile = f.keyFile
+	opts.TLSACMEDomain = f.acmeDomain
+	if f.acmeDomain != "" {
+		opts.TLSACMECacheDir = filepath.Join(storeDir, "autocert")
+	}
+}
@@ -20,6 +20,11 @@ func newSendCmd(flags *rootFlags) *cobra.Command {
 	}
 	cmd.AddCommand(newSendTextCmd(flags))
 	cmd.AddCommand(newSendFileCmd(flags))
+	cmd.AddCommand(newSendStickerCmd(flags))
+	cmd.AddCommand(newSendAudioCmd(flags))
+	cmd.AddCommand(newSendDocumentCmd(flags))
+	cmd.AddCommand(newSendContactCmd(flags))
+	cmd.AddCommand(newSendPollCmd(flags))
 	return cmd
 }
 
@@ -78,8 +83,8 @@ func newSendTextCmd(flags *rootFlags) *cobra.Command {
 			chat := toJID
 			chatName := a.WA().ResolveChatName(ctx, chat, "")
 			kind := chatKindFromJID(chat)
-			_ = a.DB().UpsertChat(chat.String(), kind, chatName, now)
-			_ = a.DB().UpsertMessage(store.UpsertMessageParams{
+			_, _, _ = a.DB().UpsertChat(ctx, chat.String(), kind, chatName, "", now)
+			_, _, _ = a.DB().UpsertMessage(ctx, store.UpsertMessageParams{
 				ChatJID:    chat.String(),
 				ChatName:   chatName,
 				MsgID:      string(msgID),
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	appPkg "github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newSendAudioCmd(flags *rootFlags) *cobra.Command {
+	var to string
+	var filePath string
+	var voice bool
+	var duration uint32
+
+	cmd := &cobra.Command{
+		Use:   "audio",
+		Short: "Send an audio file or voice note (OGG/Opus)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" || filePath == "" {
+				return fmt.Errorf("--to and --file are required")
+			}
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			if _, err := appPkg.AudioMimeType(data, voice); err != nil {
+				return err
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+			if err := a.Connect(ctx, false, nil); err != nil {
+				return err
+			}
+
+			toJID, err := wa.ParseUserOrJID(to)
+			if err != nil {
+				return err
+			}
+
+			msgID, err := a.SendAudio(ctx, toJID, data, voice, duration)
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"sent":  true,
+					"to":    toJID.String(),
+					"id":    msgID,
+					"voice": voice,
+				})
+			}
+			kind := "audio"
+			if voice {
+				kind = "voice note"
+			}
+			fmt.Fprintf(os.Stdout, "Sent %s to %s (id %s)\n", kind, toJID.String(), msgID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "recipient phone number or JID")
+	cmd.Flags().StringVar(&filePath, "file", "", "path to the audio file (.ogg with Opus for --voice)")
+	cmd.Flags().BoolVar(&voice, "voice", false, "send as a push-to-talk voice note")
+	cmd.Flags().Uint32Var(&duration, "duration", 0, "duration in seconds shown by WhatsApp clients")
+	return cmd
+}
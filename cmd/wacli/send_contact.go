@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	appPkg "github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newSendContactCmd(flags *rootFlags) *cobra.Command {
+	var to string
+	var contact string
+	var vcfPath string
+
+	cmd := &cobra.Command{
+		Use:   "contact",
+		Short: "Share a contact card",
+		Long: `Share a contact card. --contact takes a phone number (sent as a card
+with just the number) or a JID (named from the local contacts DB);
+--vcf sends an existing vCard file instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" || (contact == "") == (vcfPath == "") {
+				return fmt.Errorf("--to and exactly one of --contact or --vcf are required")
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			var vcard string
+			if vcfPath != "" {
+				data, err := os.ReadFile(vcfPath)
+				if err != nil {
+					return err
+				}
+				vcard = string(data)
+			} else if vcard, err = a.ContactVCard(ctx, contact); err != nil {
+				return err
+			}
+			name, err := appPkg.VCardName(vcard)
+			if err != nil {
+				return err
+			}
+
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+			if err := a.Connect(ctx, false, nil); err != nil {
+				return err
+			}
+
+			toJID, err := wa.ParseUserOrJID(to)
+			if err != nil {
+				return err
+			}
+
+			msgID, err := a.SendContact(ctx, toJID, vcard)
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"sent":    true,
+					"to":      toJID.String(),
+					"id":      msgID,
+					"contact": name,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Sent contact %s to %s (id %s)\n", name, toJID.String(), msgID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "recipient phone number or JID")
+	cmd.Flags().StringVar(&contact, "contact", "", "phone number or JID of the contact to share")
+	cmd.Flags().StringVar(&vcfPath, "vcf", "", "send this vCard file instead of --contact")
+	return cmd
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appPkg "github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newSendDocumentCmd(flags *rootFlags) *cobra.Command {
+	var to string
+	var filePath string
+	var filename string
+	var caption string
+
+	cmd := &cobra.Command{
+		Use:   "document",
+		Short: "Send any file as a document attachment",
+		Long: `Send a file as a document, even if it is an image or video, so it
+arrives uncompressed. The MIME type is detected from the file.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" || filePath == "" {
+				return fmt.Errorf("--to and --file are required")
+			}
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSpace(filename)
+			if name == "" {
+				name = filepath.Base(filePath)
+			}
+			mimeType := appPkg.DetectMimeType(filePath, data)
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+			if err := a.Connect(ctx, false, nil); err != nil {
+				return err
+			}
+
+			toJID, err := wa.ParseUserOrJID(to)
+			if err != nil {
+				return err
+			}
+
+			msgID, err := a.SendDocument(ctx, toJID, data, name, mimeType, caption)
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"sent":      true,
+					"to":        toJID.String(),
+					"id":        msgID,
+					"name":      name,
+					"mime_type": mimeType,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Sent %s to %s (id %s)\n", name, toJID.String(), msgID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "recipient phone number or JID")
+	cmd.Flags().StringVar(&filePath, "file", "", "path to file")
+	cmd.Flags().StringVar(&filename, "filename", "", "display name for the file (defaults to basename of --file)")
+	cmd.Flags().StringVar(&caption, "caption", "", "caption")
+	return cmd
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -12,6 +11,7 @@ import (
 	"github.com/steipete/wacli/internal/app"
 	"github.com/steipete/wacli/internal/store"
 	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
@@ -33,14 +33,7 @@ func sendFile(ctx context.Context, a interface {
 	mimeType := strings.TrimSpace(mimeOverride)
 	if mimeType == "" {
 		// Use filePath for MIME detection, not the display name override
-		mimeType = mime.TypeByExtension(strings.ToLower(filepath.Ext(filePath)))
-	}
-	if mimeType == "" {
-		sniff := data
-		if len(sniff) > 512 {
-			sniff = sniff[:512]
-		}
-		mimeType = http.DetectContentType(sniff)
+		mimeType = app.DetectMimeType(filePath, data)
 	}
 
 	mediaType := "document"
@@ -121,8 +114,8 @@ func sendFile(ctx context.Context, a interface {
 
 	chatName := a.WA().ResolveChatName(ctx, to, "")
 	kind := chatKindFromJID(to)
-	_ = a.DB().UpsertChat(to.String(), kind, chatName, now)
-	_ = a.DB().UpsertMessage(store.UpsertMessageParams{
+	_, _, _ = a.DB().UpsertChat(ctx, to.String(), kind, chatName, "", now)
+	_, _, _ = a.DB().UpsertMessage(ctx, store.UpsertMessageParams{
 		ChatJID:       to.String(),
 		ChatName:      chatName,
 		MsgID:         id,
@@ -150,10 +143,10 @@ func sendFile(ctx context.Context, a interface {
 }
 
 func chatKindFromJID(j types.JID) string {
-	if j.Server == types.GroupServer {
+	if wa.IsGroupJID(j) {
 		return "group"
 	}
-	if j.IsBroadcastList() {
+	if wa.IsBroadcastJID(j) {
 		return "broadcast"
 	}
 	if j.Server == types.DefaultUserServer {
@@ -161,3 +154,45 @@ func chatKindFromJID(j types.JID) string {
 	}
 	return "unknown"
 }
+
+// sendImageData uploads data and sends it as an image. The MIME type is
+// sniffed from the data.
+func sendImageData(ctx context.Context, c app.WAClient, to types.JID, caption string, data []byte) (types.MessageID, error) {
+	up, err := c.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		return "", err
+	}
+	return c.SendProtoMessage(ctx, to, &waProto.Message{
+		ImageMessage: &waProto.ImageMessage{
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+			Mimetype:      proto.String(http.DetectContentType(data)),
+			Caption:       proto.String(caption),
+		},
+	})
+}
+
+// sendDocumentData uploads data and sends it as a document named filename.
+func sendDocumentData(ctx context.Context, c app.WAClient, to types.JID, filename, mimeType string, data []byte) (types.MessageID, error) {
+	up, err := c.Upload(ctx, data, whatsmeow.MediaDocument)
+	if err != nil {
+		return "", err
+	}
+	return c.SendProtoMessage(ctx, to, &waProto.Message{
+		DocumentMessage: &waProto.DocumentMessage{
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+			Mimetype:      proto.String(mimeType),
+			FileName:      proto.String(filename),
+			Title:         proto.String(filename),
+		},
+	})
+}
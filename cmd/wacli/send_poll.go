@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+func newSendPollCmd(flags *rootFlags) *cobra.Command {
+	var to string
+	var question string
+	var options []string
+	var multi bool
+
+	cmd := &cobra.Command{
+		Use:   "poll",
+		Short: "Send a poll",
+		Long: `Send a poll. Votes arriving while sync runs are tallied locally; see
+wacli polls results.
+
+Example:
+  wacli send poll --to 123456789@g.us --question "What's for lunch?" --options "Pizza,Sushi,Salad"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" || question == "" {
+				return fmt.Errorf("--to and --question are required")
+			}
+			var opts []string
+			for _, o := range options {
+				if o = strings.TrimSpace(o); o != "" {
+					opts = append(opts, o)
+				}
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+			if err := a.Connect(ctx, false, nil); err != nil {
+				return err
+			}
+
+			toJID, err := wa.ParseUserOrJID(to)
+			if err != nil {
+				return err
+			}
+
+			msgID, err := a.SendPoll(ctx, toJID, question, opts, multi)
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"sent":    true,
+					"to":      toJID.String(),
+					"id":      msgID,
+					"options": opts,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Sent poll to %s (id %s)\n", toJID.String(), msgID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "recipient phone number or JID")
+	cmd.Flags().StringVar(&question, "question", "", "poll question")
+	cmd.Flags().StringSliceVar(&options, "options", nil, "comma-separated options (2-12)")
+	cmd.Flags().BoolVar(&multi, "multi-select", false, "let voters pick more than one option")
+	return cmd
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/out"
+)
+
+func newSimulateCmd(flags *rootFlags) *cobra.Command {
+	opts := app.SimulateOptions{Scenario: app.ScenarioIncomingMessage}
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Insert synthetic messages into the local DB (no WhatsApp account needed)",
+		Long: `Insert synthetic messages into the local DB so integrations built on the
+RPC server or the DB can be tested without a real WhatsApp account.
+
+Scenarios: incoming-message, outgoing-message.
+
+Examples:
+  wacli simulate --scenario incoming-message --chat "Alice" --text "Hello"
+  wacli simulate --scenario outgoing-message --chat 1234567890@s.whatsapp.net --text "Hi"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, true)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			msg, err := a.Simulate(ctx, opts)
+			if err != nil {
+				return err
+			}
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, msg)
+			}
+			fmt.Fprintf(os.Stdout, "Stored simulated message %s in %s\n", msg.MsgID, msg.ChatJID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Scenario, "scenario", opts.Scenario, "scenario to simulate")
+	cmd.Flags().StringVar(&opts.Chat, "chat", "", "chat JID or name")
+	cmd.Flags().StringVar(&opts.Text, "text", "", "message text")
+	return cmd
+}
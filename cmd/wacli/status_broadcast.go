@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steipete/wacli/internal/out"
+)
+
+func newStatusBroadcastCmd(flags *rootFlags) *cobra.Command {
+	var text string
+	var link string
+
+	cmd := &cobra.Command{
+		Use:   "status-broadcast",
+		Short: "Post a text status",
+		Long: `Post a text status, seen by the contacts your status privacy settings
+allow. With --link, the page's Open Graph title, description and image are
+fetched and shown as a link preview; the link is appended to the text
+unless it already contains it.
+
+Examples:
+  wacli status-broadcast --text "Back online"
+  wacli status-broadcast --text "New post" --link https://example.com/post`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if text == "" && link == "" {
+				return fmt.Errorf("--text or --link is required")
+			}
+
+			ctx, cancel := withTimeout(context.Background(), flags)
+			defer cancel()
+
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+			if err := a.Connect(ctx, false, nil); err != nil {
+				return err
+			}
+
+			msgID, err := a.SendStatus(ctx, text, link)
+			if err != nil {
+				return err
+			}
+
+			if flags.asJSON {
+				return out.WriteJSON(os.Stdout, map[string]any{
+					"sent": true,
+					"id":   msgID,
+					"link": link,
+				})
+			}
+			fmt.Fprintf(os.Stdout, "Posted status (id %s)\n", msgID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&text, "text", "", "status text")
+	cmd.Flags().StringVar(&link, "link", "", "http(s) URL to attach with a link preview")
+	return cmd
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/steipete/wacli/internal/logging"
 	"github.com/steipete/wacli/internal/out"
 	"github.com/steipete/wacli/internal/rpc"
+	"github.com/steipete/wacli/internal/store"
 	"go.mau.fi/whatsmeow/types"
 )
 
@@ -25,6 +26,14 @@ func newSyncCmd(flags *rootFlags) *cobra.Command {
 	var refreshGroups bool
 	var enableRPC bool
 	var rpcAddr string
+	var rpcTLSSelfSigned bool
+	var rpcTLS tlsFlags
+	var rpcAuthToken string
+	var rpcRateLimit float64
+	var rpcRateBurst int
+	var eventLogPath string
+	var webhookURL string
+	var webhookSecret string
 
 	cmd := &cobra.Command{
 		Use:   "sync",
@@ -61,41 +70,50 @@ func newSyncCmd(flags *rootFlags) *cobra.Command {
 				mode = appPkg.SyncModeOnce
 			}
 
-			// Start RPC server if enabled
+			// The RPC server also delivers webhooks, so it is created (but
+			// not started) when only --webhook-url is given.
 			var rpcServer *rpc.Server
-			if enableRPC {
-				rpcServer, err = rpc.New(rpc.Options{
-					Addr: rpcAddr,
-					DB:   a.DB(),
-				})
+			if enableRPC || webhookURL != "" {
+				opts := rpc.Options{
+					Addr:          rpcAddr,
+					DB:            a.DB(),
+					WebhookURL:    webhookURL,
+					WebhookSecret: webhookSecret,
+					TLSSelfSigned: rpcTLSSelfSigned,
+					AuthToken:     rpcAuthToken,
+					RateLimit:     rpcRateLimit,
+					RateBurst:     rpcRateBurst,
+				}
+				rpcTLS.apply(&opts, a.StoreDir())
+				rpcServer, err = rpc.New(opts)
 				if err != nil {
 					return fmt.Errorf("create rpc server: %w", err)
 				}
-				if err := rpcServer.Start(); err != nil {
-					return fmt.Errorf("start rpc server: %w", err)
-				}
 				defer func() {
 					shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 					defer cancel()
 					_ = rpcServer.Stop(shutdownCtx)
 				}()
-				if rpcServer.IsUnixSocket() {
-					fmt.Fprintf(os.Stderr, "RPC server listening on %s\n", rpcAddr)
-				} else {
-					fmt.Fprintf(os.Stderr, "RPC server listening on http://%s\n", rpcAddr)
+			}
+			if enableRPC {
+				if err := rpcServer.Start(); err != nil {
+					return fmt.Errorf("start rpc server: %w", err)
 				}
+				printRPCListening(rpcServer, rpcAddr)
 			}
 
 			// After connect callback to set WA client for RPC
 			var afterConnect func(context.Context) error
-			if enableRPC && rpcServer != nil {
+			var onReconnecting func(bool)
+			if rpcServer != nil {
 				afterConnect = func(ctx context.Context) error {
 					if wa := a.WA(); wa != nil {
-						rpcServer.SetWA(&syncWAWrapper{wa: wa})
+						rpcServer.SetWA(&syncWAWrapper{wa: wa, app: a})
 					}
 					rpcServer.SetSyncRunning(true)
 					return nil
 				}
+				onReconnecting = rpcServer.SetReconnecting
 			}
 
 			log.Debug().Str("mode", string(mode)).Msg("calling app.Sync")
@@ -107,6 +125,8 @@ func newSyncCmd(flags *rootFlags) *cobra.Command {
 				RefreshContacts: refreshContacts,
 				RefreshGroups:   refreshGroups,
 				IdleExit:        idleExit,
+				EventLogPath:    eventLogPath,
+				OnReconnecting:  onReconnecting,
 			})
 
 			if rpcServer != nil {
@@ -117,19 +137,21 @@ func newSyncCmd(flags *rootFlags) *cobra.Command {
 				log.Error().Err(err).Msg("sync failed")
 				return err
 			}
-			log.Info().Int64("messages_stored", res.MessagesStored).Msg("sync completed")
+			log.Info().Int64("messages_stored", res.MessagesStored).Int64("messages_updated", res.MessagesUpdated).Msg("sync completed")
 
 			if flags.asJSON {
 				result := map[string]any{
-					"synced":          true,
-					"messages_stored": res.MessagesStored,
+					"synced":           true,
+					"messages_stored":  res.MessagesStored,
+					"messages_updated": res.MessagesUpdated,
+					"messages_skipped": res.SkippedCount,
 				}
 				if enableRPC {
-					result["rpc_addr"] = rpcAddr
+					result["rpc_addr"] = rpcServer.Addr()
 				}
 				return out.WriteJSON(os.Stdout, result)
 			}
-			fmt.Fprintf(os.Stdout, "Messages stored: %d\n", res.MessagesStored)
+			fmt.Fprintf(os.Stdout, "Messages stored: %d (updated: %d)\n", res.MessagesStored, res.MessagesUpdated)
 			return nil
 		},
 	}
@@ -137,27 +159,76 @@ func newSyncCmd(flags *rootFlags) *cobra.Command {
 	cmd.Flags().BoolVar(&once, "once", false, "sync until idle and exit")
 	cmd.Flags().BoolVar(&follow, "follow", true, "keep syncing until Ctrl+C")
 	cmd.Flags().DurationVar(&idleExit, "idle-exit", 30*time.Second, "exit after being idle (once mode)")
+	cmd.Flags().StringVar(&eventLogPath, "event-log", "", "append every WhatsApp event as a JSON line to this file (replay with `wacli replay`)")
 	cmd.Flags().BoolVar(&downloadMedia, "download-media", false, "download media in the background during sync")
 	cmd.Flags().BoolVar(&refreshContacts, "refresh-contacts", false, "refresh contacts from session store into local DB")
 	cmd.Flags().BoolVar(&refreshGroups, "refresh-groups", false, "refresh joined groups (live) into local DB")
 	cmd.Flags().BoolVar(&enableRPC, "rpc", false, "start HTTP RPC server alongside sync")
-	cmd.Flags().StringVar(&rpcAddr, "rpc-addr", "localhost:5555", "RPC server listen address")
+	cmd.Flags().StringVar(&rpcAddr, "rpc-addr", "localhost:5555", "RPC server listen address (host:port or Unix socket path)")
+	cmd.Flags().BoolVar(&rpcTLSSelfSigned, "rpc-tls-auto-self-signed", false, "serve the RPC server over HTTPS with a self-signed certificate generated at startup")
+	addTLSFlags(cmd, &rpcTLS)
+	addAuthTokenFlag(cmd, &rpcAuthToken)
+	addRateLimitFlags(cmd, &rpcRateLimit, &rpcRateBurst)
+	addWebhookFlags(cmd, &webhookURL, &webhookSecret)
 	return cmd
 }
 
 // syncWAWrapper adapts the app.WAClient to rpc.WAClient interface.
 type syncWAWrapper struct {
-	wa appPkg.WAClient
+	wa  appPkg.WAClient
+	app *appPkg.App
 }
 
 func (w *syncWAWrapper) IsConnected() bool {
 	return w.wa != nil && w.wa.IsConnected()
 }
 
+func (w *syncWAWrapper) HealthCheck(ctx context.Context) error {
+	if w.wa == nil {
+		return fmt.Errorf("whatsapp client not initialized")
+	}
+	return w.wa.Ping(ctx)
+}
+
 func (w *syncWAWrapper) SendText(ctx context.Context, to types.JID, text string) (types.MessageID, error) {
 	return w.wa.SendText(ctx, to, text)
 }
 
+func (w *syncWAWrapper) SendTextWithReply(ctx context.Context, to types.JID, text, quotedMsgID, quotedChatJID string) (types.MessageID, error) {
+	return sendTextReply(ctx, w.app, to, text, quotedMsgID, quotedChatJID)
+}
+
+func (w *syncWAWrapper) SendImage(ctx context.Context, to types.JID, caption string, data []byte) (types.MessageID, error) {
+	return sendImageData(ctx, w.wa, to, caption, data)
+}
+
+func (w *syncWAWrapper) SendDocument(ctx context.Context, to types.JID, filename, mimeType string, data []byte) (types.MessageID, error) {
+	return sendDocumentData(ctx, w.wa, to, filename, mimeType, data)
+}
+
+func (w *syncWAWrapper) SendLocation(ctx context.Context, to types.JID, lat, lng float64, name string) (types.MessageID, error) {
+	return sendLocation(ctx, w.wa, to, lat, lng, name)
+}
+
+func (w *syncWAWrapper) SendReaction(ctx context.Context, chat types.JID, msgID types.MessageID, reaction string) error {
+	return w.app.SendReaction(ctx, chat, msgID, reaction)
+}
+
+func (w *syncWAWrapper) MarkRead(ctx context.Context, chat types.JID, msgIDs []types.MessageID) error {
+	return w.app.MarkRead(ctx, chat, msgIDs)
+}
+
+func (w *syncWAWrapper) SendChatPresence(ctx context.Context, chat types.JID, state string) error {
+	return w.wa.SendChatPresence(ctx, chat, types.ChatPresence(state))
+}
+
+func (w *syncWAWrapper) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error) {
+	return w.wa.GetGroupInfo(ctx, jid)
+}
+
 func (w *syncWAWrapper) ResolveChatName(ctx context.Context, chat types.JID, pushName string) string {
 	return w.wa.ResolveChatName(ctx, chat, pushName)
 }
+
+func (w *syncWAWrapper) Subscribe(ch chan<- store.Message)   { w.app.Subscribe(ch) }
+func (w *syncWAWrapper) Unsubscribe(ch chan<- store.Message) { w.app.Unsubscribe(ch) }
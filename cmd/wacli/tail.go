@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	appPkg "github.com/steipete/wacli/internal/app"
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+// tailOptions selects the messages tail and head print.
+type tailOptions struct {
+	chatJID string // empty = all chats
+	lines   int
+	asJSON  bool
+}
+
+func newTailCmd(flags *rootFlags) *cobra.Command {
+	var chat string
+	var lines int
+	var follow bool
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Print the most recent messages, optionally following new ones",
+		Long: `Print the last N stored messages of all chats, or of one chat with
+--chat, oldest first, in the same format as cat. Lines name the chat
+unless --chat is given.
+
+With --follow, stay connected to WhatsApp and print new messages as they
+arrive until Ctrl+C. Like sync, this needs the store lock, so it cannot
+run next to wacli sync.
+
+Examples:
+  wacli tail -n 50
+  wacli tail --chat 14155552671 --follow`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lines < 0 {
+				return fmt.Errorf("-n must not be negative")
+			}
+			opts := tailOptions{lines: lines, asJSON: flags.asJSON}
+			if chat != "" {
+				chatJID, err := wa.ParseChatJID(chat)
+				if err != nil {
+					return err
+				}
+				opts.chatJID = chatJID.String()
+			}
+
+			if !follow {
+				ctx, cancel := withTimeout(context.Background(), flags)
+				defer cancel()
+				a, lk, err := newApp(ctx, flags, false, false)
+				if err != nil {
+					return err
+				}
+				defer closeApp(a, lk)
+				return tailMessages(ctx, a.DB(), os.Stdout, opts)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			a, lk, err := newApp(ctx, flags, true, false)
+			if err != nil {
+				return err
+			}
+			defer closeApp(a, lk)
+			if err := a.EnsureAuthed(); err != nil {
+				return err
+			}
+			if err := tailMessages(ctx, a.DB(), os.Stdout, opts); err != nil {
+				return err
+			}
+
+			ch := make(chan store.Message, 64)
+			a.Subscribe(ch)
+			defer a.Unsubscribe(ch)
+			syncDone := make(chan error, 1)
+			go func() {
+				_, err := a.Sync(ctx, appPkg.SyncOptions{Mode: appPkg.SyncModeFollow})
+				syncDone <- err
+			}()
+			return followMessages(ctx, ch, syncDone, os.Stdout, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&chat, "chat", "", "only this chat (phone number or JID)")
+	cmd.Flags().IntVarP(&lines, "lines", "n", 20, "number of messages to print")
+	cmd.Flags().BoolVar(&follow, "follow", false, "keep printing new messages until Ctrl+C")
+	return cmd
+}
+
+// tailMessages writes the opts.lines newest messages to w, oldest first.
+func tailMessages(ctx context.Context, db *store.DB, w io.Writer, opts tailOptions) error {
+	if opts.lines == 0 {
+		return nil
+	}
+	msgs, err := db.ListMessages(ctx, store.ListMessagesParams{ChatJID: opts.chatJID, Limit: opts.lines})
+	if err != nil {
+		return err
+	}
+	slices.Reverse(msgs)
+	enc := json.NewEncoder(w)
+	for _, m := range msgs {
+		if err := writeCatMessage(w, enc, m, catText(m), opts.asJSON, opts.chatJID == ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// followMessages writes each message from ch that is in opts.chatJID (or
+// any chat) until ctx is done or sync stops.
+func followMessages(ctx context.Context, ch <-chan store.Message, syncDone <-chan error, w io.Writer, opts tailOptions) error {
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-syncDone:
+			return err
+		case m := <-ch:
+			if opts.chatJID != "" && m.ChatJID != opts.chatJID {
+				continue
+			}
+			if err := writeCatMessage(w, enc, m, catText(m), opts.asJSON, opts.chatJID == ""); err != nil {
+				return err
+			}
+		}
+	}
+}
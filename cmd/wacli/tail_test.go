@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestTailMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	alice := "15551234567@s.whatsapp.net"
+	bob := "15557654321@s.whatsapp.net"
+	base := time.Date(2024, 1, 15, 14, 0, 0, 0, time.Local)
+	seedChat(t, db, alice, "dm", "Alice", base)
+	seedChat(t, db, bob, "dm", "Bob", base)
+	for i, chat := range []string{alice, bob, alice, bob, alice} {
+		if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     string(rune('a' + i)),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			FromMe:    true,
+			Text:      "msg " + string(rune('0'+i)),
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tailMessages(ctx, db, &buf, tailOptions{lines: 3}); err != nil {
+		t.Fatalf("tailMessages: %v", err)
+	}
+	want := "[2024-01-15 14:02:00] Alice / me: msg 2\n" +
+		"[2024-01-15 14:03:00] Bob / me: msg 3\n" +
+		"[2024-01-15 14:04:00] Alice / me: msg 4\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := tailMessages(ctx, db, &buf, tailOptions{chatJID: bob, lines: 20}); err != nil {
+		t.Fatalf("tailMessages: %v", err)
+	}
+	want = "[2024-01-15 14:01:00] me: msg 1\n" +
+		"[2024-01-15 14:03:00] me: msg 3\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := tailMessages(ctx, db, &buf, tailOptions{lines: 0}); err != nil || buf.Len() != 0 {
+		t.Fatalf("-n 0 printed %q, %v", buf.String(), err)
+	}
+}
+
+func TestFollowMessages(t *testing.T) {
+	ch := make(chan store.Message, 3)
+	ts := time.Date(2024, 1, 15, 14, 0, 0, 0, time.Local)
+	ch <- store.Message{ChatJID: "a@s.whatsapp.net", SenderName: "Alice", Timestamp: ts, Text: "hi"}
+	ch <- store.Message{ChatJID: "b@s.whatsapp.net", SenderName: "Bob", Timestamp: ts, Text: "skip"}
+	ch <- store.Message{ChatJID: "a@s.whatsapp.net", FromMe: true, Timestamp: ts, Text: "hello"}
+
+	var buf bytes.Buffer
+	syncDone := make(chan error, 1)
+	go func() {
+		// Let the queued messages drain before sync "stops".
+		time.Sleep(50 * time.Millisecond)
+		syncDone <- errors.New("disconnected")
+	}()
+	err := followMessages(context.Background(), ch, syncDone, &buf, tailOptions{chatJID: "a@s.whatsapp.net"})
+	if err == nil || err.Error() != "disconnected" {
+		t.Fatalf("expected the sync error, got %v", err)
+	}
+	if got := buf.String(); strings.Contains(got, "skip") || !strings.Contains(got, "Alice: hi\n") || !strings.Contains(got, "me: hello\n") {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// openTestDB opens an in-memory store that is closed when the test ends.
+func openTestDB(t *testing.T) *store.DB {
+	t.Helper()
+	db, err := store.OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// seedChat adds a chat to db, failing the test if it can't.
+func seedChat(t *testing.T, db *store.DB, jid, kind, name string, lastTS time.Time) {
+	t.Helper()
+	if _, _, err := db.UpsertChat(context.Background(), jid, kind, name, "", lastTS); err != nil {
+		t.Fatalf("UpsertChat %s: %v", jid, err)
+	}
+}
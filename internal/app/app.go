@@ -20,6 +20,7 @@ type WAClient interface {
 	IsAuthed() bool
 	IsConnected() bool
 	Connect(ctx context.Context, opts wa.ConnectOptions) error
+	Ping(ctx context.Context) error
 
 	AddEventHandler(handler func(interface{})) uint32
 	RemoveEventHandler(id uint32)
@@ -28,6 +29,7 @@ type WAClient interface {
 	ResolveChatName(ctx context.Context, chat types.JID, pushName string) string
 	GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error)
 	GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error)
+	GetProfilePicture(ctx context.Context, jid types.JID) (string, error)
 
 	GetJoinedGroups(ctx context.Context) ([]*types.GroupInfo, error)
 	GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error)
@@ -39,10 +41,14 @@ type WAClient interface {
 
 	SendText(ctx context.Context, to types.JID, text string) (types.MessageID, error)
 	SendProtoMessage(ctx context.Context, to types.JID, msg *waProto.Message) (types.MessageID, error)
+	SendStatus(ctx context.Context, msg *waProto.Message) (types.MessageID, error)
 	Upload(ctx context.Context, data []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
 	DownloadMediaToFile(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string, targetPath string) (int64, error)
 
 	DecryptReaction(ctx context.Context, reaction *events.Message) (*waProto.ReactionMessage, error)
+	DecryptPollVote(ctx context.Context, vote *events.Message) (*waProto.PollVoteMessage, error)
+	MarkRead(ctx context.Context, chat, sender types.JID, ids []types.MessageID) error
+	SendChatPresence(ctx context.Context, chat types.JID, state types.ChatPresence) error
 	RequestHistorySyncOnDemand(ctx context.Context, lastKnown types.MessageInfo, count int) (types.MessageID, error)
 	Logout(ctx context.Context) error
 }
@@ -58,6 +64,10 @@ type App struct {
 	opts Options
 	wa   WAClient
 	db   *store.DB
+	hub  messageHub
+
+	// linkPreview fetches link previews; nil means FetchLinkPreview.
+	linkPreview func(ctx context.Context, url string) (LinkPreview, error)
 }
 
 func New(opts Options) (*App, error) {
@@ -70,7 +80,7 @@ func New(opts Options) (*App, error) {
 
 	indexPath := filepath.Join(opts.StoreDir, "wacli.db")
 
-	db, err := store.Open(indexPath)
+	db, err := store.Open(store.DefaultStoreOptions(indexPath))
 	if err != nil {
 		return nil, err
 	}
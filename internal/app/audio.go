@@ -0,0 +1,73 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotOggOpus is returned when a voice note is not an OGG/Opus file, the
+// only format WhatsApp clients play as push-to-talk.
+var ErrNotOggOpus = errors.New("voice notes must be OGG/Opus")
+
+// AudioMimeType sniffs the MIME type to send data with. OGG files are
+// assumed to carry Opus; ptt additionally requires OGG.
+func AudioMimeType(data []byte, ptt bool) (string, error) {
+	if bytes.HasPrefix(data, []byte("OggS")) {
+		return "audio/ogg; codecs=opus", nil
+	}
+	if ptt {
+		return "", ErrNotOggOpus
+	}
+	switch ct := http.DetectContentType(data); {
+	case strings.HasPrefix(ct, "audio/"):
+		return ct, nil
+	case ct == "video/mp4":
+		// M4A sniffs as MP4.
+		return "audio/mp4", nil
+	default:
+		return "", fmt.Errorf("unsupported audio format %s", ct)
+	}
+}
+
+// SendAudio uploads data and sends it to `to` as an audio message, or as a
+// voice note when ptt is set. duration (in seconds) is only metadata; 0
+// leaves it unset.
+func (a *App) SendAudio(ctx context.Context, to types.JID, data []byte, ptt bool, duration uint32) (types.MessageID, error) {
+	mimeType, err := AudioMimeType(data, ptt)
+	if err != nil {
+		return "", err
+	}
+	up, err := a.wa.Upload(ctx, data, whatsmeow.MediaAudio)
+	if err != nil {
+		return "", err
+	}
+	audio := &waProto.AudioMessage{
+		URL:           proto.String(up.URL),
+		DirectPath:    proto.String(up.DirectPath),
+		MediaKey:      up.MediaKey,
+		FileEncSHA256: up.FileEncSHA256,
+		FileSHA256:    up.FileSHA256,
+		FileLength:    proto.Uint64(up.FileLength),
+		Mimetype:      proto.String(mimeType),
+		PTT:           proto.Bool(ptt),
+	}
+	if duration > 0 {
+		audio.Seconds = proto.Uint32(duration)
+	}
+	id, err := a.wa.SendProtoMessage(ctx, to, &waProto.Message{AudioMessage: audio})
+	if err != nil {
+		return "", err
+	}
+	a.storeSentMedia(ctx, to, id, up, store.UpsertMessageParams{MediaType: "audio", MimeType: mimeType})
+	return id, nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSendAudioSetsPTTAndDuration(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	to := types.JID{User: "111", Server: types.DefaultUserServer}
+	ogg := []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00OpusHead")
+
+	if _, err := a.SendAudio(ctx, to, ogg, true, 7); err != nil {
+		t.Fatalf("SendAudio: %v", err)
+	}
+	audio := f.sent[len(f.sent)-1].GetAudioMessage()
+	if audio == nil {
+		t.Fatalf("expected an audio message")
+	}
+	if !audio.GetPTT() || audio.GetSeconds() != 7 {
+		t.Fatalf("PTT = %v, Seconds = %d", audio.GetPTT(), audio.GetSeconds())
+	}
+	if audio.GetMimetype() != "audio/ogg; codecs=opus" {
+		t.Fatalf("mimetype = %q", audio.GetMimetype())
+	}
+
+	if _, err := a.SendAudio(ctx, to, ogg, false, 0); err != nil {
+		t.Fatalf("SendAudio: %v", err)
+	}
+	audio = f.sent[len(f.sent)-1].GetAudioMessage()
+	if audio.GetPTT() || audio.Seconds != nil {
+		t.Fatalf("plain audio: PTT = %v, Seconds = %v", audio.GetPTT(), audio.Seconds)
+	}
+
+	m, err := a.db.GetMessage(ctx, to.String(), "msgid")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.MediaType != "audio" || !m.FromMe {
+		t.Fatalf("stored message = %+v", m)
+	}
+
+	mp3 := []byte("ID3\x03\x00\x00\x00\x00\x00\x00")
+	if _, err := a.SendAudio(ctx, to, mp3, true, 0); !errors.Is(err, ErrNotOggOpus) {
+		t.Fatalf("expected ErrNotOggOpus for an mp3 voice note, got %v", err)
+	}
+	if mt, err := AudioMimeType(mp3, false); err != nil || mt != "audio/mpeg" {
+		t.Fatalf("mp3 mime = %q, %v", mt, err)
+	}
+}
@@ -75,7 +75,7 @@ func (a *App) BackfillHistory(ctx context.Context, opts BackfillOptions) (Backfi
 		return BackfillResult{}, err
 	}
 
-	beforeCount, _ := a.db.CountMessages()
+	beforeCount, _ := a.db.CountMessages(ctx)
 
 	var mu sync.Mutex
 	var waitCh chan onDemandResponse
@@ -121,7 +121,7 @@ func (a *App) BackfillHistory(ctx context.Context, opts BackfillOptions) (Backfi
 		IdleExit: opts.IdleExit,
 		AfterConnect: func(ctx context.Context) error {
 			for i := 0; i < opts.Requests; i++ {
-				oldest, err := a.db.GetOldestMessageInfo(chatStr)
+				oldest, err := a.db.GetOldestMessageInfo(ctx, chatStr)
 				if err != nil {
 					if err == sql.ErrNoRows {
 						return fmt.Errorf("no messages for %s in local DB; run `wacli sync` first", chatStr)
@@ -177,7 +177,7 @@ func (a *App) BackfillHistory(ctx context.Context, opts BackfillOptions) (Backfi
 					Msg("received history sync response")
 				fmt.Fprintf(os.Stderr, "On-demand history sync: %d conversations, %d messages.\n", resp.conversations, resp.messages)
 
-				newOldest, err := a.db.GetOldestMessageInfo(chatStr)
+				newOldest, err := a.db.GetOldestMessageInfo(ctx, chatStr)
 				if err == nil && newOldest.MsgID == oldest.MsgID {
 					fmt.Fprintln(os.Stderr, "No older messages were added (stopping).")
 					return nil
@@ -198,7 +198,7 @@ func (a *App) BackfillHistory(ctx context.Context, opts BackfillOptions) (Backfi
 		return BackfillResult{}, err
 	}
 
-	afterCount, _ := a.db.CountMessages()
+	afterCount, _ := a.db.CountMessages(ctx)
 	messagesAdded := afterCount - beforeCount
 
 	log.Info().
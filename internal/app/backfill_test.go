@@ -16,6 +16,7 @@ import (
 )
 
 func TestBackfillHistoryAddsOlderMessages(t *testing.T) {
+	ctx := context.Background()
 	a := newTestApp(t)
 	f := newFakeWA()
 	a.wa = f
@@ -24,10 +25,10 @@ func TestBackfillHistoryAddsOlderMessages(t *testing.T) {
 	chatStr := chat.String()
 	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 
-	if err := a.db.UpsertChat(chatStr, "dm", "Alice", base); err != nil {
+	if _, _, err := a.db.UpsertChat(ctx, chatStr, "dm", "Alice", "", base); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
-	if err := a.db.UpsertMessage(storeUpsertMessage(chatStr, "m2", base.Add(2*time.Second), "newer")); err != nil {
+	if _, _, err := a.db.UpsertMessage(ctx, storeUpsertMessage(chatStr, "m2", base.Add(2*time.Second), "newer")); err != nil {
 		t.Fatalf("UpsertMessage: %v", err)
 	}
 
@@ -71,7 +72,7 @@ func TestBackfillHistoryAddsOlderMessages(t *testing.T) {
 		t.Fatalf("expected messages to be added, got %d", res.MessagesAdded)
 	}
 
-	oldest, err := a.db.GetOldestMessageInfo(chatStr)
+	oldest, err := a.db.GetOldestMessageInfo(ctx, chatStr)
 	if err != nil {
 		t.Fatalf("GetOldestMessageInfo: %v", err)
 	}
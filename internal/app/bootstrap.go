@@ -3,6 +3,10 @@ package app
 import (
 	"context"
 	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
 )
 
 func (a *App) refreshContacts(ctx context.Context) error {
@@ -14,7 +18,7 @@ func (a *App) refreshContacts(ctx context.Context) error {
 		return err
 	}
 	for jid, info := range contacts {
-		_ = a.db.UpsertContact(
+		_ = a.db.UpsertContact(ctx,
 			jid.String(),
 			jid.User,
 			info.PushName,
@@ -22,10 +26,23 @@ func (a *App) refreshContacts(ctx context.Context) error {
 			info.FirstName,
 			info.BusinessName,
 		)
+		// Only chats we already have; fetching every contact's photo is slow.
+		if _, err := a.db.GetChat(ctx, jid.String()); err == nil {
+			a.refreshAvatar(ctx, jid)
+		}
 	}
 	return nil
 }
 
+// refreshAvatar stores the current photo URL for chat. Failures are ignored.
+func (a *App) refreshAvatar(ctx context.Context, chat types.JID) {
+	url, err := a.wa.GetProfilePicture(ctx, chat)
+	if err != nil {
+		return
+	}
+	_ = a.db.SetChatAvatar(ctx, chat.String(), url)
+}
+
 func (a *App) refreshGroups(ctx context.Context) error {
 	if err := a.OpenWA(); err != nil {
 		return err
@@ -39,8 +56,19 @@ func (a *App) refreshGroups(ctx context.Context) error {
 		if g == nil {
 			continue
 		}
-		_ = a.db.UpsertGroup(g.JID.String(), g.GroupName.Name, g.OwnerJID.String(), g.GroupCreated)
-		_ = a.db.UpsertChat(g.JID.String(), "group", g.GroupName.Name, now)
+		_ = a.db.UpsertGroup(ctx, g.JID.String(), g.GroupName.Name, g.OwnerJID.String(), g.GroupCreated)
+		_, _, _ = a.db.UpsertChat(ctx, g.JID.String(), "group", g.GroupName.Name, g.Topic, now)
+		if len(g.Participants) > 0 {
+			ps := make([]store.GroupParticipant, 0, len(g.Participants))
+			for _, p := range g.Participants {
+				ps = append(ps, store.GroupParticipant{
+					GroupJID: g.JID.String(),
+					UserJID:  p.JID.String(),
+					Role:     wa.ParticipantRole(p),
+				})
+			}
+			_ = a.db.ReplaceGroupParticipants(ctx, g.JID.String(), ps)
+		}
 	}
 	return nil
 }
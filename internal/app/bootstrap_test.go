@@ -9,6 +9,7 @@ import (
 )
 
 func TestRefreshContactsStoresContacts(t *testing.T) {
+	ctx := context.Background()
 	a := newTestApp(t)
 	f := newFakeWA()
 	a.wa = f
@@ -24,7 +25,7 @@ func TestRefreshContactsStoresContacts(t *testing.T) {
 	if err := a.refreshContacts(context.Background()); err != nil {
 		t.Fatalf("refreshContacts: %v", err)
 	}
-	c, err := a.db.GetContact(jid.String())
+	c, err := a.db.GetContact(ctx, jid.String())
 	if err != nil {
 		t.Fatalf("GetContact: %v", err)
 	}
@@ -34,6 +35,7 @@ func TestRefreshContactsStoresContacts(t *testing.T) {
 }
 
 func TestRefreshGroupsStoresGroupsAndChats(t *testing.T) {
+	ctx := context.Background()
 	a := newTestApp(t)
 	f := newFakeWA()
 	a.wa = f
@@ -45,23 +47,34 @@ func TestRefreshGroupsStoresGroupsAndChats(t *testing.T) {
 		OwnerJID:     types.JID{User: "999", Server: types.DefaultUserServer},
 		GroupName:    types.GroupName{Name: "MyGroup"},
 		GroupCreated: created,
+		Participants: []types.GroupParticipant{
+			{JID: types.JID{User: "999", Server: types.DefaultUserServer}, IsSuperAdmin: true},
+			{JID: types.JID{User: "111", Server: types.DefaultUserServer}},
+		},
 	}
 
 	if err := a.refreshGroups(context.Background()); err != nil {
 		t.Fatalf("refreshGroups: %v", err)
 	}
-	gs, err := a.db.ListGroups("MyGroup", 10)
+	gs, err := a.db.ListGroups(ctx, "MyGroup", 10)
 	if err != nil {
 		t.Fatalf("ListGroups: %v", err)
 	}
 	if len(gs) != 1 || gs[0].JID != gid.String() {
 		t.Fatalf("expected group to be stored, got %+v", gs)
 	}
-	c, err := a.db.GetChat(gid.String())
+	c, err := a.db.GetChat(ctx, gid.String())
 	if err != nil {
 		t.Fatalf("GetChat: %v", err)
 	}
 	if c.Kind != "group" {
 		t.Fatalf("expected chat kind group, got %q", c.Kind)
 	}
+	ps, err := a.db.ListGroupParticipants(ctx, gid.String())
+	if err != nil {
+		t.Fatalf("ListGroupParticipants: %v", err)
+	}
+	if len(ps) != 2 || ps[0].Role != "superadmin" || !ps[0].IsAdmin() || ps[1].IsAdmin() {
+		t.Fatalf("unexpected participants: %+v", ps)
+	}
 }
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidVCard is returned for contact cards WhatsApp would not accept.
+var ErrInvalidVCard = errors.New("invalid vCard")
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+// BuildVCard returns a vCard 3.0 for name with one phone number, which may
+// have a leading +. The waid parameter lets WhatsApp offer to message it.
+func BuildVCard(name, phone string) string {
+	digits := strings.TrimPrefix(strings.TrimSpace(phone), "+")
+	if name = strings.TrimSpace(name); name == "" {
+		name = "+" + digits
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\n")
+	b.WriteString("VERSION:3.0\n")
+	fmt.Fprintf(&b, "FN:%s\n", vcardEscaper.Replace(name))
+	fmt.Fprintf(&b, "TEL;type=CELL;type=VOICE;waid=%s:+%s\n", digits, digits)
+	b.WriteString("END:VCARD\n")
+	return b.String()
+}
+
+// VCardName returns the FN (formatted name) of vcard, unescaped.
+func VCardName(vcard string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(strings.ToUpper(vcard)), "BEGIN:VCARD") {
+		return "", fmt.Errorf("%w: missing BEGIN:VCARD", ErrInvalidVCard)
+	}
+	for _, line := range strings.Split(vcard, "\n") {
+		line = strings.TrimRight(line, "\r")
+		prop, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if name, _, _ := strings.Cut(prop, ";"); strings.EqualFold(name, "FN") {
+			return unescapeVCard(value), nil
+		}
+	}
+	return "", fmt.Errorf("%w: missing FN", ErrInvalidVCard)
+}
+
+func unescapeVCard(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ContactVCard builds the vCard for contact, a phone number or JID. A JID
+// is looked up in the store for its name (alias first); a phone number gets
+// a card with just the number.
+func (a *App) ContactVCard(ctx context.Context, contact string) (string, error) {
+	contact = strings.TrimSpace(contact)
+	jid, err := wa.ParseUserOrJID(contact)
+	if err != nil {
+		return "", err
+	}
+	if jid.Server != types.DefaultUserServer {
+		return "", fmt.Errorf("%s is not a user JID", jid)
+	}
+	if !strings.Contains(contact, "@") {
+		return BuildVCard("", jid.User), nil
+	}
+
+	name, phone := "", jid.User
+	if c, err := a.db.GetContact(ctx, jid.String()); err == nil {
+		name = c.Alias
+		if name == "" {
+			name = c.Name
+		}
+		if c.Phone != "" {
+			phone = c.Phone
+		}
+	}
+	return BuildVCard(name, phone), nil
+}
+
+// SendContact sends vcard to `to` as a contact card, named after its FN.
+func (a *App) SendContact(ctx context.Context, to types.JID, vcard string) (types.MessageID, error) {
+	name, err := VCardName(vcard)
+	if err != nil {
+		return "", err
+	}
+	id, err := a.wa.SendProtoMessage(ctx, to, &waProto.Message{
+		ContactMessage: &waProto.ContactMessage{
+			DisplayName: proto.String(name),
+			Vcard:       proto.String(vcard),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	a.storeSent(ctx, to, id, store.UpsertMessageParams{DisplayText: "Sent contact: " + name})
+	return id, nil
+}
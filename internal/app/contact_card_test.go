@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestContactVCard(t *testing.T) {
+	a := newTestApp(t)
+	ctx := context.Background()
+	_ = a.db.UpsertContact(ctx, "15551234567@s.whatsapp.net", "15551234567", "ali", "Alice Smith", "Alice", "")
+	_ = a.db.UpsertContact(ctx, "15557654321@s.whatsapp.net", "15557654321", "", "Robert", "", "")
+	_ = a.db.SetAlias(ctx, "15557654321@s.whatsapp.net", "Bob; the builder")
+
+	tests := []struct {
+		contact string
+		fn      string
+		tel     string
+	}{
+		{"15551234567@s.whatsapp.net", "FN:Alice Smith", "waid=15551234567:+15551234567"},
+		{"15557654321@s.whatsapp.net", `FN:Bob\; the builder`, "waid=15557654321:+15557654321"},
+		{"15550000000@s.whatsapp.net", "FN:+15550000000", "waid=15550000000:+15550000000"},
+		{"+44 7911 123456", "FN:+447911123456", "waid=447911123456:+447911123456"},
+	}
+	for _, tt := range tests {
+		vcard, err := a.ContactVCard(ctx, tt.contact)
+		if err != nil {
+			t.Fatalf("ContactVCard(%q): %v", tt.contact, err)
+		}
+		if !strings.Contains(vcard, "\n"+tt.fn+"\n") || !strings.Contains(vcard, tt.tel) {
+			t.Errorf("ContactVCard(%q) = %q, want %s and %s", tt.contact, vcard, tt.fn, tt.tel)
+		}
+	}
+
+	if _, err := a.ContactVCard(ctx, "123@g.us"); err == nil {
+		t.Fatal("expected an error for a group JID")
+	}
+}
+
+func TestSendContact(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	to := types.JID{User: "111", Server: types.DefaultUserServer}
+	vcard := BuildVCard("Bob; the builder", "+15557654321")
+	if _, err := a.SendContact(ctx, to, vcard); err != nil {
+		t.Fatalf("SendContact: %v", err)
+	}
+	contact := f.sent[len(f.sent)-1].GetContactMessage()
+	if contact == nil {
+		t.Fatal("expected a contact message")
+	}
+	if contact.GetDisplayName() != "Bob; the builder" || contact.GetVcard() != vcard {
+		t.Fatalf("contact = %q / %q", contact.GetDisplayName(), contact.GetVcard())
+	}
+
+	if _, err := a.SendContact(ctx, to, "FN:nobody"); !errors.Is(err, ErrInvalidVCard) {
+		t.Fatalf("expected ErrInvalidVCard, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// DetectMimeType guesses the MIME type of a file from its extension, falling
+// back to sniffing its first bytes.
+func DetectMimeType(path string, data []byte) string {
+	if mt := mime.TypeByExtension(strings.ToLower(filepath.Ext(path))); mt != "" {
+		return mt
+	}
+	sniff := data
+	if len(sniff) > 512 {
+		sniff = sniff[:512]
+	}
+	return http.DetectContentType(sniff)
+}
+
+// SendDocument uploads data and sends it to `to` as a document shown as
+// filename, whatever its type, so images and videos keep their quality.
+func (a *App) SendDocument(ctx context.Context, to types.JID, data []byte, filename, mimeType, caption string) (types.MessageID, error) {
+	up, err := a.wa.Upload(ctx, data, whatsmeow.MediaDocument)
+	if err != nil {
+		return "", err
+	}
+	doc := &waProto.DocumentMessage{
+		URL:           proto.String(up.URL),
+		DirectPath:    proto.String(up.DirectPath),
+		MediaKey:      up.MediaKey,
+		FileEncSHA256: up.FileEncSHA256,
+		FileSHA256:    up.FileSHA256,
+		FileLength:    proto.Uint64(up.FileLength),
+		Mimetype:      proto.String(mimeType),
+		FileName:      proto.String(filename),
+		Title:         proto.String(filename),
+	}
+	if caption != "" {
+		doc.Caption = proto.String(caption)
+	}
+	id, err := a.wa.SendProtoMessage(ctx, to, &waProto.Message{DocumentMessage: doc})
+	if err != nil {
+		return "", err
+	}
+	a.storeSentMedia(ctx, to, id, up, store.UpsertMessageParams{
+		Text:         caption,
+		MediaType:    "document",
+		MediaCaption: caption,
+		Filename:     filename,
+		MimeType:     mimeType,
+	})
+	return id, nil
+}
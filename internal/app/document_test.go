@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestDetectMimeType(t *testing.T) {
+	tests := []struct {
+		path string
+		data []byte
+		want string
+	}{
+		{"report.pdf", []byte("%PDF-1.7"), "application/pdf"},
+		{"REPORT.PDF", nil, "application/pdf"},
+		{"/tmp/abc123", []byte("%PDF-1.4\n"), "application/pdf"},
+		{"notes", []byte("plain words"), "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		if got := DetectMimeType(tt.path, tt.data); got != tt.want {
+			t.Errorf("DetectMimeType(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSendDocumentForwardsFilename(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	to := types.JID{User: "111", Server: types.DefaultUserServer}
+	data := []byte("%PDF-1.7")
+	if _, err := a.SendDocument(ctx, to, data, "Q3 report.pdf", DetectMimeType("/tmp/upload-1.pdf", data), "see attached"); err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	doc := f.sent[len(f.sent)-1].GetDocumentMessage()
+	if doc == nil {
+		t.Fatalf("expected a document message")
+	}
+	if doc.GetMimetype() != "application/pdf" || doc.GetFileName() != "Q3 report.pdf" || doc.GetCaption() != "see attached" {
+		t.Fatalf("document = mimetype %q, filename %q, caption %q", doc.GetMimetype(), doc.GetFileName(), doc.GetCaption())
+	}
+
+	m, err := a.db.GetMediaDownloadInfo(ctx, to.String(), "msgid")
+	if err != nil {
+		t.Fatalf("GetMediaDownloadInfo: %v", err)
+	}
+	if m.MediaType != "document" || m.Filename != "Q3 report.pdf" || m.MimeType != "application/pdf" {
+		t.Fatalf("stored message = %+v", m)
+	}
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/steipete/wacli/internal/logging"
+)
+
+// eventLogBuffer is how many events may queue up before new ones are
+// dropped; the event handler must never block on disk I/O.
+const eventLogBuffer = 1024
+
+type loggedEvent struct {
+	evt interface{}
+	at  time.Time
+}
+
+// EventLogger appends whatsmeow events to a file as EventRecord JSON lines.
+// Writes happen on a background goroutine; Close drains and flushes.
+type EventLogger struct {
+	ch      chan loggedEvent
+	done    chan struct{}
+	out     io.Writer
+	closer  io.Closer
+	mu      sync.RWMutex // guards closed against late Log calls
+	closed  bool
+	dropped atomic.Int64
+}
+
+// OpenEventLog opens (or creates) path for appending.
+func OpenEventLog(path string) (*EventLogger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	l := NewEventLogger(f)
+	l.closer = f
+	return l, nil
+}
+
+// NewEventLogger writes events to w. Closing the logger does not close w.
+func NewEventLogger(w io.Writer) *EventLogger {
+	l := &EventLogger{
+		ch:   make(chan loggedEvent, eventLogBuffer),
+		done: make(chan struct{}),
+		out:  w,
+	}
+	go l.run()
+	return l
+}
+
+// Log queues evt for writing. It never blocks; events are dropped (and
+// counted) when the queue is full or the logger is closed.
+func (l *EventLogger) Log(evt interface{}) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		l.dropped.Add(1)
+		return
+	}
+	select {
+	case l.ch <- loggedEvent{evt: evt, at: time.Now()}:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many events were discarded because the queue was full.
+func (l *EventLogger) Dropped() int64 { return l.dropped.Load() }
+
+// Close stops accepting events, writes everything still queued and closes
+// the underlying file if the logger opened it.
+func (l *EventLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.ch)
+	l.mu.Unlock()
+
+	<-l.done
+	if l.closer != nil {
+		return l.closer.Close()
+	}
+	return nil
+}
+
+func (l *EventLogger) run() {
+	defer close(l.done)
+	log := logging.WithComponent("eventlog")
+	bw := bufio.NewWriter(l.out)
+	enc := json.NewEncoder(bw)
+	for e := range l.ch {
+		rec, err := EncodeEvent(e.evt, e.at)
+		if err != nil {
+			log.Debug().Err(err).Msg("skipping event")
+			continue
+		}
+		if err := enc.Encode(rec); err != nil {
+			log.Warn().Err(err).Msg("failed to write event")
+		}
+		// Flush once the queue is drained so the file stays current
+		// without a write per event during bursts.
+		if len(l.ch) == 0 {
+			if err := bw.Flush(); err != nil {
+				log.Warn().Err(err).Msg("failed to flush event log")
+			}
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		log.Warn().Err(err).Msg("failed to flush event log")
+	}
+}
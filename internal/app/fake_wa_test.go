@@ -28,6 +28,18 @@ type fakeWA struct {
 
 	contacts map[types.JID]types.ContactInfo
 	groups   map[types.JID]*types.GroupInfo
+	avatars  map[types.JID]string
+
+	// readReceipts records MarkRead calls by sender.
+	readReceipts map[types.JID][]types.MessageID
+
+	// sent records SendProtoMessage calls.
+	sent []*waProto.Message
+	// statuses records SendStatus calls.
+	statuses []*waProto.Message
+
+	// pollVotes answers DecryptPollVote by the vote message's ID.
+	pollVotes map[types.MessageID]*waProto.PollVoteMessage
 
 	onDemandHistory func(lastKnown types.MessageInfo, count int) *events.HistorySync
 }
@@ -38,6 +50,8 @@ func newFakeWA() *fakeWA {
 		handlers:      map[uint32]func(interface{}){},
 		contacts:      map[types.JID]types.ContactInfo{},
 		groups:        map[types.JID]*types.GroupInfo{},
+		avatars:       map[types.JID]string{},
+		readReceipts:  map[types.JID][]types.MessageID{},
 		nextHandlerID: 1,
 	}
 }
@@ -63,6 +77,13 @@ func (f *fakeWA) IsConnected() bool {
 	return f.connected
 }
 
+func (f *fakeWA) Ping(ctx context.Context) error {
+	if !f.IsConnected() {
+		return fmt.Errorf("not connected")
+	}
+	return nil
+}
+
 func (f *fakeWA) Connect(ctx context.Context, opts wa.ConnectOptions) error {
 	f.mu.Lock()
 	authed := f.authed
@@ -145,6 +166,23 @@ func (f *fakeWA) GetJoinedGroups(ctx context.Context) ([]*types.GroupInfo, error
 	return out, nil
 }
 
+func (f *fakeWA) GetProfilePicture(ctx context.Context, jid types.JID) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.avatars[jid], nil
+}
+
+func (f *fakeWA) MarkRead(ctx context.Context, chat, sender types.JID, ids []types.MessageID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readReceipts[sender] = append(f.readReceipts[sender], ids...)
+	return nil
+}
+
+func (f *fakeWA) SendChatPresence(ctx context.Context, chat types.JID, state types.ChatPresence) error {
+	return nil
+}
+
 func (f *fakeWA) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -209,9 +247,19 @@ func (f *fakeWA) SendText(ctx context.Context, to types.JID, text string) (types
 }
 
 func (f *fakeWA) SendProtoMessage(ctx context.Context, to types.JID, msg *waProto.Message) (types.MessageID, error) {
+	f.mu.Lock()
+	f.sent = append(f.sent, msg)
+	f.mu.Unlock()
 	return types.MessageID("msgid"), nil
 }
 
+func (f *fakeWA) SendStatus(ctx context.Context, msg *waProto.Message) (types.MessageID, error) {
+	f.mu.Lock()
+	f.statuses = append(f.statuses, msg)
+	f.mu.Unlock()
+	return types.MessageID("statusid"), nil
+}
+
 func (f *fakeWA) Upload(ctx context.Context, data []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
 	return whatsmeow.UploadResponse{}, nil
 }
@@ -220,6 +268,15 @@ func (f *fakeWA) DecryptReaction(ctx context.Context, reaction *events.Message)
 	return nil, fmt.Errorf("not supported")
 }
 
+func (f *fakeWA) DecryptPollVote(ctx context.Context, vote *events.Message) (*waProto.PollVoteMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if v, ok := f.pollVotes[vote.Info.ID]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("no poll vote for %s", vote.Info.ID)
+}
+
 func (f *fakeWA) DownloadMediaToFile(ctx context.Context, directPath string, encFileHash, fileHash, mediaKey []byte, fileLength uint64, mediaType, mmsType string, targetPath string) (int64, error) {
 	if err := os.MkdirAll(filepath.Dir(targetPath), 0o700); err != nil {
 		return 0, err
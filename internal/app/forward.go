@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ForwardHistoryOptions selects the messages ForwardHistory copies.
+type ForwardHistoryOptions struct {
+	From  types.JID
+	To    types.JID
+	Limit int           // the newest Limit messages of From; zero means 50
+	Delay time.Duration // pause between sends
+	// DryRun reports what would be forwarded without sending anything.
+	DryRun bool
+}
+
+// ForwardedMessage is one message ForwardHistory forwarded or skipped.
+type ForwardedMessage struct {
+	SourceID  string    `json:"source_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"` // text or the media type
+	Text      string    `json:"text,omitempty"`
+	NewID     string    `json:"new_id,omitempty"`
+	// Skipped says why a message was not forwarded, e.g. a poll or media
+	// whose keys were never synced.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// ForwardHistoryResult summarizes a ForwardHistory run.
+type ForwardHistoryResult struct {
+	Forwarded int                `json:"forwarded"`
+	Skipped   int                `json:"skipped"`
+	Messages  []ForwardedMessage `json:"messages"`
+}
+
+// ForwardHistory forwards the newest messages of one chat to another,
+// oldest first, marked as forwarded. Text is resent as text; media reuses
+// the original upload, so it is not downloaded again. Other messages
+// (polls, reactions, locations, ...) are skipped.
+func (a *App) ForwardHistory(ctx context.Context, opts ForwardHistoryOptions) (ForwardHistoryResult, error) {
+	if opts.From == opts.To {
+		return ForwardHistoryResult{}, fmt.Errorf("source and destination are the same chat")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+	msgs, err := a.db.ListMessages(ctx, store.ListMessagesParams{ChatJID: opts.From.String(), Limit: opts.Limit})
+	if err != nil {
+		return ForwardHistoryResult{}, err
+	}
+
+	var res ForwardHistoryResult
+	sent := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+		fm := ForwardedMessage{SourceID: m.MsgID, Timestamp: m.Timestamp, Kind: "text", Text: m.Text}
+		msg, skip := a.forwardable(ctx, m)
+		if m.MediaType != "" {
+			fm.Kind = m.MediaType
+		}
+		if skip != "" {
+			fm.Skipped = skip
+			res.Skipped++
+			res.Messages = append(res.Messages, fm)
+			continue
+		}
+		if opts.DryRun {
+			res.Forwarded++
+			res.Messages = append(res.Messages, fm)
+			continue
+		}
+
+		if sent > 0 && opts.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return res, ctx.Err()
+			case <-time.After(opts.Delay):
+			}
+		}
+		id, err := a.wa.SendProtoMessage(ctx, opts.To, msg)
+		if err != nil {
+			return res, fmt.Errorf("forward %s: %w", m.MsgID, err)
+		}
+		sent++
+		a.storeSent(ctx, opts.To, id, store.UpsertMessageParams{
+			Text:           m.Text,
+			DisplayText:    m.DisplayText,
+			MediaType:      m.MediaType,
+			ForwardedScore: m.ForwardedScore + 1,
+		})
+		fm.NewID = string(id)
+		res.Forwarded++
+		res.Messages = append(res.Messages, fm)
+	}
+	return res, nil
+}
+
+// forwardable builds the forwarded copy of m, or says why it cannot.
+func (a *App) forwardable(ctx context.Context, m store.Message) (*waProto.Message, string) {
+	ci := &waProto.ContextInfo{
+		IsForwarded:     proto.Bool(true),
+		ForwardingScore: proto.Uint32(m.ForwardedScore + 1),
+	}
+	if m.MediaType == "" {
+		if m.Text == "" {
+			return nil, "no text or media"
+		}
+		return &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(m.Text),
+			ContextInfo: ci,
+		}}, ""
+	}
+
+	info, err := a.db.GetMediaDownloadInfo(ctx, m.ChatJID, m.MsgID)
+	if err != nil || info.DirectPath == "" || len(info.MediaKey) == 0 {
+		return nil, "media keys not synced"
+	}
+	caption := proto.String(m.Text)
+	switch m.MediaType {
+	case "image":
+		return &waProto.Message{ImageMessage: &waProto.ImageMessage{
+			DirectPath: proto.String(info.DirectPath), MediaKey: info.MediaKey,
+			FileSHA256: info.FileSHA256, FileEncSHA256: info.FileEncSHA256,
+			FileLength: proto.Uint64(info.FileLength), Mimetype: proto.String(info.MimeType),
+			Caption: caption, ContextInfo: ci,
+		}}, ""
+	case "video", "gif":
+		return &waProto.Message{VideoMessage: &waProto.VideoMessage{
+			DirectPath: proto.String(info.DirectPath), MediaKey: info.MediaKey,
+			FileSHA256: info.FileSHA256, FileEncSHA256: info.FileEncSHA256,
+			FileLength: proto.Uint64(info.FileLength), Mimetype: proto.String(info.MimeType),
+			Caption: caption, GifPlayback: proto.Bool(m.MediaType == "gif"), ContextInfo: ci,
+		}}, ""
+	case "audio":
+		return &waProto.Message{AudioMessage: &waProto.AudioMessage{
+			DirectPath: proto.String(info.DirectPath), MediaKey: info.MediaKey,
+			FileSHA256: info.FileSHA256, FileEncSHA256: info.FileEncSHA256,
+			FileLength: proto.Uint64(info.FileLength), Mimetype: proto.String(info.MimeType),
+			ContextInfo: ci,
+		}}, ""
+	case "document":
+		return &waProto.Message{DocumentMessage: &waProto.DocumentMessage{
+			DirectPath: proto.String(info.DirectPath), MediaKey: info.MediaKey,
+			FileSHA256: info.FileSHA256, FileEncSHA256: info.FileEncSHA256,
+			FileLength: proto.Uint64(info.FileLength), Mimetype: proto.String(info.MimeType),
+			FileName: proto.String(info.Filename), Title: proto.String(info.Filename),
+			Caption: caption, ContextInfo: ci,
+		}}, ""
+	case "sticker":
+		return &waProto.Message{StickerMessage: &waProto.StickerMessage{
+			DirectPath: proto.String(info.DirectPath), MediaKey: info.MediaKey,
+			FileSHA256: info.FileSHA256, FileEncSHA256: info.FileEncSHA256,
+			FileLength: proto.Uint64(info.FileLength), Mimetype: proto.String(info.MimeType),
+			ContextInfo: ci,
+		}}, ""
+	default:
+		return nil, "unsupported media type " + m.MediaType
+	}
+}
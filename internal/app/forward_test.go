@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestForwardHistory(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+	ctx := context.Background()
+
+	from := types.JID{User: "111", Server: types.DefaultUserServer}
+	to := types.JID{User: "222", Server: types.DefaultUserServer}
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, _, _ = a.db.UpsertChat(ctx, from.String(), "dm", "Alice", "", base)
+	for i, p := range []store.UpsertMessageParams{
+		{MsgID: "old", Text: "too old"},
+		{MsgID: "t1", Text: "first", ForwardedScore: 2},
+		{MsgID: "img", Text: "a photo", MediaType: "image", MimeType: "image/jpeg", DirectPath: "/v/t62/abc", MediaKey: []byte{1, 2, 3}, FileLength: 10},
+		{MsgID: "nokeys", MediaType: "video"},
+		{MsgID: "t2", Text: "last"},
+	} {
+		p.ChatJID = from.String()
+		p.SenderJID = from.String()
+		p.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if _, _, err := a.db.UpsertMessage(ctx, p); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	opts := ForwardHistoryOptions{From: from, To: to, Limit: 4}
+
+	dry := opts
+	dry.DryRun = true
+	res, err := a.ForwardHistory(ctx, dry)
+	if err != nil {
+		t.Fatalf("ForwardHistory dry run: %v", err)
+	}
+	if res.Forwarded != 3 || res.Skipped != 1 || len(f.sent) != 0 {
+		t.Fatalf("dry run: forwarded %d, skipped %d, sent %d", res.Forwarded, res.Skipped, len(f.sent))
+	}
+
+	res, err = a.ForwardHistory(ctx, opts)
+	if err != nil {
+		t.Fatalf("ForwardHistory: %v", err)
+	}
+	if res.Forwarded != 3 || res.Skipped != 1 || len(f.sent) != 3 {
+		t.Fatalf("forwarded %d, skipped %d, sent %d", res.Forwarded, res.Skipped, len(f.sent))
+	}
+	if got := res.Messages[0].SourceID; got != "t1" {
+		t.Fatalf("expected oldest first, got %s", got)
+	}
+	if res.Messages[2].Skipped == "" || res.Messages[2].SourceID != "nokeys" {
+		t.Fatalf("expected nokeys skipped, got %+v", res.Messages[2])
+	}
+
+	text := f.sent[0].GetExtendedTextMessage()
+	if text.GetText() != "first" || !text.GetContextInfo().GetIsForwarded() || text.GetContextInfo().GetForwardingScore() != 3 {
+		t.Fatalf("forwarded text = %v", text)
+	}
+	img := f.sent[1].GetImageMessage()
+	if img.GetDirectPath() != "/v/t62/abc" || img.GetCaption() != "a photo" || !img.GetContextInfo().GetIsForwarded() {
+		t.Fatalf("forwarded image = %v", img)
+	}
+	if f.sent[2].GetExtendedTextMessage().GetText() != "last" {
+		t.Fatalf("expected last message sent last, got %v", f.sent[2])
+	}
+
+	if _, err := a.ForwardHistory(ctx, ForwardHistoryOptions{From: from, To: from}); err == nil {
+		t.Fatal("expected an error forwarding a chat to itself")
+	}
+}
+
+func TestForwardHistoryDelayHonoursContext(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	from := types.JID{User: "111", Server: types.DefaultUserServer}
+	_, _, _ = a.db.UpsertChat(ctx, from.String(), "dm", "Alice", "", time.Now())
+	for i, id := range []string{"a", "b"} {
+		if _, _, err := a.db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: from.String(), MsgID: id, Text: id, Timestamp: time.Unix(int64(1_700_000_000+i), 0)}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	res, err := a.ForwardHistory(ctx, ForwardHistoryOptions{From: from, To: types.JID{User: "222", Server: types.DefaultUserServer}, Delay: time.Hour})
+	if err == nil || res.Forwarded != 1 || len(f.sent) != 1 {
+		t.Fatalf("expected to stop after the first send, got forwarded %d, err %v", res.Forwarded, err)
+	}
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	linkPreviewTimeout  = 10 * time.Second
+	maxLinkPreviewPage  = 1 << 20   // only the <head> is needed
+	maxLinkPreviewThumb = 256 << 10 // WhatsApp thumbnails are small JPEGs
+)
+
+// LinkPreview is the Open Graph summary of a page.
+type LinkPreview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	Thumbnail   []byte // JPEG, nil when the page has no usable og:image
+}
+
+// FetchLinkPreview reads the Open Graph tags of the page at rawURL, falling
+// back to <title> and <meta name="description">. The og:image becomes the
+// thumbnail when it is a JPEG of at most 256 KiB; other images are skipped.
+func FetchLinkPreview(ctx context.Context, client *http.Client, rawURL string) (LinkPreview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return LinkPreview{}, fmt.Errorf("invalid link %q: must be an http(s) URL", rawURL)
+	}
+	body, ctype, err := fetchLimited(ctx, client, u.String(), maxLinkPreviewPage)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	if !strings.HasPrefix(ctype, "text/html") {
+		return LinkPreview{}, fmt.Errorf("link preview: %s is %s, not HTML", u, ctype)
+	}
+
+	p := parseOpenGraph(body)
+	p.URL = u.String()
+	if p.ImageURL != "" {
+		if img, err := u.Parse(p.ImageURL); err == nil {
+			p.ImageURL = img.String()
+			if data, ctype, err := fetchLimited(ctx, client, p.ImageURL, maxLinkPreviewThumb+1); err == nil &&
+				len(data) <= maxLinkPreviewThumb && ctype == "image/jpeg" {
+				p.Thumbnail = data
+			}
+		}
+	}
+	return p, nil
+}
+
+// fetchLimited GETs rawURL and returns at most limit bytes of the body and
+// its sniffed-or-declared media type.
+func fetchLimited(ctx context.Context, client *http.Client, rawURL string, limit int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("link preview: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("link preview: GET %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, "", fmt.Errorf("link preview: %w", err)
+	}
+	ctype := resp.Header.Get("Content-Type")
+	if ctype == "" {
+		ctype = http.DetectContentType(data)
+	}
+	ctype, _, _ = strings.Cut(ctype, ";")
+	return data, strings.ToLower(strings.TrimSpace(ctype)), nil
+}
+
+// parseOpenGraph extracts the preview fields from the <head> of page.
+func parseOpenGraph(page []byte) LinkPreview {
+	var p LinkPreview
+	var title, description string
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finishPreview(p, title, description)
+		case html.EndTagToken:
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Head {
+				return finishPreview(p, title, description)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				if title == "" && z.Next() == html.TextToken {
+					title = strings.TrimSpace(html.UnescapeString(string(z.Text())))
+				}
+			case atom.Body:
+				return finishPreview(p, title, description)
+			case atom.Meta:
+				var key, content string
+				for hasAttr {
+					var k, v []byte
+					k, v, hasAttr = z.TagAttr()
+					switch strings.ToLower(string(k)) {
+					case "property", "name":
+						key = strings.ToLower(string(v))
+					case "content":
+						content = strings.TrimSpace(string(v))
+					}
+				}
+				switch key {
+				case "og:title":
+					p.Title = content
+				case "og:description":
+					p.Description = content
+				case "og:image", "og:image:url":
+					if p.ImageURL == "" {
+						p.ImageURL = content
+					}
+				case "description":
+					description = content
+				}
+			}
+		}
+	}
+}
+
+func finishPreview(p LinkPreview, title, description string) LinkPreview {
+	if p.Title == "" {
+		p.Title = title
+	}
+	if p.Description == "" {
+		p.Description = description
+	}
+	return p
+}
+
+// SendStatus posts text to our status. With a link, the page's preview is
+// fetched and attached, and the link is appended to text if it is not
+// already in it; a failed fetch is returned as an error.
+func (a *App) SendStatus(ctx context.Context, text, link string) (types.MessageID, error) {
+	text = strings.TrimSpace(text)
+	if text == "" && link == "" {
+		return "", fmt.Errorf("status text or link is required")
+	}
+	if link == "" {
+		id, err := a.wa.SendStatus(ctx, &waProto.Message{Conversation: proto.String(text)})
+		if err != nil {
+			return "", err
+		}
+		a.storeSent(ctx, types.StatusBroadcastJID, id, store.UpsertMessageParams{Text: text})
+		return id, nil
+	}
+
+	fetch := a.linkPreview
+	if fetch == nil {
+		fetch = func(ctx context.Context, url string) (LinkPreview, error) {
+			return FetchLinkPreview(ctx, &http.Client{Timeout: linkPreviewTimeout}, url)
+		}
+	}
+	preview, err := fetch(ctx, link)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(text, link) {
+		text = strings.TrimSpace(text + "\n" + link)
+	}
+	ext := &waProto.ExtendedTextMessage{
+		Text:        proto.String(text),
+		MatchedText: proto.String(link),
+		Title:       proto.String(preview.Title),
+		Description: proto.String(preview.Description),
+		PreviewType: waProto.ExtendedTextMessage_NONE.Enum(),
+	}
+	if len(preview.Thumbnail) > 0 {
+		ext.JPEGThumbnail = preview.Thumbnail
+	}
+	id, err := a.wa.SendStatus(ctx, &waProto.Message{ExtendedTextMessage: ext})
+	if err != nil {
+		return "", err
+	}
+	a.storeSent(ctx, types.StatusBroadcastJID, id, store.UpsertMessageParams{Text: text})
+	return id, nil
+}
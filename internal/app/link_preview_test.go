@@ -0,0 +1,111 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchLinkPreview(t *testing.T) {
+	jpeg := append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte{0}, 64)...)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!doctype html><html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="Go 1.25 is released">
+<meta property="og:description" content="Faster &amp; smaller">
+<meta property="og:image" content="/img/cover.jpg">
+</head><body><meta property="og:title" content="ignored"></body></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><title>Plain page</title><meta name="description" content="No OG here"></head></html>`))
+	})
+	mux.HandleFunc("/img/cover.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(jpeg)
+	})
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	ctx := context.Background()
+
+	p, err := FetchLinkPreview(ctx, srv.Client(), srv.URL+"/article")
+	if err != nil {
+		t.Fatalf("FetchLinkPreview: %v", err)
+	}
+	if p.Title != "Go 1.25 is released" || p.Description != "Faster & smaller" {
+		t.Fatalf("preview = %+v", p)
+	}
+	if p.ImageURL != srv.URL+"/img/cover.jpg" || !bytes.Equal(p.Thumbnail, jpeg) {
+		t.Fatalf("image %q, thumbnail %d bytes", p.ImageURL, len(p.Thumbnail))
+	}
+
+	p, err = FetchLinkPreview(ctx, srv.Client(), srv.URL+"/plain")
+	if err != nil {
+		t.Fatalf("FetchLinkPreview plain: %v", err)
+	}
+	if p.Title != "Plain page" || p.Description != "No OG here" || p.Thumbnail != nil {
+		t.Fatalf("plain preview = %+v", p)
+	}
+
+	for _, bad := range []string{srv.URL + "/file.pdf", srv.URL + "/missing", "ftp://example.com/x", "not a url"} {
+		if _, err := FetchLinkPreview(ctx, srv.Client(), bad); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestSendStatusWithLink(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+	var fetched string
+	a.linkPreview = func(ctx context.Context, url string) (LinkPreview, error) {
+		fetched = url
+		return LinkPreview{URL: url, Title: "Title", Description: "Desc", Thumbnail: []byte{0xFF, 0xD8}}, nil
+	}
+	ctx := context.Background()
+
+	const link = "https://example.com/post"
+	if _, err := a.SendStatus(ctx, "Read this", link); err != nil {
+		t.Fatalf("SendStatus: %v", err)
+	}
+	if fetched != link {
+		t.Fatalf("fetched %q", fetched)
+	}
+	ext := f.statuses[len(f.statuses)-1].GetExtendedTextMessage()
+	if ext.GetText() != "Read this\n"+link || ext.GetMatchedText() != link {
+		t.Fatalf("text %q, matched %q", ext.GetText(), ext.GetMatchedText())
+	}
+	if ext.GetTitle() != "Title" || ext.GetDescription() != "Desc" || len(ext.GetJPEGThumbnail()) != 2 {
+		t.Fatalf("preview fields = %q %q %d", ext.GetTitle(), ext.GetDescription(), len(ext.GetJPEGThumbnail()))
+	}
+
+	if _, err := a.SendStatus(ctx, "Plain status", ""); err != nil {
+		t.Fatalf("SendStatus text: %v", err)
+	}
+	if got := f.statuses[len(f.statuses)-1].GetConversation(); got != "Plain status" {
+		t.Fatalf("text status = %q", got)
+	}
+	if len(f.sent) != 0 {
+		t.Fatalf("statuses must not go through SendProtoMessage, got %d", len(f.sent))
+	}
+
+	a.linkPreview = func(ctx context.Context, url string) (LinkPreview, error) {
+		return LinkPreview{}, errors.New("boom")
+	}
+	if _, err := a.SendStatus(ctx, "", link); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the fetch error, got %v", err)
+	}
+	if _, err := a.SendStatus(ctx, " ", ""); err == nil {
+		t.Fatal("expected an error for an empty status")
+	}
+}
@@ -111,7 +111,7 @@ func (a *App) runMediaWorkers(ctx context.Context, jobs <-chan mediaJob, workers
 }
 
 func (a *App) downloadMediaJob(ctx context.Context, job mediaJob) error {
-	info, err := a.db.GetMediaDownloadInfo(job.chatJID, job.msgID)
+	info, err := a.db.GetMediaDownloadInfo(ctx, job.chatJID, job.msgID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil
@@ -138,5 +138,5 @@ func (a *App) downloadMediaJob(ctx context.Context, job mediaJob) error {
 	}
 
 	now := time.Now().UTC()
-	return a.db.MarkMediaDownloaded(info.ChatJID, info.MsgID, targetPath, now)
+	return a.db.MarkMediaDownloaded(ctx, info.ChatJID, info.MsgID, targetPath, now)
 }
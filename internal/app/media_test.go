@@ -10,15 +10,16 @@ import (
 )
 
 func TestDownloadMediaJobMarksDownloaded(t *testing.T) {
+	ctx := context.Background()
 	a := newTestApp(t)
 	f := newFakeWA()
 	a.wa = f
 
 	chat := "123@s.whatsapp.net"
-	if err := a.db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+	if _, _, err := a.db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
-	if err := a.db.UpsertMessage(store.UpsertMessageParams{
+	if _, _, err := a.db.UpsertMessage(ctx, store.UpsertMessageParams{
 		ChatJID:       chat,
 		MsgID:         "mid",
 		SenderJID:     chat,
@@ -43,7 +44,7 @@ func TestDownloadMediaJobMarksDownloaded(t *testing.T) {
 		t.Fatalf("downloadMediaJob: %v", err)
 	}
 
-	info, err := a.db.GetMediaDownloadInfo(chat, "mid")
+	info, err := a.db.GetMediaDownloadInfo(ctx, chat, "mid")
 	if err != nil {
 		t.Fatalf("GetMediaDownloadInfo: %v", err)
 	}
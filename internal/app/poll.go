@@ -0,0 +1,132 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxPollOptions is the most options WhatsApp clients show on a poll.
+const maxPollOptions = 12
+
+// SendPoll sends a poll to `to` and records its options so votes can be
+// tallied. Voters may pick one option, or any number when multi is set.
+func (a *App) SendPoll(ctx context.Context, to types.JID, question string, options []string, multi bool) (types.MessageID, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", fmt.Errorf("poll question is required")
+	}
+	if len(options) < 2 || len(options) > maxPollOptions {
+		return "", fmt.Errorf("polls need 2 to %d options, got %d", maxPollOptions, len(options))
+	}
+	seen := map[string]bool{}
+	for _, o := range options {
+		if strings.TrimSpace(o) == "" {
+			return "", fmt.Errorf("poll options must not be empty")
+		}
+		// Votes name options by hash, so duplicates could not be told apart.
+		if seen[o] {
+			return "", fmt.Errorf("duplicate poll option %q", o)
+		}
+		seen[o] = true
+	}
+
+	// The message secret lets recipients encrypt their votes to us.
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	selectable := uint32(1)
+	if multi {
+		selectable = 0 // any number
+	}
+	pollOptions := make([]*waProto.PollCreationMessage_Option, len(options))
+	for i, o := range options {
+		pollOptions[i] = &waProto.PollCreationMessage_Option{OptionName: proto.String(o)}
+	}
+	id, err := a.wa.SendProtoMessage(ctx, to, &waProto.Message{
+		PollCreationMessage: &waProto.PollCreationMessage{
+			Name:                   proto.String(question),
+			Options:                pollOptions,
+			SelectableOptionsCount: proto.Uint32(selectable),
+		},
+		MessageContextInfo: &waProto.MessageContextInfo{MessageSecret: secret},
+	})
+	if err != nil {
+		return "", err
+	}
+	a.storeSent(ctx, to, id, store.UpsertMessageParams{Text: question, DisplayText: "Poll: " + question})
+	if err := a.db.SavePollOptions(ctx, to.String(), string(id), options); err != nil {
+		return id, fmt.Errorf("poll sent but its options were not saved: %w", err)
+	}
+	return id, nil
+}
+
+// recordPollOptions stores the options of a poll received in chat.
+func (a *App) recordPollOptions(ctx context.Context, chat types.JID, msgID string, msg *waProto.Message) {
+	poll := wa.PollCreation(msg)
+	if poll == nil || msgID == "" {
+		return
+	}
+	options := make([]string, 0, len(poll.GetOptions()))
+	for _, o := range poll.GetOptions() {
+		options = append(options, o.GetOptionName())
+	}
+	_ = a.db.SavePollOptions(ctx, chat.String(), msgID, options)
+}
+
+// recordPollVote decrypts a poll vote and stores the voter's selection.
+// Votes on polls we have no options for cannot be mapped and are dropped.
+func (a *App) recordPollVote(ctx context.Context, v *events.Message) {
+	update := v.Message.GetPollUpdateMessage()
+	pollID := update.GetPollCreationMessageKey().GetID()
+	if pollID == "" {
+		return
+	}
+	chat := v.Info.Chat.String()
+	options, err := a.db.PollOptions(ctx, chat, pollID)
+	if err != nil || len(options) == 0 {
+		return
+	}
+	vote, err := a.wa.DecryptPollVote(ctx, v)
+	if err != nil || vote == nil {
+		return
+	}
+
+	var selected []string
+	for _, hash := range vote.GetSelectedOptions() {
+		for _, o := range options {
+			sum := sha256.Sum256([]byte(o))
+			if bytes.Equal(hash, sum[:]) {
+				selected = append(selected, o)
+				break
+			}
+		}
+	}
+	voter := ""
+	if !v.Info.IsFromMe {
+		voter = v.Info.Sender.ToNonAD().String()
+	}
+	ts := v.Info.Timestamp
+	if ms := update.GetSenderTimestampMS(); ms > 0 {
+		ts = time.UnixMilli(ms)
+	}
+	_ = a.db.RecordPollVote(ctx, store.PollVoteParams{
+		ChatJID:   chat,
+		MsgID:     pollID,
+		VoterJID:  voter,
+		Options:   selected,
+		Timestamp: ts,
+	})
+}
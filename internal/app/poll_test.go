@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSendPollForwardsOptions(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	to := types.JID{User: "123", Server: types.GroupServer}
+	options := []string{"Pizza", "Sushi", "Salad"}
+	id, err := a.SendPoll(ctx, to, "What's for lunch?", options, false)
+	if err != nil {
+		t.Fatalf("SendPoll: %v", err)
+	}
+	sent := f.sent[len(f.sent)-1]
+	poll := sent.GetPollCreationMessage()
+	if poll == nil {
+		t.Fatal("expected a poll creation message")
+	}
+	if poll.GetName() != "What's for lunch?" || poll.GetSelectableOptionsCount() != 1 {
+		t.Fatalf("poll = %q, selectable %d", poll.GetName(), poll.GetSelectableOptionsCount())
+	}
+	var got []string
+	for _, o := range poll.GetOptions() {
+		got = append(got, o.GetOptionName())
+	}
+	if len(got) != 3 || got[0] != "Pizza" || got[1] != "Sushi" || got[2] != "Salad" {
+		t.Fatalf("options = %v", got)
+	}
+	if len(sent.GetMessageContextInfo().GetMessageSecret()) != 32 {
+		t.Fatal("expected a 32-byte message secret")
+	}
+	if stored, _ := a.db.PollOptions(ctx, to.String(), string(id)); len(stored) != 3 {
+		t.Fatalf("stored options = %v", stored)
+	}
+
+	if _, err := a.SendPoll(ctx, to, "Multi?", []string{"a", "b"}, true); err != nil {
+		t.Fatalf("SendPoll multi: %v", err)
+	}
+	if n := f.sent[len(f.sent)-1].GetPollCreationMessage().GetSelectableOptionsCount(); n != 0 {
+		t.Fatalf("multi-select selectable = %d, want 0", n)
+	}
+
+	for _, bad := range [][]string{{"only"}, {"a", "a"}, {"a", " "}} {
+		if _, err := a.SendPoll(ctx, to, "q", bad, false); err == nil {
+			t.Fatalf("expected an error for options %q", bad)
+		}
+	}
+}
+
+func TestRecordPollVoteTalliesResults(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	chat := types.JID{User: "123", Server: types.GroupServer}
+	if err := a.db.SavePollOptions(ctx, chat.String(), "poll1", []string{"Pizza", "Sushi", "Salad"}); err != nil {
+		t.Fatalf("SavePollOptions: %v", err)
+	}
+
+	hash := func(o string) []byte { sum := sha256.Sum256([]byte(o)); return sum[:] }
+	now := time.Now()
+	vote := func(id string, sender types.JID, at time.Time, options ...string) {
+		var selected [][]byte
+		for _, o := range options {
+			selected = append(selected, hash(o))
+		}
+		f.pollVotes = map[types.MessageID]*waProto.PollVoteMessage{types.MessageID(id): {SelectedOptions: selected}}
+		a.recordPollVote(ctx, &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: chat, Sender: sender},
+				ID:            types.MessageID(id),
+				Timestamp:     at,
+			},
+			Message: &waProto.Message{PollUpdateMessage: &waProto.PollUpdateMessage{
+				PollCreationMessageKey: &waProto.MessageKey{ID: proto.String("poll1")},
+			}},
+		})
+	}
+	alice := types.JID{User: "111", Server: types.DefaultUserServer}
+	bob := types.JID{User: "222", Server: types.DefaultUserServer}
+	vote("v1", alice, now, "Pizza")
+	vote("v2", bob, now, "Pizza", "Salad")
+	vote("v3", alice, now.Add(time.Minute), "Sushi") // alice changes her mind
+	vote("v4", bob, now.Add(-time.Minute), "Sushi")  // stale, ignored
+
+	results, err := a.db.PollResults(ctx, chat.String(), "poll1")
+	if err != nil {
+		t.Fatalf("PollResults: %v", err)
+	}
+	want := map[string]int{"Pizza": 1, "Sushi": 1, "Salad": 1}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	for _, r := range results {
+		if r.Count != want[r.Name] {
+			t.Fatalf("results = %+v, want %v", results, want)
+		}
+	}
+}
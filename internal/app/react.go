@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrMessageNotFound is returned when an operation needs a message that is
+// not in the local store.
+var ErrMessageNotFound = errors.New("message not found")
+
+// SendReaction reacts to msgID in chat; an empty reaction removes ours.
+// The target must be in the store, since its key needs the original sender.
+func (a *App) SendReaction(ctx context.Context, chat types.JID, msgID types.MessageID, reaction string) error {
+	target, err := a.db.GetMessage(ctx, chat.String(), string(msgID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s in %s", ErrMessageNotFound, msgID, chat)
+	}
+	if err != nil {
+		return err
+	}
+
+	key := &waProto.MessageKey{
+		RemoteJID: proto.String(chat.String()),
+		FromMe:    proto.Bool(target.FromMe),
+		ID:        proto.String(string(msgID)),
+	}
+	if !target.FromMe && wa.IsGroupJID(chat) && target.SenderJID != "" {
+		key.Participant = proto.String(target.SenderJID)
+	}
+	_, err = a.wa.SendProtoMessage(ctx, chat, &waProto.Message{
+		ReactionMessage: &waProto.ReactionMessage{
+			Key:               key,
+			Text:              proto.String(reaction),
+			SenderTimestampMS: proto.Int64(time.Now().UnixMilli()),
+		},
+	})
+	return err
+}
+
+// recordReaction keeps the reactions table in step with a reaction message.
+func (a *App) recordReaction(ctx context.Context, pm wa.ParsedMessage) {
+	sender := ""
+	if !pm.FromMe {
+		sender = pm.SenderJID
+		if jid, err := types.ParseJID(pm.SenderJID); err == nil {
+			sender = jid.ToNonAD().String()
+		}
+	}
+	_ = a.db.UpsertReaction(ctx, store.UpsertReactionParams{
+		ChatJID:   pm.Chat.String(),
+		MsgID:     pm.ReactionToID,
+		SenderJID: sender,
+		Reaction:  pm.ReactionEmoji,
+		Timestamp: pm.Timestamp,
+	})
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// MarkRead sends read receipts for msgIDs in chat. Group receipts have to
+// name each message's sender, which is looked up in the store; our own
+// messages are skipped.
+func (a *App) MarkRead(ctx context.Context, chat types.JID, msgIDs []types.MessageID) error {
+	bySender := map[types.JID][]types.MessageID{}
+	var order []types.JID
+	for _, id := range msgIDs {
+		var sender types.JID
+		if m, err := a.db.GetMessage(ctx, chat.String(), string(id)); err == nil {
+			if m.FromMe {
+				continue
+			}
+			if wa.IsGroupJID(chat) {
+				sender, _ = types.ParseJID(m.SenderJID)
+			}
+		}
+		if _, ok := bySender[sender]; !ok {
+			order = append(order, sender)
+		}
+		bySender[sender] = append(bySender[sender], id)
+	}
+	for _, sender := range order {
+		if err := a.wa.MarkRead(ctx, chat, sender, bySender[sender]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
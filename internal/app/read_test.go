@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestMarkReadGroupsReceiptsBySender(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	group := types.JID{User: "123", Server: types.GroupServer}
+	alice := types.JID{User: "111", Server: types.DefaultUserServer}
+	bob := types.JID{User: "222", Server: types.DefaultUserServer}
+	now := time.Now()
+	if _, _, err := a.db.UpsertChat(ctx, group.String(), "group", "G", "", now); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for _, m := range []store.UpsertMessageParams{
+		{MsgID: "a1", SenderJID: alice.String()},
+		{MsgID: "b1", SenderJID: bob.String()},
+		{MsgID: "a2", SenderJID: alice.String()},
+		{MsgID: "me", FromMe: true},
+	} {
+		m.ChatJID = group.String()
+		m.Timestamp = now
+		if _, _, err := a.db.UpsertMessage(ctx, m); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	if err := a.MarkRead(ctx, group, []types.MessageID{"a1", "b1", "a2", "me"}); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if got := f.readReceipts[alice]; len(got) != 2 || got[0] != "a1" || got[1] != "a2" {
+		t.Fatalf("unexpected receipts for alice: %v", got)
+	}
+	if got := f.readReceipts[bob]; len(got) != 1 || got[0] != "b1" {
+		t.Fatalf("unexpected receipts for bob: %v", got)
+	}
+	if len(f.readReceipts) != 2 {
+		t.Fatalf("expected receipts for 2 senders, got %v", f.readReceipts)
+	}
+}
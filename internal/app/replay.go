@@ -0,0 +1,159 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/logging"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// EventRecord is one line of an event log: newline-delimited JSON written by
+// `sync --event-log` and read back by `wacli replay`.
+type EventRecord struct {
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// messagePayload carries an events.Message. The protobuf body is encoded
+// with protojson since encoding/json can't round-trip its oneof fields.
+type messagePayload struct {
+	Info    types.MessageInfo `json:"info"`
+	Message json.RawMessage   `json:"message,omitempty"`
+}
+
+// EncodeEvent wraps a whatsmeow event in an EventRecord.
+func EncodeEvent(evt interface{}, at time.Time) (EventRecord, error) {
+	rec := EventRecord{EventType: eventTypeName(evt), Timestamp: at.UTC()}
+	var err error
+	switch v := evt.(type) {
+	case *events.Message:
+		p := messagePayload{Info: v.Info}
+		if v.Message != nil {
+			if p.Message, err = protojson.Marshal(v.Message); err != nil {
+				return EventRecord{}, err
+			}
+		}
+		rec.Payload, err = json.Marshal(p)
+	case *events.HistorySync:
+		rec.Payload, err = protojson.Marshal(v.Data)
+	default:
+		rec.Payload, err = json.Marshal(evt)
+	}
+	if err != nil {
+		return EventRecord{}, fmt.Errorf("encode %s event: %w", rec.EventType, err)
+	}
+	return rec, nil
+}
+
+// DecodeEvent turns a record back into the whatsmeow event it came from. Only
+// the event types that affect the local DB can be decoded; ok is false for
+// the rest.
+func DecodeEvent(rec EventRecord) (evt interface{}, ok bool, err error) {
+	switch rec.EventType {
+	case "Message":
+		var p messagePayload
+		if err := json.Unmarshal(rec.Payload, &p); err != nil {
+			return nil, false, fmt.Errorf("decode Message event: %w", err)
+		}
+		v := &events.Message{Info: p.Info}
+		if len(p.Message) > 0 {
+			v.Message = &waProto.Message{}
+			if err := protojson.Unmarshal(p.Message, v.Message); err != nil {
+				return nil, false, fmt.Errorf("decode Message event: %w", err)
+			}
+		}
+		return v, true, nil
+	case "HistorySync":
+		data := &waHistorySync.HistorySync{}
+		if err := protojson.Unmarshal(rec.Payload, data); err != nil {
+			return nil, false, fmt.Errorf("decode HistorySync event: %w", err)
+		}
+		return &events.HistorySync{Data: data}, true, nil
+	case "GroupInfo":
+		v := &events.GroupInfo{}
+		if err := json.Unmarshal(rec.Payload, v); err != nil {
+			return nil, false, fmt.Errorf("decode GroupInfo event: %w", err)
+		}
+		return v, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func eventTypeName(evt interface{}) string {
+	t := reflect.TypeOf(evt)
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+type ReplayResult struct {
+	Events          int64
+	Ignored         int64 // event types that don't touch the DB
+	MessagesStored  int64
+	MessagesUpdated int64
+	SkippedCount    int64
+}
+
+// Replay feeds the events recorded in r through the same handler Sync uses.
+// The WA client must be open (it is used to resolve names), but no
+// connection to WhatsApp is made.
+func (a *App) Replay(ctx context.Context, r io.Reader) (ReplayResult, error) {
+	log := logging.WithComponent("replay")
+	if err := a.OpenWA(); err != nil {
+		return ReplayResult{}, err
+	}
+
+	var res ReplayResult
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var rec EventRecord
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return res, fmt.Errorf("line %d: %w", line, err)
+		}
+		res.Events++
+		evt, ok, err := DecodeEvent(rec)
+		if err != nil {
+			return res, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !ok {
+			res.Ignored++
+			log.Debug().Int("line", line).Str("event_type", rec.EventType).Msg("ignoring event")
+			continue
+		}
+		stats := a.ingestEvent(ctx, evt, ingestHooks{})
+		res.MessagesStored += stats.stored
+		res.MessagesUpdated += stats.updated
+		res.SkippedCount += stats.skipped
+	}
+	if err := sc.Err(); err != nil {
+		return res, err
+	}
+	return res, nil
+}
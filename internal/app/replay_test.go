@@ -0,0 +1,82 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReplayStoresRecordedEvents(t *testing.T) {
+	ctx := context.Background()
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	live := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-live",
+			Timestamp:     base.Add(2 * time.Second),
+			PushName:      "Alice",
+		},
+		Message: &waProto.Message{Conversation: proto.String("hello")},
+	}
+	history := &events.HistorySync{
+		Data: &waHistorySync.HistorySync{
+			SyncType: waHistorySync.HistorySync_FULL.Enum(),
+			Conversations: []*waHistorySync.Conversation{{
+				ID: proto.String(chat.String()),
+				Messages: []*waHistorySync.HistorySyncMsg{{Message: &waWeb.WebMessageInfo{
+					Key: &waCommon.MessageKey{
+						RemoteJID: proto.String(chat.String()),
+						ID:        proto.String("m-hist"),
+					},
+					MessageTimestamp: proto.Uint64(uint64(base.Add(time.Second).Unix())),
+					Message:          &waProto.Message{Conversation: proto.String("older")},
+				}}},
+			}},
+		},
+	}
+
+	var log bytes.Buffer
+	enc := json.NewEncoder(&log)
+	for _, evt := range []interface{}{live, history, &events.Connected{}} {
+		rec, err := EncodeEvent(evt, base)
+		if err != nil {
+			t.Fatalf("EncodeEvent: %v", err)
+		}
+		if err := enc.Encode(rec); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+	}
+
+	a := newTestApp(t)
+	a.wa = newFakeWA()
+	res, err := a.Replay(context.Background(), &log)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if res.Events != 3 || res.Ignored != 1 || res.MessagesStored != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	msg, err := a.db.GetMessage(ctx, chat.String(), "m-live")
+	if err != nil {
+		t.Fatalf("GetMessage live: %v", err)
+	}
+	if msg.Text != "hello" || !msg.Timestamp.Equal(live.Info.Timestamp) {
+		t.Fatalf("unexpected live message: %+v", msg)
+	}
+	if msg, err = a.db.GetMessage(ctx, chat.String(), "m-hist"); err != nil || msg.Text != "older" {
+		t.Fatalf("GetMessage hist: %+v (err=%v)", msg, err)
+	}
+}
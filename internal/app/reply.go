@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/steipete/wacli/internal/store"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// SendTextReply sends text to `to` quoting quotedID from quotedChat, which
+// is usually `to` itself. Like reactions, the quoted message must be in the
+// store: its sender and text go into the quote.
+func (a *App) SendTextReply(ctx context.Context, to types.JID, text string, quotedChat types.JID, quotedID string) (types.MessageID, error) {
+	quoted, err := a.db.GetMessage(ctx, quotedChat.String(), quotedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s in %s", ErrMessageNotFound, quotedID, quotedChat)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ci := &waProto.ContextInfo{StanzaID: proto.String(quotedID)}
+	if !quoted.FromMe && quoted.SenderJID != "" {
+		ci.Participant = proto.String(quoted.SenderJID)
+	}
+	if quotedChat != to {
+		ci.RemoteJID = proto.String(quotedChat.String())
+	}
+	if quoted.Text != "" {
+		ci.QuotedMessage = &waProto.Message{Conversation: proto.String(quoted.Text)}
+	}
+	id, err := a.wa.SendProtoMessage(ctx, to, &waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: ci,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	display := a.lookupMessageDisplayText(ctx, quotedChat.String(), quotedID)
+	if display == "" {
+		display = "message"
+	}
+	a.storeSent(ctx, to, id, store.UpsertMessageParams{
+		Text:         text,
+		DisplayText:  fmt.Sprintf("> %s\n%s", display, strings.TrimSpace(text)),
+		ReplyToMsgID: quotedID,
+	})
+	return id, nil
+}
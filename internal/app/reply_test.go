@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSendTextReply(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+	ctx := context.Background()
+
+	group := types.JID{User: "123", Server: types.GroupServer}
+	alice := "111@s.whatsapp.net"
+	_, _, _ = a.db.UpsertChat(ctx, group.String(), "group", "Team", "", time.Now())
+	if _, _, err := a.db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID: group.String(), MsgID: "q1", SenderJID: alice, Timestamp: time.Now(), Text: "lunch?",
+	}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+
+	id, err := a.SendTextReply(ctx, group, "yes", group, "q1")
+	if err != nil {
+		t.Fatalf("SendTextReply: %v", err)
+	}
+	ext := f.sent[len(f.sent)-1].GetExtendedTextMessage()
+	ci := ext.GetContextInfo()
+	if ext.GetText() != "yes" || ci.GetStanzaID() != "q1" || ci.GetParticipant() != alice {
+		t.Fatalf("reply = %q, stanza %q, participant %q", ext.GetText(), ci.GetStanzaID(), ci.GetParticipant())
+	}
+	if ci.GetQuotedMessage().GetConversation() != "lunch?" || ci.RemoteJID != nil {
+		t.Fatalf("quoted = %v, remote %q", ci.GetQuotedMessage(), ci.GetRemoteJID())
+	}
+
+	stored, err := a.db.GetMessage(ctx, group.String(), string(id))
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if stored.ReplyToMsgID != "q1" || stored.DisplayText != "> lunch?\nyes" {
+		t.Fatalf("stored reply = %+v", stored)
+	}
+
+	// Quoting into another chat names the source chat.
+	dm := types.JID{User: "222", Server: types.DefaultUserServer}
+	if _, err := a.SendTextReply(ctx, dm, "see this", group, "q1"); err != nil {
+		t.Fatalf("SendTextReply across chats: %v", err)
+	}
+	if got := f.sent[len(f.sent)-1].GetExtendedTextMessage().GetContextInfo().GetRemoteJID(); got != group.String() {
+		t.Fatalf("remote JID = %q", got)
+	}
+
+	if _, err := a.SendTextReply(ctx, group, "?", group, "missing"); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got %v", err)
+	}
+}
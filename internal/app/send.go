@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// storeSent records a message we just sent, so it shows up in the store
+// before sync echoes it back. p carries the content fields (Text, media
+// fields, ...); the chat, ID, sender and time are filled in here.
+func (a *App) storeSent(ctx context.Context, to types.JID, id types.MessageID, p store.UpsertMessageParams) {
+	now := time.Now().UTC()
+	chatName := a.wa.ResolveChatName(ctx, to, "")
+	_, _, _ = a.db.UpsertChat(ctx, to.String(), chatKind(to), chatName, "", now)
+
+	p.ChatJID = to.String()
+	p.ChatName = chatName
+	p.MsgID = string(id)
+	p.SenderName = "me"
+	p.Timestamp = now
+	p.FromMe = true
+	_, _, _ = a.db.UpsertMessage(ctx, p)
+}
+
+// storeSentMedia is storeSent for an uploaded attachment; p carries the
+// media-specific fields (MediaType, MimeType, Filename, captions).
+func (a *App) storeSentMedia(ctx context.Context, to types.JID, id types.MessageID, up whatsmeow.UploadResponse, p store.UpsertMessageParams) {
+	p.DirectPath = up.DirectPath
+	p.MediaKey = up.MediaKey
+	p.FileSHA256 = up.FileSHA256
+	p.FileEncSHA256 = up.FileEncSHA256
+	p.FileLength = up.FileLength
+	a.storeSent(ctx, to, id, p)
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	ScenarioIncomingMessage = "incoming-message"
+	ScenarioOutgoingMessage = "outgoing-message"
+)
+
+// SimulateOptions describes a synthetic message written by Simulate.
+type SimulateOptions struct {
+	Scenario string
+	// Chat is a JID, or a chat name that is looked up locally (and made up
+	// if unknown).
+	Chat string
+	Text string
+	At   time.Time // defaults to now
+}
+
+// Simulate writes a synthetic message straight into the local DB, without
+// talking to WhatsApp, so downstream integrations can be exercised without
+// a real account.
+func (a *App) Simulate(ctx context.Context, opts SimulateOptions) (store.Message, error) {
+	var fromMe bool
+	switch opts.Scenario {
+	case ScenarioIncomingMessage:
+	case ScenarioOutgoingMessage:
+		fromMe = true
+	default:
+		return store.Message{}, fmt.Errorf("unknown scenario %q (use %s or %s)", opts.Scenario, ScenarioIncomingMessage, ScenarioOutgoingMessage)
+	}
+	if strings.TrimSpace(opts.Chat) == "" {
+		return store.Message{}, fmt.Errorf("chat is required")
+	}
+	if strings.TrimSpace(opts.Text) == "" {
+		return store.Message{}, fmt.Errorf("text is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return store.Message{}, err
+	}
+
+	chatJID, chatName, err := a.simulatedChat(ctx, opts.Chat)
+	if err != nil {
+		return store.Message{}, err
+	}
+	kind := "dm"
+	if strings.HasSuffix(chatJID, "@"+types.GroupServer) {
+		kind = "group"
+	}
+	at := opts.At
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	senderJID, senderName := chatJID, chatName
+	if fromMe {
+		senderJID, senderName = "", "me"
+	}
+	msgID := fmt.Sprintf("SIM-%d", at.UnixNano())
+
+	if _, _, err := a.db.UpsertChat(ctx, chatJID, kind, chatName, "", at); err != nil {
+		return store.Message{}, err
+	}
+	m, _, err := a.db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID:     chatJID,
+		ChatName:    chatName,
+		MsgID:       msgID,
+		SenderJID:   senderJID,
+		SenderName:  senderName,
+		Timestamp:   at,
+		FromMe:      fromMe,
+		Text:        opts.Text,
+		DisplayText: opts.Text,
+	})
+	return m, err
+}
+
+// simulatedChat resolves a JID or chat name to a (jid, name) pair. Unknown
+// names get a stable fake DM JID so repeated runs land in the same chat.
+func (a *App) simulatedChat(ctx context.Context, chat string) (string, string, error) {
+	chat = strings.TrimSpace(chat)
+	if strings.Contains(chat, "@") {
+		jid, err := types.ParseJID(chat)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid chat JID: %w", err)
+		}
+		name := ""
+		if c, err := a.db.GetChat(ctx, jid.String()); err == nil {
+			name = c.Name
+		}
+		return jid.String(), name, nil
+	}
+
+	chats, err := a.db.ListChats(ctx, chat, 50)
+	if err != nil {
+		return "", "", err
+	}
+	for _, c := range chats {
+		if strings.EqualFold(c.Name, chat) {
+			return c.JID, c.Name, nil
+		}
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToLower(chat)))
+	return fmt.Sprintf("1999%010d@%s", h.Sum32(), types.DefaultUserServer), chat, nil
+}
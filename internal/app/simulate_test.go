@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/steipete/wacli/internal/rpc"
+)
+
+func TestSimulateIncomingMessageVisibleOverRPC(t *testing.T) {
+	a := newTestApp(t)
+
+	msg, err := a.Simulate(context.Background(), SimulateOptions{
+		Scenario: ScenarioIncomingMessage,
+		Chat:     "Alice",
+		Text:     "Hello",
+	})
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if msg.FromMe {
+		t.Fatalf("expected incoming message")
+	}
+
+	srv, err := rpc.New(rpc.Options{Addr: "localhost:0", DB: a.DB()})
+	if err != nil {
+		t.Fatalf("rpc.New: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+url.QueryEscape(msg.ChatJID), nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Messages []struct {
+			ID   string `json:"msg_id"`
+			Text string `json:"text"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].ID != msg.MsgID || resp.Messages[0].Text != "Hello" {
+		t.Fatalf("unexpected messages: %+v", resp.Messages)
+	}
+
+	// The same name maps to the same chat.
+	again, err := a.Simulate(context.Background(), SimulateOptions{
+		Scenario: ScenarioOutgoingMessage,
+		Chat:     "alice",
+		Text:     "Hi back",
+	})
+	if err != nil {
+		t.Fatalf("Simulate: %v", err)
+	}
+	if again.ChatJID != msg.ChatJID || !again.FromMe {
+		t.Fatalf("expected outgoing reply in %s, got %+v", msg.ChatJID, again)
+	}
+}
+
+func TestSimulateRejectsUnknownScenario(t *testing.T) {
+	a := newTestApp(t)
+	if _, err := a.Simulate(context.Background(), SimulateOptions{Scenario: "nope", Chat: "Alice", Text: "x"}); err == nil {
+		t.Fatalf("expected error for unknown scenario")
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/steipete/wacli/internal/store"
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotWebP is returned for sticker data that is not a WebP image.
+var ErrNotWebP = errors.New("stickers must be WebP images")
+
+// ParseWebP checks that data is a RIFF/WEBP container and reports whether
+// its VP8X header marks it as animated.
+func ParseWebP(data []byte) (animated bool, err error) {
+	if len(data) < 12 || !bytes.Equal(data[0:4], []byte("RIFF")) || !bytes.Equal(data[8:12], []byte("WEBP")) {
+		// x/image only decodes WebP, so other formats can't be converted here.
+		if ct := http.DetectContentType(data); ct == "image/png" || ct == "image/jpeg" {
+			return false, fmt.Errorf("%w: got %s, convert it first (e.g. cwebp in.png -o out.webp)", ErrNotWebP, ct)
+		}
+		return false, ErrNotWebP
+	}
+	if len(data) >= 21 && bytes.Equal(data[12:16], []byte("VP8X")) {
+		if binary.LittleEndian.Uint32(data[16:20]) >= 10 {
+			animated = data[20]&0x02 != 0
+		}
+	}
+	return animated, nil
+}
+
+// SendSticker uploads data as a WebP sticker, sends it to `to` and stores
+// the sent message.
+func (a *App) SendSticker(ctx context.Context, to types.JID, data []byte, animated bool) (types.MessageID, error) {
+	if _, err := ParseWebP(data); err != nil {
+		return "", err
+	}
+	up, err := a.wa.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		return "", err
+	}
+	const mimeType = "image/webp"
+	id, err := a.wa.SendProtoMessage(ctx, to, &waProto.Message{
+		StickerMessage: &waProto.StickerMessage{
+			URL:           proto.String(up.URL),
+			DirectPath:    proto.String(up.DirectPath),
+			MediaKey:      up.MediaKey,
+			FileEncSHA256: up.FileEncSHA256,
+			FileSHA256:    up.FileSHA256,
+			FileLength:    proto.Uint64(up.FileLength),
+			Mimetype:      proto.String(mimeType),
+			IsAnimated:    proto.Bool(animated),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	a.storeSentMedia(ctx, to, id, up, store.UpsertMessageParams{MediaType: "sticker", MimeType: mimeType})
+	return id, nil
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// webp builds a minimal extended-format WebP header with the given VP8X flags.
+func webp(flags byte) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00")
+	return append(b, flags, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+func TestParseWebP(t *testing.T) {
+	if animated, err := ParseWebP(webp(0x02)); err != nil || !animated {
+		t.Fatalf("animated webp: animated=%v err=%v", animated, err)
+	}
+	if animated, err := ParseWebP(webp(0)); err != nil || animated {
+		t.Fatalf("static webp: animated=%v err=%v", animated, err)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	if _, err := ParseWebP(png); !errors.Is(err, ErrNotWebP) {
+		t.Fatalf("png: expected ErrNotWebP, got %v", err)
+	}
+}
+
+func TestSendStickerForwardsAnimatedFlag(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	ctx := context.Background()
+	to := types.JID{User: "111", Server: types.DefaultUserServer}
+	for _, animated := range []bool{true, false} {
+		if _, err := a.SendSticker(ctx, to, webp(0), animated); err != nil {
+			t.Fatalf("SendSticker: %v", err)
+		}
+		sticker := f.sent[len(f.sent)-1].GetStickerMessage()
+		if sticker == nil {
+			t.Fatalf("expected a sticker message")
+		}
+		if sticker.GetIsAnimated() != animated {
+			t.Fatalf("IsAnimated = %v, want %v", sticker.GetIsAnimated(), animated)
+		}
+		if sticker.GetMimetype() != "image/webp" {
+			t.Fatalf("mimetype = %q", sticker.GetMimetype())
+		}
+	}
+
+	m, err := a.db.GetMessage(ctx, to.String(), "msgid")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.MediaType != "sticker" || !m.FromMe {
+		t.Fatalf("stored message = %+v", m)
+	}
+
+	if _, err := a.SendSticker(ctx, to, []byte("not an image"), false); !errors.Is(err, ErrNotWebP) {
+		t.Fatalf("expected ErrNotWebP, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"sync"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// messageHub fans stored live messages out to subscribers. Publishing never
+// blocks: a subscriber whose channel is full misses the message.
+type messageHub struct {
+	mu   sync.RWMutex
+	subs map[chan<- store.Message]struct{}
+}
+
+// Subscribe registers ch to receive every new live message stored by Sync.
+// Sends are non-blocking, so ch should be buffered.
+func (a *App) Subscribe(ch chan<- store.Message) {
+	a.hub.mu.Lock()
+	defer a.hub.mu.Unlock()
+	if a.hub.subs == nil {
+		a.hub.subs = make(map[chan<- store.Message]struct{})
+	}
+	a.hub.subs[ch] = struct{}{}
+}
+
+// Unsubscribe stops deliveries to ch. It does not close ch.
+func (a *App) Unsubscribe(ch chan<- store.Message) {
+	a.hub.mu.Lock()
+	defer a.hub.mu.Unlock()
+	delete(a.hub.subs, ch)
+}
+
+func (h *messageHub) publish(m store.Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs {
+		select {
+		case ch <- m:
+		default:
+		}
+	}
+}
@@ -32,11 +32,15 @@ type SyncOptions struct {
 	RefreshContacts bool
 	RefreshGroups   bool
 	IdleExit        time.Duration // only used for bootstrap/once
+	EventLogPath    string        // append every event as JSON lines (see Replay)
+	OnReconnecting  func(bool)    // called with true before reconnecting and false once done
 	Verbosity       int           // future
 }
 
 type SyncResult struct {
-	MessagesStored int64
+	MessagesStored  int64 // messages seen for the first time
+	MessagesUpdated int64 // messages already in the store that were rewritten
+	SkippedCount    int64 // protocol-only messages with no text or media
 }
 
 func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
@@ -55,7 +59,29 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 		return SyncResult{}, err
 	}
 
-	var messagesStored atomic.Int64
+	var eventLog *EventLogger
+	if opts.EventLogPath != "" {
+		var err error
+		if eventLog, err = OpenEventLog(opts.EventLogPath); err != nil {
+			return SyncResult{}, fmt.Errorf("open event log: %w", err)
+		}
+		defer func() {
+			if n := eventLog.Dropped(); n > 0 {
+				log.Warn().Int64("dropped", n).Msg("event log queue overflowed")
+			}
+			_ = eventLog.Close()
+		}()
+	}
+
+	var messagesStored, messagesUpdated atomic.Int64
+	var skipped atomic.Int64
+	result := func() SyncResult {
+		return SyncResult{
+			MessagesStored:  messagesStored.Load(),
+			MessagesUpdated: messagesUpdated.Load(),
+			SkippedCount:    skipped.Load(),
+		}
+	}
 	lastEvent := atomic.Int64{}
 	lastEvent.Store(time.Now().UTC().UnixNano())
 
@@ -84,65 +110,36 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 		}
 	}
 
+	hooks := ingestHooks{touch: func() { lastEvent.Store(time.Now().UTC().UnixNano()) }}
+	if opts.DownloadMedia {
+		hooks.enqueueMedia = enqueueMedia
+	}
+
 	handlerID := a.wa.AddEventHandler(func(evt interface{}) {
 		lastEvent.Store(time.Now().UTC().UnixNano())
+		if eventLog != nil {
+			eventLog.Log(evt)
+		}
 
 		switch v := evt.(type) {
 		case *events.Message:
-			pm := wa.ParseLiveMessage(v)
-			log.Debug().
-				Str("chat", pm.Chat.String()).
-				Str("id", pm.ID).
-				Bool("from_me", pm.FromMe).
-				Msg("received message")
-			if pm.ReactionToID != "" && pm.ReactionEmoji == "" && v.Message != nil && v.Message.GetEncReactionMessage() != nil {
-				if reaction, err := a.wa.DecryptReaction(ctx, v); err == nil && reaction != nil {
-					pm.ReactionEmoji = reaction.GetText()
-					if pm.ReactionToID == "" {
-						if key := reaction.GetKey(); key != nil {
-							pm.ReactionToID = key.GetID()
-						}
-					}
-				}
-			}
-			if err := a.storeParsedMessage(ctx, pm); err == nil {
-				messagesStored.Add(1)
-			} else {
-				log.Warn().Err(err).Str("id", pm.ID).Msg("failed to store message")
-			}
-			if opts.DownloadMedia && pm.Media != nil && pm.ID != "" {
-				enqueueMedia(pm.Chat.String(), pm.ID)
-			}
-			if messagesStored.Load()%25 == 0 {
+			stats := a.ingestEvent(ctx, v, hooks)
+			messagesStored.Add(stats.stored)
+			messagesUpdated.Add(stats.updated)
+			skipped.Add(stats.skipped)
+			if stats.stored > 0 && messagesStored.Load()%25 == 0 {
 				fmt.Fprintf(os.Stderr, "\rSynced %d messages...", messagesStored.Load())
 			}
 		case *events.HistorySync:
 			log.Info().Int("conversations", len(v.Data.Conversations)).Msg("processing history sync")
 			fmt.Fprintf(os.Stderr, "\nProcessing history sync (%d conversations)...\n", len(v.Data.Conversations))
-			for _, conv := range v.Data.Conversations {
-				lastEvent.Store(time.Now().UTC().UnixNano())
-				chatID := strings.TrimSpace(conv.GetID())
-				if chatID == "" {
-					continue
-				}
-				for _, m := range conv.Messages {
-					lastEvent.Store(time.Now().UTC().UnixNano())
-					if m.Message == nil {
-						continue
-					}
-					pm := wa.ParseHistoryMessage(chatID, m.Message)
-					if pm.ID == "" || pm.Chat.IsEmpty() {
-						continue
-					}
-					if err := a.storeParsedMessage(ctx, pm); err == nil {
-						messagesStored.Add(1)
-					}
-					if opts.DownloadMedia && pm.Media != nil && pm.ID != "" {
-						enqueueMedia(pm.Chat.String(), pm.ID)
-					}
-				}
-			}
+			stats := a.ingestEvent(ctx, v, hooks)
+			messagesStored.Add(stats.stored)
+			messagesUpdated.Add(stats.updated)
+			skipped.Add(stats.skipped)
 			fmt.Fprintf(os.Stderr, "\rSynced %d messages...", messagesStored.Load())
+		case *events.GroupInfo:
+			a.ingestEvent(ctx, v, hooks)
 		case *events.Connected:
 			log.Info().Msg("connected to WhatsApp")
 			fmt.Fprintln(os.Stderr, "\nConnected.")
@@ -182,7 +179,7 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 	}
 	if opts.AfterConnect != nil {
 		if err := opts.AfterConnect(ctx); err != nil {
-			return SyncResult{MessagesStored: messagesStored.Load()}, err
+			return result(), err
 		}
 	}
 
@@ -191,11 +188,11 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 			select {
 			case <-ctx.Done():
 				fmt.Fprintln(os.Stderr, "\nStopping sync.")
-				return SyncResult{MessagesStored: messagesStored.Load()}, nil
+				return result(), nil
 			case <-disconnected:
 				fmt.Fprintln(os.Stderr, "Reconnecting...")
-				if err := a.wa.ReconnectWithBackoff(ctx, 2*time.Second, 30*time.Second); err != nil {
-					return SyncResult{MessagesStored: messagesStored.Load()}, err
+				if err := a.reconnect(ctx, opts.OnReconnecting); err != nil {
+					return result(), err
 				}
 			}
 		}
@@ -212,27 +209,154 @@ func (a *App) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
 		select {
 		case <-ctx.Done():
 			fmt.Fprintln(os.Stderr, "\nStopping sync.")
-			return SyncResult{MessagesStored: messagesStored.Load()}, nil
+			return result(), nil
 		case <-disconnected:
 			fmt.Fprintln(os.Stderr, "Reconnecting...")
-			if err := a.wa.ReconnectWithBackoff(ctx, 2*time.Second, 30*time.Second); err != nil {
-				return SyncResult{MessagesStored: messagesStored.Load()}, err
+			if err := a.reconnect(ctx, opts.OnReconnecting); err != nil {
+				return result(), err
 			}
 		case <-ticker.C:
 			last := time.Unix(0, lastEvent.Load())
 			if time.Since(last) >= opts.IdleExit {
 				fmt.Fprintf(os.Stderr, "\nIdle for %s, exiting.\n", opts.IdleExit)
-				return SyncResult{MessagesStored: messagesStored.Load()}, nil
+				return result(), nil
 			}
 		}
 	}
 }
 
+// reconnect runs the reconnect backoff loop, bracketing it with calls to
+// notify (if set) so callers can report the reconnecting state.
+func (a *App) reconnect(ctx context.Context, notify func(bool)) error {
+	if notify != nil {
+		notify(true)
+		defer notify(false)
+	}
+	return a.wa.ReconnectWithBackoff(ctx, 2*time.Second, 30*time.Second)
+}
+
+// ingestHooks lets callers of ingestEvent observe progress.
+type ingestHooks struct {
+	touch        func()                      // called while working through large batches
+	enqueueMedia func(chatJID, msgID string) // nil disables media downloads
+}
+
+type ingestStats struct {
+	stored  int64 // newly inserted
+	updated int64 // already present
+	skipped int64
+}
+
+// ingestEvent stores whatever evt carries (messages, history, group
+// membership changes). It is shared by Sync and Replay.
+func (a *App) ingestEvent(ctx context.Context, evt interface{}, hooks ingestHooks) ingestStats {
+	log := logging.WithComponent("sync")
+	var stats ingestStats
+
+	save := func(pm wa.ParsedMessage, live bool) {
+		if isProtocolOnly(pm) {
+			stats.skipped++
+			log.Debug().Str("id", pm.ID).Msg("skipping protocol-only message")
+			return
+		}
+		if m, inserted, err := a.storeParsedMessage(ctx, pm); err == nil {
+			if inserted {
+				stats.stored++
+			} else {
+				stats.updated++
+			}
+			if live && inserted {
+				a.hub.publish(m)
+			}
+		} else {
+			log.Warn().Err(err).Str("id", pm.ID).Msg("failed to store message")
+		}
+		if hooks.enqueueMedia != nil && pm.Media != nil && pm.ID != "" {
+			hooks.enqueueMedia(pm.Chat.String(), pm.ID)
+		}
+	}
+
+	switch v := evt.(type) {
+	case *events.Message:
+		pm := wa.ParseLiveMessage(v)
+		log.Debug().
+			Str("chat", pm.Chat.String()).
+			Str("id", pm.ID).
+			Bool("from_me", pm.FromMe).
+			Msg("received message")
+		// Only a plain or successfully decrypted reaction tells us the emoji;
+		// an empty one there means the reaction was removed.
+		knownReaction := v.Message.GetReactionMessage() != nil
+		if pm.ReactionToID != "" && pm.ReactionEmoji == "" && v.Message != nil && v.Message.GetEncReactionMessage() != nil {
+			if reaction, err := a.wa.DecryptReaction(ctx, v); err == nil && reaction != nil {
+				knownReaction = true
+				pm.ReactionEmoji = reaction.GetText()
+				if pm.ReactionToID == "" {
+					if key := reaction.GetKey(); key != nil {
+						pm.ReactionToID = key.GetID()
+					}
+				}
+			}
+		}
+		if knownReaction && pm.ReactionToID != "" {
+			a.recordReaction(ctx, pm)
+		}
+		if v.Message.GetPollUpdateMessage() != nil {
+			a.recordPollVote(ctx, v)
+		} else {
+			a.recordPollOptions(ctx, pm.Chat, pm.ID, v.Message)
+		}
+		save(pm, true)
+	case *events.HistorySync:
+		for _, conv := range v.Data.Conversations {
+			if hooks.touch != nil {
+				hooks.touch()
+			}
+			chatID := strings.TrimSpace(conv.GetID())
+			if chatID == "" {
+				continue
+			}
+			for _, m := range conv.Messages {
+				if hooks.touch != nil {
+					hooks.touch()
+				}
+				if m.Message == nil {
+					continue
+				}
+				pm := wa.ParseHistoryMessage(chatID, m.Message)
+				if pm.ID == "" || pm.Chat.IsEmpty() {
+					continue
+				}
+				a.recordPollOptions(ctx, pm.Chat, pm.ID, m.Message.GetMessage())
+				save(pm, false)
+			}
+		}
+	case *events.GroupInfo:
+		if delta := len(v.Join) - len(v.Leave); delta != 0 {
+			if err := a.db.AdjustChatParticipantsCount(ctx, v.JID.String(), delta); err != nil {
+				log.Warn().Err(err).Str("group", v.JID.String()).Msg("failed to update participant count")
+			}
+		}
+	}
+	return stats
+}
+
+// isProtocolOnly reports whether pm carries nothing worth storing: no text,
+// no display text (locations, contacts, polls), no media and no reaction
+// (e.g. ephemeral timer changes, key distribution).
+func isProtocolOnly(pm wa.ParsedMessage) bool {
+	return strings.TrimSpace(pm.Text) == "" &&
+		strings.TrimSpace(pm.DisplayText) == "" &&
+		pm.Media == nil &&
+		pm.ReactionToID == "" &&
+		strings.TrimSpace(pm.ReactionEmoji) == ""
+}
+
 func chatKind(chat types.JID) string {
-	if chat.Server == types.GroupServer {
+	if wa.IsGroupJID(chat) {
 		return "group"
 	}
-	if chat.IsBroadcastList() {
+	if wa.IsBroadcastJID(chat) {
 		return "broadcast"
 	}
 	if chat.Server == types.DefaultUserServer {
@@ -241,17 +365,24 @@ func chatKind(chat types.JID) string {
 	return "unknown"
 }
 
-func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error {
+// storeParsedMessage stores pm and its chat, returning the stored row and
+// whether it was new.
+func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) (store.Message, bool, error) {
 	chatJID := pm.Chat.String()
-	chatName := a.wa.ResolveChatName(ctx, pm.Chat, pm.PushName)
-	if err := a.db.UpsertChat(chatJID, chatKind(pm.Chat), chatName, pm.Timestamp); err != nil {
-		return err
+	chat, newChat, err := a.db.UpsertChat(ctx, chatJID, chatKind(pm.Chat), a.wa.ResolveChatName(ctx, pm.Chat, pm.PushName), "", pm.Timestamp)
+	if err != nil {
+		return store.Message{}, false, err
 	}
+	if newChat {
+		a.refreshAvatar(ctx, pm.Chat)
+	}
+	// The stored name keeps an earlier, better name when resolution fails.
+	chatName := chat.Name
 
 	// Best-effort: store contact info for DMs.
 	if pm.Chat.Server == types.DefaultUserServer {
 		if info, err := a.wa.GetContact(ctx, pm.Chat.ToNonAD()); err == nil {
-			_ = a.db.UpsertContact(
+			_ = a.db.UpsertContact(ctx,
 				pm.Chat.String(),
 				pm.Chat.User,
 				info.PushName,
@@ -274,7 +405,7 @@ func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error
 				if name := wa.BestContactName(info); name != "" {
 					senderName = name
 				}
-				_ = a.db.UpsertContact(
+				_ = a.db.UpsertContact(ctx,
 					jid.String(),
 					jid.User,
 					info.PushName,
@@ -287,24 +418,21 @@ func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error
 	}
 
 	// Best-effort: store group metadata (and participants) when available.
-	if pm.Chat.Server == types.GroupServer {
+	if wa.IsGroupJID(pm.Chat) {
 		if gi, err := a.wa.GetGroupInfo(ctx, pm.Chat); err == nil && gi != nil {
-			_ = a.db.UpsertGroup(gi.JID.String(), gi.GroupName.Name, gi.OwnerJID.String(), gi.GroupCreated)
+			_ = a.db.UpsertGroup(ctx, gi.JID.String(), gi.GroupName.Name, gi.OwnerJID.String(), gi.GroupCreated)
+			if c, _, err := a.db.UpsertChat(ctx, chatJID, "group", gi.GroupName.Name, gi.Topic, time.Time{}); err == nil {
+				chatName = c.Name
+			}
 			var ps []store.GroupParticipant
 			for _, p := range gi.Participants {
-				role := "member"
-				if p.IsSuperAdmin {
-					role = "superadmin"
-				} else if p.IsAdmin {
-					role = "admin"
-				}
 				ps = append(ps, store.GroupParticipant{
 					GroupJID: pm.Chat.String(),
 					UserJID:  p.JID.String(),
-					Role:     role,
+					Role:     wa.ParticipantRole(p),
 				})
 			}
-			_ = a.db.ReplaceGroupParticipants(pm.Chat.String(), ps)
+			_ = a.db.ReplaceGroupParticipants(ctx, pm.Chat.String(), ps)
 		}
 	}
 
@@ -325,25 +453,27 @@ func (a *App) storeParsedMessage(ctx context.Context, pm wa.ParsedMessage) error
 
 	displayText := a.buildDisplayText(ctx, pm)
 
-	return a.db.UpsertMessage(store.UpsertMessageParams{
-		ChatJID:       chatJID,
-		ChatName:      chatName,
-		MsgID:         pm.ID,
-		SenderJID:     pm.SenderJID,
-		SenderName:    senderName,
-		Timestamp:     pm.Timestamp,
-		FromMe:        pm.FromMe,
-		Text:          pm.Text,
-		DisplayText:   displayText,
-		MediaType:     mediaType,
-		MediaCaption:  caption,
-		Filename:      filename,
-		MimeType:      mimeType,
-		DirectPath:    directPath,
-		MediaKey:      mediaKey,
-		FileSHA256:    fileSha,
-		FileEncSHA256: fileEncSha,
-		FileLength:    fileLen,
+	return a.db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID:        chatJID,
+		ChatName:       chatName,
+		MsgID:          pm.ID,
+		SenderJID:      pm.SenderJID,
+		SenderName:     senderName,
+		Timestamp:      pm.Timestamp,
+		FromMe:         pm.FromMe,
+		Text:           pm.Text,
+		DisplayText:    displayText,
+		MediaType:      mediaType,
+		MediaCaption:   caption,
+		Filename:       filename,
+		MimeType:       mimeType,
+		DirectPath:     directPath,
+		MediaKey:       mediaKey,
+		FileSHA256:     fileSha,
+		FileEncSHA256:  fileEncSha,
+		FileLength:     fileLen,
+		ForwardedScore: pm.ForwardedScore,
+		ReplyToMsgID:   pm.ReplyToID,
 	})
 }
 
@@ -354,7 +484,7 @@ func (a *App) buildDisplayText(ctx context.Context, pm wa.ParsedMessage) string
 		target := strings.TrimSpace(pm.ReactionToID)
 		display := ""
 		if target != "" {
-			display = a.lookupMessageDisplayText(pm.Chat.String(), target)
+			display = a.lookupMessageDisplayText(ctx, pm.Chat.String(), target)
 		}
 		if display == "" {
 			display = "message"
@@ -369,7 +499,7 @@ func (a *App) buildDisplayText(ctx context.Context, pm wa.ParsedMessage) string
 	if pm.ReplyToID != "" {
 		quoted := strings.TrimSpace(pm.ReplyToDisplay)
 		if quoted == "" {
-			quoted = a.lookupMessageDisplayText(pm.Chat.String(), pm.ReplyToID)
+			quoted = a.lookupMessageDisplayText(ctx, pm.Chat.String(), pm.ReplyToID)
 		}
 		if quoted == "" {
 			quoted = "message"
@@ -390,17 +520,20 @@ func baseDisplayText(pm wa.ParsedMessage) string {
 	if pm.Media != nil {
 		return "Sent " + mediaLabel(pm.Media.Type)
 	}
+	if display := strings.TrimSpace(pm.DisplayText); display != "" {
+		return display
+	}
 	if text := strings.TrimSpace(pm.Text); text != "" {
 		return text
 	}
 	return ""
 }
 
-func (a *App) lookupMessageDisplayText(chatJID, msgID string) string {
+func (a *App) lookupMessageDisplayText(ctx context.Context, chatJID, msgID string) string {
 	if strings.TrimSpace(chatJID) == "" || strings.TrimSpace(msgID) == "" {
 		return ""
 	}
-	msg, err := a.db.GetMessage(chatJID, msgID)
+	msg, err := a.db.GetMessage(ctx, chatJID, msgID)
 	if err != nil {
 		return ""
 	}
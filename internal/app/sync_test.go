@@ -2,6 +2,10 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +16,8 @@ import (
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	"google.golang.org/protobuf/proto"
+
+	"github.com/steipete/wacli/internal/store"
 )
 
 func TestSyncStoresLiveAndHistoryMessages(t *testing.T) {
@@ -80,7 +86,7 @@ func TestSyncStoresLiveAndHistoryMessages(t *testing.T) {
 	if res.MessagesStored != 2 {
 		t.Fatalf("expected 2 MessagesStored, got %d", res.MessagesStored)
 	}
-	if n, err := a.db.CountMessages(); err != nil || n != 2 {
+	if n, err := a.db.CountMessages(context.Background()); err != nil || n != 2 {
 		t.Fatalf("expected 2 messages in DB, got %d (err=%v)", n, err)
 	}
 }
@@ -202,7 +208,7 @@ func TestSyncStoresDisplayText(t *testing.T) {
 		t.Fatalf("expected 4 MessagesStored, got %d", res.MessagesStored)
 	}
 
-	msg, err := a.db.GetMessage(chat.String(), "m-text")
+	msg, err := a.db.GetMessage(context.Background(), chat.String(), "m-text")
 	if err != nil {
 		t.Fatalf("GetMessage text: %v", err)
 	}
@@ -210,7 +216,7 @@ func TestSyncStoresDisplayText(t *testing.T) {
 		t.Fatalf("expected display text 'hello', got %q", msg.DisplayText)
 	}
 
-	msg, err = a.db.GetMessage(chat.String(), "m-image")
+	msg, err = a.db.GetMessage(context.Background(), chat.String(), "m-image")
 	if err != nil {
 		t.Fatalf("GetMessage image: %v", err)
 	}
@@ -218,7 +224,7 @@ func TestSyncStoresDisplayText(t *testing.T) {
 		t.Fatalf("expected display text 'Sent image', got %q", msg.DisplayText)
 	}
 
-	msg, err = a.db.GetMessage(chat.String(), "m-reply")
+	msg, err = a.db.GetMessage(context.Background(), chat.String(), "m-reply")
 	if err != nil {
 		t.Fatalf("GetMessage reply: %v", err)
 	}
@@ -226,13 +232,21 @@ func TestSyncStoresDisplayText(t *testing.T) {
 		t.Fatalf("unexpected reply display text: %q", msg.DisplayText)
 	}
 
-	msg, err = a.db.GetMessage(chat.String(), "m-react")
+	msg, err = a.db.GetMessage(context.Background(), chat.String(), "m-react")
 	if err != nil {
 		t.Fatalf("GetMessage react: %v", err)
 	}
 	if msg.DisplayText != "Reacted 👍 to hello" {
 		t.Fatalf("unexpected reaction display text: %q", msg.DisplayText)
 	}
+
+	reactions, err := a.db.ListReactions(context.Background(), chat.String(), "m-text")
+	if err != nil {
+		t.Fatalf("ListReactions: %v", err)
+	}
+	if len(reactions) != 1 || reactions[0].Reaction != "👍" || reactions[0].Count != 1 {
+		t.Fatalf("unexpected reactions: %+v", reactions)
+	}
 }
 
 func TestSyncOnceIdleExit(t *testing.T) {
@@ -256,3 +270,371 @@ func TestSyncOnceIdleExit(t *testing.T) {
 		t.Fatalf("expected to exit quickly on idle, took %s", time.Since(start))
 	}
 }
+
+func TestSyncSkipsProtocolOnlyMessages(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	base := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	textMsg := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-text",
+			Timestamp:     base.Add(1 * time.Second),
+		},
+		Message: &waProto.Message{Conversation: proto.String("hello")},
+	}
+	protocolMsg := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-proto",
+			Timestamp:     base.Add(2 * time.Second),
+		},
+		Message: &waProto.Message{
+			ProtocolMessage: &waProto.ProtocolMessage{
+				Type:                waProto.ProtocolMessage_EPHEMERAL_SETTING.Enum(),
+				EphemeralExpiration: proto.Uint32(86400),
+			},
+		},
+	}
+
+	f.connectEvents = []interface{}{textMsg, protocolMsg}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	res, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.MessagesStored != 1 {
+		t.Fatalf("expected 1 MessagesStored, got %d", res.MessagesStored)
+	}
+	if res.SkippedCount != 1 {
+		t.Fatalf("expected 1 SkippedCount, got %d", res.SkippedCount)
+	}
+	if _, err := a.db.GetMessage(context.Background(), chat.String(), "m-proto"); err == nil {
+		t.Fatalf("expected protocol-only message to not be stored")
+	}
+}
+
+func TestSyncStoresLocationContactAndPollMessages(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	base := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	msg := func(id string, i int, m *waProto.Message) *events.Message {
+		return &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+				ID:            id,
+				Timestamp:     base.Add(time.Duration(i) * time.Second),
+			},
+			Message: m,
+		}
+	}
+	f.connectEvents = []interface{}{
+		msg("m-loc", 1, &waProto.Message{LocationMessage: &waProto.LocationMessage{
+			DegreesLatitude: proto.Float64(52.52), DegreesLongitude: proto.Float64(13.405),
+		}}),
+		msg("m-contact", 2, &waProto.Message{ContactMessage: &waProto.ContactMessage{
+			DisplayName: proto.String("Alice"), Vcard: proto.String("BEGIN:VCARD\nEND:VCARD"),
+		}}),
+		msg("m-poll", 3, &waProto.Message{PollCreationMessageV3: &waProto.PollCreationMessage{
+			Name:    proto.String("Lunch?"),
+			Options: []*waProto.PollCreationMessage_Option{{OptionName: proto.String("Yes")}, {OptionName: proto.String("No")}},
+		}}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	res, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.MessagesStored != 3 || res.SkippedCount != 0 {
+		t.Fatalf("expected 3 stored and 0 skipped, got %d and %d", res.MessagesStored, res.SkippedCount)
+	}
+	for id, want := range map[string]string{
+		"m-loc":     "Sent location",
+		"m-contact": "Sent contact",
+		"m-poll":    "Poll: Lunch?",
+	} {
+		m, err := a.db.GetMessage(context.Background(), chat.String(), id)
+		if err != nil {
+			t.Fatalf("%s not stored: %v", id, err)
+		}
+		if m.DisplayText != want {
+			t.Errorf("%s: display text = %q, want %q", id, m.DisplayText, want)
+		}
+	}
+}
+
+func TestSyncTracksGroupParticipantCount(t *testing.T) {
+	ctx := context.Background()
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	group := types.JID{User: "123", Server: types.GroupServer}
+	alice := types.JID{User: "111", Server: types.DefaultUserServer}
+	bob := types.JID{User: "222", Server: types.DefaultUserServer}
+	carol := types.JID{User: "333", Server: types.DefaultUserServer}
+	if err := a.db.UpsertGroup(ctx, group.String(), "Group", "", time.Time{}); err != nil {
+		t.Fatalf("UpsertGroup: %v", err)
+	}
+	if err := a.db.ReplaceGroupParticipants(ctx, group.String(), []store.GroupParticipant{
+		{GroupJID: group.String(), UserJID: alice.String()},
+		{GroupJID: group.String(), UserJID: bob.String()},
+	}); err != nil {
+		t.Fatalf("ReplaceGroupParticipants: %v", err)
+	}
+
+	f.connectEvents = []interface{}{
+		&events.GroupInfo{JID: group, Join: []types.JID{carol}},
+		&events.GroupInfo{JID: group, Leave: []types.JID{alice, bob}},
+	}
+
+	syncCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := a.Sync(syncCtx, SyncOptions{Mode: SyncModeFollow}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	c, err := a.db.GetChat(ctx, group.String())
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.ParticipantsCount == nil || *c.ParticipantsCount != 1 {
+		t.Fatalf("expected 1 participant, got %v", c.ParticipantsCount)
+	}
+}
+
+func TestSyncWritesEventLog(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	f.connectEvents = []interface{}{&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-1",
+			Timestamp:     time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		},
+		Message: &waProto.Message{Conversation: proto.String("hello")},
+	}}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow, EventLogPath: path}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read event log: %v", err)
+	}
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var rec EventRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid event line %q: %v", line, err)
+		}
+		if rec.EventType == "Message" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a Message event in log, got:\n%s", b)
+	}
+}
+
+func TestSyncPublishesLiveMessagesToSubscribers(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	base := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	live := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-live",
+			Timestamp:     base,
+			PushName:      "Alice",
+		},
+		Message: &waProto.Message{Conversation: proto.String("hello")},
+	}
+	histMsg := &waWeb.WebMessageInfo{
+		Key: &waCommon.MessageKey{
+			RemoteJID: proto.String(chat.String()),
+			ID:        proto.String("m-hist"),
+		},
+		MessageTimestamp: proto.Uint64(uint64(base.Add(-time.Second).Unix())),
+		Message:          &waProto.Message{Conversation: proto.String("older")},
+	}
+	history := &events.HistorySync{
+		Data: &waHistorySync.HistorySync{
+			SyncType: waHistorySync.HistorySync_FULL.Enum(),
+			Conversations: []*waHistorySync.Conversation{{
+				ID:       proto.String(chat.String()),
+				Messages: []*waHistorySync.HistorySyncMsg{{Message: histMsg}},
+			}},
+		},
+	}
+	f.connectEvents = []interface{}{live, history}
+
+	ch := make(chan store.Message, 4)
+	a.Subscribe(ch)
+	defer a.Unsubscribe(ch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	if len(ch) != 1 {
+		t.Fatalf("expected only the live message to be published, got %d", len(ch))
+	}
+	if m := <-ch; m.MsgID != "m-live" || m.Text != "hello" || m.ChatJID != chat.String() {
+		t.Fatalf("unexpected published message: %+v", m)
+	}
+}
+
+func TestSyncCountsRedeliveredMessagesAsUpdated(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	live := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-dup",
+			Timestamp:     time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		},
+		Message: &waProto.Message{Conversation: proto.String("hello")},
+	}
+	f.connectEvents = []interface{}{live, live}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	res, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.MessagesStored != 1 || res.MessagesUpdated != 1 {
+		t.Fatalf("expected 1 stored and 1 updated, got %d and %d", res.MessagesStored, res.MessagesUpdated)
+	}
+}
+
+func TestSyncStoresAvatarForNewChats(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	f.avatars[chat] = "https://pps.whatsapp.net/alice.jpg"
+	f.connectEvents = []interface{}{&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m1",
+			Timestamp:     time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		Message: &waProto.Message{Conversation: proto.String("hello")},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	c, err := a.db.GetChat(context.Background(), chat.String())
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.AvatarURL != "https://pps.whatsapp.net/alice.jpg" {
+		t.Fatalf("expected avatar URL to be stored, got %q", c.AvatarURL)
+	}
+}
+
+func TestSyncStoresForwardedScore(t *testing.T) {
+	a := newTestApp(t)
+	f := newFakeWA()
+	a.wa = f
+
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+	forwarded := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-fwd",
+			Timestamp:     time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		Message: &waProto.Message{
+			ExtendedTextMessage: &waProto.ExtendedTextMessage{
+				Text: proto.String("chain letter"),
+				ContextInfo: &waProto.ContextInfo{
+					IsForwarded:     proto.Bool(true),
+					ForwardingScore: proto.Uint32(7),
+				},
+			},
+		},
+	}
+	plain := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            "m-plain",
+			Timestamp:     time.Date(2024, 1, 5, 0, 0, 1, 0, time.UTC),
+		},
+		Message: &waProto.Message{Conversation: proto.String("hi")},
+	}
+	f.connectEvents = []interface{}{forwarded, plain}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := a.Sync(ctx, SyncOptions{Mode: SyncModeFollow}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	for id, want := range map[string]uint32{"m-fwd": 7, "m-plain": 0} {
+		msg, err := a.db.GetMessage(context.Background(), chat.String(), id)
+		if err != nil {
+			t.Fatalf("GetMessage %s: %v", id, err)
+		}
+		if msg.ForwardedScore != want {
+			t.Fatalf("%s: expected ForwardedScore=%d, got %d", id, want, msg.ForwardedScore)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile reads a YAML config file of flag values. Keys are flag names with
+// dashes written as underscores (webhook_url for --webhook-url). Scalars
+// become their string form, lists are joined with commas and maps become
+// k=v pairs, matching how the flags themselves are parsed.
+func LoadFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		values[k] = flagString(v)
+	}
+	return values, nil
+}
+
+func flagString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		parts := make([]string, len(x))
+		for i, item := range x {
+			parts[i] = flagString(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		parts := make([]string, 0, len(x))
+		for k, item := range x {
+			parts = append(parts, k+"="+flagString(item))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// Template is the commented config written by `wacli config init`.
+const Template = `# wacli configuration. Pass it with --config or WACLI_CONFIG.
+# Keys mirror the command-line flags with dashes written as underscores;
+# flags given on the command line override these values, and WACLI_<KEY>
+# environment variables (e.g. WACLI_ADDR) override both.
+
+# Store directory (default: ~/.wacli).
+# store: /var/lib/wacli
+
+# Log level: trace, debug, info, warn or error. WACLI_LOG overrides it.
+# log_level: warn
+
+# Command timeout for non-sync commands.
+# timeout: 5m
+
+# RPC server (wacli rpc); wacli sync --rpc listens on rpc_addr.
+# addr: localhost:5555
+# rpc_addr: localhost:5555
+# healthcheck_addr: ":9090"
+# metrics_addr: ":9091"
+# proxy_trusted_cidrs: [10.0.0.0/8]
+# endpoint_timeouts:
+#   /send: 30s
+# group_members_ttl: 1h
+# rate_limit: 10
+# rate_burst: 20
+
+# Webhook for new messages.
+# webhook_url: https://example.com/hook
+# webhook_secret: s3cret
+`
@@ -0,0 +1,126 @@
+// Package export streams stored messages as CSV, NDJSON or plain text. It
+// backs both `wacli export` and the RPC GET /export/messages endpoint.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/out"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// Supported formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatTXT    = "txt"
+)
+
+// CSVHeaders are the columns of a CSV export, in order.
+var CSVHeaders = []string{"chat_jid", "msg_id", "sender_jid", "timestamp", "from_me", "text"}
+
+// ParseFormat normalizes format, defaulting to CSV when it is empty.
+func ParseFormat(format string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatNDJSON, FormatTXT:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (use csv, ndjson or txt)", format)
+	}
+}
+
+// ContentType is the MIME type of an export in format.
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatTXT:
+		return "text/plain; charset=utf-8"
+	default:
+		return "text/csv"
+	}
+}
+
+// Filename is the suggested download name of an export in format.
+func Filename(format string) string {
+	return "messages." + format
+}
+
+type messageJSON struct {
+	ChatJID   string `json:"chat_jid"`
+	MsgID     string `json:"msg_id"`
+	SenderJID string `json:"sender_jid"`
+	Timestamp string `json:"timestamp"`
+	FromMe    bool   `json:"from_me"`
+	Text      string `json:"text"`
+}
+
+// Messages writes the messages of chatJID (every chat when empty) to w,
+// oldest first. Each message is written as soon as it is read; w may flush
+// on every Write to stream the result. format must come from ParseFormat.
+func Messages(ctx context.Context, db *store.DB, w io.Writer, format, chatJID string) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(CSVHeaders); err != nil {
+			return err
+		}
+		cw.Flush()
+		return db.EachMessage(ctx, chatJID, func(m store.Message) error {
+			if err := cw.Write(out.CSVRow(m.ChatJID, m.MsgID, m.SenderJID, m.Timestamp.UTC(), m.FromMe, text(m))); err != nil {
+				return err
+			}
+			cw.Flush()
+			return cw.Error()
+		})
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		return db.EachMessage(ctx, chatJID, func(m store.Message) error {
+			return enc.Encode(messageJSON{
+				ChatJID:   m.ChatJID,
+				MsgID:     m.MsgID,
+				SenderJID: m.SenderJID,
+				Timestamp: m.Timestamp.UTC().Format(time.RFC3339),
+				FromMe:    m.FromMe,
+				Text:      text(m),
+			})
+		})
+	case FormatTXT:
+		return db.EachMessage(ctx, chatJID, func(m store.Message) error {
+			_, err := fmt.Fprintf(w, "[%s] %s: %s\n", m.Timestamp.UTC().Format(time.RFC3339), sender(m), text(m))
+			return err
+		})
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// text prefers the stored text and falls back to the display text, which
+// describes media and other non-text messages.
+func text(m store.Message) string {
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.DisplayText
+}
+
+func sender(m store.Message) string {
+	switch {
+	case m.FromMe:
+		return "me"
+	case m.SenderName != "":
+		return m.SenderName
+	case m.SenderJID != "":
+		return m.SenderJID
+	default:
+		return m.ChatJID
+	}
+}
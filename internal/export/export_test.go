@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func seed(t *testing.T) *store.DB {
+	t.Helper()
+	db, err := store.OpenMemory()
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if _, _, err := db.UpsertChat(ctx, "a@s.whatsapp.net", "dm", "Alice", "", base); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for i, p := range []store.UpsertMessageParams{
+		{MsgID: "m1", SenderJID: "a@s.whatsapp.net", SenderName: "Alice", Text: "hi, there"},
+		{MsgID: "m2", FromMe: true, Text: "hello"},
+	} {
+		p.ChatJID = "a@s.whatsapp.net"
+		p.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if _, _, err := db.UpsertMessage(ctx, p); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	return db
+}
+
+func TestMessagesCSV(t *testing.T) {
+	db := seed(t)
+	var buf bytes.Buffer
+	if err := Messages(context.Background(), db, &buf, FormatCSV, "a@s.whatsapp.net"); err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(CSVHeaders, ",") {
+		t.Fatalf("records = %v", records)
+	}
+	want := []string{"a@s.whatsapp.net", "m1", "a@s.whatsapp.net", "2024-05-01T12:00:00Z", "false", "hi, there"}
+	if strings.Join(records[1], "|") != strings.Join(want, "|") {
+		t.Fatalf("row = %v, want %v", records[1], want)
+	}
+}
+
+func TestMessagesNDJSONAndTXT(t *testing.T) {
+	db := seed(t)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	if err := Messages(ctx, db, &buf, FormatNDJSON, ""); err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var m messageJSON
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if m.MsgID != "m2" || !m.FromMe || m.Text != "hello" {
+		t.Fatalf("line = %+v", m)
+	}
+
+	buf.Reset()
+	if err := Messages(ctx, db, &buf, FormatTXT, ""); err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	want := "[2024-05-01T12:00:00Z] Alice: hi, there\n[2024-05-01T12:01:00Z] me: hello\n"
+	if buf.String() != want {
+		t.Fatalf("txt = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatCSV {
+		t.Fatalf("default = %q, %v", f, err)
+	}
+	if f, err := ParseFormat("NDJSON"); err != nil || f != FormatNDJSON {
+		t.Fatalf("ndjson = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatalf("expected error for xml")
+	}
+}
@@ -28,6 +28,11 @@ func init() {
 	log = zerolog.New(output).With().Timestamp().Logger()
 }
 
+// SetLevel changes the global log level, using the same names as WACLI_LOG.
+func SetLevel(s string) {
+	zerolog.SetGlobalLevel(parseLevel(s))
+}
+
 func parseLevel(s string) zerolog.Level {
 	switch strings.ToLower(strings.TrimSpace(s)) {
 	case "trace":
@@ -0,0 +1,45 @@
+package out
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteCSV writes headers followed by rows as RFC 4180 CSV. Cells with
+// commas, quotes or newlines are quoted by encoding/csv.
+func WriteCSV(w io.Writer, headers []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// CSVRow formats values as CSV cells. time.Time becomes RFC3339 (empty for
+// the zero time); everything else uses its default string form.
+func CSVRow(values ...interface{}) []string {
+	row := make([]string, len(values))
+	for i, v := range values {
+		switch x := v.(type) {
+		case nil:
+		case string:
+			row[i] = x
+		case time.Time:
+			if !x.IsZero() {
+				row[i] = x.Format(time.RFC3339)
+			}
+		case *time.Time:
+			if x != nil && !x.IsZero() {
+				row[i] = x.Format(time.RFC3339)
+			}
+		default:
+			row[i] = fmt.Sprint(x)
+		}
+	}
+	return row
+}
@@ -0,0 +1,36 @@
+package out
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	headers := []string{"id", "text", "ts", "from_me"}
+	rows := [][]string{
+		CSVRow("m1", "hello, \"world\"\nsecond line", ts, true),
+		CSVRow("m2", "plain", time.Time{}, false),
+	}
+
+	var b bytes.Buffer
+	if err := WriteCSV(&b, headers, rows); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+
+	got, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	want := [][]string{
+		headers,
+		{"m1", "hello, \"world\"\nsecond line", "2024-01-02T03:04:05Z", "true"},
+		{"m2", "plain", "", "false"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\ngot  %q\nwant %q", got, want)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+
+	"golang.org/x/term"
 )
 
 type envelope struct {
@@ -12,7 +15,12 @@ type envelope struct {
 	Error   *string     `json:"error"`
 }
 
+// WriteJSON writes data in the success envelope. Output is indented when w
+// is a terminal and compact otherwise (pipes, files).
 func WriteJSON(w io.Writer, data interface{}) error {
+	if isTerminal(w) {
+		return WriteJSONPretty(w, data)
+	}
 	b, err := json.Marshal(envelope{Success: true, Data: data})
 	if err != nil {
 		return err
@@ -21,6 +29,21 @@ func WriteJSON(w io.Writer, data interface{}) error {
 	return err
 }
 
+// WriteJSONPretty is WriteJSON with 2-space indentation.
+func WriteJSONPretty(w io.Writer, data interface{}) error {
+	b, err := json.MarshalIndent(envelope{Success: true, Data: data}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
 func WriteError(w io.Writer, asJSON bool, err error) error {
 	if err == nil {
 		return nil
@@ -38,3 +38,29 @@ func TestWriteErrorJSONAndText(t *testing.T) {
 		t.Fatalf("unexpected text error output: %q", b.String())
 	}
 }
+
+func TestWriteJSONPrettyDiffersOnlyInWhitespace(t *testing.T) {
+	data := map[string]any{"name": "Alice", "tags": []string{"a", "b"}, "n": 3}
+
+	var compact, pretty bytes.Buffer
+	if err := WriteJSON(&compact, data); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if err := WriteJSONPretty(&pretty, data); err != nil {
+		t.Fatalf("WriteJSONPretty: %v", err)
+	}
+	if compact.String() == pretty.String() {
+		t.Fatalf("expected pretty output to be indented")
+	}
+	if !strings.Contains(pretty.String(), "\n  \"data\"") {
+		t.Fatalf("expected 2-space indentation, got %q", pretty.String())
+	}
+
+	var squashed bytes.Buffer
+	if err := json.Compact(&squashed, pretty.Bytes()); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if squashed.String() != strings.TrimSpace(compact.String()) {
+		t.Fatalf("outputs differ beyond whitespace:\n%s\n%s", squashed.String(), compact.String())
+	}
+}
@@ -0,0 +1,181 @@
+package out
+
+import (
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	tableGap         = "  "
+	tableMinColWidth = 4
+)
+
+type tableColumn struct {
+	name  string
+	width int // 0 = auto
+}
+
+// Table renders aligned, space-separated columns. Cells wider than their
+// column are wrapped onto continuation lines.
+type Table struct {
+	cols []tableColumn
+	rows [][]string
+
+	// MaxWidth caps the total line width. 0 means the terminal width when
+	// rendering to a terminal, and unlimited otherwise.
+	MaxWidth int
+}
+
+// AddColumn appends a column. A width of 0 sizes the column to fit its
+// header and widest cell.
+func (t *Table) AddColumn(name string, width int) {
+	t.cols = append(t.cols, tableColumn{name: name, width: width})
+}
+
+// AddRow appends a row. Missing values render empty; extras are ignored.
+func (t *Table) AddRow(values ...string) {
+	row := make([]string, len(t.cols))
+	copy(row, values)
+	t.rows = append(t.rows, row)
+}
+
+func (t *Table) Render(w io.Writer) error {
+	if len(t.cols) == 0 {
+		return nil
+	}
+	widths := t.columnWidths(t.maxWidth(w))
+
+	var b strings.Builder
+	header := make([]string, len(t.cols))
+	for i, c := range t.cols {
+		header[i] = c.name
+	}
+	writeTableRow(&b, header, widths)
+	for _, row := range t.rows {
+		writeTableRow(&b, row, widths)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (t *Table) maxWidth(w io.Writer) int {
+	if t.MaxWidth > 0 {
+		return t.MaxWidth
+	}
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 0
+}
+
+// columnWidths resolves auto widths and, if the table is wider than max,
+// shrinks the widest columns until it fits (or every column is at the
+// minimum).
+func (t *Table) columnWidths(max int) []int {
+	widths := make([]int, len(t.cols))
+	for i, c := range t.cols {
+		if c.width > 0 {
+			widths[i] = c.width
+			continue
+		}
+		widths[i] = utf8.RuneCountInString(c.name)
+		for _, row := range t.rows {
+			for _, line := range strings.Split(row[i], "\n") {
+				if n := utf8.RuneCountInString(line); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+	if max <= 0 {
+		return widths
+	}
+
+	total := func() int {
+		n := len(tableGap) * (len(widths) - 1)
+		for _, w := range widths {
+			n += w
+		}
+		return n
+	}
+	for total() > max {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= tableMinColWidth {
+			break
+		}
+		widths[widest]--
+	}
+	return widths
+}
+
+func writeTableRow(b *strings.Builder, cells []string, widths []int) {
+	wrapped := make([][]string, len(cells))
+	lines := 1
+	for i, cell := range cells {
+		wrapped[i] = wrapCell(cell, widths[i])
+		if len(wrapped[i]) > lines {
+			lines = len(wrapped[i])
+		}
+	}
+	for l := 0; l < lines; l++ {
+		var line strings.Builder
+		for i := range cells {
+			var part string
+			if l < len(wrapped[i]) {
+				part = wrapped[i][l]
+			}
+			if i > 0 {
+				line.WriteString(tableGap)
+			}
+			line.WriteString(part)
+			if i < len(cells)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(part)))
+			}
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteByte('\n')
+	}
+}
+
+// wrapCell splits s into lines of at most width runes, breaking at spaces
+// where possible and hard-splitting words that are too long.
+func wrapCell(s string, width int) []string {
+	if width <= 0 {
+		return strings.Split(s, "\n")
+	}
+	var out []string
+	for _, para := range strings.Split(s, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(para) {
+			wr := []rune(word)
+			if len(line) > 0 && len(line)+1+len(wr) > width {
+				out = append(out, string(line))
+				line = line[:0]
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			for len(line)+len(wr) > width {
+				n := width - len(line)
+				line = append(line, wr[:n]...)
+				out = append(out, string(line))
+				line = line[:0]
+				wr = wr[n:]
+			}
+			line = append(line, wr...)
+		}
+		out = append(out, string(line))
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package out
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTableAlignment(t *testing.T) {
+	var tbl Table
+	tbl.AddColumn("KIND", 0)
+	tbl.AddColumn("NAME", 0)
+	tbl.AddColumn("JID", 0)
+	tbl.AddRow("dm", "Alice", "111@s.whatsapp.net")
+	tbl.AddRow("group", "Family", "123-456@g.us")
+	tbl.AddRow("dm", "Bob", "222@s.whatsapp.net")
+	tbl.AddRow("broadcast", "Status", "status@broadcast")
+	tbl.AddRow("dm", "Carol Longname", "333@s.whatsapp.net")
+
+	var b bytes.Buffer
+	if err := tbl.Render(&b); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "" +
+		"KIND       NAME            JID\n" +
+		"dm         Alice           111@s.whatsapp.net\n" +
+		"group      Family          123-456@g.us\n" +
+		"dm         Bob             222@s.whatsapp.net\n" +
+		"broadcast  Status          status@broadcast\n" +
+		"dm         Carol Longname  333@s.whatsapp.net\n"
+	if b.String() != want {
+		t.Fatalf("unexpected table:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestTableFixedWidthWraps(t *testing.T) {
+	tbl := Table{}
+	tbl.AddColumn("ID", 0)
+	tbl.AddColumn("TEXT", 10)
+	tbl.AddRow("m1", "hello there general kenobi")
+
+	var b bytes.Buffer
+	if err := tbl.Render(&b); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "" +
+		"ID  TEXT\n" +
+		"m1  hello\n" +
+		"    there\n" +
+		"    general\n" +
+		"    kenobi\n"
+	if b.String() != want {
+		t.Fatalf("unexpected table:\n%q\nwant:\n%q", b.String(), want)
+	}
+}
+
+func TestTableShrinksToMaxWidth(t *testing.T) {
+	tbl := Table{MaxWidth: 20}
+	tbl.AddColumn("ID", 0)
+	tbl.AddColumn("TEXT", 0)
+	tbl.AddRow("m1", strings.Repeat("x", 40))
+
+	var b bytes.Buffer
+	if err := tbl.Render(&b); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(b.String(), "\n"), "\n") {
+		if len(line) > 20 {
+			t.Fatalf("line exceeds max width: %q", line)
+		}
+	}
+	if !strings.Contains(b.String(), strings.Repeat("x", 16)) {
+		t.Fatalf("expected long cell to be split across lines, got:\n%s", b.String())
+	}
+}
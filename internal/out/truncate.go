@@ -0,0 +1,22 @@
+package out
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Truncate flattens s onto one line and cuts it to at most max runes,
+// counting the "…" that ends a shortened string. It never splits a
+// multi-byte character. A max of 0 or less disables the limit.
+func Truncate(s string, max int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.TrimSpace(s)
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	r := []rune(s)
+	if max <= 1 {
+		return string(r[:max])
+	}
+	return string(r[:max-1]) + "…"
+}
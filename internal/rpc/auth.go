@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// withAuth rejects requests that do not carry the configured token, either
+// as "Authorization: Bearer <token>" or, for clients that cannot set
+// headers (browser WebSockets), as ?token=. /ping stays open for health
+// probes.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	if s.authToken == "" {
+		return next
+	}
+	want := []byte(s.authToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		got := r.URL.Query().Get("token")
+		if h := r.Header.Get("Authorization"); h != "" {
+			scheme, token, _ := strings.Cut(h, " ")
+			if strings.EqualFold(scheme, "Bearer") {
+				got = strings.TrimSpace(token)
+			}
+		}
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="wacli"`)
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_AuthToken(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	const token = "0123456789abcdef0123456789abcdef"
+	srv, err := New(Options{Addr: "localhost:0", DB: db, AuthToken: token})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "/chats", "", http.StatusUnauthorized},
+		{"wrong token", "/chats", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "/chats", "Basic " + token, http.StatusUnauthorized},
+		{"bearer token", "/chats", "Bearer " + token, http.StatusOK},
+		{"query token", "/chats?token=" + token, "", http.StatusOK},
+		{"wrong query token", "/chats?token=nope", "", http.StatusUnauthorized},
+		{"ping is open", "/ping", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("expected a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestServer_NoAuthTokenIsOpen(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/chats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 without a configured token, got %d", w.Code)
+	}
+}
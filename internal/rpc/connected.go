@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// connectWait is how long /send waits for a dropped session to come
+	// back before giving up.
+	connectWait      = 2 * time.Second
+	connectPollEvery = 100 * time.Millisecond
+)
+
+type disconnectedResponse struct {
+	OK           bool   `json:"ok"`
+	Error        string `json:"error"`
+	Reconnecting bool   `json:"reconnecting"`
+}
+
+// SetReconnecting records whether sync is currently trying to re-establish
+// the WhatsApp session. It is reported to clients rejected by requireWA
+// and, on change, to /events clients.
+func (s *Server) SetReconnecting(reconnecting bool) {
+	if s.isReconnecting.Swap(reconnecting) != reconnecting {
+		s.publishStatus()
+	}
+}
+
+// WaitConnected reports whether the WhatsApp client is connected, polling
+// for up to timeout if it is not.
+func (s *Server) WaitConnected(ctx context.Context, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(connectPollEvery)
+	defer ticker.Stop()
+	for {
+		s.mu.RLock()
+		wa := s.wa
+		s.mu.RUnlock()
+		if wa != nil && wa.IsConnected() {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// requireWA guards POST-only endpoints that need WhatsApp. Other methods
+// get 405 right away; POSTs get 503 unless WhatsApp is connected (or
+// reconnects within connectWait). The request body is left untouched.
+func (s *Server) requireWA(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !s.awaitWA(w, r) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// awaitWA waits up to connectWait for WhatsApp. If it stays disconnected,
+// awaitWA writes the 503 and reports false.
+func (s *Server) awaitWA(w http.ResponseWriter, r *http.Request) bool {
+	if s.WaitConnected(r.Context(), connectWait) {
+		return true
+	}
+	writeJSON(w, http.StatusServiceUnavailable, disconnectedResponse{
+		OK:           false,
+		Error:        "WhatsApp disconnected",
+		Reconnecting: s.isReconnecting.Load(),
+	})
+	return false
+}
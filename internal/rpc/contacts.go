@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type contactJSON struct {
+	JID       string `json:"jid"`
+	Name      string `json:"name"`
+	Alias     string `json:"alias,omitempty"`
+	Phone     string `json:"phone"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type contactsResponse struct {
+	OK       bool          `json:"ok"`
+	Contacts []contactJSON `json:"contacts"`
+}
+
+func (s *Server) handleContacts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query().Get("query")
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	contacts, err := s.db.ListContacts(r.Context(), query, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]contactJSON, len(contacts))
+	for i, c := range contacts {
+		out[i] = contactJSON{
+			JID:       c.JID,
+			Name:      c.Name,
+			Alias:     c.Alias,
+			Phone:     c.Phone,
+			UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+	writeOK(w, contactsResponse{OK: true, Contacts: out})
+}
@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_Contacts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_ = db.UpsertContact(ctx, "111@s.whatsapp.net", "111", "ali", "Alice Smith", "Alice", "")
+	_ = db.UpsertContact(ctx, "222@s.whatsapp.net", "222", "Bobby", "", "", "")
+	_ = db.SetAlias(ctx, "222@s.whatsapp.net", "Bob")
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	get := func(query string) contactsResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/contacts"+query, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp contactsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !resp.OK {
+			t.Fatal("expected ok=true")
+		}
+		return resp
+	}
+
+	resp := get("")
+	if len(resp.Contacts) != 2 {
+		t.Fatalf("expected 2 contacts, got %+v", resp.Contacts)
+	}
+	if c := resp.Contacts[0]; c.JID != "111@s.whatsapp.net" || c.Name != "Alice Smith" || c.Phone != "111" {
+		t.Fatalf("unexpected first contact: %+v", c)
+	}
+	if c := resp.Contacts[1]; c.Alias != "Bob" || c.Name != "Bobby" {
+		t.Fatalf("unexpected second contact: %+v", c)
+	}
+
+	if resp = get("?query=smith"); len(resp.Contacts) != 1 || resp.Contacts[0].JID != "111@s.whatsapp.net" {
+		t.Fatalf("query=smith: unexpected contacts %+v", resp.Contacts)
+	}
+	if resp = get("?query=bob&limit=1"); len(resp.Contacts) != 1 || resp.Contacts[0].JID != "222@s.whatsapp.net" {
+		t.Fatalf("query=bob: unexpected contacts %+v", resp.Contacts)
+	}
+	if resp = get("?limit=1"); len(resp.Contacts) != 1 {
+		t.Fatalf("limit=1: expected 1 contact, got %+v", resp.Contacts)
+	}
+}
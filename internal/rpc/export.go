@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/steipete/wacli/internal/export"
+)
+
+// flushWriter flushes the response after every write so exports stream
+// instead of buffering in the server.
+type flushWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if fw.f != nil {
+		fw.f.Flush()
+	}
+	return n, err
+}
+
+func (s *Server) handleExportMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename(format)))
+	w.WriteHeader(http.StatusOK)
+
+	f, _ := w.(http.Flusher)
+	// Headers are already sent, so a failure can only cut the body short.
+	if err := export.Messages(r.Context(), s.db, flushWriter{w: w, f: f}, format, r.URL.Query().Get("chat_jid")); err != nil {
+		s.log.Warn().Err(err).Msg("export interrupted")
+	}
+}
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestServer_ExportMessages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, chat := range []string{"a@s.whatsapp.net", "b@s.whatsapp.net"} {
+		_, _, _ = db.UpsertChat(ctx, chat, "dm", "", "", ts)
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID: chat, MsgID: "m-" + chat[:1], SenderJID: chat, Timestamp: ts, Text: "hi from " + chat[:1],
+		})
+	}
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/export/messages?chat_jid=a@s.whatsapp.net&format=csv", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="messages.csv"` {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+	want := "chat_jid,msg_id,sender_jid,timestamp,from_me,text\n" +
+		"a@s.whatsapp.net,m-a,a@s.whatsapp.net,2024-05-01T12:00:00Z,false,hi from a\n"
+	if w.Body.String() != want {
+		t.Fatalf("body = %q, want %q", w.Body.String(), want)
+	}
+	if !w.Flushed {
+		t.Fatal("expected the export to be flushed")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/export/messages?format=ndjson", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("ndjson: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if n := strings.Count(w.Body.String(), "\n"); n != 2 {
+		t.Fatalf("expected 2 ndjson lines, got %q", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/export/messages?format=xml", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown format, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/export/messages", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// defaultGroupMembersTTL is how long stored group members are served before
+// /group-members asks WhatsApp again.
+const defaultGroupMembersTTL = time.Hour
+
+var errNotConnected = errors.New("WhatsApp not connected")
+
+type memberJSON struct {
+	JID     string `json:"jid"`
+	Name    string `json:"name"`
+	IsAdmin bool   `json:"is_admin"`
+}
+
+type membersResponse struct {
+	OK      bool         `json:"ok"`
+	Members []memberJSON `json:"members"`
+}
+
+// membersFresh reports whether ps were all refreshed within ttl.
+func membersFresh(ps []store.GroupParticipant, ttl time.Duration, now time.Time) bool {
+	if len(ps) == 0 || ttl < 0 {
+		return false
+	}
+	for _, p := range ps {
+		if now.Sub(p.UpdatedAt) > ttl {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) handleGroupMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	jidStr := strings.TrimSpace(q.Get("jid"))
+	if jidStr == "" {
+		writeError(w, http.StatusBadRequest, "jid is required")
+		return
+	}
+	group, err := types.ParseJID(jidStr)
+	if err != nil || !wa.IsGroupJID(group) {
+		writeError(w, http.StatusBadRequest, "jid must be a group JID (…@g.us)")
+		return
+	}
+
+	ctx := r.Context()
+	members, err := s.db.ListGroupParticipants(ctx, group.String())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if q.Get("refresh") == "true" || !membersFresh(members, s.groupMembersTTL, time.Now()) {
+		refreshed, err := s.refreshGroupMembers(ctx, group)
+		switch {
+		case err == nil:
+			members = refreshed
+		case len(members) > 0:
+			// Stale members beat none.
+			s.log.Warn().Err(err).Str("group", group.String()).Msg("failed to refresh group members, serving cached")
+		default:
+			writeError(w, http.StatusServiceUnavailable, "fetch group members: "+err.Error())
+			return
+		}
+	}
+
+	out := make([]memberJSON, 0, len(members))
+	for _, m := range members {
+		out = append(out, memberJSON{JID: m.UserJID, Name: m.Name, IsAdmin: m.IsAdmin()})
+	}
+	writeOK(w, membersResponse{OK: true, Members: out})
+}
+
+// refreshGroupMembers fetches group's members from WhatsApp and stores them.
+func (s *Server) refreshGroupMembers(ctx context.Context, group types.JID) ([]store.GroupParticipant, error) {
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+	if waClient == nil || !waClient.IsConnected() {
+		return nil, errNotConnected
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	info, err := waClient.GetGroupInfo(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.UpsertGroup(ctx, group.String(), info.GroupName.Name, info.OwnerJID.String(), info.GroupCreated); err != nil {
+		return nil, err
+	}
+	ps := make([]store.GroupParticipant, 0, len(info.Participants))
+	for _, p := range info.Participants {
+		ps = append(ps, store.GroupParticipant{
+			GroupJID: group.String(),
+			UserJID:  p.JID.String(),
+			Role:     wa.ParticipantRole(p),
+		})
+	}
+	if err := s.db.ReplaceGroupParticipants(ctx, group.String(), ps); err != nil {
+		return nil, err
+	}
+	return s.db.ListGroupParticipants(ctx, group.String())
+}
@@ -0,0 +1,153 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestServer_GroupMembers(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	group := types.JID{User: "123", Server: types.GroupServer}
+	alice := types.JID{User: "111", Server: types.DefaultUserServer}
+	bob := types.JID{User: "222", Server: types.DefaultUserServer}
+	if err := db.UpsertContact(ctx, alice.String(), "111", "", "Alice", "Alice", ""); err != nil {
+		t.Fatalf("UpsertContact: %v", err)
+	}
+
+	gi := &types.GroupInfo{
+		JID:       group,
+		GroupName: types.GroupName{Name: "Team"},
+		Participants: []types.GroupParticipant{
+			{JID: bob},
+			{JID: alice, IsAdmin: true},
+		},
+	}
+	mock := &mockWA{connected: true, groups: map[types.JID]*types.GroupInfo{group: gi}}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	get := func(query string) (int, membersResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/group-members?"+query, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var resp membersResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp
+	}
+
+	code, resp := get("jid=" + group.String())
+	if code != http.StatusOK || !resp.OK {
+		t.Fatalf("expected 200, got %d: %+v", code, resp)
+	}
+	want := []memberJSON{
+		{JID: alice.String(), Name: "Alice", IsAdmin: true},
+		{JID: bob.String()},
+	}
+	if len(resp.Members) != len(want) || resp.Members[0] != want[0] || resp.Members[1] != want[1] {
+		t.Fatalf("unexpected members: %+v", resp.Members)
+	}
+	if mock.groupCalls != 1 {
+		t.Fatalf("expected 1 GetGroupInfo call, got %d", mock.groupCalls)
+	}
+
+	// Fresh members come from the store.
+	if code, resp = get("jid=" + group.String()); code != http.StatusOK || len(resp.Members) != 2 {
+		t.Fatalf("expected cached members, got %d: %+v", code, resp)
+	}
+	if mock.groupCalls != 1 {
+		t.Fatalf("expected cached response, got %d GetGroupInfo calls", mock.groupCalls)
+	}
+
+	// refresh=true bypasses the cache.
+	gi.Participants = gi.Participants[1:]
+	if code, resp = get("jid=" + group.String() + "&refresh=true"); code != http.StatusOK || len(resp.Members) != 1 {
+		t.Fatalf("expected refreshed members, got %d: %+v", code, resp)
+	}
+	if mock.groupCalls != 2 {
+		t.Fatalf("expected 2 GetGroupInfo calls, got %d", mock.groupCalls)
+	}
+
+	// Disconnected: stale members are still served.
+	mock.connected = false
+	if code, resp = get("jid=" + group.String() + "&refresh=true"); code != http.StatusOK || len(resp.Members) != 1 {
+		t.Fatalf("expected stale members while disconnected, got %d: %+v", code, resp)
+	}
+}
+
+func TestServer_GroupMembers_Errors(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		want   int
+	}{
+		{"missing jid", http.MethodGet, "", http.StatusBadRequest},
+		{"not a group", http.MethodGet, "jid=111@s.whatsapp.net", http.StatusBadRequest},
+		{"uncached while disconnected", http.MethodGet, "jid=123@g.us", http.StatusServiceUnavailable},
+		{"POST", http.MethodPost, "jid=123@g.us", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/group-members?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_GroupMembersTTL(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	group := types.JID{User: "123", Server: types.GroupServer}
+	mock := &mockWA{connected: true, groups: map[types.JID]*types.GroupInfo{group: {
+		JID:          group,
+		Participants: []types.GroupParticipant{{JID: types.JID{User: "111", Server: types.DefaultUserServer}}},
+	}}}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock, GroupMembersTTL: -1})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/group-members?jid="+group.String(), nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if mock.groupCalls != 2 {
+		t.Fatalf("negative TTL: expected a fetch per call, got %d", mock.groupCalls)
+	}
+
+	if membersFresh(nil, time.Hour, time.Now()) {
+		t.Fatal("no members must not count as fresh")
+	}
+}
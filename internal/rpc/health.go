@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// healthHandler serves the minimal health-check routes exposed on the
+// separate health address. It deliberately does not include any data or
+// send endpoints.
+func (s *Server) healthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/ready", s.handleReady)
+	return mux
+}
+
+func (s *Server) startHealth() error {
+	ln, err := net.Listen("tcp", s.healthAddr)
+	if err != nil {
+		return fmt.Errorf("listen health %s: %w", s.healthAddr, err)
+	}
+	s.healthBound = ln.Addr().String()
+	s.healthServer = &http.Server{
+		Handler:           s.healthHandler(),
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      5 * time.Second,
+		IdleTimeout:       30 * time.Second,
+	}
+
+	s.log.Info().Str("addr", s.healthBound).Msg("health server starting")
+	go func() {
+		if err := s.healthServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.log.Error().Err(err).Msg("health server error")
+		}
+	}()
+	return nil
+}
+
+// HealthAddr returns the bound health-check address (empty if disabled or
+// not started).
+func (s *Server) HealthAddr() string {
+	return s.healthBound
+}
+
+// waHealthTimeout bounds the WhatsApp round-trip made by /health.
+const waHealthTimeout = 2 * time.Second
+
+type healthResponse struct {
+	OK        bool   `json:"ok"`
+	WAHealthy bool   `json:"wa_healthy"`
+	WAError   string `json:"wa_error,omitempty"`
+}
+
+// handleHealth is a liveness check: it fails only when the database is
+// unreachable. WhatsApp health is reported but does not fail the check;
+// use /ready for that.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := s.db.HealthCheck(r.Context()); err != nil {
+		s.log.Warn().Err(err).Msg("health check failed")
+		writeError(w, http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+
+	s.mu.RLock()
+	wa := s.wa
+	s.mu.RUnlock()
+
+	resp := healthResponse{OK: true}
+	if wa == nil {
+		resp.WAError = "WhatsApp not connected"
+	} else {
+		ctx, cancel := context.WithTimeout(r.Context(), waHealthTimeout)
+		err := wa.HealthCheck(ctx)
+		cancel()
+		if err != nil {
+			resp.WAError = err.Error()
+		} else {
+			resp.WAHealthy = true
+		}
+	}
+	writeOK(w, resp)
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	wa := s.wa
+	s.mu.RUnlock()
+
+	if wa == nil || !wa.IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, "WhatsApp not connected")
+		return
+	}
+	writeOK(w, jsonResponse{OK: true})
+}
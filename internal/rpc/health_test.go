@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_HealthServer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sock := filepath.Join(t.TempDir(), "rpc.sock")
+	srv, err := New(Options{
+		Addr:       unixSocketPrefix + sock,
+		DB:         db,
+		WA:         &mockWA{connected: false},
+		HealthAddr: "127.0.0.1:0",
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Stop(ctx)
+	}()
+
+	base := "http://" + srv.HealthAddr()
+	get := func(path string) int {
+		t.Helper()
+		resp, err := http.Get(base + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/health"); code != http.StatusOK {
+		t.Errorf("expected 200 for /health, got %d", code)
+	}
+	if code := get("/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for /ready while disconnected, got %d", code)
+	}
+	srv.SetWA(&mockWA{connected: true})
+	if code := get("/ready"); code != http.StatusOK {
+		t.Errorf("expected 200 for /ready while connected, got %d", code)
+	}
+
+	// The main RPC routes must not be reachable on the health port.
+	for _, path := range []string{"/status", "/chats", "/send", "/ping"} {
+		if code := get(path); code != http.StatusNotFound {
+			t.Errorf("expected 404 for %s on health port, got %d", path, code)
+		}
+	}
+}
+
+func TestServer_Health_DBDown(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleHealth(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 while DB is up, got %d", w.Code)
+	}
+
+	_ = db.Close()
+	w = httptest.NewRecorder()
+	srv.handleHealth(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 once the DB is closed, got %d", w.Code)
+	}
+}
+
+func TestServer_Health_WAHealthy(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	check := func() healthResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleHealth(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var resp healthResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := check(); !resp.OK || !resp.WAHealthy || resp.WAError != "" {
+		t.Errorf("expected healthy WA, got %+v", resp)
+	}
+
+	mock.HealthErr = errors.New("keepalive not acknowledged")
+	if resp := check(); !resp.OK || resp.WAHealthy || resp.WAError != "keepalive not acknowledged" {
+		t.Errorf("expected WA health error to be reported, got %+v", resp)
+	}
+}
@@ -0,0 +1,135 @@
+//go:build integration
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestIntegration_RPCServer(t *testing.T) {
+	ctx := context.Background()
+	db, err := store.Open(store.DefaultStoreOptions(filepath.Join(t.TempDir(), "wacli.db")))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	chatJID := "14155552671@s.whatsapp.net"
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if _, _, err := db.UpsertChat(ctx, chatJID, "dm", "Alice", "", ts); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if _, _, err := db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID:    chatJID,
+		ChatName:   "Alice",
+		MsgID:      "msg1",
+		SenderJID:  chatJID,
+		SenderName: "Alice",
+		Timestamp:  ts,
+		Text:       "hello integration",
+	}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "127.0.0.1:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Stop(ctx)
+	}()
+
+	base := "http://" + srv.Addr()
+	client := &http.Client{Timeout: 5 * time.Second}
+	do := func(method, path string, body interface{}, out interface{}) {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatalf("encode %s: %v", path, err)
+			}
+		}
+		req, err := http.NewRequest(method, base+path, &buf)
+		if err != nil {
+			t.Fatalf("request %s: %v", path, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: expected 200, got %d", method, path, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("%s %s: expected JSON content type, got %q", method, path, ct)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+
+	var ping map[string]interface{}
+	do(http.MethodGet, "/ping", nil, &ping)
+	if ping["ok"] != true || ping["pong"] != true {
+		t.Errorf("/ping: unexpected response %v", ping)
+	}
+
+	var status statusResponse
+	do(http.MethodGet, "/status", nil, &status)
+	if !status.OK || !status.WAConnected || status.ChatsCount != 1 || status.MessagesCount != 1 {
+		t.Errorf("/status: unexpected response %+v", status)
+	}
+
+	var chats chatsResponse
+	do(http.MethodGet, "/chats", nil, &chats)
+	if !chats.OK || len(chats.Chats) != 1 {
+		t.Fatalf("/chats: unexpected response %+v", chats)
+	}
+	if c := chats.Chats[0]; c.JID != chatJID || c.Name != "Alice" || c.Kind != "dm" || c.LastMessageTS != ts.Format(time.RFC3339) {
+		t.Errorf("/chats: unexpected chat %+v", c)
+	}
+
+	var msgs messagesResponse
+	do(http.MethodGet, "/messages?chat_jid="+chatJID, nil, &msgs)
+	if !msgs.OK || len(msgs.Messages) != 1 {
+		t.Fatalf("/messages: unexpected response %+v", msgs)
+	}
+	if m := msgs.Messages[0]; m.MsgID != "msg1" || m.Text != "hello integration" || m.ChatName != "Alice" || m.Timestamp != ts.Format(time.RFC3339) {
+		t.Errorf("/messages: unexpected message %+v", m)
+	}
+
+	var search searchResponse
+	do(http.MethodPost, "/search", searchRequest{Query: "integration"}, &search)
+	if !search.OK || len(search.Results) != 1 || search.TotalCount != 1 || search.Results[0].MsgID != "msg1" {
+		t.Errorf("/search: unexpected response %+v", search)
+	}
+
+	var sent sendResponse
+	do(http.MethodPost, "/send", sendRequest{To: "14155552671", Message: "hi from integration"}, &sent)
+	if !sent.OK || sent.MessageID != "test_msg_id" {
+		t.Errorf("/send: unexpected response %+v", sent)
+	}
+	if len(mock.sentMsgs) != 1 || mock.sentMsgs[0] != "hi from integration" {
+		t.Errorf("/send: expected message to reach WA, got %v", mock.sentMsgs)
+	}
+	do(http.MethodGet, "/messages?chat_jid="+chatJID, nil, &msgs)
+	if len(msgs.Messages) != 2 {
+		t.Errorf("/send: expected sent message to be stored, got %+v", msgs.Messages)
+	}
+}
@@ -0,0 +1,223 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxMediaSize caps attachments sent through /send.
+	maxMediaSize      = 10 << 20
+	mediaFetchTimeout = 30 * time.Second
+)
+
+var errMediaTooLarge = fmt.Errorf("media exceeds %d MB limit", maxMediaSize>>20)
+
+var errPrivateMediaAddr = errors.New("media_url must resolve to a public address")
+
+// mediaClient fetches media_url. Since the body is sent on to a chat the
+// caller picks, it only connects to public addresses, checked after DNS
+// resolution on every dial (so redirects are covered too); otherwise any
+// RPC client could read internal services. Tests swap it out.
+var mediaClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: nil, // a proxy would dial on our behalf, unchecked
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				addr, err := netip.ParseAddr(host)
+				if err != nil || !isPublicAddr(addr) {
+					return errPrivateMediaAddr
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: mediaFetchTimeout,
+	},
+}
+
+// nonPublicPrefixes are special-purpose ranges netip has no predicate for.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 can reach private IPv4
+}
+
+// isPublicAddr reports whether addr is a globally routable unicast address.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// mediaTypes lists the media_type values /send accepts. Only images are
+// sent as such; everything else goes out as a document.
+var mediaTypes = map[string]bool{"image": true, "document": true, "audio": true, "video": true}
+
+// sendMedia is an attachment resolved from a sendRequest.
+type sendMedia struct {
+	kind     string // image or document
+	data     []byte
+	filename string
+	mimeType string
+}
+
+// mediaError carries the HTTP status to report for a bad attachment.
+type mediaError struct {
+	status int
+	err    error
+}
+
+func (e *mediaError) Error() string { return e.err.Error() }
+
+func badMedia(format string, args ...interface{}) error {
+	return &mediaError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+// loadMedia resolves the attachment in req, if any. It returns nil when the
+// request carries no media.
+func loadMedia(ctx context.Context, req sendRequest) (*sendMedia, error) {
+	mediaURL := strings.TrimSpace(req.MediaURL)
+	if mediaURL == "" && req.MediaBase64 == "" {
+		return nil, nil
+	}
+	if mediaURL != "" && req.MediaBase64 != "" {
+		return nil, badMedia("media_url and media_base64 are mutually exclusive")
+	}
+	kind := strings.ToLower(strings.TrimSpace(req.MediaType))
+	if kind != "" && !mediaTypes[kind] {
+		return nil, badMedia("media_type must be one of image, document, audio, video")
+	}
+
+	m := &sendMedia{filename: strings.TrimSpace(req.Filename)}
+	var err error
+	if mediaURL != "" {
+		m.data, m.mimeType, err = fetchMedia(ctx, mediaURL)
+		if m.filename == "" {
+			if u, perr := url.Parse(mediaURL); perr == nil {
+				if base := path.Base(u.Path); base != "/" && base != "." {
+					m.filename = base
+				}
+			}
+		}
+	} else {
+		m.data, err = decodeMedia(req.MediaBase64)
+	}
+	if err != nil {
+		if errors.Is(err, errMediaTooLarge) {
+			return nil, &mediaError{status: http.StatusRequestEntityTooLarge, err: err}
+		}
+		return nil, err
+	}
+	if len(m.data) == 0 {
+		return nil, badMedia("media is empty")
+	}
+
+	if m.mimeType == "" || m.mimeType == "application/octet-stream" {
+		m.mimeType = mime.TypeByExtension(strings.ToLower(filepath.Ext(m.filename)))
+	}
+	if m.mimeType == "" {
+		m.mimeType = http.DetectContentType(m.data)
+	}
+	if kind == "" && strings.HasPrefix(m.mimeType, "image/") {
+		kind = "image"
+	}
+	m.kind = "document"
+	if kind == "image" {
+		m.kind = "image"
+	}
+	if m.filename == "" {
+		m.filename = "file"
+		if exts, _ := mime.ExtensionsByType(m.mimeType); len(exts) > 0 {
+			m.filename += exts[0]
+		}
+	}
+	return m, nil
+}
+
+func decodeMedia(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	// Accept data URLs as produced by browsers (data:image/png;base64,...).
+	if strings.HasPrefix(s, "data:") {
+		if i := strings.Index(s, ","); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	if base64.StdEncoding.DecodedLen(len(s)) > maxMediaSize+3 {
+		return nil, errMediaTooLarge
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, badMedia("invalid media_base64: %v", err)
+	}
+	if len(data) > maxMediaSize {
+		return nil, errMediaTooLarge
+	}
+	return data, nil
+}
+
+// fetchMedia downloads rawURL, giving up after mediaFetchTimeout or once
+// the body exceeds maxMediaSize.
+func fetchMedia(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", badMedia("media_url must be an http(s) URL")
+	}
+	ctx, cancel := context.WithTimeout(ctx, mediaFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", badMedia("media_url: %v", err)
+	}
+	resp, err := mediaClient.Do(req)
+	if errors.Is(err, errPrivateMediaAddr) {
+		return nil, "", badMedia("%v", errPrivateMediaAddr)
+	}
+	if err != nil {
+		return nil, "", &mediaError{status: http.StatusBadGateway, err: fmt.Errorf("fetch media_url: %w", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", &mediaError{status: http.StatusBadGateway, err: fmt.Errorf("fetch media_url: %s", resp.Status)}
+	}
+	if resp.ContentLength > maxMediaSize {
+		return nil, "", errMediaTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, "", &mediaError{status: http.StatusBadGateway, err: fmt.Errorf("fetch media_url: %w", err)}
+	}
+	if len(data) > maxMediaSize {
+		return nil, "", errMediaTooLarge
+	}
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return data, mimeType, nil
+}
@@ -0,0 +1,236 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// pngHeader is enough for http.DetectContentType to report image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func postSend(t *testing.T, srv *Server, body interface{}) (int, sendResponse) {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewReader(raw))
+	w := httptest.NewRecorder()
+	srv.handleSend(w, req)
+	var resp sendResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return w.Code, resp
+}
+
+func newSendServer(t *testing.T) (*Server, *mockWA, *store.DB) {
+	t.Helper()
+	db, cleanup := setupTestDB(t)
+	t.Cleanup(cleanup)
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	return srv, mock, db
+}
+
+func TestSend_ImageBase64(t *testing.T) {
+	srv, mock, db := newSendServer(t)
+
+	code, resp := postSend(t, srv, sendRequest{
+		To:          "14155552671",
+		Message:     "look",
+		MediaBase64: base64.StdEncoding.EncodeToString(pngHeader),
+	})
+	if code != http.StatusOK || !resp.OK || resp.MessageID != "test_media_id" {
+		t.Fatalf("unexpected response %d %+v", code, resp)
+	}
+	if len(mock.sentMedia) != 1 {
+		t.Fatalf("expected 1 media send, got %d", len(mock.sentMedia))
+	}
+	if got := mock.sentMedia[0]; got.kind != "image" || got.caption != "look" || !bytes.Equal(got.data, pngHeader) {
+		t.Errorf("unexpected media %+v", got)
+	}
+	if len(mock.sentMsgs) != 0 {
+		t.Errorf("expected no text send, got %v", mock.sentMsgs)
+	}
+
+	m, err := db.GetMessage(context.Background(), "14155552671@s.whatsapp.net", "test_media_id")
+	if err != nil {
+		t.Fatalf("GetMessage: %v", err)
+	}
+	if m.MediaType != "image" || m.Text != "look" {
+		t.Errorf("unexpected stored message %+v", m)
+	}
+}
+
+func TestSend_DocumentFromURL(t *testing.T) {
+	srv, mock, _ := newSendServer(t)
+	allowLoopbackMedia(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.4 test"))
+	}))
+	defer ts.Close()
+
+	code, resp := postSend(t, srv, sendRequest{To: "14155552671", MediaURL: ts.URL + "/files/report.pdf"})
+	if code != http.StatusOK || !resp.OK {
+		t.Fatalf("unexpected response %d %+v", code, resp)
+	}
+	if len(mock.sentMedia) != 1 {
+		t.Fatalf("expected 1 media send, got %d", len(mock.sentMedia))
+	}
+	got := mock.sentMedia[0]
+	if got.kind != "document" || got.filename != "report.pdf" || got.mimeType != "application/pdf" || string(got.data) != "%PDF-1.4 test" {
+		t.Errorf("unexpected media %+v", got)
+	}
+}
+
+func TestSend_MediaTypeForcesDocument(t *testing.T) {
+	srv, mock, _ := newSendServer(t)
+
+	code, resp := postSend(t, srv, sendRequest{
+		To:          "14155552671",
+		MediaBase64: base64.StdEncoding.EncodeToString(pngHeader),
+		MediaType:   "document",
+		Filename:    "scan.png",
+	})
+	if code != http.StatusOK || !resp.OK {
+		t.Fatalf("unexpected response %d %+v", code, resp)
+	}
+	if got := mock.sentMedia[0]; got.kind != "document" || got.filename != "scan.png" || got.mimeType != "image/png" {
+		t.Errorf("unexpected media %+v", got)
+	}
+}
+
+func TestSend_MediaTooLarge(t *testing.T) {
+	srv, mock, _ := newSendServer(t)
+	allowLoopbackMedia(t)
+
+	big := make([]byte, maxMediaSize+1)
+	code, resp := postSend(t, srv, sendRequest{To: "14155552671", MediaBase64: base64.StdEncoding.EncodeToString(big)})
+	if code != http.StatusRequestEntityTooLarge || resp.OK {
+		t.Errorf("base64: expected 413, got %d %+v", code, resp)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chunked, so the size is only discovered while reading.
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(big)
+	}))
+	defer ts.Close()
+	code, resp = postSend(t, srv, sendRequest{To: "14155552671", MediaURL: ts.URL})
+	if code != http.StatusRequestEntityTooLarge || resp.OK {
+		t.Errorf("url: expected 413, got %d %+v", code, resp)
+	}
+	if len(mock.sentMedia) != 0 {
+		t.Errorf("expected nothing sent, got %d", len(mock.sentMedia))
+	}
+}
+
+func TestSend_MediaErrors(t *testing.T) {
+	srv, mock, _ := newSendServer(t)
+	allowLoopbackMedia(t)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer failing.Close()
+
+	for _, tc := range []struct {
+		name string
+		req  sendRequest
+		code int
+	}{
+		{"both sources", sendRequest{MediaURL: "http://example.com/a.png", MediaBase64: "AAAA"}, http.StatusBadRequest},
+		{"bad base64", sendRequest{MediaBase64: "not base64!"}, http.StatusBadRequest},
+		{"bad type", sendRequest{MediaBase64: "AAAA", MediaType: "sticker"}, http.StatusBadRequest},
+		{"bad scheme", sendRequest{MediaURL: "file:///etc/passwd"}, http.StatusBadRequest},
+		{"upstream error", sendRequest{MediaURL: failing.URL}, http.StatusBadGateway},
+	} {
+		tc.req.To = "14155552671"
+		if code, resp := postSend(t, srv, tc.req); code != tc.code || resp.OK {
+			t.Errorf("%s: expected %d, got %d %+v", tc.name, tc.code, code, resp)
+		}
+	}
+	if len(mock.sentMedia) != 0 {
+		t.Errorf("expected nothing sent, got %d", len(mock.sentMedia))
+	}
+}
+
+// allowLoopbackMedia lets media_url reach httptest servers for one test.
+func allowLoopbackMedia(t *testing.T) {
+	t.Helper()
+	orig := mediaClient
+	mediaClient = http.DefaultClient
+	t.Cleanup(func() { mediaClient = orig })
+}
+
+func TestSend_MediaURLPrivateAddress(t *testing.T) {
+	srv, mock, _ := newSendServer(t)
+
+	hit := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusFound))
+	defer redirect.Close()
+
+	for _, u := range []string{
+		internal.URL + "/admin",
+		"http://localhost:" + strings.TrimPrefix(internal.URL, "http://127.0.0.1:"),
+		redirect.URL,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]:1/",
+		"http://10.0.0.1:1/",
+	} {
+		code, resp := postSend(t, srv, sendRequest{To: "14155552671", MediaURL: u})
+		if code != http.StatusBadRequest || resp.OK || !strings.Contains(resp.Error, "public address") {
+			t.Errorf("%s: expected 400, got %d %+v", u, code, resp)
+		}
+	}
+	if hit {
+		t.Errorf("internal server was contacted")
+	}
+	if len(mock.sentMedia) != 0 {
+		t.Errorf("expected nothing sent, got %d", len(mock.sentMedia))
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":              true,
+		"2606:4700:4700::1111": true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"64:ff9b::a00:1":       false,
+		"224.0.0.1":            false,
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/steipete/wacli/internal/store"
+)
+
+// metricsDBTimeout bounds the message count made on each scrape.
+const metricsDBTimeout = 2 * time.Second
+
+// metrics holds the Prometheus collectors of one Server. Each server has
+// its own registry, so several can run in one process (as in tests).
+type metrics struct {
+	registry        *prometheus.Registry
+	messages        *prometheus.CounterVec
+	httpRequests    *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+func newMetrics(s *Server) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wacli_messages_total",
+			Help: "Messages sent (out) and received (in) while the server ran.",
+		}, []string{"direction"}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wacli_http_requests_total",
+			Help: "RPC requests by endpoint and status code.",
+		}, []string{"path", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wacli_http_request_duration_seconds",
+			Help:    "RPC request latency by endpoint.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"path"}),
+	}
+	// Start both directions at zero so they show up before any traffic.
+	m.messages.WithLabelValues("in")
+	m.messages.WithLabelValues("out")
+
+	m.registry.MustRegister(
+		m.messages,
+		m.httpRequests,
+		m.requestDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wacli_wa_connected",
+			Help: "1 while the WhatsApp client is connected.",
+		}, func() float64 {
+			s.mu.RLock()
+			wa := s.wa
+			s.mu.RUnlock()
+			return boolGauge(wa != nil && wa.IsConnected())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wacli_sync_running",
+			Help: "1 while sync is running alongside the server.",
+		}, func() float64 {
+			return boolGauge(s.syncRunning.Load())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "wacli_db_messages_total",
+			Help: "Messages in the local store.",
+		}, func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), metricsDBTimeout)
+			defer cancel()
+			n, err := s.db.CountMessages(ctx)
+			if err != nil {
+				s.log.Warn().Err(err).Msg("failed to count messages for metrics")
+				return 0
+			}
+			return float64(n)
+		}),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// messageSent counts a message sent through the RPC server.
+func (m *metrics) messageSent() {
+	m.messages.WithLabelValues("out").Inc()
+}
+
+// messageSeen counts a live message from the sync feed. Our own messages
+// arrive here only when sent from another device.
+func (m *metrics) messageSeen(msg store.Message) {
+	if msg.FromMe {
+		m.messageSent()
+		return
+	}
+	m.messages.WithLabelValues("in").Inc()
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// withMetrics counts and times every request. Requests are labelled with
+// the mux pattern that serves them (e.g. /scheduled/ for /scheduled/42),
+// and unknown paths with "other", so the label set stays bounded.
+func (s *Server) withMetrics(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "other"
+		if _, pattern := mux.Handler(r); pattern != "" {
+			path = pattern
+		}
+		start := time.Now()
+		cw := &captureWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		status := cw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		s.metrics.httpRequests.WithLabelValues(path, strconv.Itoa(status)).Inc()
+		s.metrics.requestDuration.WithLabelValues(path).Observe(time.Since(start).Seconds())
+	})
+}
+
+func (s *Server) startMetrics() error {
+	ln, err := net.Listen("tcp", s.metricsAddr)
+	if err != nil {
+		return fmt.Errorf("listen metrics %s: %w", s.metricsAddr, err)
+	}
+	s.metricsBound = ln.Addr().String()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics.handler())
+	s.metricsServer = &http.Server{
+		Handler:           mux,
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       30 * time.Second,
+	}
+
+	s.log.Info().Str("addr", s.metricsBound).Msg("metrics server starting")
+	go func() {
+		if err := s.metricsServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.log.Error().Err(err).Msg("metrics server error")
+		}
+	}()
+	return nil
+}
+
+// MetricsAddr returns the bound metrics address (empty if metrics are
+// served on the main address or the server is not started).
+func (s *Server) MetricsAddr() string {
+	return s.metricsBound
+}
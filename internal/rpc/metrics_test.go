@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from /metrics, got %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestServer_Metrics(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chat := "14155552671@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chat, MsgID: "m1", SenderJID: chat, Timestamp: time.Now(), Text: "hi"})
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	srv.SetSyncRunning(true)
+	h := srv.Handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(`{"to": "14155552671", "message": "hello"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("send: %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/ping", "/scheduled/7", "/nope"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	srv.metrics.messageSeen(store.Message{ChatJID: chat, Text: "incoming"})
+
+	body := scrape(t, h)
+	for _, want := range []string{
+		`wacli_messages_total{direction="in"} 1`,
+		`wacli_messages_total{direction="out"} 1`,
+		`wacli_http_requests_total{path="/send",status="200"} 1`,
+		`wacli_http_requests_total{path="/ping",status="200"} 1`,
+		`wacli_http_requests_total{path="/scheduled/",status="405"} 1`,
+		`wacli_http_requests_total{path="other",status="404"} 1`,
+		`wacli_http_request_duration_seconds_bucket{path="/send",le="+Inf"} 1`,
+		"wacli_wa_connected 1",
+		"wacli_sync_running 1",
+		"wacli_db_messages_total 2", // m1 plus the stored send
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+
+	mock.connected = false
+	srv.SetSyncRunning(false)
+	body = scrape(t, h)
+	for _, want := range []string{"wacli_wa_connected 0", "wacli_sync_running 0"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
+
+func TestServer_MetricsAddr(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	sock := filepath.Join(t.TempDir(), "rpc.sock")
+	srv, err := New(Options{
+		Addr:        unixSocketPrefix + sock,
+		DB:          db,
+		MetricsAddr: "127.0.0.1:0",
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Stop(ctx)
+	}()
+
+	resp, err := http.Get("http://" + srv.MetricsAddr() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "wacli_wa_connected 0") {
+		t.Fatalf("unexpected metrics response %d:\n%s", resp.StatusCode, body)
+	}
+
+	// With a separate address, the main handler no longer serves metrics.
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for /metrics on the main address, got %d", w.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDRs parses a list of CIDR ranges (e.g. "10.0.0.0/8"). Bare IPs are
+// accepted and treated as single-host ranges.
+func ParseCIDRs(values []string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			out = append(out, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %q: %w", v, err)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+// isTrustedProxy reports whether addr falls within one of the trusted ranges.
+func (s *Server) isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range s.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddr returns the IP of the direct peer, ignoring any proxy headers.
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// clientIP returns the originating client IP for r. X-Forwarded-For is only
+// honoured when the direct peer is a trusted proxy; the chain is walked from
+// the right and the first untrusted hop wins.
+func (s *Server) clientIP(r *http.Request) string {
+	peer, ok := remoteAddr(r)
+	if !ok {
+		// Unix sockets and other non-IP transports.
+		return r.RemoteAddr
+	}
+	if !s.isTrustedProxy(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		for _, part := range strings.Split(h, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				hops = append(hops, part)
+			}
+		}
+	}
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !s.isTrustedProxy(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// clientScheme returns "http" or "https" as seen by the client. The
+// X-Forwarded-Proto header is only honoured from trusted proxies.
+func (s *Server) clientScheme(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	peer, ok := remoteAddr(r)
+	if !ok || !s.isTrustedProxy(peer) {
+		return scheme
+	}
+	proto := r.Header.Get("X-Forwarded-Proto")
+	if i := strings.IndexByte(proto, ','); i >= 0 {
+		proto = proto[:i]
+	}
+	switch strings.ToLower(strings.TrimSpace(proto)) {
+	case "http":
+		return "http"
+	case "https":
+		return "https"
+	}
+	return scheme
+}
+
+// withRequestLog logs each request with the resolved client address.
+func (s *Server) withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.log.Debug().
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Str("remote", s.clientIP(r)).
+			Str("scheme", s.clientScheme(r)).
+			Msg("rpc request")
+		next.ServeHTTP(w, r)
+	})
+}
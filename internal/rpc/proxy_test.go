@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	got, err := ParseCIDRs([]string{"10.0.0.0/8", " 172.16.0.0/12 ", "", "192.168.1.7"})
+	if err != nil {
+		t.Fatalf("ParseCIDRs: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 prefixes, got %v", got)
+	}
+	if got[2].String() != "192.168.1.7/32" {
+		t.Errorf("expected bare IP as /32, got %s", got[2])
+	}
+
+	if _, err := ParseCIDRs([]string{"not-a-cidr"}); err == nil {
+		t.Errorf("expected error for invalid CIDR")
+	}
+}
+
+func TestServer_ClientIP_TrustedProxy(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	trusted, err := ParseCIDRs([]string{"127.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParseCIDRs: %v", err)
+	}
+
+	tests := []struct {
+		desc    string
+		trusted bool
+		xff     string
+		want    string
+	}{
+		{desc: "untrusted peer ignores header", trusted: false, xff: "1.2.3.4", want: "127.0.0.1"},
+		{desc: "trusted peer uses header", trusted: true, xff: "1.2.3.4", want: "1.2.3.4"},
+		{desc: "trusted peer without header", trusted: true, xff: "", want: "127.0.0.1"},
+		{desc: "rightmost untrusted hop wins", trusted: true, xff: "9.9.9.9, 1.2.3.4, 127.0.0.2", want: "1.2.3.4"},
+		{desc: "garbage header falls back to peer", trusted: true, xff: "nope", want: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			opts := Options{Addr: "localhost:0", DB: db}
+			if tt.trusted {
+				opts.TrustedProxies = trusted
+			}
+			srv, err := New(opts)
+			if err != nil {
+				t.Fatalf("new server: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = "127.0.0.1:12345"
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := srv.clientIP(req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_ClientScheme_TrustedProxy(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	trusted, _ := ParseCIDRs([]string{"127.0.0.1"})
+	srv, err := New(Options{Addr: "localhost:0", DB: db, TrustedProxies: trusted})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	if got := srv.clientScheme(req); got != "https" {
+		t.Errorf("expected https from trusted proxy, got %q", got)
+	}
+
+	req.RemoteAddr = "10.1.2.3:12345"
+	if got := srv.clientScheme(req); got != "http" {
+		t.Errorf("expected http from untrusted peer, got %q", got)
+	}
+
+	req.TLS = &tls.ConnectionState{}
+	if got := srv.clientScheme(req); got != "https" {
+		t.Errorf("expected https for TLS request, got %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultRateLimit = 10 // requests per second per client IP
+	defaultRateBurst = 20
+
+	// rateLimitIdle is how long a client's bucket is kept after its last
+	// request. An idle bucket refills completely well within this time.
+	rateLimitIdle = 5 * time.Minute
+)
+
+// rateLimiter keeps one token bucket per client IP.
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	clients   sync.Map     // client IP -> *rateClient
+	lastSweep atomic.Int64 // unix nanos
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64 // unix nanos
+}
+
+// newRateLimiter returns nil, meaning no limit, when perSecond is negative.
+// Zero values take the defaults.
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	if perSecond < 0 {
+		return nil
+	}
+	if perSecond == 0 {
+		perSecond = defaultRateLimit
+	}
+	if burst <= 0 {
+		burst = defaultRateBurst
+	}
+	return &rateLimiter{limit: rate.Limit(perSecond), burst: burst}
+}
+
+// allow takes a token from ip's bucket. When none is left it returns how
+// long until one will be.
+func (l *rateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.sweep(now)
+	v, ok := l.clients.Load(ip)
+	if !ok {
+		v, _ = l.clients.LoadOrStore(ip, &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)})
+	}
+	c := v.(*rateClient)
+	c.lastSeen.Store(now.UnixNano())
+
+	res := c.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		return false, time.Second
+	}
+	if delay := res.DelayFrom(now); delay > 0 {
+		res.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// sweep drops clients idle for longer than rateLimitIdle. It runs at most
+// once per minute, piggybacking on requests so no goroutine is needed.
+func (l *rateLimiter) sweep(now time.Time) {
+	last := l.lastSweep.Load()
+	if now.UnixNano()-last < int64(time.Minute) || !l.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	cutoff := now.Add(-rateLimitIdle).UnixNano()
+	l.clients.Range(func(k, v any) bool {
+		if v.(*rateClient).lastSeen.Load() < cutoff {
+			l.clients.Delete(k)
+		}
+		return true
+	})
+}
+
+// withRateLimit answers 429 with Retry-After to clients that exceed their
+// request rate. /ping and /status stay open for health probes.
+func (s *Server) withRateLimit(next http.Handler) http.Handler {
+	if s.rateLimit == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" || r.URL.Path == "/status" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := s.rateLimit.allow(s.clientIP(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
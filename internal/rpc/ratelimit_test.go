@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestServer_RateLimit(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, RateLimit: 1, RateBurst: 5})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+	get := func(path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 5; i++ {
+		if w := get("/chats", "192.0.2.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+	}
+	w := get("/chats", "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the burst, got %d", w.Code)
+	}
+	if secs, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || secs < 1 {
+		t.Fatalf("expected Retry-After in whole seconds, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// Other clients have their own bucket.
+	if w := get("/chats", "192.0.2.2:1234"); w.Code != http.StatusOK {
+		t.Fatalf("other client: expected 200, got %d", w.Code)
+	}
+	// Health endpoints are exempt.
+	for _, path := range []string{"/ping", "/status"} {
+		if w := get(path, "192.0.2.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestServer_RateLimitDisabled(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, RateLimit: -1})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+	for i := 0; i < 3*defaultRateBurst; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/chats", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+	}
+}
+
+func TestRateLimiter_RefillAndSweep(t *testing.T) {
+	l := newRateLimiter(2, 2)
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("request %d: expected allowed", i)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected denial with 500ms wait, got ok=%v wait=%v", ok, wait)
+	}
+	// A denied request does not use up the token it waited for.
+	if ok, _ := l.allow("a", now.Add(500*time.Millisecond)); !ok {
+		t.Fatal("expected a token after 500ms")
+	}
+
+	if ok, _ := l.allow("b", now.Add(2*time.Minute)); !ok {
+		t.Fatal("expected b allowed")
+	}
+	l.allow("b", now.Add(rateLimitIdle+2*time.Minute))
+	if _, ok := l.clients.Load("a"); ok {
+		t.Fatal("expected idle client a to be swept")
+	}
+	if _, ok := l.clients.Load("b"); !ok {
+		t.Fatal("expected active client b to be kept")
+	}
+}
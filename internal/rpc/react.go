@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type reactRequest struct {
+	ChatJID  string `json:"chat_jid"`
+	MsgID    string `json:"msg_id"`
+	Reaction string `json:"reaction"` // empty removes our reaction
+}
+
+type reactionJSON struct {
+	Reaction string `json:"reaction"`
+	Count    int    `json:"count"`
+}
+
+type reactionsResponse struct {
+	OK        bool           `json:"ok"`
+	Reactions []reactionJSON `json:"reactions"`
+}
+
+func (s *Server) handleReact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+
+	var req reactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	chatJID := strings.TrimSpace(req.ChatJID)
+	msgID := strings.TrimSpace(req.MsgID)
+	if chatJID == "" || msgID == "" {
+		writeError(w, http.StatusBadRequest, "chat_jid and msg_id are required")
+		return
+	}
+	chat, err := wa.ParseUserOrJID(chatJID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chat_jid: "+err.Error())
+		return
+	}
+	if _, err := s.db.GetMessage(r.Context(), chat.String(), msgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "message not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	if err := waClient.SendReaction(ctx, chat, types.MessageID(msgID), req.Reaction); err != nil {
+		s.log.Error().Err(err).Str("chat", chat.String()).Str("msg_id", msgID).Str("remote", s.clientIP(r)).Msg("failed to send reaction via RPC")
+		writeError(w, http.StatusInternalServerError, "react failed: "+err.Error())
+		return
+	}
+
+	if err := s.db.UpsertReaction(ctx, store.UpsertReactionParams{
+		ChatJID:   chat.String(),
+		MsgID:     msgID,
+		Reaction:  req.Reaction,
+		Timestamp: time.Now().UTC(),
+	}); err != nil {
+		s.log.Warn().Err(err).Str("msg_id", msgID).Msg("failed to store reaction")
+	}
+	writeOK(w, jsonResponse{OK: true})
+}
+
+func (s *Server) handleReactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	chatJID := strings.TrimSpace(q.Get("chat_jid"))
+	msgID := strings.TrimSpace(q.Get("msg_id"))
+	if chatJID == "" || msgID == "" {
+		writeError(w, http.StatusBadRequest, "chat_jid and msg_id are required")
+		return
+	}
+
+	counts, err := s.db.ListReactions(r.Context(), chatJID, msgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]reactionJSON, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, reactionJSON{Reaction: c.Reaction, Count: c.Count})
+	}
+	writeOK(w, reactionsResponse{OK: true, Reactions: out})
+}
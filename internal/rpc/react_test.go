@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestServer_React(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	chatJID := "123@s.whatsapp.net"
+	now := time.Now()
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", now)
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID: chatJID, MsgID: "m1", SenderJID: chatJID, Timestamp: now, Text: "hi",
+	})
+	// Someone else already reacted with the same emoji.
+	_ = db.UpsertReaction(ctx, store.UpsertReactionParams{
+		ChatJID: chatJID, MsgID: "m1", SenderJID: chatJID, Reaction: "👍", Timestamp: now,
+	})
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	react := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/react", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	reactions := func() []reactionJSON {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/reactions?chat_jid="+chatJID+"&msg_id=m1", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("reactions: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp reactionsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !resp.OK {
+			t.Fatalf("expected ok=true")
+		}
+		return resp.Reactions
+	}
+
+	if w := react(`{"chat_jid":"` + chatJID + `","msg_id":"m1","reaction":"👍"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := reactions(); len(got) != 1 || got[0] != (reactionJSON{Reaction: "👍", Count: 2}) {
+		t.Fatalf("unexpected reactions after react: %+v", got)
+	}
+
+	if w := react(`{"chat_jid":"` + chatJID + `","msg_id":"m1","reaction":""}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := reactions(); len(got) != 1 || got[0].Count != 1 {
+		t.Fatalf("unexpected reactions after removal: %+v", got)
+	}
+
+	if len(mock.reactions) != 2 || mock.reactions[0] != "m1:👍" || mock.reactions[1] != "m1:" {
+		t.Fatalf("unexpected sent reactions: %v", mock.reactions)
+	}
+}
+
+func TestServer_React_Errors(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{connected: true}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"missing msg_id", http.MethodPost, "/react", `{"chat_jid":"123@s.whatsapp.net"}`, http.StatusBadRequest},
+		{"unknown message", http.MethodPost, "/react", `{"chat_jid":"123@s.whatsapp.net","msg_id":"nope","reaction":"👍"}`, http.StatusNotFound},
+		{"react via GET", http.MethodGet, "/react", ``, http.StatusMethodNotAllowed},
+		{"reactions missing params", http.MethodGet, "/reactions?chat_jid=123@s.whatsapp.net", ``, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type markReadRequest struct {
+	ChatJID string   `json:"chat_jid"`
+	MsgIDs  []string `json:"msg_ids"`
+}
+
+type unreadJSON struct {
+	ChatJID  string `json:"chat_jid"`
+	ChatName string `json:"chat_name"`
+	Count    int    `json:"count"`
+}
+
+type unreadCountsResponse struct {
+	OK     bool         `json:"ok"`
+	Unread []unreadJSON `json:"unread"`
+}
+
+func (s *Server) handleMarkRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+
+	var req markReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	chatJID := strings.TrimSpace(req.ChatJID)
+	if chatJID == "" {
+		writeError(w, http.StatusBadRequest, "chat_jid is required")
+		return
+	}
+	chat, err := wa.ParseUserOrJID(chatJID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chat_jid: "+err.Error())
+		return
+	}
+	var ids []types.MessageID
+	for _, id := range req.MsgIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, types.MessageID(id))
+		}
+	}
+	if len(ids) == 0 {
+		writeError(w, http.StatusBadRequest, "msg_ids is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	if err := waClient.MarkRead(ctx, chat, ids); err != nil {
+		s.log.Error().Err(err).Str("chat", chat.String()).Str("remote", s.clientIP(r)).Msg("failed to mark messages read via RPC")
+		writeError(w, http.StatusInternalServerError, "mark read failed: "+err.Error())
+		return
+	}
+
+	// SetLastRead ignores messages older than the current pointer, so the
+	// newest of ids wins regardless of order.
+	for _, id := range ids {
+		if err := s.db.SetLastRead(ctx, chat.String(), string(id)); err != nil {
+			s.log.Warn().Err(err).Str("msg_id", string(id)).Msg("failed to store read pointer")
+			break
+		}
+	}
+	writeOK(w, jsonResponse{OK: true})
+}
+
+func (s *Server) handleUnreadCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	counts, err := s.db.UnreadCounts(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]unreadJSON, 0, len(counts))
+	for _, c := range counts {
+		out = append(out, unreadJSON{ChatJID: c.ChatJID, ChatName: c.ChatName, Count: c.Count})
+	}
+	writeOK(w, unreadCountsResponse{OK: true, Unread: out})
+}
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestServer_MarkRead(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	chatJID := "123@s.whatsapp.net"
+	base := time.Now().Add(-time.Hour)
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", base)
+	for i, id := range []string{"m1", "m2", "m3"} {
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID: chatJID, MsgID: id, SenderJID: chatJID, Timestamp: base.Add(time.Duration(i) * time.Minute), Text: id,
+		})
+	}
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	unread := func() unreadCountsResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/unread-counts", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unread-counts: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp unreadCountsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := unread(); len(resp.Unread) != 1 || resp.Unread[0].Count != 3 || resp.Unread[0].ChatName != "Alice" {
+		t.Fatalf("unexpected unread counts before marking: %+v", resp)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mark-read", bytes.NewBufferString(`{"chat_jid":"`+chatJID+`","msg_ids":["m2","m1"]}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("mark-read: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mock.readIDs) != 2 || mock.readIDs[0] != "m2" || mock.readIDs[1] != "m1" {
+		t.Fatalf("unexpected receipts: %v", mock.readIDs)
+	}
+
+	if resp := unread(); !resp.OK || len(resp.Unread) != 1 || resp.Unread[0].Count != 1 {
+		t.Fatalf("unexpected unread counts after marking: %+v", resp)
+	}
+}
+
+func TestServer_MarkRead_Errors(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{connected: true}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"missing chat_jid", http.MethodPost, "/mark-read", `{"msg_ids":["m1"]}`, http.StatusBadRequest},
+		{"missing msg_ids", http.MethodPost, "/mark-read", `{"chat_jid":"123@s.whatsapp.net","msg_ids":[" "]}`, http.StatusBadRequest},
+		{"mark-read via GET", http.MethodGet, "/mark-read", ``, http.StatusMethodNotAllowed},
+		{"unread-counts via POST", http.MethodPost, "/unread-counts", ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
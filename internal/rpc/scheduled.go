@@ -0,0 +1,208 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+)
+
+const (
+	// scheduleInterval is how often the scheduler looks for due messages,
+	// so a message goes out up to this long after its send_at.
+	scheduleInterval = 30 * time.Second
+	// scheduleMaxRetries is how many failed sends a scheduled message gets
+	// before it is marked failed.
+	scheduleMaxRetries = 3
+)
+
+type scheduledJSON struct {
+	ID        int64  `json:"id"`
+	To        string `json:"to"`
+	Message   string `json:"message"`
+	SendAt    string `json:"send_at"`
+	CreatedAt string `json:"created_at"`
+	Status    string `json:"status"`
+	Retries   int    `json:"retries"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type scheduledResponse struct {
+	OK        bool            `json:"ok"`
+	Scheduled []scheduledJSON `json:"scheduled"`
+}
+
+// scheduler calls tick every interval from a single worker until stopped.
+type scheduler struct {
+	interval time.Duration
+	tick     func(ctx context.Context, now time.Time)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newScheduler(interval time.Duration, tick func(ctx context.Context, now time.Time)) *scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := &scheduler{
+		interval: interval,
+		tick:     tick,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go sc.run()
+	return sc
+}
+
+func (sc *scheduler) run() {
+	defer close(sc.done)
+	t := time.NewTicker(sc.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-sc.ctx.Done():
+			return
+		case now := <-t.C:
+			sc.tick(sc.ctx, now)
+		}
+	}
+}
+
+// stop cancels any send in progress and waits for the worker to exit.
+func (sc *scheduler) stop() {
+	sc.once.Do(sc.cancel)
+	<-sc.done
+}
+
+// sendDueScheduled sends the scheduled messages due by now, oldest first.
+// Nothing is attempted while WhatsApp is disconnected, so an outage does
+// not use up a message's retries.
+func (s *Server) sendDueScheduled(ctx context.Context, now time.Time) {
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+	if waClient == nil || !waClient.IsConnected() {
+		return
+	}
+
+	due, err := s.db.DueScheduledMessages(ctx, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error().Err(err).Msg("failed to load scheduled messages")
+		}
+		return
+	}
+	for _, m := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		s.sendScheduled(ctx, waClient, m.ID, now)
+	}
+}
+
+// sendScheduled claims the message with id and sends it. A message that was
+// canceled, rescheduled or edited since it was loaded is sent as it stands
+// when claimed, or not at all.
+func (s *Server) sendScheduled(ctx context.Context, waClient WAClient, id int64, now time.Time) {
+	m, err := s.db.ClaimScheduledMessage(ctx, id, now)
+	if err != nil {
+		if !store.IsNotFound(err) && ctx.Err() == nil {
+			s.log.Error().Err(err).Int64("scheduled_id", id).Msg("failed to claim scheduled message")
+		}
+		return
+	}
+	log := s.log.With().Int64("scheduled_id", m.ID).Str("to", m.To).Logger()
+	to, err := wa.ParseUserOrJID(m.To)
+	if err != nil {
+		// The recipient was checked when it was scheduled; retrying won't help.
+		_ = s.db.MarkScheduledFailure(ctx, m.ID, err.Error(), 1)
+		log.Error().Err(err).Msg("invalid scheduled recipient")
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	msgID, err := waClient.SendText(sendCtx, to, m.Message)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: the message goes back to pending for the next run.
+			if err := s.db.ReleaseScheduledMessage(context.WithoutCancel(ctx), m.ID); err != nil {
+				log.Warn().Err(err).Msg("failed to release scheduled message")
+			}
+			return
+		}
+		log.Error().Err(err).Int("retries", m.Retries+1).Msg("failed to send scheduled message")
+		if err := s.db.MarkScheduledFailure(ctx, m.ID, err.Error(), scheduleMaxRetries); err != nil {
+			log.Warn().Err(err).Msg("failed to record scheduled message failure")
+		}
+		return
+	}
+	// The message is out, so record it even if we are stopping; otherwise
+	// it would be sent again on the next run.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.db.MarkScheduledSent(ctx, m.ID, string(msgID)); err != nil {
+		log.Warn().Err(err).Msg("failed to mark scheduled message sent")
+	}
+	log.Info().Str("msg_id", string(msgID)).Msg("scheduled message sent")
+	s.metrics.messageSent()
+	s.storeSent(ctx, waClient, to, store.UpsertMessageParams{MsgID: string(msgID), Text: m.Message})
+}
+
+// handleScheduled lists the pending scheduled messages, soonest first.
+func (s *Server) handleScheduled(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	pending, err := s.db.ListScheduledMessages(r.Context(), store.ScheduledPending)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]scheduledJSON, 0, len(pending))
+	for _, m := range pending {
+		out = append(out, scheduledJSON{
+			ID:        m.ID,
+			To:        m.To,
+			Message:   m.Message,
+			SendAt:    m.SendAt.Format(time.RFC3339),
+			CreatedAt: m.CreatedAt.Format(time.RFC3339),
+			Status:    m.Status,
+			Retries:   m.Retries,
+			LastError: m.LastError,
+		})
+	}
+	writeOK(w, scheduledResponse{OK: true, Scheduled: out})
+}
+
+// handleCancelScheduled cancels the pending message at /scheduled/{id}.
+func (s *Server) handleCancelScheduled(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/scheduled/"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid scheduled message id")
+		return
+	}
+	if err := s.db.CancelScheduledMessage(r.Context(), id); err != nil {
+		if store.IsNotFound(err) {
+			writeError(w, http.StatusNotFound, "no pending scheduled message with that id")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.log.Info().Int64("scheduled_id", id).Str("remote", s.clientIP(r)).Msg("scheduled message canceled via RPC")
+	writeOK(w, jsonResponse{OK: true})
+}
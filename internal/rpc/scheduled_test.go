@@ -0,0 +1,300 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+func TestServer_SendAt_SchedulesAndCancels(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Stop(context.Background())
+	h := srv.Handler()
+
+	sendAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	body := `{"to": "14155550001", "message": "later", "send_at": "` + sendAt.Format(time.RFC3339) + `"}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp sendResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.OK || resp.ScheduledID == 0 || resp.MessageID != "" || resp.SendAt != sendAt.Format(time.RFC3339) {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if len(mock.sentMsgs) != 0 {
+		t.Fatalf("scheduled message was sent immediately: %v", mock.sentMsgs)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/scheduled", nil))
+	var list scheduledResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if w.Code != http.StatusOK || len(list.Scheduled) != 1 {
+		t.Fatalf("expected one pending message, got %d: %+v", w.Code, list)
+	}
+	if got := list.Scheduled[0]; got.ID != resp.ScheduledID || got.To != "14155550001@s.whatsapp.net" || got.Message != "later" || got.Status != store.ScheduledPending {
+		t.Fatalf("unexpected scheduled message: %+v", got)
+	}
+
+	path := "/scheduled/" + strconv.FormatInt(resp.ScheduledID, 10)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 canceling, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 canceling twice, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/scheduled/abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad id, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/scheduled", nil))
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Scheduled) != 0 {
+		t.Fatalf("canceled message still listed: %+v", list.Scheduled)
+	}
+}
+
+func TestServer_SendAt_Validation(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{connected: true}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	for _, body := range []string{
+		`{"to": "14155550001", "message": "hi", "send_at": "tomorrow"}`,
+		`{"to": "14155550001", "message": "", "send_at": "2030-01-01T00:00:00Z"}`,
+		`{"to": "14155550001", "message": "hi", "send_at": "2030-01-01T00:00:00Z", "media_base64": "aGk="}`,
+		`{"to": "14155550001", "message": "hi", "send_at": "2030-01-01T00:00:00Z", "reply_to_msg_id": "m1"}`,
+	} {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestServer_SendDueScheduled(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true, SendErrorFor: map[string]error{
+		"14155550002@s.whatsapp.net": errors.New("blocked"),
+	}}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	ctx := context.Background()
+	now := time.Now()
+	schedule := func(to, text string, at time.Time) int64 {
+		t.Helper()
+		id, err := db.UpsertScheduledMessage(ctx, store.ScheduledMessage{To: to, Message: text, SendAt: at})
+		if err != nil {
+			t.Fatalf("UpsertScheduledMessage: %v", err)
+		}
+		return id
+	}
+	ok := schedule("14155550001@s.whatsapp.net", "due", now.Add(-time.Minute))
+	failing := schedule("14155550002@s.whatsapp.net", "fails", now.Add(-time.Minute))
+	future := schedule("14155550003@s.whatsapp.net", "future", now.Add(time.Hour))
+
+	// Nothing is attempted, or counted as a retry, while disconnected.
+	mock.connected = false
+	srv.sendDueScheduled(ctx, now)
+	mock.connected = true
+	if m, _ := db.GetScheduledMessage(ctx, failing); m.Retries != 0 {
+		t.Fatalf("retry counted while disconnected: %+v", m)
+	}
+
+	for i := 0; i < scheduleMaxRetries; i++ {
+		srv.sendDueScheduled(ctx, now)
+	}
+	if len(mock.sentMsgs) != 1 || mock.sentMsgs[0] != "due" {
+		t.Fatalf("expected only the due message sent once, got %v", mock.sentMsgs)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, ok); m.Status != store.ScheduledSent || m.MsgID != "test_msg_id" {
+		t.Fatalf("expected sent, got %+v", m)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, failing); m.Status != store.ScheduledFailed || m.Retries != scheduleMaxRetries || m.LastError != "blocked" {
+		t.Fatalf("expected failed after %d retries, got %+v", scheduleMaxRetries, m)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, future); m.Status != store.ScheduledPending {
+		t.Fatalf("future message changed: %+v", m)
+	}
+	if _, err := db.GetMessage(ctx, "14155550001@s.whatsapp.net", "test_msg_id"); err != nil {
+		t.Fatalf("sent message not stored: %v", err)
+	}
+}
+
+func TestServer_SendDueScheduled_CancelDuringSend(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true, SendDelay: 200 * time.Millisecond}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	first, _ := db.UpsertScheduledMessage(ctx, store.ScheduledMessage{To: "14155550001@s.whatsapp.net", Message: "first", SendAt: now.Add(-time.Minute)})
+	second, _ := db.UpsertScheduledMessage(ctx, store.ScheduledMessage{To: "14155550002@s.whatsapp.net", Message: "second", SendAt: now.Add(-time.Minute)})
+
+	done := make(chan struct{})
+	go func() {
+		srv.sendDueScheduled(ctx, now)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if m, _ := db.GetScheduledMessage(ctx, first); m.Status == store.ScheduledSending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first message was never claimed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// While the first is being sent it can't be canceled; the second,
+	// loaded in the same batch but not claimed yet, can.
+	h := srv.Handler()
+	for id, want := range map[int64]int{first: http.StatusNotFound, second: http.StatusOK} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/scheduled/"+strconv.FormatInt(id, 10), nil))
+		if w.Code != want {
+			t.Fatalf("cancel %d: expected %d, got %d: %s", id, want, w.Code, w.Body.String())
+		}
+	}
+	<-done
+
+	if len(mock.sentMsgs) != 1 || mock.sentMsgs[0] != "first" {
+		t.Fatalf("expected only the first message sent, got %v", mock.sentMsgs)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, first); m.Status != store.ScheduledSent {
+		t.Fatalf("first: expected sent, got %+v", m)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, second); m.Status != store.ScheduledCanceled {
+		t.Fatalf("second: expected canceled, got %+v", m)
+	}
+}
+
+func TestServer_SendAt_WhileDisconnected(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: false}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	start := time.Now()
+	sendAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	body := `{"to": "14155550001", "message": "later", "send_at": "` + sendAt + `"}`
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 while disconnected, got %d: %s", w.Code, w.Body.String())
+	}
+	if d := time.Since(start); d >= connectWait {
+		t.Fatalf("scheduling waited %v for WhatsApp", d)
+	}
+	pending, err := db.ListScheduledMessages(context.Background(), store.ScheduledPending)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected one pending message, got %d (err %v)", len(pending), err)
+	}
+}
+
+func TestServer_SchedulerStartsWithServer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	_ = srv.Handler()
+	if srv.scheduler != nil {
+		t.Fatalf("scheduler running before Start")
+	}
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatalf("stop unstarted server: %v", err)
+	}
+
+	srv, err = New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if srv.scheduler == nil {
+		t.Fatalf("scheduler not started by Start")
+	}
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+}
+
+func TestScheduler_StopsPromptly(t *testing.T) {
+	ticks := make(chan struct{}, 1)
+	sc := newScheduler(time.Millisecond, func(ctx context.Context, now time.Time) {
+		select {
+		case ticks <- struct{}{}:
+		default:
+		}
+		<-ctx.Done() // a send in progress is canceled by stop
+	})
+	<-ticks
+
+	stopped := make(chan struct{})
+	go func() {
+		sc.stop()
+		sc.stop() // idempotent
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("scheduler did not stop")
+	}
+}
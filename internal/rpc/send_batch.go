@@ -0,0 +1,131 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	defaultMaxBatchSize     = 100
+	defaultBatchConcurrency = 3
+	// batchItemTimeout bounds each send in a batch, like a single /send.
+	batchItemTimeout = 30 * time.Second
+)
+
+type sendBatchItem struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+type sendBatchRequest struct {
+	Messages []sendBatchItem `json:"messages"`
+}
+
+type sendBatchResult struct {
+	To        string `json:"to"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type sendBatchResponse struct {
+	OK      bool              `json:"ok"`
+	Results []sendBatchResult `json:"results"`
+}
+
+// handleSendBatch sends up to maxBatchSize text messages, batchConcurrency
+// at a time. Every item gets a result in input order; a failed item does
+// not stop the others, so the response is 200 unless the request itself
+// is invalid.
+func (s *Server) handleSendBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+
+	var req sendBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "messages is required")
+		return
+	}
+	if len(req.Messages) > s.maxBatchSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d messages per batch, got %d", s.maxBatchSize, len(req.Messages)))
+		return
+	}
+
+	results := make([]sendBatchResult, len(req.Messages))
+	sem := make(chan struct{}, s.batchConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range req.Messages {
+		results[i].To = item.To
+		to, err := batchRecipient(item)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-r.Context().Done():
+				results[i].Error = r.Context().Err().Error()
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), batchItemTimeout)
+			defer cancel()
+			msgID, err := waClient.SendText(ctx, to, item.Message)
+			if err != nil {
+				s.log.Error().Err(err).Str("to", to.String()).Str("remote", s.clientIP(r)).Msg("failed to send batch message via RPC")
+				results[i].Error = "send failed: " + err.Error()
+				return
+			}
+			results[i].MessageID = string(msgID)
+			s.metrics.messageSent()
+			s.storeSent(ctx, waClient, to, store.UpsertMessageParams{MsgID: string(msgID), Text: item.Message})
+		}()
+	}
+	wg.Wait()
+
+	sent := 0
+	for _, res := range results {
+		if res.Error == "" {
+			sent++
+		}
+	}
+	s.log.Info().Int("sent", sent).Int("failed", len(results)-sent).Str("remote", s.clientIP(r)).Msg("batch sent via RPC")
+	writeOK(w, sendBatchResponse{OK: true, Results: results})
+}
+
+func batchRecipient(item sendBatchItem) (types.JID, error) {
+	if strings.TrimSpace(item.To) == "" {
+		return types.JID{}, fmt.Errorf("to is required")
+	}
+	if strings.TrimSpace(item.Message) == "" {
+		return types.JID{}, fmt.Errorf("message is required")
+	}
+	to, err := wa.ParseUserOrJID(item.To)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("invalid recipient: %w", err)
+	}
+	return to, nil
+}
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postBatch(t *testing.T, h http.Handler, body string) (*httptest.ResponseRecorder, sendBatchResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send-batch", bytes.NewBufferString(body)))
+	var resp sendBatchResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return w, resp
+}
+
+func TestServer_SendBatch_PartialFailure(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true, SendErrorFor: map[string]error{
+		"14155550002@s.whatsapp.net": errors.New("blocked"),
+	}}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	w, resp := postBatch(t, srv.Handler(), `{"messages": [
+		{"to": "14155550001", "message": "one"},
+		{"to": "14155550002", "message": "two"},
+		{"to": "", "message": "three"},
+		{"to": "14155550004", "message": "  "},
+		{"to": "14155550005", "message": "five"}
+	]}`)
+	if w.Code != http.StatusOK || !resp.OK {
+		t.Fatalf("expected 200 ok, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(resp.Results))
+	}
+	want := []struct{ to, id, err string }{
+		{"14155550001", "test_msg_id", ""},
+		{"14155550002", "", "blocked"},
+		{"", "", "to is required"},
+		{"14155550004", "", "message is required"},
+		{"14155550005", "test_msg_id", ""},
+	}
+	for i, wnt := range want {
+		got := resp.Results[i]
+		if got.To != wnt.to || got.MessageID != wnt.id || !strings.Contains(got.Error, wnt.err) || (wnt.err == "") != (got.Error == "") {
+			t.Errorf("result %d = %+v, want to=%q id=%q error~%q", i, got, wnt.to, wnt.id, wnt.err)
+		}
+	}
+	if len(mock.sentMsgs) != 2 {
+		t.Fatalf("expected 2 sends, got %v", mock.sentMsgs)
+	}
+	if m, err := db.GetMessage(context.Background(), "14155550005@s.whatsapp.net", "test_msg_id"); err != nil || m.Text != "five" || !m.FromMe {
+		t.Fatalf("expected sent message stored, got %+v, %v", m, err)
+	}
+}
+
+func TestServer_SendBatch_Limits(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true, SendDelay: 50 * time.Millisecond}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock, MaxBatchSize: 6, BatchConcurrency: 3})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+
+	items := make([]string, 7)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"to": "1415555%04d", "message": "m%d"}`, i, i)
+	}
+	if w, _ := postBatch(t, h, `{"messages": [`+strings.Join(items, ",")+`]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("oversized batch: expected 400, got %d", w.Code)
+	}
+	if w, _ := postBatch(t, h, `{"messages": []}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("empty batch: expected 400, got %d", w.Code)
+	}
+
+	// Six sends of 50ms, three at a time, take at least two rounds.
+	start := time.Now()
+	w, resp := postBatch(t, h, `{"messages": [`+strings.Join(items[:6], ",")+`]}`)
+	if w.Code != http.StatusOK || len(resp.Results) != 6 {
+		t.Fatalf("expected 6 results, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expected sends to be limited to 3 at a time, took %v", elapsed)
+	}
+	for i, r := range resp.Results {
+		if r.Error != "" || r.To != fmt.Sprintf("1415555%04d", i) {
+			t.Fatalf("result %d = %+v", i, r)
+		}
+	}
+}
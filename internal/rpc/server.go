@@ -4,11 +4,17 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,34 +33,142 @@ const unixSocketPrefix = "unix://"
 // WAClient defines the interface for WhatsApp operations.
 type WAClient interface {
 	IsConnected() bool
+	// HealthCheck round-trips to the WhatsApp server (e.g. a keepalive).
+	HealthCheck(ctx context.Context) error
 	SendText(ctx context.Context, to types.JID, text string) (types.MessageID, error)
+	// SendTextWithReply sends text quoting quotedMsgID from quotedChatJID
+	// (to's chat when empty). The quoted message must be stored.
+	SendTextWithReply(ctx context.Context, to types.JID, text, quotedMsgID, quotedChatJID string) (types.MessageID, error)
+	SendImage(ctx context.Context, to types.JID, caption string, data []byte) (types.MessageID, error)
+	SendDocument(ctx context.Context, to types.JID, filename, mimeType string, data []byte) (types.MessageID, error)
+	// SendLocation sends a pin at lat, lng, labelled name when not empty.
+	SendLocation(ctx context.Context, to types.JID, lat, lng float64, name string) (types.MessageID, error)
+	// SendReaction reacts to msgID in chat; an empty reaction removes it.
+	SendReaction(ctx context.Context, chat types.JID, msgID types.MessageID, reaction string) error
+	// MarkRead sends read receipts for msgIDs in chat.
+	MarkRead(ctx context.Context, chat types.JID, msgIDs []types.MessageID) error
+	// SendChatPresence sets our typing state in chat: composing or paused.
+	SendChatPresence(ctx context.Context, chat types.JID, state string) error
+	GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error)
 	ResolveChatName(ctx context.Context, chat types.JID, pushName string) string
+	// Subscribe registers ch to receive every message that arrives live
+	// from WhatsApp. Sends must not block; a full ch misses messages.
+	Subscribe(ch chan<- store.Message)
+	Unsubscribe(ch chan<- store.Message)
 }
 
 // Server is the HTTP RPC server.
 type Server struct {
 	addr       string
+	bound      string // actual listen address once started
 	db         *store.DB
 	wa         WAClient
 	isUnixSock bool   // true if listening on Unix socket
 	sockPath   string // path to Unix socket file (if isUnixSock)
 
+	trustedProxies   []netip.Prefix
+	requestTimeouts  map[string]time.Duration // per-path deadlines, see withTimeouts
+	groupMembersTTL  time.Duration
+	authToken        string
+	rateLimit        *rateLimiter // nil when disabled
+	maxBatchSize     int
+	batchConcurrency int
+
+	healthAddr   string
+	healthBound  string
+	healthServer *http.Server
+
+	metrics       *metrics
+	metricsAddr   string
+	metricsBound  string
+	metricsServer *http.Server
+
+	tlsConfig      *tls.Config // nil serves plain HTTP
+	tlsFingerprint string
+
 	server *http.Server
 	mu     sync.RWMutex
 
-	syncRunning atomic.Bool
-	startTime   time.Time
-	log         zerolog.Logger
+	ws        wsHub    // /ws clients and their message feed
+	sse       sseHub   // /events clients
+	webhook   *webhook // nil unless Options.WebhookURL is set
+	typing    *typingTracker
+	scheduler *scheduler // sends messages queued with send_at when due; started by Start
+
+	syncRunning    atomic.Bool
+	isReconnecting atomic.Bool
+	startTime      time.Time
+	log            zerolog.Logger
 }
 
 // Options configures the RPC server.
 type Options struct {
-	Addr string // e.g., "localhost:5555"
+	Addr string // e.g., "localhost:5555", "/run/wacli.sock" or "unix:///run/wacli.sock"
 	DB   *store.DB
 	WA   WAClient
+
+	// TrustedProxies lists the peer ranges whose X-Forwarded-For and
+	// X-Forwarded-Proto headers are honoured.
+	TrustedProxies []netip.Prefix
+
+	// HealthAddr, if set, starts a separate listener serving only
+	// GET /health and GET /ready.
+	HealthAddr string
+
+	// MetricsAddr, if set, serves GET /metrics on a separate listener,
+	// without auth, instead of on the main address.
+	MetricsAddr string
+
+	// Timeouts sets a deadline per endpoint path (e.g. "/send"); requests
+	// that exceed it get 408. Paths not listed have no extra deadline.
+	Timeouts map[string]time.Duration
+
+	// WebhookURL, if set, receives a POST with a messageJSON body for every
+	// new message. Failed deliveries are retried up to 3 times.
+	WebhookURL string
+	// WebhookSecret, if set, signs each webhook body; see SignatureHeader.
+	WebhookSecret string
+
+	// TLSSelfSigned serves HTTPS with a certificate generated at startup
+	// for the host in Addr, valid for a year. See Server.TLSFingerprint.
+	TLSSelfSigned bool
+	// TLSCertFile and TLSKeyFile serve HTTPS with a PEM certificate and
+	// key loaded at startup. Both must be set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSACMEDomain serves HTTPS with a Let's Encrypt certificate for the
+	// domain, obtained on first use via the TLS-ALPN-01 challenge (so the
+	// server must be reachable on port 443) and cached in TLSACMECacheDir.
+	TLSACMEDomain   string
+	TLSACMECacheDir string
+
+	// AuthToken, if set, must be sent with every request except /ping as
+	// "Authorization: Bearer <token>" or ?token=. Use at least 32 random
+	// bytes, e.g. `openssl rand -hex 32`.
+	AuthToken string
+
+	// RateLimit is the sustained requests per second allowed per client
+	// IP, with bursts of up to RateBurst; excess requests get 429. /ping
+	// and /status are exempt. Zero means 10/s with a burst of 20;
+	// negative disables the limit.
+	RateLimit float64
+	RateBurst int
+
+	// MaxBatchSize caps the messages in one POST /send-batch (default
+	// 100); BatchConcurrency is how many of them are sent at once
+	// (default 3), kept low to avoid WhatsApp rate limits.
+	MaxBatchSize     int
+	BatchConcurrency int
+
+	// GroupMembersTTL is how long /group-members serves stored members
+	// before fetching them again. Zero means one hour; negative means
+	// always fetch.
+	GroupMembersTTL time.Duration
 }
 
-// New creates a new RPC server.
+// New creates a new RPC server. Options is copied, including the
+// TrustedProxies slice and Timeouts map, so later changes by the caller
+// have no effect; DB and WA are shared handles by design.
 func New(opts Options) (*Server, error) {
 	if opts.Addr == "" {
 		opts.Addr = "localhost:5555"
@@ -69,34 +183,87 @@ func New(opts Options) (*Server, error) {
 		wa:        opts.WA,
 		startTime: time.Now(),
 		log:       logging.WithComponent("rpc"),
+
+		trustedProxies:  slices.Clone(opts.TrustedProxies),
+		requestTimeouts: maps.Clone(opts.Timeouts),
+		healthAddr:      opts.HealthAddr,
+		metricsAddr:     opts.MetricsAddr,
+		authToken:       opts.AuthToken,
+		rateLimit:       newRateLimiter(opts.RateLimit, opts.RateBurst),
 	}
+	if err := s.setupTLS(opts, time.Now()); err != nil {
+		return nil, err
+	}
+	s.metrics = newMetrics(s)
+	s.typing = newTypingTracker(typingIdle, s.autoPause)
+	s.maxBatchSize = opts.MaxBatchSize
+	if s.maxBatchSize <= 0 {
+		s.maxBatchSize = defaultMaxBatchSize
+	}
+	s.batchConcurrency = opts.BatchConcurrency
+	if s.batchConcurrency <= 0 {
+		s.batchConcurrency = defaultBatchConcurrency
+	}
+	s.groupMembersTTL = opts.GroupMembersTTL
+	if s.groupMembersTTL == 0 {
+		s.groupMembersTTL = defaultGroupMembersTTL
+	}
+	if opts.WebhookURL != "" {
+		s.webhook = newWebhook(opts.WebhookURL, opts.WebhookSecret, s.log)
+	}
+	s.attachFeed(opts.WA)
 	return s, nil
 }
 
 // SetWA sets the WhatsApp client (for deferred initialization).
 func (s *Server) SetWA(wa WAClient) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.wa = wa
+	s.mu.Unlock()
+	s.attachFeed(wa)
+	s.publishStatus()
 }
 
 // SetSyncRunning updates the sync running status.
 func (s *Server) SetSyncRunning(running bool) {
-	s.syncRunning.Store(running)
+	if s.syncRunning.Swap(running) != running {
+		s.sse.publish(sseEventSync, sseSyncJSON{SyncRunning: running})
+	}
 }
 
-// Start starts the HTTP server.
-func (s *Server) Start() error {
+// Handler returns the HTTP handler with all routes and middleware.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/status", s.handleStatus)
 	mux.HandleFunc("/chats", s.handleChats)
+	mux.HandleFunc("/contacts", s.handleContacts)
 	mux.HandleFunc("/messages", s.handleMessages)
 	mux.HandleFunc("/search", s.handleSearch)
-	mux.HandleFunc("/send", s.handleSend)
+	mux.HandleFunc("/export/messages", s.handleExportMessages)
+	mux.HandleFunc("/send", s.handleSend) // waits for WhatsApp unless send_at is set
+	mux.HandleFunc("/send-batch", s.requireWA(s.handleSendBatch))
+	mux.HandleFunc("/scheduled", s.handleScheduled)
+	mux.HandleFunc("/scheduled/", s.handleCancelScheduled)
+	mux.HandleFunc("/react", s.requireWA(s.handleReact))
+	mux.HandleFunc("/reactions", s.handleReactions)
+	mux.HandleFunc("/mark-read", s.requireWA(s.handleMarkRead))
+	mux.HandleFunc("/unread-counts", s.handleUnreadCounts)
+	mux.HandleFunc("/typing", s.requireWA(s.handleTyping))
+	mux.HandleFunc("/group-members", s.handleGroupMembers)
 	mux.HandleFunc("/ping", s.handlePing)
+	mux.HandleFunc("/ws", s.handleWS)
+	mux.HandleFunc("/events", s.handleEvents)
+	if s.metricsAddr == "" {
+		mux.Handle("/metrics", s.metrics.handler())
+	}
+
+	return s.withRequestLog(s.withMetrics(mux, s.withTrace(s.withRateLimit(s.withAuth(s.withTimeouts(mux))))))
+}
 
+// Start starts the HTTP server.
+func (s *Server) Start() error {
 	s.server = &http.Server{
-		Handler:           mux,
+		Handler:           s.Handler(),
 		ReadTimeout:       30 * time.Second,
 		ReadHeaderTimeout: 10 * time.Second,
 		WriteTimeout:      60 * time.Second,
@@ -107,15 +274,15 @@ func (s *Server) Start() error {
 	network := "tcp"
 	listenAddr := s.addr
 
-	if strings.HasPrefix(s.addr, unixSocketPrefix) {
+	if path, ok := unixSocketPath(s.addr); ok {
 		network = "unix"
-		s.sockPath = strings.TrimPrefix(s.addr, unixSocketPrefix)
+		s.sockPath = path
 		listenAddr = s.sockPath
 		s.isUnixSock = true
 
 		// Remove existing socket file if it exists
-		if err := os.Remove(s.sockPath); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("remove existing socket %s: %w", s.sockPath, err)
+		if err := removeStaleSocket(s.sockPath); err != nil {
+			return err
 		}
 	}
 
@@ -123,8 +290,40 @@ func (s *Server) Start() error {
 	if err != nil {
 		return fmt.Errorf("listen %s: %w", s.addr, err)
 	}
+	if !s.isUnixSock {
+		s.mu.Lock()
+		s.bound = ln.Addr().String()
+		s.mu.Unlock()
+	}
+
+	if s.tlsConfig != nil {
+		ln = tls.NewListener(ln, s.tlsConfig)
+	}
+
+	if s.healthAddr != "" {
+		if err := s.startHealth(); err != nil {
+			_ = ln.Close()
+			return err
+		}
+	}
+	if s.metricsAddr != "" {
+		if err := s.startMetrics(); err != nil {
+			_ = ln.Close()
+			if s.healthServer != nil {
+				_ = s.healthServer.Close()
+			}
+			return err
+		}
+	}
 
-	s.log.Info().Str("addr", s.addr).Str("network", network).Msg("RPC server starting")
+	// A previous run that stopped mid-send left its message claimed.
+	if n, err := s.db.RequeueSendingScheduled(context.Background()); err != nil {
+		s.log.Warn().Err(err).Msg("failed to requeue scheduled messages")
+	} else if n > 0 {
+		s.log.Warn().Int64("count", n).Msg("requeued scheduled messages interrupted mid-send")
+	}
+	s.scheduler = newScheduler(scheduleInterval, s.sendDueScheduled)
+	s.log.Info().Str("addr", s.Addr()).Str("network", network).Bool("tls", s.tlsConfig != nil).Msg("RPC server starting")
 	go func() {
 		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
 			s.log.Error().Err(err).Msg("RPC server error")
@@ -134,13 +333,35 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Stop gracefully stops the HTTP server.
+// Stop gracefully stops the HTTP server and message delivery. It is safe
+// to call on a server that was never started.
 func (s *Server) Stop(ctx context.Context) error {
+	// Shutdown does not track hijacked connections, so close them first.
+	s.closeWS()
+	// Open /events streams would hold Shutdown until ctx expires.
+	s.sse.closeAll()
+	if s.webhook != nil {
+		s.webhook.stop()
+	}
+	s.typing.stop()
+	if s.scheduler != nil {
+		s.scheduler.stop()
+	}
 	if s.server == nil {
 		return nil
 	}
 	s.log.Info().Msg("RPC server stopping")
 	err := s.server.Shutdown(ctx)
+	if s.healthServer != nil {
+		if hErr := s.healthServer.Shutdown(ctx); hErr != nil && err == nil {
+			err = hErr
+		}
+	}
+	if s.metricsServer != nil {
+		if mErr := s.metricsServer.Shutdown(ctx); mErr != nil && err == nil {
+			err = mErr
+		}
+	}
 
 	// Clean up Unix socket file
 	if s.isUnixSock && s.sockPath != "" {
@@ -152,8 +373,45 @@ func (s *Server) Stop(ctx context.Context) error {
 	return err
 }
 
-// Addr returns the listen address.
+// unixSocketPath reports whether addr names a Unix socket, either as
+// "unix://path" or as an absolute path, and returns the path.
+func unixSocketPath(addr string) (string, bool) {
+	if strings.HasPrefix(addr, unixSocketPrefix) {
+		return strings.TrimPrefix(addr, unixSocketPrefix), true
+	}
+	if strings.HasPrefix(addr, "/") {
+		return addr, true
+	}
+	return "", false
+}
+
+// removeStaleSocket deletes a socket left behind by a previous run. Any
+// other kind of file at path is left alone.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("stat socket %s: %w", path, err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove existing socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// Addr returns the listen address. Once a TCP server has started this is
+// the bound address, so a ":0" port resolves to the one the OS picked.
 func (s *Server) Addr() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.bound != "" {
+		return s.bound
+	}
 	return s.addr
 }
 
@@ -167,6 +425,20 @@ func (s *Server) SocketPath() string {
 	return s.sockPath
 }
 
+// TLSFingerprint returns the SHA-256 fingerprint of the self-signed
+// certificate, or "" when serving plain HTTP.
+func (s *Server) TLSFingerprint() string {
+	return s.tlsFingerprint
+}
+
+// Scheme returns "https" when serving TLS and "http" otherwise.
+func (s *Server) Scheme() string {
+	if s.tlsConfig != nil {
+		return "https"
+	}
+	return "http"
+}
+
 // --- Response helpers ---
 
 type jsonResponse struct {
@@ -188,6 +460,17 @@ func writeOK(w http.ResponseWriter, data interface{}) {
 	writeJSON(w, http.StatusOK, data)
 }
 
+// splitList splits a comma-separated query value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 // --- Handlers ---
 
 func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
@@ -212,6 +495,7 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	ctx := r.Context()
 
 	s.mu.RLock()
 	wa := s.wa
@@ -224,13 +508,13 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 
 	// Count chats (fast query)
 	var chatsCount int64
-	chats, err := s.db.ListChats("", 100000)
+	chats, err := s.db.ListChats(ctx, "", 100000)
 	if err == nil {
 		chatsCount = int64(len(chats))
 	}
 
 	// Count messages
-	msgsCount, _ := s.db.CountMessages()
+	msgsCount, _ := s.db.CountMessages(ctx)
 
 	resp := statusResponse{
 		OK:            true,
@@ -245,15 +529,19 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 type chatJSON struct {
-	JID           string `json:"jid"`
-	Kind          string `json:"kind"`
-	Name          string `json:"name"`
-	LastMessageTS string `json:"last_message_ts"`
+	JID               string `json:"jid"`
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	ParticipantsCount *int   `json:"participants_count"`
+	LastMessageTS     string `json:"last_message_ts"`
+	AvatarURL         string `json:"avatar_url,omitempty"`
 }
 
 type chatsResponse struct {
-	OK    bool       `json:"ok"`
-	Chats []chatJSON `json:"chats"`
+	OK         bool       `json:"ok"`
+	Chats      []chatJSON `json:"chats"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 func (s *Server) handleChats(w http.ResponseWriter, r *http.Request) {
@@ -261,6 +549,7 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	ctx := r.Context()
 
 	query := r.URL.Query().Get("query")
 	limitStr := r.URL.Query().Get("limit")
@@ -271,23 +560,45 @@ func (s *Server) handleChats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	chats, err := s.db.ListChats(query, limit)
+	var cursor *store.ChatCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		c, err := store.ParseChatCursor(cursorStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		cursor = &c
+	}
+
+	page, err := s.db.ListChatsPage(ctx, store.ListChatsParams{
+		Query:  query,
+		Limit:  limit,
+		Cursor: cursor,
+	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	chats := page.Chats
 
 	out := make([]chatJSON, len(chats))
 	for i, c := range chats {
 		out[i] = chatJSON{
-			JID:           c.JID,
-			Kind:          c.Kind,
-			Name:          c.Name,
-			LastMessageTS: c.LastMessageTS.Format(time.RFC3339),
+			JID:               c.JID,
+			Kind:              c.Kind,
+			Name:              c.Name,
+			Description:       c.Description,
+			ParticipantsCount: c.ParticipantsCount,
+			LastMessageTS:     c.LastMessageTS.Format(time.RFC3339),
+			AvatarURL:         c.AvatarURL,
 		}
 	}
 
-	writeOK(w, chatsResponse{OK: true, Chats: out})
+	resp := chatsResponse{OK: true, Chats: out}
+	if page.NextCursor != nil {
+		resp.NextCursor = page.NextCursor.Encode()
+	}
+	writeOK(w, resp)
 }
 
 type messageJSON struct {
@@ -295,16 +606,63 @@ type messageJSON struct {
 	ChatName    string `json:"chat_name"`
 	MsgID       string `json:"msg_id"`
 	SenderJID   string `json:"sender_jid"`
+	SenderName  string `json:"sender_name"`
 	Timestamp   string `json:"timestamp"`
 	FromMe      bool   `json:"from_me"`
 	Text        string `json:"text"`
 	DisplayText string `json:"display_text"`
 	MediaType   string `json:"media_type,omitempty"`
+	// ForwardedScore is 0 for messages that were not forwarded; 5 and up
+	// means "forwarded many times".
+	ForwardedScore uint32 `json:"forwarded_score"`
+	ReplyToMsgID   string `json:"reply_to_msg_id,omitempty"`
+	// Snippet is the matched fragment with hits wrapped in [] (or <b></b>
+	// with highlight); only set by /search when FTS is available.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+func newMessageJSON(m store.Message) messageJSON {
+	return messageJSON{
+		ChatJID:        m.ChatJID,
+		ChatName:       m.ChatName,
+		MsgID:          m.MsgID,
+		SenderJID:      m.SenderJID,
+		SenderName:     m.SenderName,
+		Timestamp:      m.Timestamp.Format(time.RFC3339),
+		FromMe:         m.FromMe,
+		Text:           m.Text,
+		DisplayText:    m.DisplayText,
+		MediaType:      m.MediaType,
+		ForwardedScore: m.ForwardedScore,
+		ReplyToMsgID:   m.ReplyToMsgID,
+		Snippet:        m.Snippet,
+	}
+}
+
+// messageMediaTypes are the media_type values messages are stored with.
+var messageMediaTypes = map[string]bool{
+	"image": true, "video": true, "gif": true, "audio": true,
+	"document": true, "sticker": true, "location": true,
 }
 
 type messagesResponse struct {
-	OK       bool          `json:"ok"`
-	Messages []messageJSON `json:"messages"`
+	OK         bool           `json:"ok"`
+	Messages   []messageJSON  `json:"messages"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Filter     messagesFilter `json:"filter"`
+}
+
+// messagesFilter echoes the filters a /messages request applied.
+type messagesFilter struct {
+	ChatJID    string   `json:"chat_jid"`
+	SenderJID  string   `json:"sender_jid,omitempty"`
+	SenderName string   `json:"sender_name,omitempty"`
+	FromMe     *bool    `json:"from_me,omitempty"`
+	MediaTypes []string `json:"media_types,omitempty"`
+	HasMedia   *bool    `json:"has_media,omitempty"`
+	Order      string   `json:"order"`
+	Before     string   `json:"before,omitempty"`
+	After      string   `json:"after,omitempty"`
 }
 
 func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
@@ -312,6 +670,7 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	ctx := r.Context()
 
 	chatJID := r.URL.Query().Get("chat_jid")
 	if chatJID == "" {
@@ -324,56 +683,152 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 		limit = l
 	}
 
-	var before, after *time.Time
-	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
-		if t, err := time.Parse(time.RFC3339, beforeStr); err == nil {
-			before = &t
+	before, err := parseTimeParam("before", r.URL.Query().Get("before"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	after, err := parseTimeParam("after", r.URL.Query().Get("after"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var fromMe *bool
+	if fromMeStr := r.URL.Query().Get("from_me"); fromMeStr != "" {
+		v, err := strconv.ParseBool(fromMeStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "from_me must be true or false")
+			return
 		}
+		fromMe = &v
 	}
-	if afterStr := r.URL.Query().Get("after"); afterStr != "" {
-		if t, err := time.Parse(time.RFC3339, afterStr); err == nil {
-			after = &t
+
+	mediaTypes := splitList(r.URL.Query().Get("media_type"))
+	for _, t := range mediaTypes {
+		if !messageMediaTypes[t] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown media_type %q (use image, video, gif, audio, document, sticker or location)", t))
+			return
+		}
+	}
+	var hasMedia *bool
+	if hasMediaStr := r.URL.Query().Get("has_media"); hasMediaStr != "" {
+		v, err := strconv.ParseBool(hasMediaStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "has_media must be true or false")
+			return
+		}
+		if !v && len(mediaTypes) > 0 {
+			writeError(w, http.StatusBadRequest, "has_media=false cannot be combined with media_type")
+			return
 		}
+		hasMedia = &v
 	}
 
-	msgs, err := s.db.ListMessages(store.ListMessagesParams{
-		ChatJID: chatJID,
-		Limit:   limit,
-		Before:  before,
-		After:   after,
-	})
+	order := r.URL.Query().Get("order")
+	switch order {
+	case "":
+		order = store.OrderDesc
+	case store.OrderDesc, store.OrderAsc:
+	default:
+		writeError(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+
+	// The cursor continues in the page's order: towards older messages
+	// when descending, newer ones when ascending.
+	var beforeCursor, afterCursor *store.MessageCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		c, err := store.ParseMessageCursor(cursorStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if order == store.OrderAsc {
+			afterCursor = &c
+		} else {
+			beforeCursor = &c
+		}
+	}
+
+	params := store.ListMessagesParams{
+		ChatJID:          chatJID,
+		SenderJID:        strings.TrimSpace(r.URL.Query().Get("sender_jid")),
+		SenderNameSearch: strings.TrimSpace(r.URL.Query().Get("sender_name")),
+		FromMe:           fromMe,
+		MediaTypes:       mediaTypes,
+		HasMedia:         hasMedia,
+		Limit:            limit,
+		Before:           before,
+		After:            after,
+		BeforeCursor:     beforeCursor,
+		AfterCursor:      afterCursor,
+		Order:            order,
+	}
+	page, err := s.db.ListMessagesPage(ctx, params)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	msgs := page.Messages
 
 	out := make([]messageJSON, len(msgs))
 	for i, m := range msgs {
-		out[i] = messageJSON{
-			ChatJID:     m.ChatJID,
-			ChatName:    m.ChatName,
-			MsgID:       m.MsgID,
-			SenderJID:   m.SenderJID,
-			Timestamp:   m.Timestamp.Format(time.RFC3339),
-			FromMe:      m.FromMe,
-			Text:        m.Text,
-			DisplayText: m.DisplayText,
-			MediaType:   m.MediaType,
-		}
+		out[i] = newMessageJSON(m)
 	}
 
-	writeOK(w, messagesResponse{OK: true, Messages: out})
+	resp := messagesResponse{OK: true, Messages: out, Filter: messagesFilter{
+		ChatJID:    params.ChatJID,
+		SenderJID:  params.SenderJID,
+		SenderName: params.SenderNameSearch,
+		FromMe:     params.FromMe,
+		MediaTypes: params.MediaTypes,
+		HasMedia:   params.HasMedia,
+		Order:      params.Order,
+	}}
+	if before != nil {
+		resp.Filter.Before = before.Format(time.RFC3339)
+	}
+	if after != nil {
+		resp.Filter.After = after.Format(time.RFC3339)
+	}
+	if page.NextCursor != nil {
+		resp.NextCursor = page.NextCursor.Encode()
+	}
+	writeOK(w, resp)
 }
 
 type searchRequest struct {
 	Query   string `json:"query"`
 	ChatJID string `json:"chat_jid"`
 	Limit   int    `json:"limit"`
+	Fuzzy   bool   `json:"fuzzy"`
+	// SnippetLen is the number of tokens of context in snippets (FTS only).
+	SnippetLen int `json:"snippet_len"`
+	// Highlight wraps matches in snippets in <b></b> instead of [].
+	Highlight bool `json:"highlight"`
+	// After and Before (RFC3339) limit results to messages strictly
+	// between them.
+	After  string `json:"after"`
+	Before string `json:"before"`
+}
+
+// parseTimeParam parses an optional RFC3339 timestamp; empty gives nil.
+func parseTimeParam(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 timestamp", name)
+	}
+	return &t, nil
 }
 
 type searchResponse struct {
-	OK      bool          `json:"ok"`
-	Results []messageJSON `json:"results"`
+	OK         bool          `json:"ok"`
+	Results    []messageJSON `json:"results"`
+	TotalCount int64         `json:"total_count"`
 }
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
@@ -381,6 +836,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
+	ctx := r.Context()
 
 	var req searchRequest
 
@@ -390,6 +846,11 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil {
 			req.Limit = l
 		}
+		req.Fuzzy, _ = strconv.ParseBool(r.URL.Query().Get("fuzzy"))
+		req.SnippetLen, _ = strconv.Atoi(r.URL.Query().Get("snippet_len"))
+		req.Highlight, _ = strconv.ParseBool(r.URL.Query().Get("highlight"))
+		req.After = r.URL.Query().Get("after")
+		req.Before = r.URL.Query().Get("before")
 	} else {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
@@ -404,45 +865,82 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if req.Limit <= 0 {
 		req.Limit = 50
 	}
+	after, err := parseTimeParam("after", req.After)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before, err := parseTimeParam("before", req.Before)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if after != nil && before != nil && !after.Before(*before) {
+		writeError(w, http.StatusBadRequest, "after must be earlier than before")
+		return
+	}
 
-	msgs, err := s.db.SearchMessages(store.SearchMessagesParams{
+	res, err := s.db.SearchMessagesWithCount(ctx, store.SearchMessagesParams{
 		Query:   req.Query,
 		ChatJID: req.ChatJID,
 		Limit:   req.Limit,
+		Fuzzy:   req.Fuzzy,
+		After:   after,
+		Before:  before,
+
+		SnippetLen: req.SnippetLen,
+		Highlight:  req.Highlight,
 	})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	msgs := res.Messages
 
 	out := make([]messageJSON, len(msgs))
 	for i, m := range msgs {
-		out[i] = messageJSON{
-			ChatJID:     m.ChatJID,
-			ChatName:    m.ChatName,
-			MsgID:       m.MsgID,
-			SenderJID:   m.SenderJID,
-			Timestamp:   m.Timestamp.Format(time.RFC3339),
-			FromMe:      m.FromMe,
-			Text:        m.Text,
-			DisplayText: m.DisplayText,
-			MediaType:   m.MediaType,
-		}
+		out[i] = newMessageJSON(m)
 	}
 
-	writeOK(w, searchResponse{OK: true, Results: out})
+	writeOK(w, searchResponse{OK: true, Results: out, TotalCount: res.TotalCount})
 }
 
 type sendRequest struct {
 	To      string `json:"to"`
-	Message string `json:"message"`
+	Message string `json:"message"`  // caption when media is attached
 	ChatJID string `json:"chat_jid"` // alias for 'to'
+
+	// Optional attachment, either fetched from MediaURL or decoded from
+	// MediaBase64 (at most 10 MB).
+	MediaURL    string `json:"media_url"`
+	MediaBase64 string `json:"media_base64"`
+	MediaType   string `json:"media_type"` // image, document, audio or video; guessed if empty
+	Filename    string `json:"filename"`
+
+	// ReplyToMsgID quotes a stored message (text only); it is looked up
+	// in ReplyToChatJID, which defaults to the recipient's chat.
+	ReplyToMsgID   string `json:"reply_to_msg_id"`
+	ReplyToChatJID string `json:"reply_to_chat_jid"`
+
+	// LocationLat and LocationLng send a location pin instead of text,
+	// labelled LocationName when set. Message is ignored.
+	LocationLat  *float64 `json:"location_lat"`
+	LocationLng  *float64 `json:"location_lng"`
+	LocationName string   `json:"location_name"`
+
+	// SendAt (RFC3339) queues a text message to be sent at that time
+	// instead of now; see GET /scheduled.
+	SendAt string `json:"send_at"`
 }
 
 type sendResponse struct {
 	OK        bool   `json:"ok"`
 	MessageID string `json:"message_id,omitempty"`
 	Error     string `json:"error,omitempty"`
+
+	// Set instead of MessageID when the message was scheduled.
+	ScheduledID int64  `json:"scheduled_id,omitempty"`
+	SendAt      string `json:"send_at,omitempty"`
 }
 
 func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
@@ -451,18 +949,6 @@ func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.mu.RLock()
-	waClient := s.wa
-	s.mu.RUnlock()
-
-	if waClient == nil || !waClient.IsConnected() {
-		writeJSON(w, http.StatusServiceUnavailable, sendResponse{
-			OK:    false,
-			Error: "WhatsApp not connected",
-		})
-		return
-	}
-
 	var req sendRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSON(w, http.StatusBadRequest, sendResponse{
@@ -483,29 +969,106 @@ func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	if strings.TrimSpace(req.Message) == "" {
+	// Parse the recipient first so a bad one doesn't cost a media download.
+	toJID, err := wa.ParseUserOrJID(to)
+	if err != nil {
 		writeJSON(w, http.StatusBadRequest, sendResponse{
 			OK:    false,
-			Error: "message is required",
+			Error: "invalid recipient: " + err.Error(),
 		})
 		return
 	}
+	if strings.TrimSpace(req.SendAt) != "" {
+		s.scheduleSend(w, r, toJID, req)
+		return
+	}
+	// Only sending now needs WhatsApp; scheduled messages wait for it.
+	if !s.awaitWA(w, r) {
+		return
+	}
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
 
-	toJID, err := wa.ParseUserOrJID(to)
+	isLocation := req.LocationLat != nil || req.LocationLng != nil
+	if isLocation {
+		if err := validateLocation(req); err != nil {
+			writeJSON(w, http.StatusBadRequest, sendResponse{OK: false, Error: err.Error()})
+			return
+		}
+		if req.MediaURL != "" || req.MediaBase64 != "" || strings.TrimSpace(req.ReplyToMsgID) != "" {
+			writeJSON(w, http.StatusBadRequest, sendResponse{
+				OK:    false,
+				Error: "location can't be combined with media or reply_to_msg_id",
+			})
+			return
+		}
+	}
+
+	media, err := loadMedia(r.Context(), req)
 	if err != nil {
+		status := http.StatusBadRequest
+		var me *mediaError
+		if errors.As(err, &me) {
+			status = me.status
+		}
+		writeJSON(w, status, sendResponse{OK: false, Error: err.Error()})
+		return
+	}
+	if media == nil && !isLocation && strings.TrimSpace(req.Message) == "" {
 		writeJSON(w, http.StatusBadRequest, sendResponse{
 			OK:    false,
-			Error: "invalid recipient: " + err.Error(),
+			Error: "message is required",
 		})
 		return
 	}
+	replyTo := strings.TrimSpace(req.ReplyToMsgID)
+	replyChat := toJID
+	if replyTo != "" {
+		if media != nil {
+			writeJSON(w, http.StatusBadRequest, sendResponse{
+				OK:    false,
+				Error: "reply_to_msg_id is only supported for text messages",
+			})
+			return
+		}
+		if c := strings.TrimSpace(req.ReplyToChatJID); c != "" {
+			if replyChat, err = wa.ParseChatJID(c); err != nil {
+				writeJSON(w, http.StatusBadRequest, sendResponse{
+					OK:    false,
+					Error: "invalid reply_to_chat_jid: " + err.Error(),
+				})
+				return
+			}
+		}
+		if _, err := s.db.GetMessage(r.Context(), replyChat.String(), replyTo); err != nil {
+			status, msg := http.StatusInternalServerError, err.Error()
+			if errors.Is(err, sql.ErrNoRows) {
+				status, msg = http.StatusNotFound, "reply_to message not found"
+			}
+			writeJSON(w, status, sendResponse{OK: false, Error: msg})
+			return
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	msgID, err := waClient.SendText(ctx, toJID, req.Message)
+	var msgID types.MessageID
+	switch {
+	case isLocation:
+		msgID, err = waClient.SendLocation(ctx, toJID, *req.LocationLat, *req.LocationLng, req.LocationName)
+	case replyTo != "":
+		msgID, err = waClient.SendTextWithReply(ctx, toJID, req.Message, replyTo, replyChat.String())
+	case media == nil:
+		msgID, err = waClient.SendText(ctx, toJID, req.Message)
+	case media.kind == "image":
+		msgID, err = waClient.SendImage(ctx, toJID, req.Message, media.data)
+	default:
+		msgID, err = waClient.SendDocument(ctx, toJID, media.filename, media.mimeType, media.data)
+	}
 	if err != nil {
-		s.log.Error().Err(err).Str("to", to).Msg("failed to send message via RPC")
+		s.log.Error().Err(err).Str("to", to).Str("remote", s.clientIP(r)).Msg("failed to send message via RPC")
 		writeJSON(w, http.StatusInternalServerError, sendResponse{
 			OK:    false,
 			Error: "send failed: " + err.Error(),
@@ -513,31 +1076,116 @@ func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.log.Info().Str("to", to).Str("msg_id", string(msgID)).Msg("message sent via RPC")
+	s.log.Info().Str("to", to).Str("msg_id", string(msgID)).Str("remote", s.clientIP(r)).Msg("message sent via RPC")
+	s.metrics.messageSent()
 
-	// Store the sent message in DB.
-	now := time.Now().UTC()
-	chatName := waClient.ResolveChatName(ctx, toJID, "")
-	kind := "dm"
-	if toJID.Server == types.GroupServer {
-		kind = "group"
-	} else if toJID.IsBroadcastList() {
-		kind = "broadcast"
+	stored := store.UpsertMessageParams{
+		MsgID:        string(msgID),
+		Text:         req.Message,
+		ReplyToMsgID: replyTo,
 	}
-	_ = s.db.UpsertChat(toJID.String(), kind, chatName, now)
-	_ = s.db.UpsertMessage(store.UpsertMessageParams{
-		ChatJID:    toJID.String(),
-		ChatName:   chatName,
-		MsgID:      string(msgID),
-		SenderJID:  "",
-		SenderName: "me",
-		Timestamp:  now,
-		FromMe:     true,
-		Text:       req.Message,
-	})
+	if isLocation {
+		stored.Text = locationText(*req.LocationLat, *req.LocationLng, req.LocationName)
+		stored.MediaType = "location"
+	}
+	if media != nil {
+		stored.MediaType = media.kind
+		stored.MediaCaption = req.Message
+		stored.Filename = media.filename
+		stored.MimeType = media.mimeType
+	}
+	s.storeSent(ctx, waClient, toJID, stored)
 
 	writeJSON(w, http.StatusOK, sendResponse{
 		OK:        true,
 		MessageID: string(msgID),
 	})
 }
+
+// validateLocation checks that req carries both coordinates, in range.
+func validateLocation(req sendRequest) error {
+	if req.LocationLat == nil || req.LocationLng == nil {
+		return errors.New("location_lat and location_lng are both required")
+	}
+	if lat := *req.LocationLat; lat < -90 || lat > 90 {
+		return fmt.Errorf("location_lat %v is out of range [-90,90]", lat)
+	}
+	if lng := *req.LocationLng; lng < -180 || lng > 180 {
+		return fmt.Errorf("location_lng %v is out of range [-180,180]", lng)
+	}
+	return nil
+}
+
+// locationText is how a sent location is stored: "📍 name (lat,lng)".
+func locationText(lat, lng float64, name string) string {
+	coords := "(" + strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64) + ")"
+	if name = strings.TrimSpace(name); name != "" {
+		return "📍 " + name + " " + coords
+	}
+	return "📍 " + coords
+}
+
+// scheduleSend queues req's text for toJID at req.SendAt.
+func (s *Server) scheduleSend(w http.ResponseWriter, r *http.Request, toJID types.JID, req sendRequest) {
+	sendAt, err := time.Parse(time.RFC3339, strings.TrimSpace(req.SendAt))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, sendResponse{
+			OK:    false,
+			Error: "invalid send_at (want RFC3339): " + err.Error(),
+		})
+		return
+	}
+	if req.MediaURL != "" || req.MediaBase64 != "" || strings.TrimSpace(req.ReplyToMsgID) != "" ||
+		req.LocationLat != nil || req.LocationLng != nil {
+		writeJSON(w, http.StatusBadRequest, sendResponse{
+			OK:    false,
+			Error: "send_at is only supported for plain text messages",
+		})
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		writeJSON(w, http.StatusBadRequest, sendResponse{
+			OK:    false,
+			Error: "message is required",
+		})
+		return
+	}
+
+	id, err := s.db.UpsertScheduledMessage(r.Context(), store.ScheduledMessage{
+		To:      toJID.String(),
+		Message: req.Message,
+		SendAt:  sendAt,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, sendResponse{OK: false, Error: err.Error()})
+		return
+	}
+	s.log.Info().Str("to", toJID.String()).Int64("scheduled_id", id).Time("send_at", sendAt).Str("remote", s.clientIP(r)).Msg("message scheduled via RPC")
+	writeJSON(w, http.StatusAccepted, sendResponse{
+		OK:          true,
+		ScheduledID: id,
+		SendAt:      sendAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// storeSent records a message we sent to `to`, so it is in the store before
+// sync echoes it back. p carries the message ID and content; the chat,
+// sender and time are filled in here.
+func (s *Server) storeSent(ctx context.Context, waClient WAClient, to types.JID, p store.UpsertMessageParams) {
+	now := time.Now().UTC()
+	chatName := waClient.ResolveChatName(ctx, to, "")
+	kind := "dm"
+	if wa.IsGroupJID(to) {
+		kind = "group"
+	} else if wa.IsBroadcastJID(to) {
+		kind = "broadcast"
+	}
+	_, _, _ = s.db.UpsertChat(ctx, to.String(), kind, chatName, "", now)
+
+	p.ChatJID = to.String()
+	p.ChatName = chatName
+	p.SenderName = "me"
+	p.Timestamp = now
+	p.FromMe = true
+	_, _, _ = s.db.UpsertMessage(ctx, p)
+}
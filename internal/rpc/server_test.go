@@ -1,13 +1,21 @@
 package rpc
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,18 +23,13 @@ import (
 	"go.mau.fi/whatsmeow/types"
 )
 
-func setupTestDB(t *testing.T) (*store.DB, func()) {
+func setupTestDB(t testing.TB) (*store.DB, func()) {
 	t.Helper()
-	dir := t.TempDir()
-	dbPath := filepath.Join(dir, "test.db")
-	db, err := store.Open(dbPath)
+	db, err := store.OpenMemory()
 	if err != nil {
 		t.Fatalf("open db: %v", err)
 	}
-	return db, func() {
-		_ = db.Close()
-		_ = os.RemoveAll(dir)
-	}
+	return db, func() { _ = db.Close() }
 }
 
 func TestServer_Ping(t *testing.T) {
@@ -62,6 +65,61 @@ func TestServer_Ping(t *testing.T) {
 	}
 }
 
+func TestServer_AddrResolvesEphemeralPort(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Stop(ctx)
+	}()
+
+	if strings.HasSuffix(srv.Addr(), ":0") {
+		t.Fatalf("expected bound port, got %q", srv.Addr())
+	}
+	resp, err := http.Get("http://" + srv.Addr() + "/ping")
+	if err != nil {
+		t.Fatalf("GET /ping: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestNew_CopiesOptions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	opts := Options{
+		Addr:           "localhost:5555",
+		DB:             db,
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+	srv, err := New(opts)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	opts.Addr = "localhost:6666"
+	opts.TrustedProxies[0] = netip.MustParsePrefix("0.0.0.0/0")
+
+	if got := srv.Addr(); got != "localhost:5555" {
+		t.Errorf("expected Addr to stay localhost:5555, got %q", got)
+	}
+	if !srv.isTrustedProxy(netip.MustParseAddr("10.1.2.3")) || srv.isTrustedProxy(netip.MustParseAddr("192.168.1.1")) {
+		t.Errorf("trusted proxies changed after New: %v", srv.trustedProxies)
+	}
+}
+
 func TestServer_Status(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -96,13 +154,45 @@ func TestServer_Status(t *testing.T) {
 	}
 }
 
+func TestServer_Status_ConcurrentSyncToggle(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{connected: true}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			srv.SetSyncRunning(i%2 == 0)
+		}(i)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			srv.handleStatus(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("expected 200, got %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func TestServer_Chats(t *testing.T) {
+	ctx := context.Background()
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	// Insert test chats
-	_ = db.UpsertChat("123@s.whatsapp.net", "dm", "Alice", time.Now())
-	_ = db.UpsertChat("456@g.us", "group", "Test Group", time.Now())
+	_, _, _ = db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertChat(ctx, "456@g.us", "group", "Test Group", "", time.Now().Add(-time.Minute))
+	if err := db.SetChatAvatar(ctx, "123@s.whatsapp.net", "https://pps.whatsapp.net/alice.jpg"); err != nil {
+		t.Fatalf("SetChatAvatar: %v", err)
+	}
 
 	srv, err := New(Options{Addr: "localhost:0", DB: db})
 	if err != nil {
@@ -129,24 +219,32 @@ func TestServer_Chats(t *testing.T) {
 		t.Errorf("expected ok=true")
 	}
 	if len(resp.Chats) != 2 {
-		t.Errorf("expected 2 chats, got %d", len(resp.Chats))
+		t.Fatalf("expected 2 chats, got %d", len(resp.Chats))
+	}
+	if got := resp.Chats[0].AvatarURL; got != "https://pps.whatsapp.net/alice.jpg" {
+		t.Errorf("expected avatar_url for Alice, got %q", got)
+	}
+	if got := resp.Chats[1].AvatarURL; got != "" {
+		t.Errorf("expected no avatar_url for the group, got %q", got)
 	}
 }
 
 func TestServer_Messages(t *testing.T) {
+	ctx := context.Background()
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	chatJID := "123@s.whatsapp.net"
-	_ = db.UpsertChat(chatJID, "dm", "Alice", time.Now())
-	_ = db.UpsertMessage(store.UpsertMessageParams{
-		ChatJID:   chatJID,
-		ChatName:  "Alice",
-		MsgID:     "msg1",
-		SenderJID: chatJID,
-		Timestamp: time.Now(),
-		FromMe:    false,
-		Text:      "Hello!",
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID:    chatJID,
+		ChatName:   "Alice",
+		MsgID:      "msg1",
+		SenderJID:  chatJID,
+		SenderName: "Alice",
+		Timestamp:  time.Now(),
+		FromMe:     false,
+		Text:       "Hello!",
 	})
 
 	srv, err := New(Options{Addr: "localhost:0", DB: db})
@@ -188,15 +286,19 @@ func TestServer_Messages(t *testing.T) {
 	if resp.Messages[0].Text != "Hello!" {
 		t.Errorf("expected 'Hello!', got %q", resp.Messages[0].Text)
 	}
+	if resp.Messages[0].SenderName != "Alice" {
+		t.Errorf("expected sender_name 'Alice', got %q", resp.Messages[0].SenderName)
+	}
 }
 
 func TestServer_Search(t *testing.T) {
+	ctx := context.Background()
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	chatJID := "123@s.whatsapp.net"
-	_ = db.UpsertChat(chatJID, "dm", "Alice", time.Now())
-	_ = db.UpsertMessage(store.UpsertMessageParams{
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
 		ChatJID:   chatJID,
 		ChatName:  "Alice",
 		MsgID:     "msg1",
@@ -205,7 +307,7 @@ func TestServer_Search(t *testing.T) {
 		FromMe:    false,
 		Text:      "Hello world!",
 	})
-	_ = db.UpsertMessage(store.UpsertMessageParams{
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
 		ChatJID:   chatJID,
 		ChatName:  "Alice",
 		MsgID:     "msg2",
@@ -257,19 +359,189 @@ func TestServer_Search(t *testing.T) {
 }
 
 // mockWA is a mock WhatsApp client for testing.
+type mockMedia struct {
+	kind     string
+	caption  string
+	filename string
+	mimeType string
+	data     []byte
+}
+
 type mockWA struct {
 	connected bool
 	sentMsgs  []string
+	sendMu    sync.Mutex // guards sentMsgs for concurrent senders
+
+	// SendErrorFor fails SendText for the listed recipient JIDs.
+	SendErrorFor map[string]error
+
+	// SendError, if set, is returned by SendText instead of sending.
+	SendError error
+	// SendDelay makes SendText wait (or until ctx is done) before returning.
+	SendDelay time.Duration
+	// HealthErr is returned by HealthCheck.
+	HealthErr error
+	// sentMedia records SendImage and SendDocument calls.
+	sentMedia []mockMedia
+	// replies records SendTextWithReply calls as "quotedChatJID/quotedMsgID".
+	replies []string
+	// locations records SendLocation calls as "lat,lng name".
+	locations []string
+	// reactions records SendReaction calls as "msgID:reaction".
+	reactions []string
+	// readIDs records the message IDs passed to MarkRead.
+	readIDs []types.MessageID
+	// presence records SendChatPresence states.
+	presence []string
+	// groups is served by GetGroupInfo; groupCalls counts the calls.
+	groups     map[types.JID]*types.GroupInfo
+	groupCalls int
+
+	subMu sync.Mutex
+	subs  []chan<- store.Message
 }
 
-func (m *mockWA) IsConnected() bool { return m.connected }
+func (m *mockWA) IsConnected() bool                     { return m.connected }
+func (m *mockWA) HealthCheck(ctx context.Context) error { return m.HealthErr }
 func (m *mockWA) SendText(ctx context.Context, to types.JID, text string) (types.MessageID, error) {
+	if m.SendDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(m.SendDelay):
+		}
+	}
+	if m.SendError != nil {
+		return "", m.SendError
+	}
+	if err := m.SendErrorFor[to.String()]; err != nil {
+		return "", err
+	}
+	m.sendMu.Lock()
 	m.sentMsgs = append(m.sentMsgs, text)
+	m.sendMu.Unlock()
 	return "test_msg_id", nil
 }
+func (m *mockWA) SendTextWithReply(ctx context.Context, to types.JID, text, quotedMsgID, quotedChatJID string) (types.MessageID, error) {
+	if m.SendError != nil {
+		return "", m.SendError
+	}
+	m.sentMsgs = append(m.sentMsgs, text)
+	m.replies = append(m.replies, quotedChatJID+"/"+quotedMsgID)
+	return "test_reply_id", nil
+}
+func (m *mockWA) SendLocation(ctx context.Context, to types.JID, lat, lng float64, name string) (types.MessageID, error) {
+	if m.SendError != nil {
+		return "", m.SendError
+	}
+	m.locations = append(m.locations, fmt.Sprintf("%v,%v %s", lat, lng, name))
+	return "test_location_id", nil
+}
+func (m *mockWA) SendImage(ctx context.Context, to types.JID, caption string, data []byte) (types.MessageID, error) {
+	if m.SendError != nil {
+		return "", m.SendError
+	}
+	m.sentMedia = append(m.sentMedia, mockMedia{kind: "image", caption: caption, data: data})
+	return "test_media_id", nil
+}
+func (m *mockWA) SendDocument(ctx context.Context, to types.JID, filename, mimeType string, data []byte) (types.MessageID, error) {
+	if m.SendError != nil {
+		return "", m.SendError
+	}
+	m.sentMedia = append(m.sentMedia, mockMedia{kind: "document", filename: filename, mimeType: mimeType, data: data})
+	return "test_media_id", nil
+}
+func (m *mockWA) SendReaction(ctx context.Context, chat types.JID, msgID types.MessageID, reaction string) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.reactions = append(m.reactions, string(msgID)+":"+reaction)
+	return nil
+}
+func (m *mockWA) MarkRead(ctx context.Context, chat types.JID, msgIDs []types.MessageID) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.readIDs = append(m.readIDs, msgIDs...)
+	return nil
+}
+func (m *mockWA) SendChatPresence(ctx context.Context, chat types.JID, state string) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.presence = append(m.presence, state)
+	return nil
+}
+func (m *mockWA) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupInfo, error) {
+	m.groupCalls++
+	if gi, ok := m.groups[jid]; ok {
+		return gi, nil
+	}
+	return nil, errors.New("group not found")
+}
 func (m *mockWA) ResolveChatName(ctx context.Context, chat types.JID, pushName string) string {
 	return "Test Chat"
 }
+func (m *mockWA) Subscribe(ch chan<- store.Message) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	m.subs = append(m.subs, ch)
+}
+func (m *mockWA) Unsubscribe(ch chan<- store.Message) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	m.subs = slices.DeleteFunc(m.subs, func(c chan<- store.Message) bool { return c == ch })
+}
+
+// deliver pushes msg to every subscriber, as the sync loop would.
+func (m *mockWA) deliver(msg store.Message) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	for _, ch := range m.subs {
+		ch <- msg
+	}
+}
+
+func FuzzHandleSearch(f *testing.F) {
+	for _, seed := range []string{
+		`{"query":"hello"}`,
+		`{"query":"hello","chat_jid":"123@s.whatsapp.net","limit":5,"fuzzy":true}`,
+		`{"query":"' OR 1=1 --"}`,
+		`{"query":"\"unterminated"}`,
+		`{"query":"a*","limit":-1}`,
+		`{"query":null}`,
+		`{"query":"hello",}`,
+		`{"query":["hello"]}`,
+		`[]`,
+		``,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	db, cleanup := setupTestDB(f)
+	f.Cleanup(cleanup)
+	ctx := context.Background()
+	chatJID := "123@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "msg1", Timestamp: time.Now(), Text: "hello world"})
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		f.Fatalf("new server: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		req := httptest.NewRequest(http.MethodPost, "/search", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.handleSearch(w, req)
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("expected JSON content type, got %q", ct)
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Fatalf("invalid JSON response (status %d): %q", w.Code, w.Body.String())
+		}
+	})
+}
 
 func TestServer_Send(t *testing.T) {
 	db, cleanup := setupTestDB(t)
@@ -286,7 +558,7 @@ func TestServer_Send(t *testing.T) {
 	mux.HandleFunc("/send", srv.handleSend)
 
 	// Test send
-	body := `{"to": "123456789", "message": "Hello from RPC!"}`
+	body := `{"to": "14155552671", "message": "Hello from RPC!"}`
 	req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -312,6 +584,190 @@ func TestServer_Send(t *testing.T) {
 	}
 }
 
+func TestServer_Send_ReplyTo(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chat := "14155552671@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chat, MsgID: "q1", SenderJID: chat, Timestamp: time.Now().Add(-time.Minute), Text: "lunch?"})
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := send(`{"to": "14155552671", "message": "yes", "reply_to_msg_id": "q1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mock.replies) != 1 || mock.replies[0] != chat+"/q1" {
+		t.Fatalf("expected a reply quoting q1, got %v", mock.replies)
+	}
+	stored, err := db.GetMessage(ctx, chat, "test_reply_id")
+	if err != nil || stored.ReplyToMsgID != "q1" {
+		t.Fatalf("stored reply = %+v, %v", stored, err)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chat, nil))
+	var page messagesResponse
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Messages) != 2 || page.Messages[0].ReplyToMsgID != "q1" || page.Messages[1].ReplyToMsgID != "" {
+		t.Fatalf("/messages = %+v", page.Messages)
+	}
+
+	if w := send(`{"to": "14155552671", "message": "?", "reply_to_msg_id": "missing"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("missing quote: expected 404, got %d", w.Code)
+	}
+	if w := send(`{"to": "14155552671", "message": "?", "reply_to_msg_id": "q1", "reply_to_chat_jid": "not a phone"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad reply chat: expected 400, got %d", w.Code)
+	}
+	if w := send(`{"to": "14155552671", "reply_to_msg_id": "q1", "media_base64": "aGVsbG8=", "filename": "a.txt"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("media reply: expected 400, got %d", w.Code)
+	}
+	if len(mock.replies) != 1 {
+		t.Fatalf("expected no further sends, got %v", mock.replies)
+	}
+}
+
+func TestServer_Send_Location(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	h := srv.Handler()
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)))
+		return w
+	}
+
+	w := send(`{"to": "14155552671", "location_lat": 52.52, "location_lng": -13.405, "location_name": "Office"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mock.locations) != 1 || mock.locations[0] != "52.52,-13.405 Office" || len(mock.sentMsgs) != 0 {
+		t.Fatalf("expected one location sent, got %v (texts %v)", mock.locations, mock.sentMsgs)
+	}
+	stored, err := db.GetMessage(ctx, "14155552671@s.whatsapp.net", "test_location_id")
+	if err != nil || stored.Text != "📍 Office (52.52,-13.405)" || stored.MediaType != "location" {
+		t.Fatalf("stored location = %+v, %v", stored, err)
+	}
+	if w := send(`{"to": "14155552671", "location_lat": 0, "location_lng": 0}`); w.Code != http.StatusOK {
+		t.Fatalf("unnamed location at 0,0: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"to": "14155552671", "location_lat": 90.5, "location_lng": 0}`,
+		`{"to": "14155552671", "location_lat": 0, "location_lng": -180.1}`,
+		`{"to": "14155552671", "location_lat": 10}`,
+		`{"to": "14155552671", "location_lat": 10, "location_lng": 10, "media_base64": "aGVsbG8="}`,
+		`{"to": "14155552671", "location_lat": 10, "location_lng": 10, "reply_to_msg_id": "q1"}`,
+	} {
+		if w := send(body); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if len(mock.locations) != 2 {
+		t.Fatalf("expected no further sends, got %v", mock.locations)
+	}
+}
+
+func TestServer_Send_Errors(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	send := func(mock *mockWA, ctx context.Context) (int, sendResponse) {
+		t.Helper()
+		srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+		if err != nil {
+			t.Fatalf("new server: %v", err)
+		}
+		body := `{"to": "14155552671", "message": "Hello!"}`
+		req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		srv.handleSend(w, req)
+		var resp sendResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return w.Code, resp
+	}
+
+	code, resp := send(&mockWA{connected: true, SendError: errors.New("server returned error 479")}, context.Background())
+	if code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", code)
+	}
+	if resp.OK || !strings.Contains(resp.Error, "server returned error 479") {
+		t.Errorf("expected send error in response, got %+v", resp)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	code, resp = send(&mockWA{connected: true, SendDelay: time.Second}, ctx)
+	if code != http.StatusInternalServerError {
+		t.Errorf("expected 500 on timeout, got %d", code)
+	}
+	if resp.OK || !strings.Contains(resp.Error, context.DeadlineExceeded.Error()) {
+		t.Errorf("expected deadline error in response, got %+v", resp)
+	}
+}
+
+func TestServer_Send_InvalidJSON(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name, contentType, body string
+	}{
+		{"truncated", "application/json", `{"to": "123", "message":`},
+		{"empty", "application/json", ``},
+		{"plain text", "text/plain", `send hello to 123`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+		srv.handleSend(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, w.Code)
+			continue
+		}
+		var resp sendResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", tc.name, err)
+		}
+		if resp.OK || !strings.Contains(resp.Error, "invalid JSON") {
+			t.Errorf("%s: expected invalid JSON error, got %+v", tc.name, resp)
+		}
+	}
+	if len(mock.sentMsgs) != 0 {
+		t.Errorf("expected nothing sent, got %v", mock.sentMsgs)
+	}
+}
+
 func TestServer_Send_NoWA(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -321,18 +777,71 @@ func TestServer_Send_NoWA(t *testing.T) {
 		t.Fatalf("new server: %v", err)
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/send", srv.handleSend)
-
-	body := `{"to": "123456789", "message": "Hello!"}`
-	req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body))
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	body := `{"to": "14155552671", "message": "Hello!"}`
+	req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)).WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
-	mux.ServeHTTP(w, req)
+	srv.Handler().ServeHTTP(w, req)
 
 	if w.Code != http.StatusServiceUnavailable {
 		t.Errorf("expected 503 when WA not connected, got %d", w.Code)
 	}
+
+	// The method is checked before waiting for WhatsApp.
+	start := time.Now()
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/send", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET /send, got %d", w.Code)
+	}
+	if d := time.Since(start); d >= connectWait {
+		t.Errorf("GET /send waited %v for WhatsApp", d)
+	}
+}
+
+func TestServer_Send_Disconnected(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: false}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	srv.SetReconnecting(true)
+
+	// The request deadline cuts the connectivity wait short.
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	body := `{"to": "14155552671", "message": "Hello!"}`
+	req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp["ok"] != false || resp["error"] != "WhatsApp disconnected" || resp["reconnecting"] != true {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	if len(mock.sentMsgs) != 0 {
+		t.Errorf("expected nothing sent, got %v", mock.sentMsgs)
+	}
+
+	srv.SetReconnecting(false)
+	mock.connected = true
+	req = httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 once connected, got %d: %s", w.Code, w.Body.String())
+	}
 }
 
 func TestServer_MethodNotAllowed(t *testing.T) {
@@ -346,21 +855,711 @@ func TestServer_MethodNotAllowed(t *testing.T) {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/status", srv.handleStatus)
+	mux.HandleFunc("/chats", srv.handleChats)
+	mux.HandleFunc("/messages", srv.handleMessages)
 	mux.HandleFunc("/send", srv.handleSend)
 
-	// POST to GET endpoint
-	req := httptest.NewRequest(http.MethodPost, "/status", nil)
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/status"},
+		{http.MethodDelete, "/status"},
+		{http.MethodPost, "/chats"},
+		{http.MethodPost, "/messages"},
+		{http.MethodGet, "/send"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected 405 for %s %s, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func TestServer_Messages_SenderFilter(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	group := "123@g.us"
+	_, _, _ = db.UpsertChat(ctx, group, "group", "Group", "", time.Now())
+	seed := []struct{ id, sender, name string }{
+		{"msg1", "111@s.whatsapp.net", "Alice"},
+		{"msg2", "222@s.whatsapp.net", "Bob"},
+		{"msg3", "111@s.whatsapp.net", "Alice"},
+	}
+	for i, m := range seed {
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+			ChatJID:    group,
+			MsgID:      m.id,
+			SenderJID:  m.sender,
+			SenderName: m.name,
+			Timestamp:  time.Now().Add(time.Duration(i) * time.Second),
+			Text:       "hi",
+		})
+	}
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+group+"&sender_jid=222@s.whatsapp.net", nil)
 	w := httptest.NewRecorder()
-	mux.ServeHTTP(w, req)
-	if w.Code != http.StatusMethodNotAllowed {
-		t.Errorf("expected 405 for POST to /status, got %d", w.Code)
+	srv.handleMessages(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp messagesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].SenderJID != "222@s.whatsapp.net" {
+		t.Errorf("expected 1 message from 222, got %+v", resp.Messages)
 	}
 
-	// GET to POST endpoint
-	req = httptest.NewRequest(http.MethodGet, "/send", nil)
+	req = httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+group+"&sender_name=ali", nil)
 	w = httptest.NewRecorder()
-	mux.ServeHTTP(w, req)
-	if w.Code != http.StatusMethodNotAllowed {
-		t.Errorf("expected 405 for GET to /send, got %d", w.Code)
+	srv.handleMessages(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	resp = messagesResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Messages) != 2 || resp.Messages[0].SenderName != "Alice" {
+		t.Errorf("expected 2 messages from Alice, got %+v", resp.Messages)
+	}
+
+	// A bare phone number matches the sender on any device.
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID: group, MsgID: "msg4", SenderJID: "111:3@s.whatsapp.net", SenderName: "Alice",
+		Timestamp: time.Now().Add(10 * time.Second), Text: "from the laptop",
+	})
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{
+		ChatJID: group, MsgID: "msg5", SenderJID: "1112@s.whatsapp.net", SenderName: "Carol",
+		Timestamp: time.Now().Add(11 * time.Second), Text: "similar number",
+	})
+	req = httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+group+"&sender_jid=%2B111&from_me=false&media_type=", nil)
+	w = httptest.NewRecorder()
+	srv.handleMessages(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	resp = messagesResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var ids []string
+	for _, m := range resp.Messages {
+		ids = append(ids, m.MsgID)
+	}
+	if strings.Join(ids, ",") != "msg4,msg3,msg1" {
+		t.Errorf("expected msg4,msg3,msg1 from +111, got %v", ids)
+	}
+	if f := resp.Filter; f.ChatJID != group || f.SenderJID != "+111" || f.FromMe == nil || *f.FromMe || f.SenderName != "" || f.MediaTypes != nil {
+		t.Errorf("unexpected filter echo %+v", f)
+	}
+}
+
+func TestServer_Messages_FromMeFilter(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chatJID := "123@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "sent", Timestamp: time.Now(), FromMe: true, Text: "out"})
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "recv", SenderJID: chatJID, Timestamp: time.Now(), Text: "in"})
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		wantCode int
+		wantID   string // empty = expect both messages
+	}{
+		{"", http.StatusOK, ""},
+		{"&from_me=true", http.StatusOK, "sent"},
+		{"&from_me=false", http.StatusOK, "recv"},
+		{"&from_me=maybe", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chatJID+tt.query, nil)
+		w := httptest.NewRecorder()
+		srv.handleMessages(w, req)
+		if w.Code != tt.wantCode {
+			t.Errorf("%q: expected %d, got %d", tt.query, tt.wantCode, w.Code)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+		var resp messagesResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if tt.wantID == "" {
+			if len(resp.Messages) != 2 {
+				t.Errorf("%q: expected 2 messages, got %d", tt.query, len(resp.Messages))
+			}
+			continue
+		}
+		if len(resp.Messages) != 1 || resp.Messages[0].MsgID != tt.wantID {
+			t.Errorf("%q: expected only %s, got %+v", tt.query, tt.wantID, resp.Messages)
+		}
+	}
+}
+
+func TestServer_Messages_BeforeAfterFilter(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ref := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	chatJID := "123@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", ref)
+	for id, ts := range map[string]time.Time{
+		"yesterday": ref.Add(-24 * time.Hour),
+		"today":     ref,
+		"tomorrow":  ref.Add(24 * time.Hour),
+	} {
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: id, Timestamp: ts, Text: id})
+	}
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	after := ref.Add(-12 * time.Hour).Format(time.RFC3339)
+	before := ref.Add(12 * time.Hour).Format(time.RFC3339)
+	req := httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chatJID+"&after="+after+"&before="+before, nil)
+	w := httptest.NewRecorder()
+	srv.handleMessages(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp messagesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0].MsgID != "today" {
+		t.Errorf("expected only today's message, got %+v", resp.Messages)
+	}
+
+	for _, q := range []string{"before=yesterday", "after=2024-06-15"} {
+		req := httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chatJID+"&"+q, nil)
+		w := httptest.NewRecorder()
+		srv.handleMessages(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestServer_Messages_MediaTypeFilter(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chatJID := "123@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "txt", Timestamp: time.Now(), Text: "hi"})
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "img", Timestamp: time.Now(), MediaType: "image"})
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "doc", Timestamp: time.Now(), MediaType: "document"})
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chatJID+"&media_type=image,document", nil)
+	w := httptest.NewRecorder()
+	srv.handleMessages(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp messagesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Messages) != 2 {
+		t.Fatalf("expected 2 media messages, got %d", len(resp.Messages))
+	}
+	for _, m := range resp.Messages {
+		if m.MediaType != "image" && m.MediaType != "document" {
+			t.Errorf("unexpected media type %q", m.MediaType)
+		}
+	}
+
+	ids := func(url string) []string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleMessages(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var resp messagesResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		var out []string
+		for _, m := range resp.Messages {
+			out = append(out, m.MsgID)
+		}
+		slices.Sort(out)
+		return out
+	}
+	if got := ids("/messages?chat_jid=" + chatJID + "&has_media=true"); !slices.Equal(got, []string{"doc", "img"}) {
+		t.Errorf("has_media=true: got %v, want [doc img]", got)
+	}
+	if got := ids("/messages?chat_jid=" + chatJID + "&has_media=false"); !slices.Equal(got, []string{"txt"}) {
+		t.Errorf("has_media=false: got %v, want [txt]", got)
+	}
+	if got := ids("/messages?chat_jid=" + chatJID + "&has_media=true&media_type=image"); !slices.Equal(got, []string{"img"}) {
+		t.Errorf("has_media=true&media_type=image: got %v, want [img]", got)
+	}
+
+	for _, q := range []string{"media_type=photo", "media_type=image,bogus", "has_media=maybe", "has_media=false&media_type=image"} {
+		w := httptest.NewRecorder()
+		srv.handleMessages(w, httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chatJID+"&"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestServer_Messages_Cursor(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chatJID := "123@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	ts := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		// All in the same second: only the msg_id tie-breaker orders them.
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: fmt.Sprintf("m%d", i), Timestamp: ts, Text: "hi"})
+	}
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	walk := func(order string) []string {
+		t.Helper()
+		var ids []string
+		cursor := ""
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatalf("too many pages")
+			}
+			url := "/messages?chat_jid=" + chatJID + "&limit=2" + order
+			if cursor != "" {
+				url += "&cursor=" + cursor
+			}
+			w := httptest.NewRecorder()
+			srv.handleMessages(w, httptest.NewRequest(http.MethodGet, url, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp messagesResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			for _, m := range resp.Messages {
+				ids = append(ids, m.MsgID)
+			}
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+		return ids
+	}
+	if ids := walk(""); fmt.Sprint(ids) != "[m4 m3 m2 m1 m0]" {
+		t.Errorf("unexpected page order %v", ids)
+	}
+	if ids := walk("&order=desc"); fmt.Sprint(ids) != "[m4 m3 m2 m1 m0]" {
+		t.Errorf("unexpected descending page order %v", ids)
+	}
+	if ids := walk("&order=asc"); fmt.Sprint(ids) != "[m0 m1 m2 m3 m4]" {
+		t.Errorf("unexpected ascending page order %v", ids)
+	}
+
+	for _, q := range []string{"cursor=%25%25", "order=newest"} {
+		w := httptest.NewRecorder()
+		srv.handleMessages(w, httptest.NewRequest(http.MethodGet, "/messages?chat_jid="+chatJID+"&"+q, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestServer_Search_EmptyQuery(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(`{"query":""}`)),
+		httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(`{"query":"   "}`)),
+		httptest.NewRequest(http.MethodGet, "/search?query=", nil),
+	} {
+		w := httptest.NewRecorder()
+		srv.handleSearch(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: expected 400, got %d", req.Method, req.URL, w.Code)
+			continue
+		}
+		var resp jsonResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.OK || resp.Error != "query is required" {
+			t.Errorf("%s %s: unexpected response %+v", req.Method, req.URL, resp)
+		}
+	}
+}
+
+func TestServer_Search_TotalCount(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chatJID := "123@s.whatsapp.net"
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", time.Now())
+	for _, id := range []string{"a", "b", "c", "d"} {
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: id, Timestamp: time.Now(), Text: "hello again"})
+	}
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?query=hello&limit=2", nil)
+	w := httptest.NewRecorder()
+	srv.handleSearch(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp searchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Errorf("expected 2 results, got %d", len(resp.Results))
+	}
+	if resp.TotalCount != 4 {
+		t.Errorf("expected total_count=4, got %d", resp.TotalCount)
+	}
+}
+
+func TestServer_Search_Highlight(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chatJID := "123@s.whatsapp.net"
+	base := time.Now().Add(-time.Hour)
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", base)
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "best", Timestamp: base, Text: "gopher gopher gopher"})
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "weak", Timestamp: base.Add(time.Minute), Text: "one gopher among many other words in a long message"})
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleSearch(w, httptest.NewRequest(http.MethodGet, "/search?query=gopher&highlight=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp searchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(resp.Results))
+	}
+	if !db.HasFTS() {
+		// LIKE fallback: newest first, no snippets.
+		if resp.Results[0].MsgID != "weak" || resp.Results[0].Snippet != "" {
+			t.Fatalf("unexpected fallback results %+v", resp.Results)
+		}
+		return
+	}
+	if resp.Results[0].MsgID != "best" || !strings.Contains(resp.Results[0].Snippet, "<b>gopher</b>") {
+		t.Fatalf("unexpected highlighted results %+v", resp.Results)
+	}
+
+	// Without highlight, snippets keep the bracket markers.
+	w = httptest.NewRecorder()
+	srv.handleSearch(w, httptest.NewRequest(http.MethodGet, "/search?query=gopher", nil))
+	resp = searchResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].MsgID != "best" || !strings.Contains(resp.Results[0].Snippet, "[gopher]") {
+		t.Fatalf("unexpected default results %+v", resp.Results)
+	}
+}
+
+func TestServer_Search_DateRange(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	chatJID := "123@s.whatsapp.net"
+	week := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	_, _, _ = db.UpsertChat(ctx, chatJID, "dm", "Alice", "", week)
+	for id, ts := range map[string]time.Time{
+		"before": week.Add(-time.Hour),
+		"inside": week.Add(48 * time.Hour),
+		"after":  week.Add(8 * 24 * time.Hour),
+	} {
+		_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: id, Timestamp: ts, Text: "standup notes"})
+	}
+	_, _, _ = db.UpsertMessage(ctx, store.UpsertMessageParams{ChatJID: chatJID, MsgID: "other", Timestamp: week.Add(time.Hour), Text: "lunch"})
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	search := func(req *http.Request) searchResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleSearch(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected 200, got %d: %s", req.Method, req.URL, w.Code, w.Body.String())
+		}
+		var resp searchResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/search?query=standup&after=2024-03-04T00:00:00Z&before=2024-03-11T00:00:00Z", nil),
+		httptest.NewRequest(http.MethodPost, "/search", bytes.NewBufferString(`{"query": "standup", "after": "2024-03-04T00:00:00Z", "before": "2024-03-11T00:00:00Z"}`)),
+	} {
+		resp := search(req)
+		if len(resp.Results) != 1 || resp.Results[0].MsgID != "inside" || resp.TotalCount != 1 {
+			t.Errorf("%s: unexpected results %+v (total %d)", req.Method, resp.Results, resp.TotalCount)
+		}
+	}
+
+	// One bound only.
+	resp := search(httptest.NewRequest(http.MethodGet, "/search?query=standup&after=2024-03-04T00:00:00Z", nil))
+	// FTS ranks equal texts arbitrarily, so compare as a set.
+	got := map[string]bool{}
+	for _, m := range resp.Results {
+		got[m.MsgID] = true
+	}
+	if len(resp.Results) != 2 || !got["after"] || !got["inside"] {
+		t.Errorf("after only: unexpected results %+v", resp.Results)
+	}
+
+	for _, url := range []string{
+		"/search?query=standup&after=yesterday",
+		"/search?query=standup&after=2024-03-11T00:00:00Z&before=2024-03-04T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		srv.handleSearch(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestServer_Chats_Cursor(t *testing.T) {
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	base := time.Now().Add(-time.Hour)
+	for i, jid := range []string{"1@s.whatsapp.net", "2@s.whatsapp.net", "3@s.whatsapp.net"} {
+		_, _, _ = db.UpsertChat(ctx, jid, "dm", "", "", base.Add(time.Duration(i)*time.Minute))
+	}
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	get := func(url string) chatsResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		srv.handleChats(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", url, w.Code)
+		}
+		var resp chatsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	first := get("/chats?limit=2")
+	if len(first.Chats) != 2 || first.NextCursor == "" {
+		t.Fatalf("expected 2 chats and a cursor, got %+v", first)
+	}
+	second := get("/chats?limit=2&cursor=" + first.NextCursor)
+	if len(second.Chats) != 1 || second.NextCursor != "" {
+		t.Fatalf("expected final page with 1 chat, got %+v", second)
+	}
+	if second.Chats[0].JID != "1@s.whatsapp.net" {
+		t.Errorf("expected oldest chat last, got %s", second.Chats[0].JID)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/chats?cursor=!!!", nil)
+	w := httptest.NewRecorder()
+	srv.handleChats(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for bad cursor, got %d", w.Code)
+	}
+}
+
+// seedBenchDB returns a DB holding n messages spread over 100 chats.
+func seedBenchDB(b *testing.B, n int) *store.DB {
+	b.Helper()
+	db, cleanup := setupTestDB(b)
+	b.Cleanup(cleanup)
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, `
+		WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i+1 FROM n WHERE i < 99)
+		INSERT INTO chats(jid, kind, name, last_message_ts)
+		SELECT 'chat' || i || '@s.whatsapp.net', 'dm', 'Chat ' || i, ? FROM n
+	`, n); err != nil {
+		b.Fatalf("seed chats: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i+1 FROM n WHERE i < ?)
+		INSERT INTO messages(chat_jid, msg_id, sender_jid, ts, from_me, text, display_text)
+		SELECT 'chat' || (i % 100) || '@s.whatsapp.net', 'm' || i, 'chat' || (i % 100) || '@s.whatsapp.net', i, i % 2,
+			'hello benchmark message ' || i, 'hello benchmark message ' || i
+		FROM n
+	`, n-1); err != nil {
+		b.Fatalf("seed messages: %v", err)
+	}
+	return db
+}
+
+func benchmarkHandler(b *testing.B, h http.HandlerFunc, method, target string, body string) {
+	b.Helper()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var r *http.Request
+		if body != "" {
+			r = httptest.NewRequest(method, target, strings.NewReader(body))
+		} else {
+			r = httptest.NewRequest(method, target, nil)
+		}
+		w := httptest.NewRecorder()
+		h(w, r)
+		if w.Code != http.StatusOK {
+			b.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func BenchmarkHandleMessages(b *testing.B) {
+	srv, err := New(Options{DB: seedBenchDB(b, 10000)})
+	if err != nil {
+		b.Fatalf("new server: %v", err)
+	}
+	benchmarkHandler(b, srv.handleMessages, http.MethodGet, "/messages?chat_jid=chat7@s.whatsapp.net&limit=50", "")
+}
+
+func BenchmarkHandleSearch(b *testing.B) {
+	srv, err := New(Options{DB: seedBenchDB(b, 10000)})
+	if err != nil {
+		b.Fatalf("new server: %v", err)
+	}
+	benchmarkHandler(b, srv.handleSearch, http.MethodPost, "/search", `{"query":"benchmark","limit":50}`)
+}
+
+func BenchmarkHandleChats(b *testing.B) {
+	srv, err := New(Options{DB: seedBenchDB(b, 10000)})
+	if err != nil {
+		b.Fatalf("new server: %v", err)
+	}
+	benchmarkHandler(b, srv.handleChats, http.MethodGet, "/chats?limit=50", "")
+}
+
+func TestServer_UnixSocket(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, prefix := range []string{"", unixSocketPrefix} {
+		sock := filepath.Join(t.TempDir(), "rpc.sock")
+		srv, err := New(Options{Addr: prefix + sock, DB: db})
+		if err != nil {
+			t.Fatalf("new server: %v", err)
+		}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("start %q: %v", prefix+sock, err)
+		}
+		if !srv.IsUnixSocket() || srv.SocketPath() != sock {
+			t.Fatalf("expected unix socket at %s, got %q", sock, srv.SocketPath())
+		}
+
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		_, _ = conn.Write([]byte("GET /ping HTTP/1.0\r\n\r\n"))
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatalf("read response: %v", err)
+		}
+		_ = resp.Body.Close()
+		_ = conn.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected 200 from /ping, got %d", resp.StatusCode)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_ = srv.Stop(ctx)
+		cancel()
+		if _, err := os.Stat(sock); !os.IsNotExist(err) {
+			t.Errorf("expected socket file to be removed on shutdown, stat err=%v", err)
+		}
+	}
+}
+
+func TestServer_UnixSocketKeepsRegularFile(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := New(Options{Addr: path, DB: db})
+	if err := srv.Start(); err == nil {
+		_ = srv.Stop(context.Background())
+		t.Fatal("expected start to fail when the path is a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected regular file to be kept: %v", err)
 	}
 }
@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types streamed by GET /events.
+const (
+	sseEventMessage = "message" // a new message, as messageJSON
+	sseEventStatus  = "status"  // the WhatsApp connection changed
+	sseEventSync    = "sync"    // sync started or stopped
+)
+
+const (
+	// sseClientBuffer is how many events may queue for one client.
+	sseClientBuffer = 64
+	// sseSlowTimeout is how long a publish waits on a client whose buffer
+	// is full before dropping it.
+	sseSlowTimeout = time.Second
+	// sseKeepAlive is how often an idle stream gets a comment line, so
+	// proxies don't close it.
+	sseKeepAlive = 30 * time.Second
+)
+
+// sseEvent is the JSON payload of each event's data line.
+type sseEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+type sseStatusJSON struct {
+	WAConnected  bool `json:"wa_connected"`
+	Reconnecting bool `json:"reconnecting"`
+}
+
+type sseSyncJSON struct {
+	SyncRunning bool `json:"sync_running"`
+}
+
+// sseClient is one /events stream. Its done channel is closed when the
+// client is dropped or the server stops.
+type sseClient struct {
+	types map[string]bool // nil = every type
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (c *sseClient) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// sseHub fans events out to /events clients.
+type sseHub struct {
+	clients sync.Map // chan []byte -> *sseClient
+}
+
+// publish sends an event to every client that wants its type. A client
+// whose buffer stays full for sseSlowTimeout is dropped; the wait is shared
+// by all clients of one publish.
+func (h *sseHub) publish(typ string, data interface{}) {
+	b, err := json.Marshal(sseEvent{Type: typ, Data: data})
+	if err != nil {
+		return
+	}
+	var timer *time.Timer
+	expired := false
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	h.clients.Range(func(key, value any) bool {
+		ch, c := key.(chan []byte), value.(*sseClient)
+		if c.types != nil && !c.types[typ] {
+			return true
+		}
+		select {
+		case ch <- b:
+			return true
+		default:
+		}
+		if timer == nil {
+			timer = time.NewTimer(sseSlowTimeout)
+		}
+		if !expired {
+			select {
+			case ch <- b:
+				return true
+			case <-c.done:
+				return true
+			case <-timer.C:
+				expired = true
+			}
+		}
+		h.clients.Delete(ch)
+		c.close()
+		return true
+	})
+}
+
+func (h *sseHub) closeAll() {
+	h.clients.Range(func(key, value any) bool {
+		h.clients.Delete(key)
+		value.(*sseClient).close()
+		return true
+	})
+}
+
+// parseSSETypes reads the comma-separated ?types= filter; empty means all.
+func parseSSETypes(raw string) (map[string]bool, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	types := map[string]bool{}
+	for _, t := range strings.Split(raw, ",") {
+		switch t = strings.TrimSpace(t); t {
+		case sseEventMessage, sseEventStatus, sseEventSync:
+			types[t] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown event type %q (use message, status or sync)", t)
+		}
+	}
+	return types, nil
+}
+
+func (s *Server) connectionStatus() sseStatusJSON {
+	s.mu.RLock()
+	wa := s.wa
+	s.mu.RUnlock()
+	return sseStatusJSON{
+		WAConnected:  wa != nil && wa.IsConnected(),
+		Reconnecting: s.isReconnecting.Load(),
+	}
+}
+
+// publishStatus tells /events clients about the WhatsApp connection.
+func (s *Server) publishStatus() {
+	s.sse.publish(sseEventStatus, s.connectionStatus())
+}
+
+// handleEvents streams events as Server-Sent Events, one JSON sseEvent per
+// data line, until the client goes away or the server stops. The current
+// connection status is sent first.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	types, err := parseSSETypes(r.URL.Query().Get("types"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	// The stream outlives the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.log.Debug().Err(err).Msg("failed to clear write deadline for /events")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan []byte, sseClientBuffer)
+	if types == nil || types[sseEventStatus] {
+		b, _ := json.Marshal(sseEvent{Type: sseEventStatus, Data: s.connectionStatus()})
+		ch <- b
+	}
+	c := &sseClient{types: types, done: make(chan struct{})}
+	s.sse.clients.Store(ch, c)
+	defer func() {
+		s.sse.clients.Delete(ch)
+		c.close()
+	}()
+	s.log.Debug().Str("remote", s.clientIP(r)).Msg("events client connected")
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.done:
+			return
+		case b := <-ch:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+// flushRecorder is a ResponseRecorder that can be read while the handler
+// is still writing; each Flush signals flushed.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	mu      sync.Mutex
+	body    bytes.Buffer
+	flushed chan struct{}
+}
+
+func newFlushRecorder() *flushRecorder {
+	return &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushed: make(chan struct{}, 16)}
+}
+
+func (r *flushRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body.Write(p)
+}
+
+func (r *flushRecorder) Flush() {
+	select {
+	case r.flushed <- struct{}{}:
+	default:
+	}
+}
+
+func (r *flushRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body.String()
+}
+
+// waitFor waits until the streamed body contains want.
+func (r *flushRecorder) waitFor(t *testing.T, want string) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for !strings.Contains(r.String(), want) {
+		select {
+		case <-r.flushed:
+		case <-deadline:
+			t.Fatalf("timed out waiting for %q; got:\n%s", want, r.String())
+		}
+	}
+}
+
+// streamEvents runs GET path against srv until the returned stop is called.
+func streamEvents(t *testing.T, srv *Server, path string) (*flushRecorder, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := newFlushRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.handleEvents(rec, httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx))
+	}()
+	<-rec.flushed // headers are out
+	return rec, func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{connected: true}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec, stop := streamEvents(t, srv, "/events")
+	defer stop()
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("Cache-Control = %q", cc)
+	}
+	rec.waitFor(t, `data: {"type":"status","data":{"wa_connected":true,"reconnecting":false}}`+"\n\n")
+
+	srv.broadcast(store.Message{ChatJID: "123@s.whatsapp.net", MsgID: "m1", Text: "hello", Timestamp: time.Unix(1700000000, 0)})
+	rec.waitFor(t, `data: {"type":"message","data":{`)
+	rec.waitFor(t, `"msg_id":"m1"`)
+
+	srv.SetReconnecting(true)
+	rec.waitFor(t, `"reconnecting":true`)
+	srv.SetSyncRunning(true)
+	rec.waitFor(t, `data: {"type":"sync","data":{"sync_running":true}}`)
+}
+
+func TestServer_Events_TypeFilter(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	rec, stop := streamEvents(t, srv, "/events?types=sync")
+	defer stop()
+
+	srv.broadcast(store.Message{ChatJID: "123@s.whatsapp.net", MsgID: "m1", Text: "hello"})
+	srv.SetReconnecting(true)
+	srv.SetSyncRunning(true)
+	rec.waitFor(t, `"type":"sync"`)
+	if body := rec.String(); strings.Contains(body, `"type":"message"`) || strings.Contains(body, `"type":"status"`) {
+		t.Fatalf("filtered events leaked:\n%s", body)
+	}
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?types=message,bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown type, got %d", w.Code)
+	}
+}
+
+func TestServer_Events_StopEndsStreams(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	_, stop := streamEvents(t, srv, "/events")
+	_ = srv.Stop(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("stream still open after Stop")
+	}
+}
+
+func TestSSEHub_DropsSlowClient(t *testing.T) {
+	var h sseHub
+	slow := make(chan []byte) // never read
+	slowClient := &sseClient{done: make(chan struct{})}
+	h.clients.Store(slow, slowClient)
+	fast := make(chan []byte, 1)
+	h.clients.Store(fast, &sseClient{done: make(chan struct{})})
+
+	start := time.Now()
+	h.publish(sseEventMessage, "x")
+	if elapsed := time.Since(start); elapsed < sseSlowTimeout/2 || elapsed > 3*sseSlowTimeout {
+		t.Fatalf("publish took %v, want about %v", elapsed, sseSlowTimeout)
+	}
+	select {
+	case <-slowClient.done:
+	default:
+		t.Fatalf("slow client was not dropped")
+	}
+	if _, ok := h.clients.Load(slow); ok {
+		t.Fatalf("slow client still registered")
+	}
+	if len(fast) != 1 {
+		t.Fatalf("fast client missed the event")
+	}
+}
+
+func TestServer_Events_ThroughMiddleware(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "127.0.0.1:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = srv.Stop(ctx)
+	}()
+
+	resp, err := http.Get("http://" + srv.Addr() + "/events?types=status")
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if want := `data: {"type":"status","data":{"wa_connected":false,"reconnecting":false}}` + "\n"; line != want {
+		t.Fatalf("first line = %q, want %q", line, want)
+	}
+}
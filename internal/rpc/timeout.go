@@ -0,0 +1,131 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// streamingEndpoints write their response as it is produced and may run
+// for as long as the client stays, so a buffered deadline can't apply.
+var streamingEndpoints = map[string]bool{
+	"/events":          true,
+	"/export/messages": true,
+	"/ws":              true,
+}
+
+// ParseTimeouts parses "path=duration" pairs (e.g. "/send=30s") as used by
+// the --endpoint-timeouts flag. Streaming endpoints are rejected.
+func ParseTimeouts(values map[string]string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration, len(values))
+	for path, raw := range values {
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid endpoint %q: must start with /", path)
+		}
+		if streamingEndpoints[path] {
+			return nil, fmt.Errorf("invalid endpoint %q: streaming endpoints can't have a timeout", path)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q for %s", raw, path)
+		}
+		out[path] = d
+	}
+	return out, nil
+}
+
+// withTimeouts gives requests to endpoints listed in requestTimeouts a
+// context deadline. If the handler hasn't finished by then the client gets
+// 408 and anything the handler writes afterwards is discarded. Streaming
+// endpoints always get the real writer.
+func (s *Server) withTimeouts(next http.Handler) http.Handler {
+	if len(s.requestTimeouts) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, ok := s.requestTimeouts[r.URL.Path]
+		// Upgraded connections and streams are long-lived and need the real
+		// writer.
+		if !ok || streamingEndpoints[r.URL.Path] || websocket.IsWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for k, v := range tw.header {
+				w.Header()[k] = v
+			}
+			if tw.code == 0 {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			_, _ = w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			s.log.Warn().Str("path", r.URL.Path).Dur("timeout", d).Msg("request timed out")
+			writeError(w, http.StatusRequestTimeout, "request timed out after "+d.String())
+		}
+	})
+}
+
+// timeoutWriter buffers a handler's response so it can be dropped if the
+// deadline fires first.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_EndpointTimeouts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{
+		Addr:     "localhost:0",
+		DB:       db,
+		Timeouts: map[string]time.Duration{"/ping": 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		writeOK(w, jsonResponse{OK: true})
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/ping", slow)
+	mux.Handle("/status", slow)
+	h := srv.withTimeouts(mux)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if w.Code != http.StatusRequestTimeout {
+		t.Fatalf("expected 408, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("timeout took %s", elapsed)
+	}
+	var resp jsonResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.OK || !strings.Contains(resp.Error, "timed out") {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// Endpoints without a configured timeout are untouched.
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for /status, got %d", w.Code)
+	}
+	// A fast handler on a timed endpoint passes through, headers included.
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected 200 JSON for fast /ping, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestParseTimeouts(t *testing.T) {
+	got, err := ParseTimeouts(map[string]string{"/send": "30s", " /ping ": "500ms"})
+	if err != nil {
+		t.Fatalf("ParseTimeouts: %v", err)
+	}
+	if got["/send"] != 30*time.Second || got["/ping"] != 500*time.Millisecond {
+		t.Errorf("unexpected timeouts: %v", got)
+	}
+	for _, bad := range []map[string]string{
+		{"send": "30s"},
+		{"/send": "soon"},
+		{"/send": "0s"},
+		{"/events": "30s"},
+		{"/export/messages": "30s"},
+	} {
+		if _, err := ParseTimeouts(bad); err == nil {
+			t.Errorf("expected error for %v", bad)
+		}
+	}
+}
+
+func TestServer_EndpointTimeouts_StreamingPassThrough(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Options.Timeouts bypasses ParseTimeouts, so streams must still get the
+	// real writer.
+	srv, err := New(Options{
+		Addr:     "localhost:0",
+		DB:       db,
+		Timeouts: map[string]time.Duration{"/events": 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w := newFlushRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %q: %s", w.Code, w.Header().Get("Content-Type"), w.String())
+	}
+}
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// selfSignedValidity is how long a generated certificate is valid.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// certExpiryWarning is how close to expiry a loaded certificate has to be
+// for New to log a warning.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// setupTLS sets s.tlsConfig from whichever TLS option is set in opts.
+func (s *Server) setupTLS(opts Options, now time.Time) error {
+	modes := 0
+	for _, on := range []bool{opts.TLSSelfSigned, opts.TLSCertFile != "" || opts.TLSKeyFile != "", opts.TLSACMEDomain != ""} {
+		if on {
+			modes++
+		}
+	}
+	if modes == 0 {
+		return nil
+	}
+	if modes > 1 {
+		return errors.New("choose one of a self-signed certificate, a certificate file or ACME")
+	}
+	if _, ok := unixSocketPath(opts.Addr); ok {
+		return fmt.Errorf("TLS needs a TCP address, not a Unix socket")
+	}
+
+	switch {
+	case opts.TLSSelfSigned:
+		cert, err := selfSignedCert(addrHost(opts.Addr), now)
+		if err != nil {
+			return fmt.Errorf("generate self-signed certificate: %w", err)
+		}
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		s.tlsFingerprint = certFingerprint(cert)
+	case opts.TLSACMEDomain != "":
+		if opts.TLSACMECacheDir == "" {
+			return errors.New("ACME needs a cache directory")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.TLSACMEDomain),
+			Cache:      autocert.DirCache(opts.TLSACMECacheDir),
+		}
+		s.tlsConfig = m.TLSConfig()
+		s.tlsConfig.MinVersion = tls.VersionTLS12
+	default:
+		if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+			return errors.New("TLS needs both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("load TLS certificate: %w", err)
+		}
+		if expiresSoon(cert, now) {
+			s.log.Warn().Time("not_after", cert.Leaf.NotAfter).Str("cert", opts.TLSCertFile).Msg("TLS certificate expires within 30 days")
+		}
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+	return nil
+}
+
+// selfSignedCert generates an in-memory certificate for host, which may be
+// a name or an IP address. A wildcard or empty host gets one for localhost.
+func selfSignedCert(host string, now time.Time) (tls.Certificate, error) {
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: host, Organization: []string{"wacli"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// expiresSoon reports whether cert's leaf expires within certExpiryWarning.
+func expiresSoon(cert tls.Certificate, now time.Time) bool {
+	return cert.Leaf != nil && cert.Leaf.NotAfter.Sub(now) < certExpiryWarning
+}
+
+// certFingerprint returns the SHA-256 fingerprint of cert's leaf in the
+// colon-separated form printed by openssl.
+func certFingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// addrHost returns the host part of a host:port listen address.
+func addrHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
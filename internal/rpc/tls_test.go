@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_TLSSelfSigned(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "127.0.0.1:0", DB: db, TLSSelfSigned: true})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() { _ = srv.Stop(context.Background()) }()
+
+	if srv.Scheme() != "https" {
+		t.Fatalf("expected https scheme, got %q", srv.Scheme())
+	}
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Get("https://" + srv.Addr() + "/ping")
+	if err != nil {
+		t.Fatalf("GET /ping over TLS: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	state := resp.TLS
+	if state == nil || len(state.PeerCertificates) == 0 {
+		t.Fatal("expected a peer certificate")
+	}
+	leaf := state.PeerCertificates[0]
+	sum := sha256.Sum256(leaf.Raw)
+	if got, want := srv.TLSFingerprint(), strings.ToUpper(hex.EncodeToString(sum[:])); strings.ReplaceAll(got, ":", "") != want {
+		t.Fatalf("fingerprint %s does not match served certificate %s", got, want)
+	}
+	if len(leaf.IPAddresses) != 1 || leaf.IPAddresses[0].String() != "127.0.0.1" {
+		t.Fatalf("expected certificate for 127.0.0.1, got %v", leaf.IPAddresses)
+	}
+	if validity := leaf.NotAfter.Sub(time.Now()); validity < 364*24*time.Hour || validity > 366*24*time.Hour {
+		t.Fatalf("expected about a year of validity, got %s", validity)
+	}
+}
+
+func TestSelfSignedCertHostnames(t *testing.T) {
+	now := time.Now()
+	for host, want := range map[string]string{"wacli.local": "wacli.local", "": "localhost", "0.0.0.0": "localhost"} {
+		cert, err := selfSignedCert(host, now)
+		if err != nil {
+			t.Fatalf("selfSignedCert(%q): %v", host, err)
+		}
+		if len(cert.Leaf.DNSNames) != 1 || cert.Leaf.DNSNames[0] != want {
+			t.Fatalf("selfSignedCert(%q): expected DNS name %q, got %v", host, want, cert.Leaf.DNSNames)
+		}
+	}
+}
+
+func TestNew_TLSSelfSignedRejectsUnixSocket(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := New(Options{Addr: "unix:///tmp/wacli-test.sock", DB: db, TLSSelfSigned: true}); err == nil {
+		t.Fatal("expected an error for TLS on a Unix socket")
+	}
+}
+
+// writeCertPair writes a PEM certificate for 127.0.0.1 valid until notAfter
+// and its key to dir.
+func writeCertPair(t *testing.T, dir string, notAfter time.Time) (certFile, keyFile string, leaf *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	leaf, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile, leaf
+}
+
+func TestServer_TLSCertFiles(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	certFile, keyFile, leaf := writeCertPair(t, t.TempDir(), time.Now().Add(90*24*time.Hour))
+	srv, err := New(Options{Addr: "127.0.0.1:0", DB: db, TLSCertFile: certFile, TLSKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() { _ = srv.Stop(context.Background()) }()
+
+	if srv.Scheme() != "https" || srv.TLSFingerprint() != "" {
+		t.Fatalf("scheme = %q, fingerprint = %q", srv.Scheme(), srv.TLSFingerprint())
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+	}
+	resp, err := client.Get("https://" + srv.Addr() + "/ping")
+	if err != nil {
+		t.Fatalf("GET /ping over TLS: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestNew_TLSOptionErrors(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	certFile, keyFile, _ := writeCertPair(t, t.TempDir(), time.Now().Add(24*time.Hour))
+	for name, opts := range map[string]Options{
+		"cert without key":   {TLSCertFile: certFile},
+		"missing file":       {TLSCertFile: certFile + ".missing", TLSKeyFile: keyFile},
+		"two modes":          {TLSSelfSigned: true, TLSCertFile: certFile, TLSKeyFile: keyFile},
+		"acme without cache": {TLSACMEDomain: "wacli.example.com"},
+	} {
+		opts.Addr = "127.0.0.1:0"
+		opts.DB = db
+		if _, err := New(opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNew_TLSACME(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: ":443", DB: db, TLSACMEDomain: "wacli.example.com", TLSACMECacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	if srv.Scheme() != "https" || srv.tlsConfig.GetCertificate == nil {
+		t.Fatal("expected certificates to be fetched on demand")
+	}
+	// Only the configured domain may trigger issuance.
+	if _, err := srv.tlsConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"}); err == nil {
+		t.Fatal("expected a certificate request for another host to be refused")
+	}
+}
+
+func TestExpiresSoon(t *testing.T) {
+	now := time.Now()
+	for days, want := range map[int]bool{10: true, 29: true, 31: false, 365: false} {
+		_, _, leaf := writeCertPair(t, t.TempDir(), now.Add(time.Duration(days)*24*time.Hour))
+		if got := expiresSoon(tls.Certificate{Leaf: leaf}, now); got != want {
+			t.Errorf("expiresSoon(%d days) = %v, want %v", days, got, want)
+		}
+	}
+}
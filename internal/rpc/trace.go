@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// traceBodyLimit caps how much of each request/response body is logged so
+// media uploads don't flood the log.
+const traceBodyLimit = 4 << 10
+
+// limitedBuffer keeps the first traceBodyLimit bytes written to it and
+// silently discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remain := traceBodyLimit - b.buf.Len()
+	if remain <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return len(p), nil
+	}
+	if len(p) > remain {
+		b.buf.Write(p[:remain])
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "…(truncated)"
+	}
+	return b.buf.String()
+}
+
+// captureWriter records the status code and, when body is set, a copy of
+// the response body.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   *limitedBuffer
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body != nil {
+		_, _ = w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *captureWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades pass through while tracing is on.
+func (w *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (s *Server) traceEnabled() bool {
+	return zerolog.GlobalLevel() <= zerolog.TraceLevel && s.log.GetLevel() <= zerolog.TraceLevel
+}
+
+// redactQuery hides the value of the token parameter (the withAuth
+// fallback) in a raw query string, keeping everything else as sent.
+func redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	params := strings.Split(raw, "&")
+	for i, p := range params {
+		key, _, _ := strings.Cut(p, "=")
+		if k, err := url.QueryUnescape(key); err == nil && k == "token" {
+			params[i] = key + "=REDACTED"
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// withTrace logs request and response bodies when trace logging is enabled
+// (WACLI_LOG=trace). Headers are never logged, since Authorization carries
+// the bearer token, and a ?token= value is redacted from the query.
+func (s *Server) withTrace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.traceEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var reqBody limitedBuffer
+		if r.Body != nil {
+			r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, &reqBody), Closer: r.Body}
+		}
+		cw := &captureWriter{ResponseWriter: w, body: &limitedBuffer{}}
+		next.ServeHTTP(cw, r)
+
+		s.log.Trace().
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Str("query", redactQuery(r.URL.RawQuery)).
+			Int("status", cw.status).
+			Str("request_body", reqBody.String()).
+			Str("response_body", cw.body.String()).
+			Msg("rpc trace")
+	})
+}
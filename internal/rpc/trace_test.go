@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestServer_TraceLogsBodies(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	prev := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	defer zerolog.SetGlobalLevel(prev)
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: &mockWA{connected: true}})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	var logBuf bytes.Buffer
+	srv.log = zerolog.New(&logBuf).Level(zerolog.TraceLevel)
+
+	body := `{"to": "14155552671", "message": "traced hello"}`
+	req := httptest.NewRequest(http.MethodPost, "/send", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	logs := logBuf.String()
+	if !strings.Contains(logs, "traced hello") {
+		t.Errorf("expected request body in trace log, got %q", logs)
+	}
+	if !strings.Contains(logs, "test_msg_id") {
+		t.Errorf("expected response body in trace log, got %q", logs)
+	}
+}
+
+func TestServer_TraceDisabled(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{Addr: "localhost:0", DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	var logBuf bytes.Buffer
+	srv.log = zerolog.New(&logBuf).Level(zerolog.DebugLevel)
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if strings.Contains(logBuf.String(), "rpc trace") {
+		t.Errorf("expected no trace output below trace level, got %q", logBuf.String())
+	}
+}
+
+func TestLimitedBufferTruncates(t *testing.T) {
+	var b limitedBuffer
+	_, _ = b.Write(bytes.Repeat([]byte("a"), traceBodyLimit+10))
+	if b.buf.Len() != traceBodyLimit {
+		t.Fatalf("expected %d bytes kept, got %d", traceBodyLimit, b.buf.Len())
+	}
+	if !strings.HasSuffix(b.String(), "(truncated)") {
+		t.Fatalf("expected truncation marker")
+	}
+}
+
+func TestServer_TraceRedactsToken(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	prev := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	defer zerolog.SetGlobalLevel(prev)
+
+	const token = "s3cret-token-value"
+	srv, err := New(Options{Addr: "localhost:0", DB: db, AuthToken: token})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	var logBuf bytes.Buffer
+	srv.log = zerolog.New(&logBuf).Level(zerolog.TraceLevel)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/chats?limit=5&token="+token, nil),
+		httptest.NewRequest(http.MethodGet, "/chats?%74oken="+token, nil),
+		httptest.NewRequest(http.MethodGet, "/chats?limit=5", nil),
+	} {
+		if req.URL.RawQuery == "limit=5" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", req.URL, w.Code, w.Body.String())
+		}
+	}
+
+	logs := logBuf.String()
+	if strings.Contains(logs, token) {
+		t.Errorf("token leaked into trace log: %q", logs)
+	}
+	if !strings.Contains(logs, `limit=5&token=REDACTED`) {
+		t.Errorf("expected redacted query in trace log, got %q", logs)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	for raw, want := range map[string]string{
+		"":                        "",
+		"limit=5":                 "limit=5",
+		"token=abc":               "token=REDACTED",
+		"a=1&token=abc&b=2":       "a=1&token=REDACTED&b=2",
+		"%74oken=abc":             "%74oken=REDACTED",
+		"tokens=abc&token":        "tokens=abc&token=REDACTED",
+		"query=token%3Dabc&x=%zz": "query=token%3Dabc&x=%zz",
+	} {
+		if got := redactQuery(raw); got != want {
+			t.Errorf("redactQuery(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steipete/wacli/internal/wa"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	typingComposing = "composing"
+	typingPaused    = "paused"
+
+	// typingIdle is how long a composing state lasts without a follow-up
+	// before /typing sends paused on the client's behalf.
+	typingIdle = 5 * time.Second
+)
+
+type typingRequest struct {
+	ChatJID string `json:"chat_jid"`
+	State   string `json:"state"` // composing or paused
+}
+
+// typingTracker sends paused for chats left composing longer than idle.
+type typingTracker struct {
+	idle   time.Duration
+	pause  func(chat types.JID)
+	timers sync.Map // chat JID string -> *typingTimer
+}
+
+type typingTimer struct {
+	mu sync.Mutex
+	t  *time.Timer
+}
+
+func (e *typingTimer) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.t != nil {
+		e.t.Stop()
+	}
+}
+
+func newTypingTracker(idle time.Duration, pause func(chat types.JID)) *typingTracker {
+	return &typingTracker{idle: idle, pause: pause}
+}
+
+// composing (re)starts chat's idle timer.
+func (tr *typingTracker) composing(chat types.JID) {
+	key := chat.String()
+	e := &typingTimer{}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if old, ok := tr.timers.Swap(key, e); ok {
+		old.(*typingTimer).stop()
+	}
+	e.t = time.AfterFunc(tr.idle, func() {
+		if tr.timers.CompareAndDelete(key, e) {
+			tr.pause(chat)
+		}
+	})
+}
+
+// paused cancels chat's idle timer, if any.
+func (tr *typingTracker) paused(chat types.JID) {
+	if old, ok := tr.timers.LoadAndDelete(chat.String()); ok {
+		old.(*typingTimer).stop()
+	}
+}
+
+// stop cancels every pending timer without sending anything.
+func (tr *typingTracker) stop() {
+	tr.timers.Range(func(key, v any) bool {
+		tr.timers.Delete(key)
+		v.(*typingTimer).stop()
+		return true
+	})
+}
+
+// autoPause is the typingTracker callback: it clears a typing indicator the
+// client never cleared itself.
+func (s *Server) autoPause(chat types.JID) {
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+	if waClient == nil || !waClient.IsConnected() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := waClient.SendChatPresence(ctx, chat, typingPaused); err != nil {
+		s.log.Warn().Err(err).Str("chat", chat.String()).Msg("failed to send paused state")
+	}
+}
+
+func (s *Server) handleTyping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	waClient := s.wa
+	s.mu.RUnlock()
+
+	var req typingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	chatJID := strings.TrimSpace(req.ChatJID)
+	if chatJID == "" {
+		writeError(w, http.StatusBadRequest, "chat_jid is required")
+		return
+	}
+	chat, err := wa.ParseUserOrJID(chatJID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chat_jid: "+err.Error())
+		return
+	}
+	state := strings.ToLower(strings.TrimSpace(req.State))
+	if state != typingComposing && state != typingPaused {
+		writeError(w, http.StatusBadRequest, "state must be composing or paused")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := waClient.SendChatPresence(ctx, chat, state); err != nil {
+		s.log.Error().Err(err).Str("chat", chat.String()).Str("remote", s.clientIP(r)).Msg("failed to send chat presence via RPC")
+		writeError(w, http.StatusInternalServerError, "typing failed: "+err.Error())
+		return
+	}
+	if state == typingComposing {
+		s.typing.composing(chat)
+	} else {
+		s.typing.paused(chat)
+	}
+	writeOK(w, jsonResponse{OK: true})
+}
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func newTestTracker(idle time.Duration) (*typingTracker, chan types.JID) {
+	paused := make(chan types.JID, 10)
+	return newTypingTracker(idle, func(chat types.JID) { paused <- chat }), paused
+}
+
+func TestTypingTracker_AutoPause(t *testing.T) {
+	tr, paused := newTestTracker(20 * time.Millisecond)
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+
+	tr.composing(chat)
+	select {
+	case got := <-paused:
+		if got != chat {
+			t.Fatalf("paused %s, want %s", got, chat)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected automatic paused")
+	}
+	select {
+	case got := <-paused:
+		t.Fatalf("unexpected second paused for %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTypingTracker_ComposingResetsTimer(t *testing.T) {
+	tr, paused := newTestTracker(60 * time.Millisecond)
+	chat := types.JID{User: "123", Server: types.DefaultUserServer}
+
+	start := time.Now()
+	tr.composing(chat)
+	time.Sleep(40 * time.Millisecond)
+	tr.composing(chat)
+
+	select {
+	case <-paused:
+		if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+			t.Fatalf("paused after %s, expected the second composing to restart the timer", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected automatic paused")
+	}
+	select {
+	case <-paused:
+		t.Fatal("expected a single paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTypingTracker_ExplicitPauseCancels(t *testing.T) {
+	tr, paused := newTestTracker(20 * time.Millisecond)
+	alice := types.JID{User: "111", Server: types.DefaultUserServer}
+	bob := types.JID{User: "222", Server: types.DefaultUserServer}
+
+	tr.composing(alice)
+	tr.composing(bob)
+	tr.paused(alice)
+
+	select {
+	case got := <-paused:
+		if got != bob {
+			t.Fatalf("paused %s, want only %s", got, bob)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected automatic paused for bob")
+	}
+	select {
+	case got := <-paused:
+		t.Fatalf("unexpected paused for %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTypingTracker_StopCancelsAll(t *testing.T) {
+	tr, paused := newTestTracker(20 * time.Millisecond)
+	tr.composing(types.JID{User: "111", Server: types.DefaultUserServer})
+	tr.stop()
+
+	select {
+	case got := <-paused:
+		t.Fatalf("unexpected paused for %s after stop", got)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestServer_Typing(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{Addr: "localhost:0", DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.typing.stop()
+	h := srv.Handler()
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"composing", `{"chat_jid":"123@s.whatsapp.net","state":"composing"}`, http.StatusOK},
+		{"paused", `{"chat_jid":"123@s.whatsapp.net","state":"paused"}`, http.StatusOK},
+		{"unknown state", `{"chat_jid":"123@s.whatsapp.net","state":"recording"}`, http.StatusBadRequest},
+		{"missing chat", `{"state":"composing"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/typing", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if len(mock.presence) != 2 || mock.presence[0] != "composing" || mock.presence[1] != "paused" {
+		t.Fatalf("unexpected presence updates: %v", mock.presence)
+	}
+	// The explicit paused cancelled the automatic one.
+	if _, pending := srv.typing.timers.Load("123@s.whatsapp.net"); pending {
+		t.Fatal("expected no pending auto-pause")
+	}
+}
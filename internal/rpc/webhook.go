@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook body when a
+// secret is configured.
+const SignatureHeader = "X-Wacli-Signature"
+
+const (
+	webhookQueueSize = 256
+	// webhookDeliveryTimeout bounds all attempts at one message together,
+	// so a dead endpoint can't hold up the queue behind it for long.
+	webhookDeliveryTimeout = 20 * time.Second
+)
+
+// webhookBackoff is the wait before each retry: a message is POSTed at most
+// len(webhookBackoff)+1 = 3 times before it is given up.
+var webhookBackoff = []time.Duration{1 * time.Second, 2 * time.Second}
+
+// webhook POSTs each new message to a configured URL from a single worker,
+// so deliveries keep their order and never block the WhatsApp event loop.
+type webhook struct {
+	url     string
+	secret  []byte
+	client  *http.Client
+	backoff []time.Duration
+	log     zerolog.Logger
+
+	queue  chan messageJSON
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newWebhook(url, secret string, log zerolog.Logger) *webhook {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &webhook{
+		url:     url,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		backoff: webhookBackoff,
+		log:     log,
+		queue:   make(chan messageJSON, webhookQueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	if secret != "" {
+		h.secret = []byte(secret)
+	}
+	go h.run()
+	return h
+}
+
+// enqueue schedules m for delivery, dropping it if the queue is full.
+func (h *webhook) enqueue(m messageJSON) {
+	select {
+	case h.queue <- m:
+	default:
+		h.log.Warn().Str("msg_id", m.MsgID).Msg("webhook queue full, dropping message")
+	}
+}
+
+// stop abandons queued and in-flight deliveries and waits for the worker.
+func (h *webhook) stop() {
+	h.once.Do(h.cancel)
+	<-h.done
+}
+
+func (h *webhook) run() {
+	defer close(h.done)
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case m := <-h.queue:
+			h.deliver(m)
+		}
+	}
+}
+
+func (h *webhook) deliver(m messageJSON) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		h.log.Warn().Err(err).Str("msg_id", m.MsgID).Msg("webhook encode failed")
+		return
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, webhookDeliveryTimeout)
+	defer cancel()
+	attempts := 0
+	for {
+		attempts++
+		err = h.post(ctx, body)
+		if err == nil || h.ctx.Err() != nil {
+			return
+		}
+		if attempts > len(h.backoff) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(h.backoff[attempts-1]):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	h.log.Warn().Err(err).Str("msg_id", m.MsgID).Int("attempts", attempts).Msg("webhook delivery failed")
+}
+
+func (h *webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.secret != nil {
+		req.Header.Set(SignatureHeader, Sign(h.secret, body))
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
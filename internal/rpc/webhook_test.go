@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steipete/wacli/internal/store"
+)
+
+type webhookHit struct {
+	body      []byte
+	signature string
+}
+
+func newWebhookTarget(t *testing.T, status func(n int32) int) (*httptest.Server, <-chan webhookHit, *atomic.Int32) {
+	t.Helper()
+	hits := make(chan webhookHit, 16)
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		hits <- webhookHit{body: body, signature: r.Header.Get(SignatureHeader)}
+		w.WriteHeader(status(n.Add(1)))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, hits, &n
+}
+
+func newWebhookServer(t *testing.T, url, secret string) (*Server, *mockWA) {
+	t.Helper()
+	db, cleanup := setupTestDB(t)
+	t.Cleanup(cleanup)
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{DB: db, WA: mock, WebhookURL: url, WebhookSecret: secret})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	srv.webhook.backoff = []time.Duration{time.Millisecond, time.Millisecond}
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+	return srv, mock
+}
+
+func waitHit(t *testing.T, hits <-chan webhookHit) webhookHit {
+	t.Helper()
+	select {
+	case h := <-hits:
+		return h
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for webhook")
+		return webhookHit{}
+	}
+}
+
+func TestWebhook_DeliversSignedMessage(t *testing.T) {
+	ts, hits, _ := newWebhookTarget(t, func(int32) int { return http.StatusOK })
+	_, mock := newWebhookServer(t, ts.URL, "s3cret")
+
+	mock.deliver(store.Message{
+		ChatJID:   "123@s.whatsapp.net",
+		MsgID:     "hook1",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Text:      "hello",
+	})
+
+	hit := waitHit(t, hits)
+	var got messageJSON
+	if err := json.Unmarshal(hit.body, &got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.MsgID != "hook1" || got.Text != "hello" || got.Timestamp != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected body %+v", got)
+	}
+	if want := Sign([]byte("s3cret"), hit.body); hit.signature != want {
+		t.Errorf("expected signature %q, got %q", want, hit.signature)
+	}
+}
+
+func TestWebhook_NoSignatureWithoutSecret(t *testing.T) {
+	ts, hits, _ := newWebhookTarget(t, func(int32) int { return http.StatusOK })
+	_, mock := newWebhookServer(t, ts.URL, "")
+
+	mock.deliver(store.Message{MsgID: "hook1"})
+	if hit := waitHit(t, hits); hit.signature != "" {
+		t.Errorf("expected no signature, got %q", hit.signature)
+	}
+}
+
+func TestWebhook_RetriesUntilSuccess(t *testing.T) {
+	ts, hits, n := newWebhookTarget(t, func(n int32) int {
+		if n < 3 {
+			return http.StatusBadGateway
+		}
+		return http.StatusOK
+	})
+	_, mock := newWebhookServer(t, ts.URL, "")
+
+	mock.deliver(store.Message{MsgID: "hook1"})
+	for i := 0; i < 3; i++ {
+		waitHit(t, hits)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := n.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestWebhook_GivesUpAfterRetries(t *testing.T) {
+	ts, hits, n := newWebhookTarget(t, func(int32) int { return http.StatusInternalServerError })
+	_, mock := newWebhookServer(t, ts.URL, "")
+
+	mock.deliver(store.Message{MsgID: "hook1"})
+	mock.deliver(store.Message{MsgID: "hook2"})
+
+	// Three attempts for the first message, then the second is still sent.
+	for i := 0; i < 3; i++ {
+		var got messageJSON
+		if hit := waitHit(t, hits); json.Unmarshal(hit.body, &got) != nil || got.MsgID != "hook1" {
+			t.Fatalf("attempt %d: expected hook1, got %s", i+1, hit.body)
+		}
+	}
+	var got messageJSON
+	if hit := waitHit(t, hits); json.Unmarshal(hit.body, &got) != nil || got.MsgID != "hook2" {
+		t.Errorf("expected delivery to move on to hook2 after 3 attempts, got %s", hit.body)
+	}
+	if got := n.Load(); got < 4 {
+		t.Errorf("expected at least 4 attempts, got %d", got)
+	}
+}
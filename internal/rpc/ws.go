@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/steipete/wacli/internal/store"
+)
+
+const (
+	// wsSendBuffer is how many messages may queue for one client before it
+	// is considered too slow and disconnected.
+	wsSendBuffer = 64
+	// wsFeedBuffer buffers messages between the WhatsApp event loop and the
+	// fan-out goroutine.
+	wsFeedBuffer = 256
+	wsWriteWait  = 10 * time.Second
+	wsPingPeriod = 30 * time.Second
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+type wsClient struct {
+	conn *websocket.Conn
+	send chan messageJSON
+
+	once        sync.Once
+	done        chan struct{}
+	closeCode   int
+	closeReason string
+}
+
+// wsHub tracks connected WebSocket clients and the subscription feeding
+// them from the WhatsApp client.
+type wsHub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+
+	feedWA   WAClient
+	feed     chan store.Message
+	feedStop chan struct{}
+}
+
+// attachFeed subscribes to wa's live messages, replacing any previous
+// subscription.
+func (s *Server) attachFeed(wa WAClient) {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	s.detachFeedLocked()
+	if wa == nil {
+		return
+	}
+	feed := make(chan store.Message, wsFeedBuffer)
+	stop := make(chan struct{})
+	wa.Subscribe(feed)
+	s.ws.feedWA, s.ws.feed, s.ws.feedStop = wa, feed, stop
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case m := <-feed:
+				s.metrics.messageSeen(m)
+				s.broadcast(m)
+			}
+		}
+	}()
+}
+
+func (s *Server) detachFeedLocked() {
+	if s.ws.feedWA == nil {
+		return
+	}
+	s.ws.feedWA.Unsubscribe(s.ws.feed)
+	close(s.ws.feedStop)
+	s.ws.feedWA, s.ws.feed, s.ws.feedStop = nil, nil, nil
+}
+
+// broadcast queues m for the webhook and every client. A client whose buffer is full is
+// dropped rather than allowed to stall the others.
+func (s *Server) broadcast(m store.Message) {
+	mj := newMessageJSON(m)
+	if s.webhook != nil {
+		s.webhook.enqueue(mj)
+	}
+	s.sse.publish(sseEventMessage, mj)
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	for c := range s.ws.clients {
+		select {
+		case c.send <- mj:
+		default:
+			s.removeWSLocked(c, websocket.ClosePolicyViolation, "client too slow")
+		}
+	}
+}
+
+func (s *Server) removeWS(c *wsClient, code int, reason string) {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	s.removeWSLocked(c, code, reason)
+}
+
+func (s *Server) removeWSLocked(c *wsClient, code int, reason string) {
+	delete(s.ws.clients, c)
+	c.once.Do(func() {
+		c.closeCode, c.closeReason = code, reason
+		close(c.done)
+	})
+}
+
+// closeWS disconnects every client and drops the WhatsApp subscription.
+func (s *Server) closeWS() {
+	s.ws.mu.Lock()
+	defer s.ws.mu.Unlock()
+	s.detachFeedLocked()
+	for c := range s.ws.clients {
+		s.removeWSLocked(c, websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// handleWS upgrades to a WebSocket and streams every new message as a
+// messageJSON object. Clients only receive; anything they send is ignored.
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an HTTP error.
+		s.log.Debug().Err(err).Str("remote", s.clientIP(r)).Msg("websocket upgrade failed")
+		return
+	}
+	c := &wsClient{
+		conn: conn,
+		send: make(chan messageJSON, wsSendBuffer),
+		done: make(chan struct{}),
+	}
+	s.ws.mu.Lock()
+	if s.ws.clients == nil {
+		s.ws.clients = make(map[*wsClient]struct{})
+	}
+	s.ws.clients[c] = struct{}{}
+	s.ws.mu.Unlock()
+	s.log.Debug().Str("remote", s.clientIP(r)).Msg("websocket client connected")
+
+	go s.wsWriter(c)
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			s.removeWS(c, websocket.CloseNormalClosure, "")
+			return
+		}
+	}
+}
+
+func (s *Server) wsWriter(c *wsClient) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	defer c.conn.Close()
+	for {
+		// Prefer closing over draining a backlog the client can't keep up with.
+		select {
+		case <-c.done:
+			c.writeClose()
+			return
+		default:
+		}
+		select {
+		case mj := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(mj); err != nil {
+				s.removeWS(c, websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				s.removeWS(c, websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			c.writeClose()
+			return
+		}
+	}
+}
+
+func (c *wsClient) writeClose() {
+	if c.closeCode == websocket.CloseAbnormalClosure {
+		return // connection already broken
+	}
+	msg := websocket.FormatCloseMessage(c.closeCode, c.closeReason)
+	_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+}
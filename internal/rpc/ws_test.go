@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/steipete/wacli/internal/store"
+)
+
+func dialWS(t *testing.T, srv *Server) *websocket.Conn {
+	t.Helper()
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// waitWSClients polls until n clients are registered, since the upgrade
+// handler registers them after the dial returns.
+func waitWSClients(t *testing.T, srv *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		srv.ws.mu.Lock()
+		got := len(srv.ws.clients)
+		srv.ws.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d websocket clients, got %d", n, got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWS_PushesLiveMessages(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	mock := &mockWA{connected: true}
+	srv, err := New(Options{DB: db, WA: mock})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.closeWS()
+
+	a := dialWS(t, srv)
+	b := dialWS(t, srv)
+	waitWSClients(t, srv, 2)
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.deliver(store.Message{
+		ChatJID:   "123@s.whatsapp.net",
+		ChatName:  "Alice",
+		MsgID:     "live1",
+		SenderJID: "123@s.whatsapp.net",
+		Timestamp: ts,
+		Text:      "hello",
+	})
+
+	for _, conn := range []*websocket.Conn{a, b} {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var got messageJSON
+		if err := conn.ReadJSON(&got); err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if got.MsgID != "live1" || got.Text != "hello" || got.ChatName != "Alice" || got.Timestamp != ts.Format(time.RFC3339) {
+			t.Errorf("unexpected message %+v", got)
+		}
+	}
+}
+
+func TestWS_SetWAMovesSubscription(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	first := &mockWA{connected: true}
+	srv, err := New(Options{DB: db, WA: first})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.closeWS()
+
+	second := &mockWA{connected: true}
+	srv.SetWA(second)
+	if len(first.subs) != 0 {
+		t.Errorf("expected old client to be unsubscribed, has %d subscribers", len(first.subs))
+	}
+	if len(second.subs) != 1 {
+		t.Errorf("expected new client to have 1 subscriber, has %d", len(second.subs))
+	}
+}
+
+func TestWS_DropsSlowClient(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	// A client whose writer never drains: its buffer is already full.
+	slow := &wsClient{send: make(chan messageJSON, 1), done: make(chan struct{})}
+	slow.send <- messageJSON{}
+	srv.ws.clients = map[*wsClient]struct{}{slow: {}}
+
+	srv.broadcast(store.Message{MsgID: "m1"})
+
+	select {
+	case <-slow.done:
+	default:
+		t.Fatal("expected slow client to be closed")
+	}
+	if slow.closeCode != websocket.ClosePolicyViolation {
+		t.Errorf("expected close code %d, got %d", websocket.ClosePolicyViolation, slow.closeCode)
+	}
+	if len(srv.ws.clients) != 0 {
+		t.Errorf("expected slow client to be removed, %d left", len(srv.ws.clients))
+	}
+}
+
+func TestWS_SlowClientReceivesCloseFrame(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, err := New(Options{DB: db})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	defer srv.closeWS()
+
+	conn := dialWS(t, srv)
+	waitWSClients(t, srv, 1)
+
+	// Flood without reading; the client must eventually be cut off with a
+	// policy-violation close instead of stalling broadcast.
+	for i := 0; i < wsSendBuffer*4; i++ {
+		srv.broadcast(store.Message{MsgID: "m", Text: strings.Repeat("x", 64<<10)})
+	}
+	waitWSClients(t, srv, 0)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+			t.Fatalf("expected policy violation close, got %v", err)
+		}
+		return
+	}
+}
+
+func TestWS_RejectsNonGet(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	srv, _ := New(Options{DB: db})
+	req := httptest.NewRequest(http.MethodPost, "/ws", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
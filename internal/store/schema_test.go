@@ -1,17 +1,19 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestOpenCreatesExpectedSchema(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "wacli.db")
 
-	db, err := Open(path)
+	db, err := Open(DefaultStoreOptions(path))
 	if err != nil {
 		t.Fatalf("Open: %v", err)
 	}
@@ -57,3 +59,101 @@ func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
 	}
 	return cols, rows.Err()
 }
+
+func TestOpenAddsChatAvatarColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := old.Exec(`CREATE TABLE chats (jid TEXT PRIMARY KEY, kind TEXT NOT NULL, name TEXT, last_message_ts INTEGER)`); err != nil {
+		t.Fatalf("create old chats: %v", err)
+	}
+	if _, err := old.Exec(`INSERT INTO chats(jid, kind, name) VALUES ('123@s.whatsapp.net', 'dm', 'Alice')`); err != nil {
+		t.Fatalf("insert old chat: %v", err)
+	}
+	_ = old.Close()
+
+	db, err := Open(DefaultStoreOptions(path))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	cols, err := tableColumns(db.sql, "chats")
+	if err != nil {
+		t.Fatalf("tableColumns: %v", err)
+	}
+	if !cols["avatar_url"] {
+		t.Fatalf("expected chats.avatar_url to be added")
+	}
+
+	ctx := context.Background()
+	if err := db.SetChatAvatar(ctx, "123@s.whatsapp.net", "https://pps.whatsapp.net/a.jpg"); err != nil {
+		t.Fatalf("SetChatAvatar: %v", err)
+	}
+	c, err := db.GetChat(ctx, "123@s.whatsapp.net")
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.AvatarURL != "https://pps.whatsapp.net/a.jpg" || c.Name != "Alice" {
+		t.Fatalf("unexpected chat %+v", c)
+	}
+}
+
+func TestOpenAddsChatLastReadColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := old.Exec(`CREATE TABLE chats (jid TEXT PRIMARY KEY, kind TEXT NOT NULL, name TEXT, last_message_ts INTEGER)`); err != nil {
+		t.Fatalf("create old chats: %v", err)
+	}
+	_ = old.Close()
+
+	db, err := Open(DefaultStoreOptions(path))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	cols, err := tableColumns(db.sql, "chats")
+	if err != nil {
+		t.Fatalf("tableColumns: %v", err)
+	}
+	if !cols["last_read_msg_id"] {
+		t.Fatalf("expected chats.last_read_msg_id to be added")
+	}
+}
+
+func TestOpenBackfillsMessageCounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := Open(DefaultStoreOptions(path))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	ctx := context.Background()
+	if _, _, err := db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	for _, id := range []string{"m1", "m2"} {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{ChatJID: "123@s.whatsapp.net", MsgID: id, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	// Simulate a database from before the counts table existed.
+	if _, err := db.sql.Exec(`DROP TRIGGER messages_count_ai; DROP TRIGGER messages_count_ad; DROP TABLE chat_message_counts`); err != nil {
+		t.Fatalf("drop counts: %v", err)
+	}
+	_ = db.Close()
+
+	db, err = Open(DefaultStoreOptions(path))
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	if n, err := db.CountMessages(ctx); err != nil || n != 2 {
+		t.Fatalf("CountMessages = %d (err=%v), want 2", n, err)
+	}
+}
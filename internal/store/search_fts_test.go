@@ -3,21 +3,24 @@
 package store
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestSearchMessagesUsesFTSWhenEnabled(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 	if !db.HasFTS() {
 		t.Fatalf("expected HasFTS=true in sqlite_fts5 build")
 	}
 
 	chat := "123@s.whatsapp.net"
-	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
-	if err := db.UpsertMessage(UpsertMessageParams{
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
 		ChatJID:    chat,
 		ChatName:   "Alice",
 		MsgID:      "m1",
@@ -30,7 +33,7 @@ func TestSearchMessagesUsesFTSWhenEnabled(t *testing.T) {
 		t.Fatalf("UpsertMessage: %v", err)
 	}
 
-	ms, err := db.SearchMessages(SearchMessagesParams{Query: "hello", Limit: 10})
+	ms, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "hello", Limit: 10})
 	if err != nil {
 		t.Fatalf("SearchMessages: %v", err)
 	}
@@ -41,3 +44,68 @@ func TestSearchMessagesUsesFTSWhenEnabled(t *testing.T) {
 		t.Fatalf("expected snippet for FTS search, got empty")
 	}
 }
+
+func TestSearchMessagesRankAndSnippets(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Oldest first, so newest-first and best-first orders disagree.
+	docs := []struct{ id, text string }{
+		{"best", "gopher gopher gopher"},
+		{"good", "the gopher sat on the mat"},
+		{"weak", "a long message that mentions a gopher once among many other unrelated words here"},
+		{"none", "nothing to see"},
+	}
+	for i, d := range docs {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     d.id,
+			SenderJID: chat,
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+			Text:      d.text,
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	ids := func(ms []Message) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.MsgID)
+		}
+		return out
+	}
+
+	// FTS matches come best first (BM25).
+	ranked, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "gopher"})
+	if err != nil {
+		t.Fatalf("SearchMessages: %v", err)
+	}
+	if got := ids(ranked); len(got) != 3 || got[0] != "best" || got[1] != "good" || got[2] != "weak" {
+		t.Fatalf("ranked order = %v", got)
+	}
+	if ranked[0].Snippet != "[gopher] [gopher] [gopher]" {
+		t.Fatalf("snippet = %q", ranked[0].Snippet)
+	}
+
+	hl, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "gopher", Highlight: true})
+	if err != nil {
+		t.Fatalf("SearchMessages highlight: %v", err)
+	}
+	if hl[0].Snippet != "<b>gopher</b> <b>gopher</b> <b>gopher</b>" {
+		t.Fatalf("highlighted snippet = %q", hl[0].Snippet)
+	}
+
+	short, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "gopher", Highlight: true, SnippetLen: 3})
+	if err != nil {
+		t.Fatalf("SearchMessages short snippet: %v", err)
+	}
+	if s := short[2].Snippet; !strings.Contains(s, "<b>gopher</b>") || !strings.Contains(s, "…") || len(strings.Fields(s)) > 3 {
+		t.Fatalf("short snippet = %q", s)
+	}
+}
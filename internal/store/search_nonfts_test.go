@@ -3,21 +3,23 @@
 package store
 
 import (
+	"context"
 	"testing"
 	"time"
 )
 
 func TestSearchMessagesUsesLIKEWhenFTSDisabled(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 	if db.HasFTS() {
 		t.Fatalf("expected HasFTS=false in !sqlite_fts5 build")
 	}
 
 	chat := "123@s.whatsapp.net"
-	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
-	if err := db.UpsertMessage(UpsertMessageParams{
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
 		ChatJID:    chat,
 		ChatName:   "Alice",
 		MsgID:      "m1",
@@ -30,7 +32,7 @@ func TestSearchMessagesUsesLIKEWhenFTSDisabled(t *testing.T) {
 		t.Fatalf("UpsertMessage: %v", err)
 	}
 
-	ms, err := db.SearchMessages(SearchMessagesParams{Query: "hello", Limit: 10})
+	ms, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "hello", Limit: 10})
 	if err != nil {
 		t.Fatalf("SearchMessages: %v", err)
 	}
@@ -40,4 +42,10 @@ func TestSearchMessagesUsesLIKEWhenFTSDisabled(t *testing.T) {
 	if ms[0].Snippet != "" {
 		t.Fatalf("expected empty snippet for LIKE search, got %q", ms[0].Snippet)
 	}
+
+	// Snippet options need FTS; without it the LIKE path still answers.
+	ms, err = db.SearchMessages(ctx, SearchMessagesParams{Query: "hello", Limit: 10, Highlight: true, SnippetLen: 5})
+	if err != nil || len(ms) != 1 || ms[0].Snippet != "" {
+		t.Fatalf("highlighted SearchMessages without FTS: %+v, err %v", ms, err)
+	}
 }
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+)
+
+// soundex returns the American Soundex code for s (e.g. "Alexander" and
+// "Aleksander" both map to "A425"). Only ASCII letters are considered; the
+// result is empty if s contains none.
+func soundex(s string) string {
+	var out []byte
+	var last byte
+	for _, r := range strings.ToUpper(s) {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			continue
+		}
+		c := byte(r)
+		code := soundexCode(c)
+		if len(out) == 0 {
+			out = append(out, c)
+			last = code
+			continue
+		}
+		switch {
+		case c == 'H' || c == 'W':
+			// Ignored, and do not separate equal codes.
+			continue
+		case code == '0':
+			// Vowels separate equal codes.
+			last = code
+			continue
+		case code == last:
+			continue
+		}
+		out = append(out, code)
+		last = code
+		if len(out) == 4 {
+			break
+		}
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	for len(out) < 4 {
+		out = append(out, '0')
+	}
+	return string(out[:4])
+}
+
+func soundexCode(c byte) byte {
+	switch c {
+	case 'B', 'F', 'P', 'V':
+		return '1'
+	case 'C', 'G', 'J', 'K', 'Q', 'S', 'X', 'Z':
+		return '2'
+	case 'D', 'T':
+		return '3'
+	case 'L':
+		return '4'
+	case 'M', 'N':
+		return '5'
+	case 'R':
+		return '6'
+	default:
+		return '0'
+	}
+}
+
+// phoneticMatch reports whether every word of query has a phonetically
+// equal word in name.
+func phoneticMatch(query, name string) bool {
+	var nameCodes []string
+	for _, w := range strings.Fields(name) {
+		if c := soundex(w); c != "" {
+			nameCodes = append(nameCodes, c)
+		}
+	}
+	matched := false
+	for _, w := range strings.Fields(query) {
+		qc := soundex(w)
+		if qc == "" {
+			continue
+		}
+		found := false
+		for _, nc := range nameCodes {
+			if nc == qc {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
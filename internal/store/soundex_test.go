@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSoundex(t *testing.T) {
+	tests := map[string]string{
+		"Robert":     "R163",
+		"Rupert":     "R163",
+		"Tymczak":    "T522",
+		"Pfister":    "P236",
+		"Ashcraft":   "A261",
+		"Alexander":  "A425",
+		"Aleksander": "A425",
+		"Lee":        "L000",
+		"":           "",
+		"123":        "",
+	}
+	for in, want := range tests {
+		if got := soundex(in); got != want {
+			t.Errorf("soundex(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchMessagesFuzzySenderName(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@g.us"
+	if _, _, err := db.UpsertChat(ctx, chat, "group", "Group", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	seed := []struct{ id, sender string }{
+		{"m1", "Alexander Smith"},
+		{"m2", "Bob"},
+	}
+	for i, m := range seed {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:    chat,
+			MsgID:      m.id,
+			SenderJID:  "sender@s.whatsapp.net",
+			SenderName: m.sender,
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+			Text:       "see you tomorrow",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	ms, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "Aleksander", Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchMessages: %v", err)
+	}
+	if len(ms) != 0 {
+		t.Fatalf("expected no exact matches without fuzzy, got %d", len(ms))
+	}
+
+	ms, err = db.SearchMessages(ctx, SearchMessagesParams{Query: "Aleksander", Limit: 10, Fuzzy: true})
+	if err != nil {
+		t.Fatalf("SearchMessages fuzzy: %v", err)
+	}
+	if len(ms) != 1 || ms[0].MsgID != "m1" {
+		t.Fatalf("expected fuzzy match on m1, got %+v", ms)
+	}
+}
@@ -1,53 +1,192 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/steipete/wacli/internal/logging"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// MemoryPath opens a private in-memory database instead of a file.
+const MemoryPath = ":memory:"
+
 type DB struct {
 	path       string
 	sql        *sql.DB
 	ftsEnabled bool
+	readOnly   bool
+	wal        bool
+}
+
+// defaultOpenRetries is how many times Open retries when another process
+// holds the database lock. Override with WACLI_DB_OPEN_RETRIES.
+const defaultOpenRetries = 5
+
+// StoreOptions configures how a database is opened. Start from
+// DefaultStoreOptions; the zero value disables WAL.
+type StoreOptions struct {
+	Path string
+	// ReadOnly opens the file with mode=ro and skips schema setup, so the
+	// database must already exist.
+	ReadOnly bool
+	// MaxConns caps the connection pool (defaults to runtime.NumCPU()). WAL
+	// mode lets one writer and several readers use the pool concurrently.
+	MaxConns int
+	// WAL selects journal_mode=WAL; otherwise the rollback journal is used.
+	WAL bool
+	// BusyTimeout is how long a statement waits on a locked database.
+	BusyTimeout time.Duration
+}
+
+const defaultBusyTimeout = 5 * time.Second
+
+// DefaultStoreOptions returns the options wacli itself uses for path.
+func DefaultStoreOptions(path string) StoreOptions {
+	return StoreOptions{Path: path, WAL: true, BusyTimeout: defaultBusyTimeout}
+}
+
+// OpenMemory opens an empty in-memory database, mostly useful in tests.
+func OpenMemory() (*DB, error) {
+	return Open(DefaultStoreOptions(MemoryPath))
 }
 
-func Open(path string) (*DB, error) {
+func Open(opts StoreOptions) (*DB, error) {
+	path := opts.Path
 	log := logging.WithComponent("store")
-	log.Debug().Str("path", path).Msg("opening database")
+	log.Debug().Str("path", path).Bool("read_only", opts.ReadOnly).Msg("opening database")
 
 	if strings.TrimSpace(path) == "" {
 		return nil, fmt.Errorf("db path is required")
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
-		log.Error().Err(err).Msg("failed to create db directory")
-		return nil, fmt.Errorf("create db directory: %w", err)
+	if path == MemoryPath {
+		s, err := openMemory()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to initialize database")
+			return nil, err
+		}
+		return s, nil
+	}
+	if !opts.ReadOnly {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			log.Error().Err(err).Msg("failed to create db directory")
+			return nil, fmt.Errorf("create db directory: %w", err)
+		}
+	}
+
+	retries := openRetries()
+	for attempt := 1; ; attempt++ {
+		s, err := openOnce(opts)
+		if err == nil {
+			log.Info().Str("path", path).Bool("fts_enabled", s.ftsEnabled).Msg("database opened")
+			return s, nil
+		}
+		if !isBusy(err) || attempt > retries {
+			log.Error().Err(err).Msg("failed to initialize database")
+			return nil, err
+		}
+		log.Debug().Err(err).Int("attempt", attempt).Int("max_retries", retries).Msg("database locked, retrying open")
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+}
+
+// dsn builds the connection string. Connection-scoped pragmas live here so
+// every pooled connection gets them.
+func (o StoreOptions) dsn() string {
+	q := url.Values{}
+	q.Set("_foreign_keys", "on")
+	busy := o.BusyTimeout
+	if busy < 0 {
+		busy = 0
+	}
+	q.Set("_busy_timeout", strconv.FormatInt(busy.Milliseconds(), 10))
+	if o.ReadOnly {
+		q.Set("mode", "ro")
+	} else if o.WAL {
+		q.Set("_journal_mode", "WAL")
+		q.Set("_synchronous", "NORMAL")
+	} else {
+		q.Set("_journal_mode", "DELETE")
+	}
+	return "file:" + o.Path + "?" + q.Encode()
+}
+
+func openOnce(opts StoreOptions) (*DB, error) {
+	db, err := sql.Open("sqlite3", opts.dsn())
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	maxConns := opts.MaxConns
+	if maxConns <= 0 {
+		maxConns = runtime.NumCPU()
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns)
+
+	s := &DB{path: opts.Path, sql: db, readOnly: opts.ReadOnly, wal: opts.WAL}
+	if err := s.init(); err != nil {
+		_ = db.Close()
+		return nil, err
 	}
+	return s, nil
+}
 
-	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+// openMemory opens an in-memory database. Every connection to ":memory:"
+// gets its own empty database, so the pool is pinned to one connection that
+// is never recycled. There is no file, so no WAL and nothing to retry.
+func openMemory() (*DB, error) {
+	db, err := sql.Open("sqlite3", "file::memory:?_foreign_keys=on")
 	if err != nil {
-		log.Error().Err(err).Msg("failed to open sqlite")
 		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
 
-	s := &DB{path: path, sql: db}
+	s := &DB{path: MemoryPath, sql: db}
 	if err := s.init(); err != nil {
 		_ = db.Close()
-		log.Error().Err(err).Msg("failed to initialize database")
 		return nil, err
 	}
-	log.Info().Str("path", path).Bool("fts_enabled", s.ftsEnabled).Msg("database opened")
 	return s, nil
 }
 
+func openRetries() int {
+	raw := strings.TrimSpace(os.Getenv("WACLI_DB_OPEN_RETRIES"))
+	if raw == "" {
+		return defaultOpenRetries
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return defaultOpenRetries
+	}
+	return n
+}
+
+// isBusy reports whether err means another connection holds the lock.
+func isBusy(err error) bool {
+	var se sqlite3.Error
+	if errors.As(err, &se) {
+		return se.Code == sqlite3.ErrBusy || se.Code == sqlite3.ErrLocked
+	}
+	// Some init steps don't wrap the driver error.
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
+
 func (d *DB) Close() error {
 	if d == nil || d.sql == nil {
 		return nil
@@ -56,9 +195,22 @@ func (d *DB) Close() error {
 }
 
 func (d *DB) init() error {
-	// Pragmas: keep consistent for writers/readers.
-	_, _ = d.sql.Exec("PRAGMA journal_mode=WAL;")
-	_, _ = d.sql.Exec("PRAGMA synchronous=NORMAL;")
+	// Pragmas: keep consistent for writers/readers. The connection-scoped
+	// ones are also in the DSN so every pooled connection gets them.
+	if d.readOnly {
+		// No schema changes; just see whether an FTS index is there to use.
+		_, _ = d.sql.Exec("PRAGMA temp_store=MEMORY;")
+		hasFTS, err := d.tableExists("messages_fts")
+		if err != nil {
+			return err
+		}
+		d.ftsEnabled = hasFTS
+		return nil
+	}
+	if d.path != MemoryPath && d.wal {
+		_, _ = d.sql.Exec("PRAGMA journal_mode=WAL;")
+		_, _ = d.sql.Exec("PRAGMA synchronous=NORMAL;")
+	}
 	_, _ = d.sql.Exec("PRAGMA temp_store=MEMORY;")
 	_, _ = d.sql.Exec("PRAGMA foreign_keys=ON;")
 
@@ -74,7 +226,11 @@ func (d *DB) ensureSchema() error {
 			jid TEXT PRIMARY KEY,
 			kind TEXT NOT NULL, -- dm|group|broadcast|unknown
 			name TEXT,
-			last_message_ts INTEGER
+			description TEXT,
+			participants_count INTEGER, -- groups only
+			last_message_ts INTEGER,
+			avatar_url TEXT,
+			last_read_msg_id TEXT
 		);
 
 		CREATE TABLE IF NOT EXISTS contacts (
@@ -118,6 +274,46 @@ func (d *DB) ensureSchema() error {
 			PRIMARY KEY (jid, tag)
 		);
 
+		CREATE TABLE IF NOT EXISTS reactions (
+			chat_jid TEXT NOT NULL,
+			msg_id TEXT NOT NULL,
+			sender_jid TEXT NOT NULL, -- '' for reactions sent by us
+			reaction TEXT NOT NULL,
+			ts INTEGER NOT NULL,
+			PRIMARY KEY (chat_jid, msg_id, sender_jid)
+		);
+
+		CREATE TABLE IF NOT EXISTS poll_options (
+			chat_jid TEXT NOT NULL,
+			msg_id TEXT NOT NULL,
+			idx INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			PRIMARY KEY (chat_jid, msg_id, idx)
+		);
+
+		CREATE TABLE IF NOT EXISTS poll_votes (
+			chat_jid TEXT NOT NULL,
+			msg_id TEXT NOT NULL,
+			voter_jid TEXT NOT NULL, -- '' for our own vote
+			option_name TEXT NOT NULL,
+			ts INTEGER NOT NULL,
+			PRIMARY KEY (chat_jid, msg_id, voter_jid, option_name)
+		);
+
+		CREATE TABLE IF NOT EXISTS scheduled_messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			to_jid TEXT NOT NULL,
+			message TEXT NOT NULL,
+			send_at INTEGER NOT NULL,
+			created_at INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending', -- pending, sending, sent, failed or canceled
+			retries INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			msg_id TEXT -- set once sent
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_scheduled_messages_due ON scheduled_messages(status, send_at);
+
 		CREATE TABLE IF NOT EXISTS messages (
 			rowid INTEGER PRIMARY KEY AUTOINCREMENT,
 			chat_jid TEXT NOT NULL,
@@ -130,6 +326,8 @@ func (d *DB) ensureSchema() error {
 			text TEXT,
 			display_text TEXT,
 			media_type TEXT,
+			forwarded_score INTEGER,
+			reply_to_msg_id TEXT,
 			media_caption TEXT,
 			filename TEXT,
 			mime_type TEXT,
@@ -154,6 +352,10 @@ func (d *DB) ensureSchema() error {
 		return err
 	}
 
+	if err := d.ensureMessageCounts(); err != nil {
+		return err
+	}
+
 	if err := d.ensureMessagesFTS(); err != nil {
 		return err
 	}
@@ -162,15 +364,77 @@ func (d *DB) ensureSchema() error {
 }
 
 func (d *DB) ensureMessageColumns() error {
-	ok, err := d.tableHasColumn("messages", "display_text")
+	if err := d.ensureColumn("messages", "display_text", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureColumn("messages", "forwarded_score", "INTEGER"); err != nil {
+		return err
+	}
+	if err := d.ensureColumn("messages", "reply_to_msg_id", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureColumn("chats", "description", "TEXT"); err != nil {
+		return err
+	}
+	if err := d.ensureColumn("chats", "participants_count", "INTEGER"); err != nil {
+		return err
+	}
+	if err := d.ensureColumn("chats", "avatar_url", "TEXT"); err != nil {
+		return err
+	}
+	return d.ensureColumn("chats", "last_read_msg_id", "TEXT")
+}
+
+// ensureColumn adds column to table if an older database lacks it.
+func (d *DB) ensureColumn(table, column, typ string) error {
+	ok, err := d.tableHasColumn(table, column)
 	if err != nil {
 		return err
 	}
 	if ok {
 		return nil
 	}
-	if _, err := d.sql.Exec(`ALTER TABLE messages ADD COLUMN display_text TEXT`); err != nil {
-		return fmt.Errorf("add display_text column: %w", err)
+	if _, err := d.sql.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, typ)); err != nil {
+		return fmt.Errorf("add %s column: %w", column, err)
+	}
+	return nil
+}
+
+// ensureMessageCounts keeps chat_message_counts in step with messages via
+// triggers, so counting never scans the messages table. Upserts that hit an
+// existing row take the UPDATE path and leave the counts alone.
+func (d *DB) ensureMessageCounts() error {
+	exists, err := d.tableExists("chat_message_counts")
+	if err != nil {
+		return err
+	}
+	if _, err := d.sql.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_message_counts (
+			chat_jid TEXT PRIMARY KEY,
+			count INTEGER NOT NULL
+		);
+
+		CREATE TRIGGER IF NOT EXISTS messages_count_ai AFTER INSERT ON messages BEGIN
+			INSERT INTO chat_message_counts(chat_jid, count) VALUES (new.chat_jid, 1)
+			ON CONFLICT(chat_jid) DO UPDATE SET count = count + 1;
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS messages_count_ad AFTER DELETE ON messages BEGIN
+			UPDATE chat_message_counts SET count = count - 1 WHERE chat_jid = old.chat_jid;
+			DELETE FROM chat_message_counts WHERE chat_jid = old.chat_jid AND count <= 0;
+		END;
+	`); err != nil {
+		return fmt.Errorf("create message counts: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	// Older databases already hold messages the triggers never saw.
+	if _, err := d.sql.Exec(`
+		INSERT OR REPLACE INTO chat_message_counts(chat_jid, count)
+		SELECT chat_jid, COUNT(1) FROM messages GROUP BY chat_jid
+	`); err != nil {
+		return fmt.Errorf("backfill message counts: %w", err)
 	}
 	return nil
 }
@@ -296,10 +560,15 @@ func (d *DB) tableHasColumn(table, column string) (bool, error) {
 // --- domain types + helpers
 
 type Chat struct {
-	JID           string
-	Kind          string
-	Name          string
-	LastMessageTS time.Time
+	JID         string
+	Kind        string
+	Name        string
+	Description string
+	// ParticipantsCount is nil for chats that are not groups or whose
+	// membership has not been synced yet.
+	ParticipantsCount *int
+	LastMessageTS     time.Time
+	AvatarURL         string // profile or group photo; empty if unknown or unset
 }
 
 type Group struct {
@@ -313,15 +582,23 @@ type Group struct {
 type GroupParticipant struct {
 	GroupJID  string
 	UserJID   string
-	Role      string
+	Name      string // from contacts; read-only
+	Role      string // member, admin or superadmin
 	UpdatedAt time.Time
 }
 
+// IsAdmin reports whether the participant is an admin or the group's
+// super admin.
+func (p GroupParticipant) IsAdmin() bool {
+	return p.Role == "admin" || p.Role == "superadmin"
+}
+
 type MediaDownloadInfo struct {
 	ChatJID       string
 	ChatName      string
 	MsgID         string
 	MediaType     string
+	Caption       string
 	Filename      string
 	MimeType      string
 	DirectPath    string
@@ -338,12 +615,17 @@ type Message struct {
 	ChatName    string
 	MsgID       string
 	SenderJID   string
+	SenderName  string
 	Timestamp   time.Time
 	FromMe      bool
 	Text        string
 	DisplayText string
 	MediaType   string
-	Snippet     string
+	// ForwardedScore is how many times the message has been forwarded;
+	// WhatsApp labels 5 and up "forwarded many times".
+	ForwardedScore uint32
+	ReplyToMsgID   string // the quoted message, if this is a reply
+	Snippet        string
 }
 
 type MessageInfo struct {
@@ -385,19 +667,38 @@ func boolToInt(b bool) int {
 	return 0
 }
 
-func (d *DB) UpsertChat(jid, kind, name string, lastTS time.Time) error {
+// UpsertChat inserts or updates a chat and returns the stored row. Empty
+// name or description values leave the stored ones untouched. inserted is
+// true if the chat was not in the store before.
+func (d *DB) UpsertChat(ctx context.Context, jid, kind, name, description string, lastTS time.Time) (c Chat, inserted bool, err error) {
 	if strings.TrimSpace(kind) == "" {
 		kind = "unknown"
 	}
-	_, err := d.sql.Exec(`
-		INSERT INTO chats(jid, kind, name, last_message_ts)
-		VALUES(?, ?, ?, ?)
-		ON CONFLICT(jid) DO UPDATE SET
-			kind=excluded.kind,
-			name=CASE WHEN excluded.name IS NOT NULL AND excluded.name != '' THEN excluded.name ELSE chats.name END,
-			last_message_ts=CASE WHEN excluded.last_message_ts > COALESCE(chats.last_message_ts, 0) THEN excluded.last_message_ts ELSE chats.last_message_ts END
-	`, jid, kind, name, unix(lastTS))
-	return err
+	err = d.WithTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM chats WHERE jid = ?)`, jid).Scan(&exists); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO chats(jid, kind, name, description, last_message_ts)
+			VALUES(?, ?, ?, ?, ?)
+			ON CONFLICT(jid) DO UPDATE SET
+				kind=excluded.kind,
+				name=CASE WHEN excluded.name IS NOT NULL AND excluded.name != '' THEN excluded.name ELSE chats.name END,
+				description=CASE WHEN excluded.description IS NOT NULL AND excluded.description != '' THEN excluded.description ELSE chats.description END,
+				last_message_ts=CASE WHEN excluded.last_message_ts > COALESCE(chats.last_message_ts, 0) THEN excluded.last_message_ts ELSE chats.last_message_ts END
+		`, jid, kind, name, nullIfEmpty(description), unix(lastTS)); err != nil {
+			return err
+		}
+		var err error
+		c, err = getChat(ctx, tx, jid)
+		inserted = exists == 0
+		return err
+	})
+	if err != nil {
+		return Chat{}, false, err
+	}
+	return c, inserted, nil
 }
 
 type UpsertMessageParams struct {
@@ -419,15 +720,49 @@ type UpsertMessageParams struct {
 	FileSHA256    []byte
 	FileEncSHA256 []byte
 	FileLength    uint64
+	// ForwardedScore is kept from an earlier copy when this one has none.
+	ForwardedScore uint32
+	// ReplyToMsgID is the ID of the message this one quotes, if any.
+	ReplyToMsgID string
 }
 
-func (d *DB) UpsertMessage(p UpsertMessageParams) error {
-	_, err := d.sql.Exec(`
+// UpsertMessage inserts or updates a message and returns the stored row;
+// inserted is true if the message was not in the store before. In the same
+// transaction it advances the chat's last_message_ts, so chat ordering never
+// lags behind its messages.
+func (d *DB) UpsertMessage(ctx context.Context, p UpsertMessageParams) (m Message, inserted bool, err error) {
+	err = d.WithTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM messages WHERE chat_jid = ? AND msg_id = ?)`, p.ChatJID, p.MsgID).Scan(&exists); err != nil {
+			return err
+		}
+		if err := upsertMessage(ctx, tx, p); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE chats SET last_message_ts = ?
+			WHERE jid = ? AND COALESCE(last_message_ts, 0) < ?
+		`, unix(p.Timestamp), p.ChatJID, unix(p.Timestamp)); err != nil {
+			return err
+		}
+		var err error
+		m, err = getMessage(ctx, tx, p.ChatJID, p.MsgID)
+		inserted = exists == 0
+		return err
+	})
+	if err != nil {
+		return Message{}, false, err
+	}
+	return m, inserted, nil
+}
+
+func upsertMessage(ctx context.Context, tx *sql.Tx, p UpsertMessageParams) error {
+	_, err := tx.ExecContext(ctx, `
 		INSERT INTO messages(
 			chat_jid, chat_name, msg_id, sender_jid, sender_name, ts, from_me, text, display_text,
 			media_type, media_caption, filename, mime_type, direct_path,
-			media_key, file_sha256, file_enc_sha256, file_length
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			media_key, file_sha256, file_enc_sha256, file_length, forwarded_score, reply_to_msg_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(chat_jid, msg_id) DO UPDATE SET
 			chat_name=COALESCE(NULLIF(excluded.chat_name,''), messages.chat_name),
 			sender_jid=excluded.sender_jid,
@@ -444,10 +779,12 @@ func (d *DB) UpsertMessage(p UpsertMessageParams) error {
 			media_key=CASE WHEN excluded.media_key IS NOT NULL AND length(excluded.media_key)>0 THEN excluded.media_key ELSE messages.media_key END,
 			file_sha256=CASE WHEN excluded.file_sha256 IS NOT NULL AND length(excluded.file_sha256)>0 THEN excluded.file_sha256 ELSE messages.file_sha256 END,
 			file_enc_sha256=CASE WHEN excluded.file_enc_sha256 IS NOT NULL AND length(excluded.file_enc_sha256)>0 THEN excluded.file_enc_sha256 ELSE messages.file_enc_sha256 END,
-			file_length=CASE WHEN excluded.file_length>0 THEN excluded.file_length ELSE messages.file_length END
+			file_length=CASE WHEN excluded.file_length>0 THEN excluded.file_length ELSE messages.file_length END,
+			forwarded_score=CASE WHEN excluded.forwarded_score>0 THEN excluded.forwarded_score ELSE messages.forwarded_score END,
+			reply_to_msg_id=COALESCE(NULLIF(excluded.reply_to_msg_id,''), messages.reply_to_msg_id)
 	`, p.ChatJID, nullIfEmpty(p.ChatName), p.MsgID, nullIfEmpty(p.SenderJID), nullIfEmpty(p.SenderName), unix(p.Timestamp), boolToInt(p.FromMe), nullIfEmpty(p.Text), nullIfEmpty(p.DisplayText),
 		nullIfEmpty(p.MediaType), nullIfEmpty(p.MediaCaption), nullIfEmpty(p.Filename), nullIfEmpty(p.MimeType), nullIfEmpty(p.DirectPath),
-		p.MediaKey, p.FileSHA256, p.FileEncSHA256, int64(p.FileLength),
+		p.MediaKey, p.FileSHA256, p.FileEncSHA256, int64(p.FileLength), int64(p.ForwardedScore), nullIfEmpty(p.ReplyToMsgID),
 	)
 	return err
 }
@@ -461,18 +798,102 @@ func nullIfEmpty(s string) interface{} {
 }
 
 type ListMessagesParams struct {
-	ChatJID string
-	Limit   int
-	Before  *time.Time
-	After   *time.Time
+	ChatJID    string
+	SenderJID  string   // a JID, or a bare phone number matching any device
+	FromMe     *bool    // nil = all, true = sent by me, false = received
+	MediaTypes []string // e.g. image, document; empty = any
+	HasMedia   *bool    // nil = all, true = any media, false = text only
+	TextSearch string   // plain substring match on the text, no FTS needed
+	Limit      int
+	Before     *time.Time
+	After      *time.Time
+
+	// SenderNameSearch is a substring of the sender's name. When SenderJID
+	// is also set, messages matching either are returned.
+	SenderNameSearch string
+
+	// BeforeCursor pages towards older messages: only rows strictly older
+	// than the cursor (by timestamp, then msg_id) are returned.
+	BeforeCursor *MessageCursor
+	// AfterCursor pages towards newer messages: the page holds the rows
+	// immediately newer than the cursor.
+	AfterCursor *MessageCursor
+
+	// Order sorts the page: OrderDesc (the default) newest first, OrderAsc
+	// oldest first. It doesn't pick the rows, the cursor does; without one,
+	// a descending page holds the newest rows and an ascending one the
+	// oldest. To read chronologically, page with AfterCursor and OrderAsc;
+	// to read back in time, with BeforeCursor and OrderDesc.
+	Order string
 }
 
-func (d *DB) ListMessages(p ListMessagesParams) ([]Message, error) {
+// Message orders for ListMessagesParams.Order.
+const (
+	OrderDesc = "desc"
+	OrderAsc  = "asc"
+)
+
+// senderJIDCondition matches m.sender_jid against a JID, or against a bare
+// phone number ("+" optional), which matches that user on any device.
+func senderJIDCondition(sender string) (string, []interface{}) {
+	if strings.Contains(sender, "@") {
+		return "m.sender_jid = ?", []interface{}{sender}
+	}
+	user := escapeLike(strings.TrimPrefix(sender, "+"))
+	return `(m.sender_jid LIKE ? ESCAPE '\' OR m.sender_jid LIKE ? ESCAPE '\')`, []interface{}{user + "@%", user + ":%"}
+}
+
+// MessageCursor marks the edge of a message page, ordered by
+// (timestamp, msg_id) so rows sharing a second are neither skipped nor
+// repeated.
+type MessageCursor struct {
+	Timestamp time.Time
+	MsgID     string
+}
+
+// Encode returns an opaque, URL-safe representation of the cursor.
+func (c MessageCursor) Encode() string {
+	raw := strconv.FormatInt(unix(c.Timestamp), 10) + ":" + c.MsgID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseMessageCursor decodes a cursor produced by MessageCursor.Encode.
+func ParseMessageCursor(s string) (MessageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return MessageCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	tsStr, msgID, ok := strings.Cut(string(raw), ":")
+	if !ok || msgID == "" {
+		return MessageCursor{}, fmt.Errorf("invalid cursor")
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return MessageCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return MessageCursor{Timestamp: fromUnix(ts), MsgID: msgID}, nil
+}
+
+type MessagePage struct {
+	Messages []Message
+	// NextCursor continues in the direction of the request: pass it as
+	// BeforeCursor (or AfterCursor, if that was used, or if neither was and
+	// Order is OrderAsc) to get the next page. It is nil when there are no
+	// more rows.
+	NextCursor *MessageCursor
+}
+
+func (d *DB) ListMessages(ctx context.Context, p ListMessagesParams) ([]Message, error) {
+	page, err := d.ListMessagesPage(ctx, p)
+	return page.Messages, err
+}
+
+func (d *DB) ListMessagesPage(ctx context.Context, p ListMessagesParams) (MessagePage, error) {
 	if p.Limit <= 0 {
 		p.Limit = 50
 	}
 	query := `
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,'')
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,'')
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE 1=1`
@@ -481,6 +902,46 @@ func (d *DB) ListMessages(p ListMessagesParams) ([]Message, error) {
 		query += " AND m.chat_jid = ?"
 		args = append(args, p.ChatJID)
 	}
+	senderJID := strings.TrimSpace(p.SenderJID)
+	senderName := strings.TrimSpace(p.SenderNameSearch)
+	switch {
+	case senderJID != "" && senderName != "":
+		cond, condArgs := senderJIDCondition(senderJID)
+		query += ` AND (` + cond + ` OR m.sender_name LIKE ? ESCAPE '\')`
+		args = append(append(args, condArgs...), "%"+escapeLike(senderName)+"%")
+	case senderJID != "":
+		cond, condArgs := senderJIDCondition(senderJID)
+		query += " AND " + cond
+		args = append(args, condArgs...)
+	case senderName != "":
+		query += ` AND m.sender_name LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(senderName)+"%")
+	}
+	if p.FromMe != nil {
+		query += " AND m.from_me = ?"
+		args = append(args, boolToInt(*p.FromMe))
+	}
+	var mediaTypes []interface{}
+	for _, t := range p.MediaTypes {
+		if t = strings.TrimSpace(t); t != "" {
+			mediaTypes = append(mediaTypes, t)
+		}
+	}
+	if len(mediaTypes) > 0 {
+		query += " AND m.media_type IN (" + strings.TrimSuffix(strings.Repeat("?,", len(mediaTypes)), ",") + ")"
+		args = append(args, mediaTypes...)
+	}
+	if p.HasMedia != nil {
+		if *p.HasMedia {
+			query += " AND COALESCE(m.media_type,'') != ''"
+		} else {
+			query += " AND COALESCE(m.media_type,'') = ''"
+		}
+	}
+	if p.TextSearch != "" {
+		query += ` AND m.text LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(p.TextSearch)+"%")
+	}
 	if p.After != nil {
 		query += " AND m.ts > ?"
 		args = append(args, unix(*p.After))
@@ -489,12 +950,32 @@ func (d *DB) ListMessages(p ListMessagesParams) ([]Message, error) {
 		query += " AND m.ts < ?"
 		args = append(args, unix(*p.Before))
 	}
-	query += " ORDER BY m.ts DESC LIMIT ?"
-	args = append(args, p.Limit)
+	if p.BeforeCursor != nil {
+		query += " AND (m.ts, m.msg_id) < (?, ?)"
+		args = append(args, unix(p.BeforeCursor.Timestamp), p.BeforeCursor.MsgID)
+	}
+	if p.AfterCursor != nil {
+		query += " AND (m.ts, m.msg_id) > (?, ?)"
+		args = append(args, unix(p.AfterCursor.Timestamp), p.AfterCursor.MsgID)
+	}
+	// Walk away from the cursor (or, without one, from the end the order
+	// starts at) and flip the page afterwards if that runs against the
+	// requested order. Fetch one extra row to know whether another page
+	// exists.
+	forward := p.AfterCursor != nil && p.BeforeCursor == nil
+	if p.AfterCursor == nil && p.BeforeCursor == nil {
+		forward = p.Order == OrderAsc
+	}
+	if forward {
+		query += " ORDER BY m.ts ASC, m.msg_id ASC LIMIT ?"
+	} else {
+		query += " ORDER BY m.ts DESC, m.msg_id DESC LIMIT ?"
+	}
+	args = append(args, p.Limit+1)
 
-	rows, err := d.sql.Query(query, args...)
+	rows, err := d.sql.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return MessagePage{}, err
 	}
 	defer rows.Close()
 
@@ -503,14 +984,66 @@ func (d *DB) ListMessages(p ListMessagesParams) ([]Message, error) {
 		var m Message
 		var ts int64
 		var fromMe int
-		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType); err != nil {
-			return nil, err
+		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.ForwardedScore, &m.ReplyToMsgID); err != nil {
+			return MessagePage{}, err
 		}
 		m.Timestamp = fromUnix(ts)
 		m.FromMe = fromMe != 0
 		out = append(out, m)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return MessagePage{}, err
+	}
+
+	page := MessagePage{Messages: out}
+	if len(out) > p.Limit {
+		page.Messages = out[:p.Limit]
+		last := page.Messages[p.Limit-1]
+		page.NextCursor = &MessageCursor{Timestamp: last.Timestamp, MsgID: last.MsgID}
+	}
+	if forward != (p.Order == OrderAsc) {
+		slices.Reverse(page.Messages)
+	}
+	return page, nil
+}
+
+// EachMessage calls fn for every stored message, oldest first, without
+// loading them all into memory. An empty chatJID walks every chat. Iteration
+// stops at the first error fn returns. The rows hold a connection open, so
+// fn must not query d on a single-connection (in-memory) database.
+func (d *DB) EachMessage(ctx context.Context, chatJID string, fn func(Message) error) error {
+	query := `
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,'')
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(chatJID) != "" {
+		query += " AND m.chat_jid = ?"
+		args = append(args, chatJID)
+	}
+	query += " ORDER BY m.ts ASC, m.msg_id ASC"
+
+	rows, err := d.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m Message
+		var ts int64
+		var fromMe int
+		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.ForwardedScore, &m.ReplyToMsgID); err != nil {
+			return err
+		}
+		m.Timestamp = fromUnix(ts)
+		m.FromMe = fromMe != 0
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 type SearchMessagesParams struct {
@@ -521,53 +1054,217 @@ type SearchMessagesParams struct {
 	Before  *time.Time
 	After   *time.Time
 	Type    string
+
+	// Fuzzy additionally matches messages whose sender name sounds like the
+	// query (Soundex). This is a fallback for spelling variants such as
+	// "Aleksander"/"Alexander": it only looks at sender names, is tuned for
+	// English pronunciation, and ignores non-ASCII letters.
+	Fuzzy bool
+
+	// SnippetLen is how many tokens of context Message.Snippet shows
+	// around the match (FTS only). Zero means 12; at most 64.
+	SnippetLen int
+	// Highlight wraps matched terms in Message.Snippet in <b></b> instead
+	// of [].
+	Highlight bool
+}
+
+const (
+	defaultSnippetLen = 12
+	maxSnippetLen     = 64 // FTS5's limit
+)
+
+// SearchResult is a page of search results plus the total number of matches.
+type SearchResult struct {
+	Messages   []Message
+	TotalCount int64
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
-func (d *DB) SearchMessages(p SearchMessagesParams) ([]Message, error) {
+func (d *DB) SearchMessages(ctx context.Context, p SearchMessagesParams) ([]Message, error) {
 	if strings.TrimSpace(p.Query) == "" {
 		return nil, fmt.Errorf("query is required")
 	}
 	if p.Limit <= 0 {
 		p.Limit = 50
 	}
+	return d.search(ctx, d.sql, p)
+}
+
+// SearchMessagesWithCount is like SearchMessages but also reports the total
+// number of direct matches (ignoring Limit), counted in the same read
+// transaction as the page. Fuzzy sender-name matches only count as far as
+// they appear in the returned page.
+func (d *DB) SearchMessagesWithCount(ctx context.Context, p SearchMessagesParams) (SearchResult, error) {
+	if strings.TrimSpace(p.Query) == "" {
+		return SearchResult{}, fmt.Errorf("query is required")
+	}
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	msgs, err := d.search(ctx, tx, p)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	from, args := d.searchFrom(p)
+	var total int64
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) "+from, args...).Scan(&total); err != nil {
+		return SearchResult{}, err
+	}
+	if n := int64(len(msgs)); n > total {
+		total = n
+	}
+	return SearchResult{Messages: msgs, TotalCount: total}, tx.Commit()
+}
 
+func (d *DB) search(ctx context.Context, q queryer, p SearchMessagesParams) ([]Message, error) {
+	var out []Message
+	var err error
 	if d.ftsEnabled {
-		return d.searchFTS(p)
+		out, err = searchFTS(ctx, q, p)
+	} else {
+		out, err = searchLIKE(ctx, q, p)
+	}
+	if err != nil || !p.Fuzzy || len(out) >= p.Limit {
+		return out, err
+	}
+
+	phonetic, err := searchSenderPhonetic(ctx, q, p)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(out))
+	for _, m := range out {
+		seen[m.ChatJID+"/"+m.MsgID] = true
+	}
+	for _, m := range phonetic {
+		if len(out) >= p.Limit {
+			break
+		}
+		if !seen[m.ChatJID+"/"+m.MsgID] {
+			out = append(out, m)
+		}
 	}
-	return d.searchLIKE(p)
+	return out, nil
 }
 
-func (d *DB) searchLIKE(p SearchMessagesParams) ([]Message, error) {
+// searchSenderPhonetic returns messages whose sender name phonetically
+// matches the query. Sender names are matched in Go since SQLite's soundex()
+// is not compiled in by default.
+func searchSenderPhonetic(ctx context.Context, q queryer, p SearchMessagesParams) ([]Message, error) {
+	rows, err := q.QueryContext(ctx, `SELECT DISTINCT sender_name FROM messages WHERE COALESCE(sender_name,'') != ''`)
+	if err != nil {
+		return nil, err
+	}
+	var names []interface{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if phoneticMatch(p.Query, name) {
+			names = append(names, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
 	query := `
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), ''
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,''), ''
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
-		WHERE (LOWER(m.text) LIKE LOWER(?) OR LOWER(m.display_text) LIKE LOWER(?) OR LOWER(m.media_caption) LIKE LOWER(?) OR LOWER(m.filename) LIKE LOWER(?) OR LOWER(COALESCE(m.chat_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(m.sender_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.name,'')) LIKE LOWER(?))`
-	needle := "%" + p.Query + "%"
-	args := []interface{}{needle, needle, needle, needle, needle, needle, needle}
+		WHERE m.sender_name IN (` + strings.TrimSuffix(strings.Repeat("?,", len(names)), ",") + `)`
+	args := names
 	query, args = applyMessageFilters(query, args, p)
 	query += " ORDER BY m.ts DESC LIMIT ?"
 	args = append(args, p.Limit)
-	return d.scanMessages(query, args...)
+	return scanMessages(ctx, q, query, args...)
 }
 
-func (d *DB) searchFTS(p SearchMessagesParams) ([]Message, error) {
-	query := `
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''),
-		       snippet(messages_fts, 0, '[', ']', '…', 12)
-		FROM messages_fts
-		JOIN messages m ON messages_fts.rowid = m.rowid
-		LEFT JOIN chats c ON c.jid = m.chat_jid
-		WHERE messages_fts MATCH ?`
-	args := []interface{}{p.Query}
-	query, args = applyMessageFilters(query, args, p)
-	query += " ORDER BY bm25(messages_fts) LIMIT ?"
-	args = append(args, p.Limit)
-	return d.scanMessages(query, args...)
+// searchFrom returns the FROM/WHERE clause (with filters) shared by the
+// search page and count queries.
+func (d *DB) searchFrom(p SearchMessagesParams) (string, []interface{}) {
+	if d.ftsEnabled {
+		return searchFTSFrom(p)
+	}
+	return searchLIKEFrom(p)
 }
 
-func applyMessageFilters(query string, args []interface{}, p SearchMessagesParams) (string, []interface{}) {
-	if strings.TrimSpace(p.ChatJID) != "" {
+// escapeLike escapes LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func searchLIKEFrom(p SearchMessagesParams) (string, []interface{}) {
+	from := `
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE (LOWER(m.text) LIKE LOWER(?) OR LOWER(m.display_text) LIKE LOWER(?) OR LOWER(m.media_caption) LIKE LOWER(?) OR LOWER(m.filename) LIKE LOWER(?) OR LOWER(COALESCE(m.chat_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(m.sender_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.name,'')) LIKE LOWER(?))`
+	needle := "%" + p.Query + "%"
+	args := []interface{}{needle, needle, needle, needle, needle, needle, needle}
+	return applyMessageFilters(from, args, p)
+}
+
+func searchFTSFrom(p SearchMessagesParams) (string, []interface{}) {
+	from := `
+		FROM messages_fts
+		JOIN messages m ON messages_fts.rowid = m.rowid
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE messages_fts MATCH ?`
+	args := []interface{}{p.Query}
+	return applyMessageFilters(from, args, p)
+}
+
+func searchLIKE(ctx context.Context, q queryer, p SearchMessagesParams) ([]Message, error) {
+	from, args := searchLIKEFrom(p)
+	query := `
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,''), ''` + from
+	query += " ORDER BY m.ts DESC LIMIT ?"
+	args = append(args, p.Limit)
+	return scanMessages(ctx, q, query, args...)
+}
+
+func searchFTS(ctx context.Context, q queryer, p SearchMessagesParams) ([]Message, error) {
+	snippetLen := p.SnippetLen
+	if snippetLen <= 0 {
+		snippetLen = defaultSnippetLen
+	}
+	snippetLen = min(snippetLen, maxSnippetLen)
+
+	from, args := searchFTSFrom(p)
+	query := `
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,''),
+		       snippet(messages_fts, 0, ?, ?, ?, ?)` + from
+	left, right := "[", "]"
+	if p.Highlight {
+		left, right = "<b>", "</b>"
+	}
+	args = append([]interface{}{left, right, "…", snippetLen}, args...)
+	query += " ORDER BY bm25(messages_fts) LIMIT ?"
+	args = append(args, p.Limit)
+	return scanMessages(ctx, q, query, args...)
+}
+
+func applyMessageFilters(query string, args []interface{}, p SearchMessagesParams) (string, []interface{}) {
+	if strings.TrimSpace(p.ChatJID) != "" {
 		query += " AND m.chat_jid = ?"
 		args = append(args, p.ChatJID)
 	}
@@ -590,8 +1287,8 @@ func applyMessageFilters(query string, args []interface{}, p SearchMessagesParam
 	return query, args
 }
 
-func (d *DB) scanMessages(query string, args ...interface{}) ([]Message, error) {
-	rows, err := d.sql.Query(query, args...)
+func scanMessages(ctx context.Context, q queryer, query string, args ...interface{}) ([]Message, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -602,7 +1299,7 @@ func (d *DB) scanMessages(query string, args ...interface{}) ([]Message, error)
 		var m Message
 		var ts int64
 		var fromMe int
-		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.Snippet); err != nil {
+		if err := rows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.ForwardedScore, &m.ReplyToMsgID, &m.Snippet); err != nil {
 			return nil, err
 		}
 		m.Timestamp = fromUnix(ts)
@@ -612,9 +1309,13 @@ func (d *DB) scanMessages(query string, args ...interface{}) ([]Message, error)
 	return out, rows.Err()
 }
 
-func (d *DB) GetMessage(chatJID, msgID string) (Message, error) {
-	row := d.sql.QueryRow(`
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,'')
+func (d *DB) GetMessage(ctx context.Context, chatJID, msgID string) (Message, error) {
+	return getMessage(ctx, d.sql, chatJID, msgID)
+}
+
+func getMessage(ctx context.Context, q queryer, chatJID, msgID string) (Message, error) {
+	row := q.QueryRowContext(ctx, `
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,'')
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE m.chat_jid = ? AND m.msg_id = ?
@@ -622,7 +1323,7 @@ func (d *DB) GetMessage(chatJID, msgID string) (Message, error) {
 	var m Message
 	var ts int64
 	var fromMe int
-	if err := row.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType); err != nil {
+	if err := row.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.ForwardedScore, &m.ReplyToMsgID); err != nil {
 		return Message{}, err
 	}
 	m.Timestamp = fromUnix(ts)
@@ -630,8 +1331,10 @@ func (d *DB) GetMessage(chatJID, msgID string) (Message, error) {
 	return m, nil
 }
 
-func (d *DB) CountMessages() (int64, error) {
-	row := d.sql.QueryRow(`SELECT COUNT(1) FROM messages`)
+// CountMessages returns the number of stored messages. It reads the
+// trigger-maintained chat_message_counts rather than scanning messages.
+func (d *DB) CountMessages(ctx context.Context) (int64, error) {
+	row := d.sql.QueryRowContext(ctx, `SELECT COALESCE(SUM(count), 0) FROM chat_message_counts`)
 	var n int64
 	if err := row.Scan(&n); err != nil {
 		return 0, err
@@ -639,12 +1342,90 @@ func (d *DB) CountMessages() (int64, error) {
 	return n, nil
 }
 
-func (d *DB) GetOldestMessageInfo(chatJID string) (MessageInfo, error) {
+// CountChatMessages returns the number of stored messages in one chat.
+func (d *DB) CountChatMessages(ctx context.Context, chatJID string) (int64, error) {
+	row := d.sql.QueryRowContext(ctx, `SELECT COALESCE(SUM(count), 0) FROM chat_message_counts WHERE chat_jid = ?`, chatJID)
+	var n int64
+	if err := row.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Groupings for CountMessagesBy.
+const (
+	CountBySender = "sender"
+	CountByDay    = "day"
+	CountByWeek   = "week"
+	CountByMonth  = "month"
+)
+
+// countByKey is the SQL expression each grouping buckets messages by. Times
+// are bucketed in local time; a week is keyed by the date of its Monday.
+var countByKey = map[string]string{
+	CountBySender: `CASE WHEN m.from_me = 1 THEN '' ELSE COALESCE(NULLIF(m.sender_jid,''), m.chat_jid) END`,
+	CountByDay:    `date(m.ts, 'unixepoch', 'localtime')`,
+	CountByWeek:   `date(m.ts, 'unixepoch', 'localtime', 'weekday 0', '-6 days')`,
+	CountByMonth:  `strftime('%Y-%m', m.ts, 'unixepoch', 'localtime')`,
+}
+
+// MessageCount is the number of messages in one CountMessagesBy group.
+type MessageCount struct {
+	Key   string // sender JID ('' for me), or a date: 2006-01-02 (day, week) or 2006-01 (month)
+	Name  string // sender name, for CountBySender
+	Count int64
+}
+
+// CountMessagesBy counts the messages of chatJID (all chats when empty)
+// since the given time (all time when zero), grouped by sender, day, week
+// or month. Senders come most active first, dates in order.
+func (d *DB) CountMessagesBy(ctx context.Context, chatJID, by string, since time.Time) ([]MessageCount, error) {
+	key, ok := countByKey[by]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q (use sender, day, week or month)", by)
+	}
+	query := `SELECT ` + key + ` AS k, COALESCE(MAX(m.sender_name),''), COUNT(1) FROM messages m WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(chatJID) != "" {
+		query += " AND m.chat_jid = ?"
+		args = append(args, chatJID)
+	}
+	if !since.IsZero() {
+		query += " AND m.ts >= ?"
+		args = append(args, unix(since))
+	}
+	query += " GROUP BY k"
+	if by == CountBySender {
+		query += " ORDER BY COUNT(1) DESC, k"
+	} else {
+		query += " ORDER BY k"
+	}
+
+	rows, err := d.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []MessageCount
+	for rows.Next() {
+		var c MessageCount
+		if err := rows.Scan(&c.Key, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		if by != CountBySender || c.Key == "" {
+			c.Name = ""
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (d *DB) GetOldestMessageInfo(ctx context.Context, chatJID string) (MessageInfo, error) {
 	chatJID = strings.TrimSpace(chatJID)
 	if chatJID == "" {
 		return MessageInfo{}, fmt.Errorf("chat JID is required")
 	}
-	row := d.sql.QueryRow(`
+	row := d.sql.QueryRowContext(ctx, `
 		SELECT m.chat_jid, m.msg_id, m.ts, m.from_me, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,'')
 		FROM messages m
 		WHERE m.chat_jid = ?
@@ -662,12 +1443,14 @@ func (d *DB) GetOldestMessageInfo(chatJID string) (MessageInfo, error) {
 	return out, nil
 }
 
-func (d *DB) GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, error) {
-	row := d.sql.QueryRow(`
+// mediaInfoColumns selects a MediaDownloadInfo from messages m joined with
+// chats c; scan it with scanMediaDownloadInfo.
+const mediaInfoColumns = `
 		SELECT m.chat_jid,
 		       COALESCE(c.name,''),
 		       m.msg_id,
 		       COALESCE(m.media_type,''),
+		       COALESCE(m.media_caption,''),
 		       COALESCE(m.filename,''),
 		       COALESCE(m.mime_type,''),
 		       COALESCE(m.direct_path,''),
@@ -678,10 +1461,14 @@ func (d *DB) GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, err
 		       COALESCE(m.local_path,''),
 		       COALESCE(m.downloaded_at,0)
 		FROM messages m
-		LEFT JOIN chats c ON c.jid = m.chat_jid
-		WHERE m.chat_jid = ? AND m.msg_id = ?
-	`, chatJID, msgID)
+		LEFT JOIN chats c ON c.jid = m.chat_jid`
+
+// rowScanner is a *sql.Row or *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+func scanMediaDownloadInfo(row rowScanner) (MediaDownloadInfo, error) {
 	var info MediaDownloadInfo
 	var fileLen sql.NullInt64
 	var downloadedAt int64
@@ -690,6 +1477,7 @@ func (d *DB) GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, err
 		&info.ChatName,
 		&info.MsgID,
 		&info.MediaType,
+		&info.Caption,
 		&info.Filename,
 		&info.MimeType,
 		&info.DirectPath,
@@ -709,8 +1497,61 @@ func (d *DB) GetMediaDownloadInfo(chatJID, msgID string) (MediaDownloadInfo, err
 	return info, nil
 }
 
-func (d *DB) MarkMediaDownloaded(chatJID, msgID, localPath string, downloadedAt time.Time) error {
-	_, err := d.sql.Exec(`
+func (d *DB) GetMediaDownloadInfo(ctx context.Context, chatJID, msgID string) (MediaDownloadInfo, error) {
+	return scanMediaDownloadInfo(d.sql.QueryRowContext(ctx, mediaInfoColumns+`
+		WHERE m.chat_jid = ? AND m.msg_id = ?
+	`, chatJID, msgID))
+}
+
+// GetMediaMetadata looks a message up by ID alone, for callers that don't
+// know its chat. IDs are unique in practice; should two chats share one,
+// the newest message wins.
+func (d *DB) GetMediaMetadata(ctx context.Context, msgID string) (MediaDownloadInfo, error) {
+	return scanMediaDownloadInfo(d.sql.QueryRowContext(ctx, mediaInfoColumns+`
+		WHERE m.msg_id = ?
+		ORDER BY m.ts DESC
+		LIMIT 1
+	`, msgID))
+}
+
+// ListMediaMetadata returns messages with a downloaded file that was
+// fetched before olderThan, oldest first. When the download time is
+// unknown the message's own timestamp counts.
+func (d *DB) ListMediaMetadata(ctx context.Context, olderThan time.Time) ([]MediaDownloadInfo, error) {
+	rows, err := d.sql.QueryContext(ctx, mediaInfoColumns+`
+		WHERE COALESCE(m.local_path,'') != ''
+		  AND COALESCE(NULLIF(m.downloaded_at,0), m.ts) < ?
+		ORDER BY COALESCE(NULLIF(m.downloaded_at,0), m.ts)
+	`, unix(olderThan))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MediaDownloadInfo
+	for rows.Next() {
+		info, err := scanMediaDownloadInfo(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, info)
+	}
+	return out, rows.Err()
+}
+
+// ClearMediaDownloaded forgets a message's downloaded file, as after the
+// file has been deleted. The media can still be downloaded again.
+func (d *DB) ClearMediaDownloaded(ctx context.Context, chatJID, msgID string) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE messages
+		SET local_path = NULL, downloaded_at = NULL
+		WHERE chat_jid = ? AND msg_id = ?
+	`, chatJID, msgID)
+	return err
+}
+
+func (d *DB) MarkMediaDownloaded(ctx context.Context, chatJID, msgID, localPath string, downloadedAt time.Time) error {
+	_, err := d.sql.ExecContext(ctx, `
 		UPDATE messages
 		SET local_path = ?, downloaded_at = ?
 		WHERE chat_jid = ? AND msg_id = ?
@@ -718,20 +1559,20 @@ func (d *DB) MarkMediaDownloaded(chatJID, msgID, localPath string, downloadedAt
 	return err
 }
 
-func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message, error) {
+func (d *DB) MessageContext(ctx context.Context, chatJID, msgID string, before, after int) ([]Message, error) {
 	if before < 0 {
 		before = 0
 	}
 	if after < 0 {
 		after = 0
 	}
-	target, err := d.GetMessage(chatJID, msgID)
+	target, err := d.GetMessage(ctx, chatJID, msgID)
 	if err != nil {
 		return nil, err
 	}
 
-	beforeRows, err := d.sql.Query(`
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), ''
+	beforeRows, err := d.sql.QueryContext(ctx, `
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,''), ''
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE m.chat_jid = ? AND m.ts < ?
@@ -748,7 +1589,7 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 		var m Message
 		var ts int64
 		var fromMe int
-		if err := beforeRows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.Snippet); err != nil {
+		if err := beforeRows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.ForwardedScore, &m.ReplyToMsgID, &m.Snippet); err != nil {
 			return nil, err
 		}
 		m.Timestamp = fromUnix(ts)
@@ -759,8 +1600,8 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 		return nil, err
 	}
 
-	afterRows, err := d.sql.Query(`
-		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), ''
+	afterRows, err := d.sql.QueryContext(ctx, `
+		SELECT m.chat_jid, COALESCE(c.name,''), m.msg_id, COALESCE(m.sender_jid,''), COALESCE(m.sender_name,''), m.ts, m.from_me, COALESCE(m.text,''), COALESCE(m.display_text,''), COALESCE(m.media_type,''), COALESCE(m.forwarded_score,0), COALESCE(m.reply_to_msg_id,''), ''
 		FROM messages m
 		LEFT JOIN chats c ON c.jid = m.chat_jid
 		WHERE m.chat_jid = ? AND m.ts > ?
@@ -777,7 +1618,7 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 		var m Message
 		var ts int64
 		var fromMe int
-		if err := afterRows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.Snippet); err != nil {
+		if err := afterRows.Scan(&m.ChatJID, &m.ChatName, &m.MsgID, &m.SenderJID, &m.SenderName, &ts, &fromMe, &m.Text, &m.DisplayText, &m.MediaType, &m.ForwardedScore, &m.ReplyToMsgID, &m.Snippet); err != nil {
 			return nil, err
 		}
 		m.Timestamp = fromUnix(ts)
@@ -800,53 +1641,140 @@ func (d *DB) MessageContext(chatJID, msgID string, before, after int) ([]Message
 	return out, nil
 }
 
-func (d *DB) ListChats(query string, limit int) ([]Chat, error) {
-	if limit <= 0 {
-		limit = 50
+func (d *DB) ListChats(ctx context.Context, query string, limit int) ([]Chat, error) {
+	page, err := d.ListChatsPage(ctx, ListChatsParams{Query: query, Limit: limit})
+	if err != nil {
+		return nil, err
 	}
-	q := `SELECT jid, kind, COALESCE(name,''), COALESCE(last_message_ts,0) FROM chats WHERE 1=1`
+	return page.Chats, nil
+}
+
+// ChatCursor marks the last chat of a page, ordered by
+// (last_message_ts DESC, jid DESC).
+type ChatCursor struct {
+	LastMessageTS time.Time
+	JID           string
+}
+
+// Encode returns an opaque, URL-safe representation of the cursor.
+func (c ChatCursor) Encode() string {
+	raw := strconv.FormatInt(unix(c.LastMessageTS), 10) + ":" + c.JID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseChatCursor decodes a cursor produced by ChatCursor.Encode.
+func ParseChatCursor(s string) (ChatCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return ChatCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	tsStr, jid, ok := strings.Cut(string(raw), ":")
+	if !ok || jid == "" {
+		return ChatCursor{}, fmt.Errorf("invalid cursor")
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return ChatCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return ChatCursor{LastMessageTS: fromUnix(ts), JID: jid}, nil
+}
+
+type ListChatsParams struct {
+	Query  string
+	Limit  int
+	Cursor *ChatCursor // resume after this chat
+}
+
+type ChatPage struct {
+	Chats      []Chat
+	NextCursor *ChatCursor // nil when there are no more chats
+}
+
+func (d *DB) ListChatsPage(ctx context.Context, p ListChatsParams) (ChatPage, error) {
+	if p.Limit <= 0 {
+		p.Limit = 50
+	}
+	q := `SELECT jid, kind, COALESCE(name,''), COALESCE(description,''), participants_count, COALESCE(last_message_ts,0), COALESCE(avatar_url,'') FROM chats WHERE 1=1`
 	var args []interface{}
-	if strings.TrimSpace(query) != "" {
+	if strings.TrimSpace(p.Query) != "" {
 		q += ` AND (LOWER(name) LIKE LOWER(?) OR LOWER(jid) LIKE LOWER(?))`
-		needle := "%" + query + "%"
+		needle := "%" + p.Query + "%"
 		args = append(args, needle, needle)
 	}
-	q += ` ORDER BY last_message_ts DESC LIMIT ?`
-	args = append(args, limit)
+	if p.Cursor != nil {
+		ts := unix(p.Cursor.LastMessageTS)
+		q += ` AND (COALESCE(last_message_ts,0) < ? OR (COALESCE(last_message_ts,0) = ? AND jid < ?))`
+		args = append(args, ts, ts, p.Cursor.JID)
+	}
+	// Fetch one extra row to know whether another page exists.
+	q += ` ORDER BY COALESCE(last_message_ts,0) DESC, jid DESC LIMIT ?`
+	args = append(args, p.Limit+1)
 
-	rows, err := d.sql.Query(q, args...)
+	rows, err := d.sql.QueryContext(ctx, q, args...)
 	if err != nil {
-		return nil, err
+		return ChatPage{}, err
 	}
 	defer rows.Close()
 	var out []Chat
 	for rows.Next() {
 		var c Chat
 		var ts int64
-		if err := rows.Scan(&c.JID, &c.Kind, &c.Name, &ts); err != nil {
-			return nil, err
+		var participants sql.NullInt64
+		if err := rows.Scan(&c.JID, &c.Kind, &c.Name, &c.Description, &participants, &ts, &c.AvatarURL); err != nil {
+			return ChatPage{}, err
 		}
 		c.LastMessageTS = fromUnix(ts)
+		c.ParticipantsCount = nullIntPtr(participants)
 		out = append(out, c)
 	}
-	return out, rows.Err()
+	if err := rows.Err(); err != nil {
+		return ChatPage{}, err
+	}
+
+	page := ChatPage{Chats: out}
+	if len(out) > p.Limit {
+		page.Chats = out[:p.Limit]
+		last := page.Chats[p.Limit-1]
+		page.NextCursor = &ChatCursor{LastMessageTS: last.LastMessageTS, JID: last.JID}
+	}
+	return page, nil
+}
+
+func (d *DB) GetChat(ctx context.Context, jid string) (Chat, error) {
+	return getChat(ctx, d.sql, jid)
 }
 
-func (d *DB) GetChat(jid string) (Chat, error) {
-	row := d.sql.QueryRow(`SELECT jid, kind, COALESCE(name,''), COALESCE(last_message_ts,0) FROM chats WHERE jid = ?`, jid)
+func getChat(ctx context.Context, q queryer, jid string) (Chat, error) {
+	row := q.QueryRowContext(ctx, `SELECT jid, kind, COALESCE(name,''), COALESCE(description,''), participants_count, COALESCE(last_message_ts,0), COALESCE(avatar_url,'') FROM chats WHERE jid = ?`, jid)
 	var c Chat
 	var ts int64
-	if err := row.Scan(&c.JID, &c.Kind, &c.Name, &ts); err != nil {
+	var participants sql.NullInt64
+	if err := row.Scan(&c.JID, &c.Kind, &c.Name, &c.Description, &participants, &ts, &c.AvatarURL); err != nil {
 		return Chat{}, err
 	}
 	c.LastMessageTS = fromUnix(ts)
+	c.ParticipantsCount = nullIntPtr(participants)
 	return c, nil
 }
 
-func (d *DB) SearchContacts(query string, limit int) ([]Contact, error) {
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func (d *DB) SearchContacts(ctx context.Context, query string, limit int) ([]Contact, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, fmt.Errorf("query is required")
 	}
+	return d.ListContacts(ctx, query, limit)
+}
+
+// ListContacts returns contacts ordered by name. A non-empty query keeps
+// those whose alias, name, phone or JID contains it.
+func (d *DB) ListContacts(ctx context.Context, query string, limit int) ([]Contact, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -858,11 +1786,18 @@ func (d *DB) SearchContacts(query string, limit int) ([]Contact, error) {
 		       c.updated_at
 		FROM contacts c
 		LEFT JOIN contact_aliases a ON a.jid = c.jid
-		WHERE LOWER(COALESCE(a.alias,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.full_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.push_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.phone,'')) LIKE LOWER(?) OR LOWER(c.jid) LIKE LOWER(?)
+		WHERE 1=1`
+	var args []interface{}
+	if strings.TrimSpace(query) != "" {
+		needle := "%" + query + "%"
+		q += ` AND (LOWER(COALESCE(a.alias,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.full_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.push_name,'')) LIKE LOWER(?) OR LOWER(COALESCE(c.phone,'')) LIKE LOWER(?) OR LOWER(c.jid) LIKE LOWER(?))`
+		args = append(args, needle, needle, needle, needle, needle)
+	}
+	q += `
 		ORDER BY COALESCE(NULLIF(a.alias,''), NULLIF(c.full_name,''), NULLIF(c.push_name,''), c.jid)
 		LIMIT ?`
-	needle := "%" + query + "%"
-	rows, err := d.sql.Query(q, needle, needle, needle, needle, needle, limit)
+	args = append(args, limit)
+	rows, err := d.sql.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -880,8 +1815,8 @@ func (d *DB) SearchContacts(query string, limit int) ([]Contact, error) {
 	return out, rows.Err()
 }
 
-func (d *DB) GetContact(jid string) (Contact, error) {
-	row := d.sql.QueryRow(`
+func (d *DB) GetContact(ctx context.Context, jid string) (Contact, error) {
+	row := d.sql.QueryRowContext(ctx, `
 		SELECT c.jid,
 		       COALESCE(c.phone,''),
 		       COALESCE(NULLIF(a.alias,''), ''),
@@ -897,13 +1832,13 @@ func (d *DB) GetContact(jid string) (Contact, error) {
 		return Contact{}, err
 	}
 	c.UpdatedAt = fromUnix(updated)
-	tags, _ := d.ListTags(jid)
+	tags, _ := d.ListTags(ctx, jid)
 	c.Tags = tags
 	return c, nil
 }
 
-func (d *DB) ListTags(jid string) ([]string, error) {
-	rows, err := d.sql.Query(`SELECT tag FROM contact_tags WHERE jid = ? ORDER BY tag`, jid)
+func (d *DB) ListTags(ctx context.Context, jid string) ([]string, error) {
+	rows, err := d.sql.QueryContext(ctx, `SELECT tag FROM contact_tags WHERE jid = ? ORDER BY tag`, jid)
 	if err != nil {
 		return nil, err
 	}
@@ -919,9 +1854,9 @@ func (d *DB) ListTags(jid string) ([]string, error) {
 	return tags, rows.Err()
 }
 
-func (d *DB) UpsertContact(jid, phone, pushName, fullName, firstName, businessName string) error {
+func (d *DB) UpsertContact(ctx context.Context, jid, phone, pushName, fullName, firstName, businessName string) error {
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.sql.ExecContext(ctx, `
 		INSERT INTO contacts(jid, phone, push_name, full_name, first_name, business_name, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(jid) DO UPDATE SET
@@ -935,9 +1870,9 @@ func (d *DB) UpsertContact(jid, phone, pushName, fullName, firstName, businessNa
 	return err
 }
 
-func (d *DB) UpsertGroup(jid, name, ownerJID string, created time.Time) error {
+func (d *DB) UpsertGroup(ctx context.Context, jid, name, ownerJID string, created time.Time) error {
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.sql.ExecContext(ctx, `
 		INSERT INTO groups(jid, name, owner_jid, created_ts, updated_at)
 		VALUES (?, ?, ?, ?, ?)
 		ON CONFLICT(jid) DO UPDATE SET
@@ -949,39 +1884,517 @@ func (d *DB) UpsertGroup(jid, name, ownerJID string, created time.Time) error {
 	return err
 }
 
-func (d *DB) ReplaceGroupParticipants(groupJID string, participants []GroupParticipant) error {
-	tx, err := d.sql.Begin()
-	if err != nil {
+// UpsertReactionParams describes one sender's reaction to a message.
+type UpsertReactionParams struct {
+	ChatJID   string
+	MsgID     string
+	SenderJID string // empty for our own reactions
+	Reaction  string // empty removes the sender's reaction
+	Timestamp time.Time
+}
+
+// UpsertReaction records a sender's current reaction to a message. Each
+// sender has at most one; a reaction older than the stored one is ignored.
+func (d *DB) UpsertReaction(ctx context.Context, p UpsertReactionParams) error {
+	if strings.TrimSpace(p.ChatJID) == "" || strings.TrimSpace(p.MsgID) == "" {
+		return fmt.Errorf("chat JID and message ID are required")
+	}
+	if p.Reaction == "" {
+		_, err := d.sql.ExecContext(ctx, `
+			DELETE FROM reactions WHERE chat_jid = ? AND msg_id = ? AND sender_jid = ? AND ts <= ?
+		`, p.ChatJID, p.MsgID, p.SenderJID, unix(p.Timestamp))
 		return err
 	}
-	defer func() {
+	_, err := d.sql.ExecContext(ctx, `
+		INSERT INTO reactions(chat_jid, msg_id, sender_jid, reaction, ts)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chat_jid, msg_id, sender_jid) DO UPDATE SET
+			reaction=excluded.reaction,
+			ts=excluded.ts
+		WHERE excluded.ts >= reactions.ts
+	`, p.ChatJID, p.MsgID, p.SenderJID, p.Reaction, unix(p.Timestamp))
+	return err
+}
+
+// ReactionCount is how many senders reacted to a message with Reaction.
+type ReactionCount struct {
+	Reaction string
+	Count    int
+}
+
+// ListReactions summarises the reactions to a message, most common first.
+func (d *DB) ListReactions(ctx context.Context, chatJID, msgID string) ([]ReactionCount, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+		SELECT reaction, COUNT(1) FROM reactions
+		WHERE chat_jid = ? AND msg_id = ?
+		GROUP BY reaction
+		ORDER BY COUNT(1) DESC, reaction
+	`, chatJID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ReactionCount
+	for rows.Next() {
+		var rc ReactionCount
+		if err := rows.Scan(&rc.Reaction, &rc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, rc)
+	}
+	return out, rows.Err()
+}
+
+// SavePollOptions records the options of a poll message, in order.
+func (d *DB) SavePollOptions(ctx context.Context, chatJID, msgID string, options []string) error {
+	return d.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM poll_options WHERE chat_jid = ? AND msg_id = ?`, chatJID, msgID); err != nil {
+			return err
+		}
+		for i, name := range options {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO poll_options(chat_jid, msg_id, idx, name) VALUES (?, ?, ?, ?)
+			`, chatJID, msgID, i, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PollOptions returns the options of a poll message in order; it is empty
+// when the message is not a known poll.
+func (d *DB) PollOptions(ctx context.Context, chatJID, msgID string) ([]string, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+		SELECT name FROM poll_options WHERE chat_jid = ? AND msg_id = ? ORDER BY idx
+	`, chatJID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+type PollVoteParams struct {
+	ChatJID   string
+	MsgID     string
+	VoterJID  string   // empty for our own vote
+	Options   []string // empty when the vote was withdrawn
+	Timestamp time.Time
+}
+
+// RecordPollVote replaces a voter's selection on a poll. A vote older than
+// the stored one is ignored.
+func (d *DB) RecordPollVote(ctx context.Context, p PollVoteParams) error {
+	if strings.TrimSpace(p.ChatJID) == "" || strings.TrimSpace(p.MsgID) == "" {
+		return fmt.Errorf("chat JID and message ID are required")
+	}
+	return d.WithTx(ctx, func(tx *sql.Tx) error {
+		var newer int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(1) FROM poll_votes WHERE chat_jid = ? AND msg_id = ? AND voter_jid = ? AND ts > ?
+		`, p.ChatJID, p.MsgID, p.VoterJID, unix(p.Timestamp)).Scan(&newer); err != nil {
+			return err
+		}
+		if newer > 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM poll_votes WHERE chat_jid = ? AND msg_id = ? AND voter_jid = ?
+		`, p.ChatJID, p.MsgID, p.VoterJID); err != nil {
+			return err
+		}
+		for _, name := range p.Options {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO poll_votes(chat_jid, msg_id, voter_jid, option_name, ts) VALUES (?, ?, ?, ?, ?)
+			`, p.ChatJID, p.MsgID, p.VoterJID, name, unix(p.Timestamp)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PollOptionCount is how many voters picked a poll option.
+type PollOptionCount struct {
+	Name  string
+	Count int
+}
+
+// PollResults tallies the votes on a poll, in option order. It is empty
+// when the message is not a known poll.
+func (d *DB) PollResults(ctx context.Context, chatJID, msgID string) ([]PollOptionCount, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+		SELECT o.name, COUNT(v.voter_jid)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.chat_jid = o.chat_jid AND v.msg_id = o.msg_id AND v.option_name = o.name
+		WHERE o.chat_jid = ? AND o.msg_id = ?
+		GROUP BY o.idx
+		ORDER BY o.idx
+	`, chatJID, msgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PollOptionCount
+	for rows.Next() {
+		var pc PollOptionCount
+		if err := rows.Scan(&pc.Name, &pc.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, pc)
+	}
+	return out, rows.Err()
+}
+
+// FindPollChat returns the chat of the poll with msgID, for callers that
+// only know the message ID.
+func (d *DB) FindPollChat(ctx context.Context, msgID string) (string, error) {
+	var chatJID string
+	err := d.sql.QueryRowContext(ctx, `
+		SELECT chat_jid FROM poll_options WHERE msg_id = ? LIMIT 1
+	`, msgID).Scan(&chatJID)
+	return chatJID, err
+}
+
+// Scheduled message states.
+const (
+	ScheduledPending  = "pending"
+	ScheduledSending  = "sending" // claimed by a sender; can't be edited or canceled
+	ScheduledSent     = "sent"
+	ScheduledFailed   = "failed"
+	ScheduledCanceled = "canceled"
+)
+
+// ScheduledMessage is a text message queued to be sent at SendAt.
+type ScheduledMessage struct {
+	ID        int64
+	To        string
+	Message   string
+	SendAt    time.Time
+	CreatedAt time.Time
+	Status    string
+	Retries   int
+	LastError string
+	MsgID     string // set once sent
+}
+
+// UpsertScheduledMessage queues m and returns its ID. A zero m.ID inserts
+// a new pending message; otherwise the recipient, text and time of that
+// message are replaced, provided it is still pending.
+func (d *DB) UpsertScheduledMessage(ctx context.Context, m ScheduledMessage) (int64, error) {
+	if strings.TrimSpace(m.To) == "" || strings.TrimSpace(m.Message) == "" {
+		return 0, fmt.Errorf("recipient and message are required")
+	}
+	var id interface{}
+	if m.ID != 0 {
+		id = m.ID
+	}
+	res, err := d.sql.ExecContext(ctx, `
+		INSERT INTO scheduled_messages(id, to_jid, message, send_at, created_at, status)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			to_jid=excluded.to_jid,
+			message=excluded.message,
+			send_at=excluded.send_at
+		WHERE scheduled_messages.status = ?
+	`, id, m.To, m.Message, unix(m.SendAt), time.Now().UTC().Unix(), ScheduledPending, ScheduledPending)
+	if err != nil {
+		return 0, err
+	}
+	if m.ID != 0 {
+		if n, _ := res.RowsAffected(); n == 0 {
+			return 0, sql.ErrNoRows
+		}
+		return m.ID, nil
+	}
+	return res.LastInsertId()
+}
+
+const scheduledColumns = `id, to_jid, message, send_at, created_at, status, retries, COALESCE(last_error,''), COALESCE(msg_id,'')`
+
+func (d *DB) scanScheduled(ctx context.Context, query string, args ...interface{}) ([]ScheduledMessage, error) {
+	rows, err := d.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ScheduledMessage
+	for rows.Next() {
+		var m ScheduledMessage
+		var sendAt, createdAt int64
+		if err := rows.Scan(&m.ID, &m.To, &m.Message, &sendAt, &createdAt, &m.Status, &m.Retries, &m.LastError, &m.MsgID); err != nil {
+			return nil, err
+		}
+		m.SendAt = fromUnix(sendAt)
+		m.CreatedAt = fromUnix(createdAt)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// GetScheduledMessage returns the scheduled message with id, whatever its
+// status.
+func (d *DB) GetScheduledMessage(ctx context.Context, id int64) (ScheduledMessage, error) {
+	out, err := d.scanScheduled(ctx, `SELECT `+scheduledColumns+` FROM scheduled_messages WHERE id = ?`, id)
+	if err != nil {
+		return ScheduledMessage{}, err
+	}
+	if len(out) == 0 {
+		return ScheduledMessage{}, sql.ErrNoRows
+	}
+	return out[0], nil
+}
+
+// ListScheduledMessages returns the scheduled messages with status, or all
+// of them when status is empty, soonest first.
+func (d *DB) ListScheduledMessages(ctx context.Context, status string) ([]ScheduledMessage, error) {
+	return d.scanScheduled(ctx, `
+		SELECT `+scheduledColumns+` FROM scheduled_messages
+		WHERE ? = '' OR status = ?
+		ORDER BY send_at, id
+	`, status, status)
+}
+
+// DueScheduledMessages returns the pending messages whose time has come by
+// now, oldest first.
+func (d *DB) DueScheduledMessages(ctx context.Context, now time.Time) ([]ScheduledMessage, error) {
+	return d.scanScheduled(ctx, `
+		SELECT `+scheduledColumns+` FROM scheduled_messages
+		WHERE status = ? AND send_at <= ?
+		ORDER BY send_at, id
+	`, ScheduledPending, unix(now))
+}
+
+// ClaimScheduledMessage moves a pending message that is due by now to
+// sending and returns it as it is stored at that moment, so an edit made
+// before the claim is what gets sent. It returns sql.ErrNoRows when the
+// message was canceled, edited to a later time or claimed by someone else.
+func (d *DB) ClaimScheduledMessage(ctx context.Context, id int64, now time.Time) (ScheduledMessage, error) {
+	var m ScheduledMessage
+	err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE scheduled_messages SET status = ?
+			WHERE id = ? AND status = ? AND send_at <= ?
+		`, ScheduledSending, id, ScheduledPending, unix(now))
 		if err != nil {
-			_ = tx.Rollback()
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return sql.ErrNoRows
 		}
-	}()
+		var sendAt, createdAt int64
+		if err := tx.QueryRowContext(ctx, `SELECT `+scheduledColumns+` FROM scheduled_messages WHERE id = ?`, id).
+			Scan(&m.ID, &m.To, &m.Message, &sendAt, &createdAt, &m.Status, &m.Retries, &m.LastError, &m.MsgID); err != nil {
+			return err
+		}
+		m.SendAt = fromUnix(sendAt)
+		m.CreatedAt = fromUnix(createdAt)
+		return nil
+	})
+	if err != nil {
+		return ScheduledMessage{}, err
+	}
+	return m, nil
+}
 
-	if _, err = tx.Exec(`DELETE FROM group_participants WHERE group_jid = ?`, groupJID); err != nil {
-		return err
+// ReleaseScheduledMessage puts a claimed message that was not sent back to
+// pending.
+func (d *DB) ReleaseScheduledMessage(ctx context.Context, id int64) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE scheduled_messages SET status = ? WHERE id = ? AND status = ?
+	`, ScheduledPending, id, ScheduledSending)
+	return err
+}
+
+// RequeueSendingScheduled puts every claimed message back to pending, for
+// a sender that stopped mid-send. Such a message may go out twice.
+func (d *DB) RequeueSendingScheduled(ctx context.Context) (int64, error) {
+	res, err := d.sql.ExecContext(ctx, `
+		UPDATE scheduled_messages SET status = ? WHERE status = ?
+	`, ScheduledPending, ScheduledSending)
+	if err != nil {
+		return 0, err
 	}
-	stmt, err := tx.Prepare(`INSERT INTO group_participants(group_jid, user_jid, role, updated_at) VALUES(?, ?, ?, ?)`)
+	return res.RowsAffected()
+}
+
+// MarkScheduledSent records that a claimed scheduled message went out as
+// msgID.
+func (d *DB) MarkScheduledSent(ctx context.Context, id int64, msgID string) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE scheduled_messages SET status = ?, msg_id = ?, last_error = NULL
+		WHERE id = ? AND status = ?
+	`, ScheduledSent, msgID, id, ScheduledSending)
+	return err
+}
+
+// MarkScheduledFailure records a failed attempt to send a claimed scheduled
+// message and puts it back to pending. After maxRetries failures it is
+// marked failed and not retried.
+func (d *DB) MarkScheduledFailure(ctx context.Context, id int64, sendErr string, maxRetries int) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE scheduled_messages SET
+			retries = retries + 1,
+			last_error = ?,
+			status = CASE WHEN retries + 1 >= ? THEN ? ELSE ? END
+		WHERE id = ? AND status = ?
+	`, sendErr, maxRetries, ScheduledFailed, ScheduledPending, id, ScheduledSending)
+	return err
+}
+
+// CancelScheduledMessage cancels a pending scheduled message. It returns
+// sql.ErrNoRows when there is no pending message with id.
+func (d *DB) CancelScheduledMessage(ctx context.Context, id int64) error {
+	res, err := d.sql.ExecContext(ctx, `
+		UPDATE scheduled_messages SET status = ? WHERE id = ? AND status = ?
+	`, ScheduledCanceled, id, ScheduledPending)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
-	now := time.Now().UTC()
-	for _, p := range participants {
-		role := strings.TrimSpace(p.Role)
-		if role == "" {
-			role = "member"
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetLastRead moves a chat's read pointer to msgID. The pointer only moves
+// forward: a message older than the current one is ignored, as is a msgID
+// that is not in the store.
+func (d *DB) SetLastRead(ctx context.Context, chatJID, msgID string) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE chats SET last_read_msg_id = ?
+		WHERE jid = ?
+		  AND EXISTS (SELECT 1 FROM messages WHERE chat_jid = ? AND msg_id = ?)
+		  AND COALESCE((SELECT ts FROM messages WHERE chat_jid = chats.jid AND msg_id = chats.last_read_msg_id), -1)
+		      <= (SELECT ts FROM messages WHERE chat_jid = ? AND msg_id = ?)
+	`, msgID, chatJID, chatJID, msgID, chatJID, msgID)
+	return err
+}
+
+// UnreadCount is the number of incoming messages in a chat newer than its
+// read pointer.
+type UnreadCount struct {
+	ChatJID  string
+	ChatName string
+	Count    int
+}
+
+// UnreadCounts lists chats with unread incoming messages, most recently
+// active first. In a chat that was never marked read every incoming
+// message counts.
+func (d *DB) UnreadCounts(ctx context.Context) ([]UnreadCount, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+		SELECT c.jid, COALESCE(c.name,''), COUNT(1)
+		FROM chats c
+		JOIN messages m ON m.chat_jid = c.jid
+		WHERE m.from_me = 0
+		  AND m.ts > COALESCE((SELECT r.ts FROM messages r WHERE r.chat_jid = c.jid AND r.msg_id = c.last_read_msg_id), -1)
+		GROUP BY c.jid
+		ORDER BY MAX(m.ts) DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []UnreadCount
+	for rows.Next() {
+		var uc UnreadCount
+		if err := rows.Scan(&uc.ChatJID, &uc.ChatName, &uc.Count); err != nil {
+			return nil, err
 		}
-		if _, err = stmt.Exec(groupJID, p.UserJID, role, unix(now)); err != nil {
+		out = append(out, uc)
+	}
+	return out, rows.Err()
+}
+
+func (d *DB) ReplaceGroupParticipants(ctx context.Context, groupJID string, participants []GroupParticipant) error {
+	return d.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM group_participants WHERE group_jid = ?`, groupJID); err != nil {
 			return err
 		}
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO group_participants(group_jid, user_jid, role, updated_at) VALUES(?, ?, ?, ?)`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		now := time.Now().UTC()
+		for _, p := range participants {
+			role := strings.TrimSpace(p.Role)
+			if role == "" {
+				role = "member"
+			}
+			if _, err := stmt.ExecContext(ctx, groupJID, p.UserJID, role, unix(now)); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO chats(jid, kind, participants_count) VALUES(?, 'group', ?)
+			ON CONFLICT(jid) DO UPDATE SET participants_count=excluded.participants_count
+		`, groupJID, len(participants))
+		return err
+	})
+}
+
+// AdjustChatParticipantsCount applies delta to a group's participant count
+// (e.g. on join/leave events). Counts that were never synced stay unknown.
+func (d *DB) AdjustChatParticipantsCount(ctx context.Context, jid string, delta int) error {
+	_, err := d.sql.ExecContext(ctx, `
+		UPDATE chats SET participants_count = MAX(participants_count + ?, 0)
+		WHERE jid = ? AND participants_count IS NOT NULL
+	`, delta, jid)
+	return err
+}
+
+// SetChatAvatar records a chat's photo URL. An empty url clears it.
+func (d *DB) SetChatAvatar(ctx context.Context, jid, url string) error {
+	_, err := d.sql.ExecContext(ctx, `UPDATE chats SET avatar_url = ? WHERE jid = ?`, nullIfEmpty(url), jid)
+	return err
+}
+
+// ListGroupParticipants returns a group's stored participants, admins
+// first. Names come from contacts (alias first) and are empty if unknown.
+func (d *DB) ListGroupParticipants(ctx context.Context, groupJID string) ([]GroupParticipant, error) {
+	rows, err := d.sql.QueryContext(ctx, `
+		SELECT p.group_jid, p.user_jid,
+		       COALESCE(NULLIF(a.alias,''), NULLIF(c.full_name,''), NULLIF(c.push_name,''), NULLIF(c.business_name,''), NULLIF(c.first_name,''), ''),
+		       COALESCE(p.role,'member'), p.updated_at
+		FROM group_participants p
+		LEFT JOIN contacts c ON c.jid = p.user_jid
+		LEFT JOIN contact_aliases a ON a.jid = p.user_jid
+		WHERE p.group_jid = ?
+		ORDER BY CASE COALESCE(p.role,'member') WHEN 'superadmin' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, p.user_jid
+	`, groupJID)
+	if err != nil {
+		return nil, err
 	}
-	return tx.Commit()
+	defer rows.Close()
+	var out []GroupParticipant
+	for rows.Next() {
+		var p GroupParticipant
+		var updated int64
+		if err := rows.Scan(&p.GroupJID, &p.UserJID, &p.Name, &p.Role, &updated); err != nil {
+			return nil, err
+		}
+		p.UpdatedAt = fromUnix(updated)
+		out = append(out, p)
+	}
+	return out, rows.Err()
 }
 
-func (d *DB) ListGroups(query string, limit int) ([]Group, error) {
+func (d *DB) ListGroups(ctx context.Context, query string, limit int) ([]Group, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -994,7 +2407,7 @@ func (d *DB) ListGroups(query string, limit int) ([]Group, error) {
 	}
 	q += ` ORDER BY COALESCE(created_ts,0) DESC LIMIT ?`
 	args = append(args, limit)
-	rows, err := d.sql.Query(q, args...)
+	rows, err := d.sql.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1013,13 +2426,13 @@ func (d *DB) ListGroups(query string, limit int) ([]Group, error) {
 	return out, rows.Err()
 }
 
-func (d *DB) SetAlias(jid, alias string) error {
+func (d *DB) SetAlias(ctx context.Context, jid, alias string) error {
 	alias = strings.TrimSpace(alias)
 	if alias == "" {
 		return fmt.Errorf("alias is required")
 	}
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.sql.ExecContext(ctx, `
 		INSERT INTO contact_aliases(jid, alias, notes, updated_at)
 		VALUES (?, ?, NULL, ?)
 		ON CONFLICT(jid) DO UPDATE SET alias=excluded.alias, updated_at=excluded.updated_at
@@ -1027,31 +2440,75 @@ func (d *DB) SetAlias(jid, alias string) error {
 	return err
 }
 
-func (d *DB) RemoveAlias(jid string) error {
-	_, err := d.sql.Exec(`DELETE FROM contact_aliases WHERE jid = ?`, jid)
+func (d *DB) RemoveAlias(ctx context.Context, jid string) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM contact_aliases WHERE jid = ?`, jid)
 	return err
 }
 
-func (d *DB) AddTag(jid, tag string) error {
+func (d *DB) AddTag(ctx context.Context, jid, tag string) error {
 	tag = strings.TrimSpace(tag)
 	if tag == "" {
 		return fmt.Errorf("tag is required")
 	}
 	now := time.Now().UTC().Unix()
-	_, err := d.sql.Exec(`
+	_, err := d.sql.ExecContext(ctx, `
 		INSERT INTO contact_tags(jid, tag, updated_at) VALUES(?, ?, ?)
 		ON CONFLICT(jid, tag) DO UPDATE SET updated_at=excluded.updated_at
 	`, jid, tag, now)
 	return err
 }
 
-func (d *DB) RemoveTag(jid, tag string) error {
-	_, err := d.sql.Exec(`DELETE FROM contact_tags WHERE jid = ? AND tag = ?`, jid, tag)
+func (d *DB) RemoveTag(ctx context.Context, jid, tag string) error {
+	_, err := d.sql.ExecContext(ctx, `DELETE FROM contact_tags WHERE jid = ? AND tag = ?`, jid, tag)
 	return err
 }
 
 func (d *DB) HasFTS() bool { return d.ftsEnabled }
 
+// Path returns the database file path.
+func (d *DB) Path() string { return d.path }
+
+// healthCheckTimeout bounds HealthCheck when ctx has no earlier deadline.
+const healthCheckTimeout = time.Second
+
+// HealthCheck verifies the database answers a trivial query.
+func (d *DB) HealthCheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	var one int
+	if err := d.sql.QueryRowContext(ctx, `SELECT 1`).Scan(&one); err != nil {
+		return fmt.Errorf("db health check: %w", err)
+	}
+	return nil
+}
+
+// WithTx runs fn inside a transaction, committing if it returns nil and
+// rolling back otherwise.
+func (d *DB) WithTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := d.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ExecContext runs raw SQL against the database. It is meant for migrations
+// and other advanced uses; prefer the typed methods, which keep the FTS
+// index and derived columns consistent.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.sql.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs a raw SQL query. Like ExecContext it bypasses the typed
+// API; callers must close the returned rows.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.sql.QueryContext(ctx, query, args...)
+}
+
 func IsNotFound(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
 }
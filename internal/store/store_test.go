@@ -1,17 +1,24 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 func openTestDB(t *testing.T) *DB {
 	t.Helper()
 	dir := t.TempDir()
 	path := filepath.Join(dir, "wacli.db")
-	db, err := Open(path)
+	db, err := Open(DefaultStoreOptions(path))
 	if err != nil {
 		t.Fatalf("Open: %v", err)
 	}
@@ -29,20 +36,192 @@ func countRows(t *testing.T, db *sql.DB, q string, args ...any) int {
 	return n
 }
 
+func TestOpenConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wacli.db")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := Open(DefaultStoreOptions(path))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = db.Close()
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Open #%d: %v", i, err)
+		}
+	}
+}
+
+func TestOpenRetries(t *testing.T) {
+	t.Setenv("WACLI_DB_OPEN_RETRIES", "")
+	if got := openRetries(); got != defaultOpenRetries {
+		t.Fatalf("expected default %d, got %d", defaultOpenRetries, got)
+	}
+	t.Setenv("WACLI_DB_OPEN_RETRIES", "2")
+	if got := openRetries(); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+	t.Setenv("WACLI_DB_OPEN_RETRIES", "nope")
+	if got := openRetries(); got != defaultOpenRetries {
+		t.Fatalf("expected default for invalid value, got %d", got)
+	}
+
+	if !isBusy(sqlite3.Error{Code: sqlite3.ErrBusy}) {
+		t.Fatalf("expected SQLITE_BUSY to be retryable")
+	}
+	if !isBusy(fmt.Errorf("create tables: %w", sqlite3.Error{Code: sqlite3.ErrLocked})) {
+		t.Fatalf("expected wrapped SQLITE_LOCKED to be retryable")
+	}
+	if isBusy(fmt.Errorf("db path is required")) {
+		t.Fatalf("expected unrelated error to not be retryable")
+	}
+}
+
+func TestStoreOptions(t *testing.T) {
+	ctx := context.Background()
+	pragma := func(t *testing.T, db *DB, name string) string {
+		t.Helper()
+		var v string
+		if err := db.sql.QueryRow("PRAGMA " + name).Scan(&v); err != nil {
+			t.Fatalf("PRAGMA %s: %v", name, err)
+		}
+		return v
+	}
+
+	path := filepath.Join(t.TempDir(), "wacli.db")
+	opts := DefaultStoreOptions(path)
+	if !opts.WAL || opts.BusyTimeout != 5*time.Second || opts.Path != path {
+		t.Fatalf("unexpected defaults: %+v", opts)
+	}
+	opts.MaxConns = 3
+	opts.BusyTimeout = 1500 * time.Millisecond
+	db, err := Open(opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got := db.sql.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("MaxOpenConnections = %d, want 3", got)
+	}
+	if got := pragma(t, db, "journal_mode"); got != "wal" {
+		t.Errorf("journal_mode = %q, want wal", got)
+	}
+	if got := pragma(t, db, "busy_timeout"); got != "1500" {
+		t.Errorf("busy_timeout = %q, want 1500", got)
+	}
+	if _, _, err := db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	_ = db.Close()
+
+	ro := DefaultStoreOptions(path)
+	ro.ReadOnly = true
+	rdb, err := Open(ro)
+	if err != nil {
+		t.Fatalf("Open read-only: %v", err)
+	}
+	if _, err := rdb.GetChat(ctx, "123@s.whatsapp.net"); err != nil {
+		t.Errorf("GetChat read-only: %v", err)
+	}
+	if _, _, err := rdb.UpsertChat(ctx, "456@s.whatsapp.net", "dm", "Bob", "", time.Now()); err == nil {
+		t.Errorf("expected write to fail on a read-only DB")
+	}
+	_ = rdb.Close()
+
+	ro.Path = filepath.Join(t.TempDir(), "missing.db")
+	if db, err := Open(ro); err == nil {
+		_ = db.Close()
+		t.Errorf("expected read-only open of a missing file to fail")
+	}
+
+	rollback := DefaultStoreOptions(filepath.Join(t.TempDir(), "rollback.db"))
+	rollback.WAL = false
+	db, err = Open(rollback)
+	if err != nil {
+		t.Fatalf("Open without WAL: %v", err)
+	}
+	defer db.Close()
+	if got := pragma(t, db, "journal_mode"); got != "delete" {
+		t.Errorf("journal_mode = %q, want delete", got)
+	}
+}
+
+func TestConcurrentListMessages(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "wacli.db")
+	opts := DefaultStoreOptions(path)
+	opts.MaxConns = 4
+	db, err := Open(opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     fmt.Sprintf("m%02d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Text:      "hello",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msgs, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: chat, Limit: 50})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(msgs) != 20 {
+				errs <- fmt.Errorf("expected 20 messages, got %d", len(msgs))
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent ListMessages: %v", err)
+	}
+	if got := db.sql.Stats().MaxOpenConnections; got != 4 {
+		t.Fatalf("expected MaxOpenConnections=4, got %d", got)
+	}
+}
+
 func TestUpsertChatNameAndLastMessageTS(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 
 	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	t2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
 
-	if err := db.UpsertChat("123@s.whatsapp.net", "dm", "Alice", t1); err != nil {
+	if _, _, err := db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "Alice", "", t1); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
 	// Empty name should not clobber.
-	if err := db.UpsertChat("123@s.whatsapp.net", "dm", "", t2); err != nil {
+	if _, _, err := db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "", "", t2); err != nil {
 		t.Fatalf("UpsertChat empty name: %v", err)
 	}
-	c, err := db.GetChat("123@s.whatsapp.net")
+	c, err := db.GetChat(ctx, "123@s.whatsapp.net")
 	if err != nil {
 		t.Fatalf("GetChat: %v", err)
 	}
@@ -54,10 +233,10 @@ func TestUpsertChatNameAndLastMessageTS(t *testing.T) {
 	}
 
 	// Older timestamp should not override.
-	if err := db.UpsertChat("123@s.whatsapp.net", "dm", "Alice2", t1); err != nil {
+	if _, _, err := db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "Alice2", "", t1); err != nil {
 		t.Fatalf("UpsertChat older ts: %v", err)
 	}
-	c, err = db.GetChat("123@s.whatsapp.net")
+	c, err = db.GetChat(ctx, "123@s.whatsapp.net")
 	if err != nil {
 		t.Fatalf("GetChat: %v", err)
 	}
@@ -66,11 +245,127 @@ func TestUpsertChatNameAndLastMessageTS(t *testing.T) {
 	}
 }
 
+func TestUpsertChatReportsInsert(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, inserted, err := db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "Alice", "", t1)
+	if err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if !inserted {
+		t.Fatalf("expected first upsert to report an insert")
+	}
+	if c.JID != "123@s.whatsapp.net" || c.Kind != "dm" || c.Name != "Alice" || !c.LastMessageTS.Equal(t1) {
+		t.Fatalf("unexpected row: %+v", c)
+	}
+
+	// The returned row reflects stored values, not the arguments.
+	c, inserted, err = db.UpsertChat(ctx, "123@s.whatsapp.net", "dm", "", "", t1.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("UpsertChat again: %v", err)
+	}
+	if inserted {
+		t.Fatalf("expected second upsert to report an update")
+	}
+	if c.Name != "Alice" || !c.LastMessageTS.Equal(t1) {
+		t.Fatalf("expected stored name and timestamp, got %+v", c)
+	}
+}
+
+func TestUpsertChatDescription(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	group := "123@g.us"
+	if _, _, err := db.UpsertChat(ctx, group, "group", "Team", "Weekly sync notes", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	c, err := db.GetChat(ctx, group)
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.Description != "Weekly sync notes" {
+		t.Fatalf("expected description to be stored, got %q", c.Description)
+	}
+
+	// Empty description should not clobber.
+	if _, _, err := db.UpsertChat(ctx, group, "group", "Team", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat empty description: %v", err)
+	}
+	chats, err := db.ListChats(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("ListChats: %v", err)
+	}
+	if len(chats) != 1 || chats[0].Description != "Weekly sync notes" {
+		t.Fatalf("expected description to survive upsert, got %+v", chats)
+	}
+}
+
+func TestChatParticipantsCount(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	dm := "111@s.whatsapp.net"
+	group := "123@g.us"
+	if _, _, err := db.UpsertChat(ctx, dm, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if _, _, err := db.UpsertChat(ctx, group, "group", "Group", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if err := db.UpsertGroup(ctx, group, "Group", "", time.Time{}); err != nil {
+		t.Fatalf("UpsertGroup: %v", err)
+	}
+	if err := db.ReplaceGroupParticipants(ctx, group, []GroupParticipant{
+		{GroupJID: group, UserJID: "111@s.whatsapp.net"},
+		{GroupJID: group, UserJID: "222@s.whatsapp.net"},
+	}); err != nil {
+		t.Fatalf("ReplaceGroupParticipants: %v", err)
+	}
+
+	// Join, then leave.
+	if err := db.AdjustChatParticipantsCount(ctx, group, 1); err != nil {
+		t.Fatalf("AdjustChatParticipantsCount join: %v", err)
+	}
+	c, err := db.GetChat(ctx, group)
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.ParticipantsCount == nil || *c.ParticipantsCount != 3 {
+		t.Fatalf("expected 3 participants after join, got %v", c.ParticipantsCount)
+	}
+	if err := db.AdjustChatParticipantsCount(ctx, group, -1); err != nil {
+		t.Fatalf("AdjustChatParticipantsCount leave: %v", err)
+	}
+	c, err = db.GetChat(ctx, group)
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.ParticipantsCount == nil || *c.ParticipantsCount != 2 {
+		t.Fatalf("expected 2 participants after leave, got %v", c.ParticipantsCount)
+	}
+
+	// Unknown counts are not invented.
+	if err := db.AdjustChatParticipantsCount(ctx, dm, 1); err != nil {
+		t.Fatalf("AdjustChatParticipantsCount dm: %v", err)
+	}
+	c, err = db.GetChat(ctx, dm)
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if c.ParticipantsCount != nil {
+		t.Fatalf("expected nil participants for DM, got %d", *c.ParticipantsCount)
+	}
+}
+
 func TestMessageUpsertIdempotentAndContext(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 
 	chat := "123@s.whatsapp.net"
-	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
 
@@ -85,7 +380,7 @@ func TestMessageUpsertIdempotentAndContext(t *testing.T) {
 		{"m3", base.Add(3 * time.Second), "third"},
 	}
 	for _, m := range msgs {
-		if err := db.UpsertMessage(UpsertMessageParams{
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
 			ChatJID:    chat,
 			ChatName:   "Alice",
 			MsgID:      m.id,
@@ -100,7 +395,7 @@ func TestMessageUpsertIdempotentAndContext(t *testing.T) {
 	}
 
 	// Upsert same message again should not create duplicates.
-	if err := db.UpsertMessage(UpsertMessageParams{
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
 		ChatJID:    chat,
 		ChatName:   "Alice",
 		MsgID:      "m2",
@@ -116,27 +411,92 @@ func TestMessageUpsertIdempotentAndContext(t *testing.T) {
 		t.Fatalf("expected 3 messages, got %d", got)
 	}
 
-	ctx, err := db.MessageContext(chat, "m2", 1, 1)
+	around, err := db.MessageContext(ctx, chat, "m2", 1, 1)
 	if err != nil {
 		t.Fatalf("MessageContext: %v", err)
 	}
-	if len(ctx) != 3 {
-		t.Fatalf("expected 3 context messages, got %d", len(ctx))
+	if len(around) != 3 {
+		t.Fatalf("expected 3 context messages, got %d", len(around))
+	}
+	if around[0].MsgID != "m1" || around[1].MsgID != "m2" || around[2].MsgID != "m3" {
+		t.Fatalf("unexpected context order: %v, %v, %v", around[0].MsgID, around[1].MsgID, around[2].MsgID)
+	}
+}
+
+func TestUpsertMessageReportsInsert(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	ts := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	p := UpsertMessageParams{
+		ChatJID:   chat,
+		MsgID:     "m1",
+		SenderJID: chat,
+		Timestamp: ts,
+		Text:      "first",
+	}
+
+	m, inserted, err := db.UpsertMessage(ctx, p)
+	if err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	if !inserted {
+		t.Fatalf("expected first upsert to report an insert")
+	}
+	if m.MsgID != "m1" || m.Text != "first" || m.ChatName != "Alice" || !m.Timestamp.Equal(ts) {
+		t.Fatalf("unexpected row: %+v", m)
+	}
+
+	p.Text = "edited"
+	m, inserted, err = db.UpsertMessage(ctx, p)
+	if err != nil {
+		t.Fatalf("UpsertMessage again: %v", err)
+	}
+	if inserted {
+		t.Fatalf("expected duplicate upsert to report an update")
+	}
+	if m.Text != "edited" {
+		t.Fatalf("expected updated row, got %+v", m)
+	}
+}
+
+func TestUpsertMessageReplyTo(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	p := UpsertMessageParams{ChatJID: chat, MsgID: "r1", Timestamp: time.Now(), Text: "yes", ReplyToMsgID: "q1"}
+	if m, _, err := db.UpsertMessage(ctx, p); err != nil || m.ReplyToMsgID != "q1" {
+		t.Fatalf("UpsertMessage: %+v, %v", m, err)
+	}
+	// A later copy without the quote (e.g. an edit) keeps it.
+	p.ReplyToMsgID = ""
+	if m, _, err := db.UpsertMessage(ctx, p); err != nil || m.ReplyToMsgID != "q1" {
+		t.Fatalf("UpsertMessage again: %+v, %v", m, err)
 	}
-	if ctx[0].MsgID != "m1" || ctx[1].MsgID != "m2" || ctx[2].MsgID != "m3" {
-		t.Fatalf("unexpected context order: %v, %v, %v", ctx[0].MsgID, ctx[1].MsgID, ctx[2].MsgID)
+	ms, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: chat})
+	if err != nil || len(ms) != 1 || ms[0].ReplyToMsgID != "q1" {
+		t.Fatalf("ListMessages: %+v, %v", ms, err)
 	}
 }
 
 func TestMediaDownloadInfoAndMarkDownloaded(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 
 	chat := "123@s.whatsapp.net"
-	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
 	ts := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
-	if err := db.UpsertMessage(UpsertMessageParams{
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
 		ChatJID:       chat,
 		ChatName:      "Alice",
 		MsgID:         "mid",
@@ -158,7 +518,7 @@ func TestMediaDownloadInfoAndMarkDownloaded(t *testing.T) {
 		t.Fatalf("UpsertMessage: %v", err)
 	}
 
-	info, err := db.GetMediaDownloadInfo(chat, "mid")
+	info, err := db.GetMediaDownloadInfo(ctx, chat, "mid")
 	if err != nil {
 		t.Fatalf("GetMediaDownloadInfo: %v", err)
 	}
@@ -170,10 +530,10 @@ func TestMediaDownloadInfoAndMarkDownloaded(t *testing.T) {
 	}
 
 	when := time.Date(2024, 3, 1, 0, 0, 1, 0, time.UTC)
-	if err := db.MarkMediaDownloaded(chat, "mid", "/tmp/file", when); err != nil {
+	if err := db.MarkMediaDownloaded(ctx, chat, "mid", "/tmp/file", when); err != nil {
 		t.Fatalf("MarkMediaDownloaded: %v", err)
 	}
-	info, err = db.GetMediaDownloadInfo(chat, "mid")
+	info, err = db.GetMediaDownloadInfo(ctx, chat, "mid")
 	if err != nil {
 		t.Fatalf("GetMediaDownloadInfo: %v", err)
 	}
@@ -186,23 +546,24 @@ func TestMediaDownloadInfoAndMarkDownloaded(t *testing.T) {
 }
 
 func TestContactsAliasTagsAndSearch(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 
 	jid := "111@s.whatsapp.net"
-	if err := db.UpsertContact(jid, "111", "Push", "Full Name", "First", "Biz"); err != nil {
+	if err := db.UpsertContact(ctx, jid, "111", "Push", "Full Name", "First", "Biz"); err != nil {
 		t.Fatalf("UpsertContact: %v", err)
 	}
-	if err := db.SetAlias(jid, "Ali"); err != nil {
+	if err := db.SetAlias(ctx, jid, "Ali"); err != nil {
 		t.Fatalf("SetAlias: %v", err)
 	}
-	if err := db.AddTag(jid, "friends"); err != nil {
+	if err := db.AddTag(ctx, jid, "friends"); err != nil {
 		t.Fatalf("AddTag: %v", err)
 	}
-	if err := db.AddTag(jid, "work"); err != nil {
+	if err := db.AddTag(ctx, jid, "work"); err != nil {
 		t.Fatalf("AddTag: %v", err)
 	}
 
-	c, err := db.GetContact(jid)
+	c, err := db.GetContact(ctx, jid)
 	if err != nil {
 		t.Fatalf("GetContact: %v", err)
 	}
@@ -213,7 +574,7 @@ func TestContactsAliasTagsAndSearch(t *testing.T) {
 		t.Fatalf("expected 2 tags, got %v", c.Tags)
 	}
 
-	found, err := db.SearchContacts("Ali", 10)
+	found, err := db.SearchContacts(ctx, "Ali", 10)
 	if err != nil {
 		t.Fatalf("SearchContacts: %v", err)
 	}
@@ -221,13 +582,13 @@ func TestContactsAliasTagsAndSearch(t *testing.T) {
 		t.Fatalf("expected to find contact by alias, got %+v", found)
 	}
 
-	if err := db.RemoveTag(jid, "work"); err != nil {
+	if err := db.RemoveTag(ctx, jid, "work"); err != nil {
 		t.Fatalf("RemoveTag: %v", err)
 	}
-	if err := db.RemoveAlias(jid); err != nil {
+	if err := db.RemoveAlias(ctx, jid); err != nil {
 		t.Fatalf("RemoveAlias: %v", err)
 	}
-	c, err = db.GetContact(jid)
+	c, err = db.GetContact(ctx, jid)
 	if err != nil {
 		t.Fatalf("GetContact: %v", err)
 	}
@@ -240,19 +601,20 @@ func TestContactsAliasTagsAndSearch(t *testing.T) {
 }
 
 func TestCountMessagesAndOldestMessageInfo(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 
 	chat := "123@s.whatsapp.net"
-	if err := db.UpsertChat(chat, "dm", "Alice", time.Now()); err != nil {
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
 		t.Fatalf("UpsertChat: %v", err)
 	}
 
-	if n, err := db.CountMessages(); err != nil || n != 0 {
+	if n, err := db.CountMessages(ctx); err != nil || n != 0 {
 		t.Fatalf("CountMessages expected 0, got %d (err=%v)", n, err)
 	}
 
 	base := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
-	_ = db.UpsertMessage(UpsertMessageParams{
+	_, _, _ = db.UpsertMessage(ctx, UpsertMessageParams{
 		ChatJID:    chat,
 		MsgID:      "m2",
 		Timestamp:  base.Add(2 * time.Second),
@@ -261,7 +623,7 @@ func TestCountMessagesAndOldestMessageInfo(t *testing.T) {
 		SenderName: "Alice",
 		Text:       "second",
 	})
-	_ = db.UpsertMessage(UpsertMessageParams{
+	_, _, _ = db.UpsertMessage(ctx, UpsertMessageParams{
 		ChatJID:    chat,
 		MsgID:      "m1",
 		Timestamp:  base.Add(1 * time.Second),
@@ -271,7 +633,7 @@ func TestCountMessagesAndOldestMessageInfo(t *testing.T) {
 		Text:       "first",
 	})
 
-	oldest, err := db.GetOldestMessageInfo(chat)
+	oldest, err := db.GetOldestMessageInfo(ctx, chat)
 	if err != nil {
 		t.Fatalf("GetOldestMessageInfo: %v", err)
 	}
@@ -285,27 +647,28 @@ func TestCountMessagesAndOldestMessageInfo(t *testing.T) {
 		t.Fatalf("expected oldest.FromMe=false")
 	}
 
-	if n, err := db.CountMessages(); err != nil || n != 2 {
+	if n, err := db.CountMessages(ctx); err != nil || n != 2 {
 		t.Fatalf("CountMessages expected 2, got %d (err=%v)", n, err)
 	}
 }
 
 func TestGroupsUpsertListAndParticipantsReplace(t *testing.T) {
+	ctx := context.Background()
 	db := openTestDB(t)
 
 	gid := "123@g.us"
 	created := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
-	if err := db.UpsertGroup(gid, "Group", "owner@s.whatsapp.net", created); err != nil {
+	if err := db.UpsertGroup(ctx, gid, "Group", "owner@s.whatsapp.net", created); err != nil {
 		t.Fatalf("UpsertGroup: %v", err)
 	}
-	if err := db.ReplaceGroupParticipants(gid, []GroupParticipant{
+	if err := db.ReplaceGroupParticipants(ctx, gid, []GroupParticipant{
 		{GroupJID: gid, UserJID: "a@s.whatsapp.net", Role: "admin"},
 		{GroupJID: gid, UserJID: "b@s.whatsapp.net", Role: ""},
 	}); err != nil {
 		t.Fatalf("ReplaceGroupParticipants: %v", err)
 	}
 
-	gs, err := db.ListGroups("Gro", 10)
+	gs, err := db.ListGroups(ctx, "Gro", 10)
 	if err != nil {
 		t.Fatalf("ListGroups: %v", err)
 	}
@@ -319,3 +682,990 @@ func TestGroupsUpsertListAndParticipantsReplace(t *testing.T) {
 		t.Fatalf("expected roles admin=1 member=1, got admin=%d member=%d", admins, members)
 	}
 }
+
+func TestListMessagesSenderFilter(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	group := "123@g.us"
+	if _, _, err := db.UpsertChat(ctx, group, "group", "Group", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	alice := "111@s.whatsapp.net"
+	bob := "222@s.whatsapp.net"
+	base := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i, sender := range []string{alice, bob, alice, bob, alice} {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:   group,
+			MsgID:     fmt.Sprintf("m%d", i),
+			SenderJID: sender,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Text:      "hi",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	msgs, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: group, SenderJID: alice})
+	if err != nil {
+		t.Fatalf("ListMessages: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages from alice, got %d", len(msgs))
+	}
+	for _, m := range msgs {
+		if m.SenderJID != alice {
+			t.Fatalf("unexpected sender %q", m.SenderJID)
+		}
+	}
+
+	msgs, err = db.ListMessages(ctx, ListMessagesParams{ChatJID: group})
+	if err != nil {
+		t.Fatalf("ListMessages: %v", err)
+	}
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages without filter, got %d", len(msgs))
+	}
+}
+
+func TestListMessagesSenderNameSearch(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	group := "123@g.us"
+	if _, _, err := db.UpsertChat(ctx, group, "group", "Group", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	alice := "111@s.whatsapp.net"
+	bob := "222@s.whatsapp.net"
+	carol := "333@s.whatsapp.net"
+	base := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	for i, m := range []struct{ jid, name string }{
+		{alice, "Alice Smith"}, {bob, "Bob"}, {alice, "Alice Smith"}, {carol, "Carol_1"},
+	} {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:    group,
+			MsgID:      fmt.Sprintf("m%d", i),
+			SenderJID:  m.jid,
+			SenderName: m.name,
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+			Text:       "hi",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		p    ListMessagesParams
+		want int
+	}{
+		{"case-insensitive substring", ListMessagesParams{SenderNameSearch: "smith"}, 2},
+		{"no match", ListMessagesParams{SenderNameSearch: "dave"}, 0},
+		{"underscore is literal", ListMessagesParams{SenderNameSearch: "l_"}, 1},
+		{"OR with sender JID", ListMessagesParams{SenderNameSearch: "bob", SenderJID: carol}, 2},
+	} {
+		tc.p.ChatJID = group
+		msgs, err := db.ListMessages(ctx, tc.p)
+		if err != nil {
+			t.Fatalf("%s: ListMessages: %v", tc.name, err)
+		}
+		if len(msgs) != tc.want {
+			t.Fatalf("%s: expected %d messages, got %+v", tc.name, tc.want, msgs)
+		}
+	}
+}
+
+func TestMessagesIncludeSenderName(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	group := "123@g.us"
+	ts := time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+	if _, _, err := db.UpsertChat(ctx, group, "group", "Group", "", ts); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+		ChatJID:    group,
+		MsgID:      "m1",
+		SenderJID:  "111@s.whatsapp.net",
+		SenderName: "Alice",
+		Timestamp:  ts,
+		Text:       "hi there",
+	}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	// A later upsert without a name keeps the stored one.
+	m, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+		ChatJID:   group,
+		MsgID:     "m1",
+		SenderJID: "111@s.whatsapp.net",
+		Timestamp: ts,
+		Text:      "hi there",
+	})
+	if err != nil {
+		t.Fatalf("UpsertMessage again: %v", err)
+	}
+	if m.SenderName != "Alice" {
+		t.Fatalf("UpsertMessage: expected sender name Alice, got %q", m.SenderName)
+	}
+
+	msgs, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: group})
+	if err != nil {
+		t.Fatalf("ListMessages: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].SenderName != "Alice" {
+		t.Fatalf("ListMessages: expected sender name Alice, got %+v", msgs)
+	}
+	if got, err := db.GetMessage(ctx, group, "m1"); err != nil || got.SenderName != "Alice" {
+		t.Fatalf("GetMessage: expected sender name Alice, got %q (err=%v)", got.SenderName, err)
+	}
+	res, err := db.SearchMessages(ctx, SearchMessagesParams{Query: "there"})
+	if err != nil {
+		t.Fatalf("SearchMessages: %v", err)
+	}
+	if len(res) != 1 || res[0].SenderName != "Alice" {
+		t.Fatalf("SearchMessages: expected sender name Alice, got %+v", res)
+	}
+}
+
+func TestListMessagesFromMeFilter(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+	for i, fromMe := range []bool{true, false, false, true, false} {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     fmt.Sprintf("m%d", i),
+			SenderJID: chat,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			FromMe:    fromMe,
+			Text:      "hi",
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		desc   string
+		fromMe *bool
+		want   int
+	}{
+		{"any", nil, 5},
+		{"sent", &yes, 2},
+		{"received", &no, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			msgs, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: chat, FromMe: tt.fromMe})
+			if err != nil {
+				t.Fatalf("ListMessages: %v", err)
+			}
+			if len(msgs) != tt.want {
+				t.Fatalf("expected %d messages, got %d", tt.want, len(msgs))
+			}
+			for _, m := range msgs {
+				if tt.fromMe != nil && m.FromMe != *tt.fromMe {
+					t.Fatalf("unexpected FromMe=%v", m.FromMe)
+				}
+			}
+		})
+	}
+}
+
+func TestListMessagesMediaTypeFilter(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 4, 3, 0, 0, 0, 0, time.UTC)
+	for i, mt := range []string{"", "image", "document", "video", "image"} {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     fmt.Sprintf("m%d", i),
+			SenderJID: chat,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Text:      "hi",
+			MediaType: mt,
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	tests := []struct {
+		types []string
+		want  int
+	}{
+		{nil, 5},
+		{[]string{"image"}, 2},
+		{[]string{"image", "document"}, 3},
+		{[]string{"sticker"}, 0},
+		{[]string{"image' OR 1=1 --"}, 0},
+	}
+	for _, tt := range tests {
+		msgs, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: chat, MediaTypes: tt.types})
+		if err != nil {
+			t.Fatalf("ListMessages(%v): %v", tt.types, err)
+		}
+		if len(msgs) != tt.want {
+			t.Fatalf("ListMessages(%v): expected %d, got %d", tt.types, tt.want, len(msgs))
+		}
+	}
+}
+
+func TestListMessagesTextSearch(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, text := range []string{"50% off today", "500 offers", "snake_case name", "snakeXcase", "Lunch at noon"} {
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{ChatJID: chat, MsgID: fmt.Sprintf("m%d", i), Timestamp: base.Add(time.Duration(i) * time.Minute), Text: text}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		search string
+		want   []string
+	}{
+		{"lunch", []string{"m4"}},
+		{"50%", []string{"m0"}},
+		{"snake_case", []string{"m2"}},
+		{"off", []string{"m1", "m0"}},
+		{"nothing", nil},
+	} {
+		msgs, err := db.ListMessages(ctx, ListMessagesParams{ChatJID: chat, TextSearch: tc.search})
+		if err != nil {
+			t.Fatalf("ListMessages(%q): %v", tc.search, err)
+		}
+		var got []string
+		for _, m := range msgs {
+			got = append(got, m.MsgID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("TextSearch %q: got %v, want %v", tc.search, got, tc.want)
+		}
+	}
+}
+
+func TestSearchMessagesWithCount(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		text := "lunch plans"
+		if i%3 == 0 {
+			text = "unrelated"
+		}
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID:   chat,
+			MsgID:     fmt.Sprintf("m%d", i),
+			SenderJID: chat,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Text:      text,
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	res, err := db.SearchMessagesWithCount(ctx, SearchMessagesParams{Query: "lunch", Limit: 5})
+	if err != nil {
+		t.Fatalf("SearchMessagesWithCount: %v", err)
+	}
+	if len(res.Messages) != 5 {
+		t.Fatalf("expected 5 messages in page, got %d", len(res.Messages))
+	}
+	if res.TotalCount != 20 {
+		t.Fatalf("expected TotalCount=20, got %d", res.TotalCount)
+	}
+}
+
+func TestListChatsPageCursor(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 25; i++ {
+		// Pairs of chats share a timestamp to exercise the jid tie-breaker.
+		ts := base.Add(time.Duration(i/2) * time.Minute)
+		if _, _, err := db.UpsertChat(ctx, fmt.Sprintf("%03d@s.whatsapp.net", i), "dm", "", "", ts); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+
+	seen := map[string]int{}
+	var cursor *ChatCursor
+	pages := 0
+	for {
+		page, err := db.ListChatsPage(ctx, ListChatsParams{Limit: 10, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("ListChatsPage: %v", err)
+		}
+		pages++
+		for _, c := range page.Chats {
+			seen[c.JID]++
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		// Round-trip through the opaque encoding like RPC clients do.
+		next, err := ParseChatCursor(page.NextCursor.Encode())
+		if err != nil {
+			t.Fatalf("ParseChatCursor: %v", err)
+		}
+		cursor = &next
+		if pages > 5 {
+			t.Fatalf("too many pages")
+		}
+	}
+
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+	if len(seen) != 25 {
+		t.Fatalf("expected 25 distinct chats, got %d", len(seen))
+	}
+	for jid, n := range seen {
+		if n != 1 {
+			t.Fatalf("chat %s visited %d times", jid, n)
+		}
+	}
+}
+
+func TestListMessagesPageCursor(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 25; i++ {
+		// Triples share a second to exercise the msg_id tie-breaker.
+		ts := base.Add(time.Duration(i/3) * time.Second)
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{ChatJID: chat, MsgID: fmt.Sprintf("m%02d", i), Timestamp: ts, Text: "hi"}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+
+	var order []string
+	var cursor *MessageCursor
+	pages := 0
+	for {
+		page, err := db.ListMessagesPage(ctx, ListMessagesParams{ChatJID: chat, Limit: 10, BeforeCursor: cursor})
+		if err != nil {
+			t.Fatalf("ListMessagesPage: %v", err)
+		}
+		pages++
+		for _, m := range page.Messages {
+			order = append(order, m.MsgID)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		next, err := ParseMessageCursor(page.NextCursor.Encode())
+		if err != nil {
+			t.Fatalf("ParseMessageCursor: %v", err)
+		}
+		cursor = &next
+		if pages > 5 {
+			t.Fatalf("too many pages")
+		}
+	}
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, got %d", pages)
+	}
+	if len(order) != 25 {
+		t.Fatalf("expected 25 messages, got %d: %v", len(order), order)
+	}
+	for i, id := range order {
+		if want := fmt.Sprintf("m%02d", 24-i); id != want {
+			t.Fatalf("position %d: got %s, want %s (order %v)", i, id, want, order)
+		}
+	}
+
+	// Exactly one page worth of rows leaves no next cursor.
+	page, err := db.ListMessagesPage(ctx, ListMessagesParams{ChatJID: chat, Limit: 25})
+	if err != nil {
+		t.Fatalf("ListMessagesPage: %v", err)
+	}
+	if len(page.Messages) != 25 || page.NextCursor != nil {
+		t.Fatalf("expected a single full page, got %d rows and cursor %v", len(page.Messages), page.NextCursor)
+	}
+
+	// AfterCursor returns the rows just newer than the cursor, newest first.
+	after := &MessageCursor{Timestamp: base.Add(time.Second), MsgID: "m04"}
+	page, err = db.ListMessagesPage(ctx, ListMessagesParams{ChatJID: chat, Limit: 3, AfterCursor: after})
+	if err != nil {
+		t.Fatalf("ListMessagesPage after: %v", err)
+	}
+	var got []string
+	for _, m := range page.Messages {
+		got = append(got, m.MsgID)
+	}
+	if fmt.Sprint(got) != "[m07 m06 m05]" {
+		t.Fatalf("AfterCursor page = %v, want [m07 m06 m05]", got)
+	}
+	if page.NextCursor == nil || page.NextCursor.MsgID != "m07" {
+		t.Fatalf("expected next cursor at m07, got %v", page.NextCursor)
+	}
+
+	// OrderAsc sorts the same rows oldest first; without a cursor it
+	// starts at the oldest, and a BeforeCursor still picks the rows just
+	// older than it.
+	for _, tc := range []struct {
+		name       string
+		p          ListMessagesParams
+		want, next string
+	}{
+		{"asc", ListMessagesParams{Order: OrderAsc}, "[m00 m01 m02]", "m02"},
+		{"asc after", ListMessagesParams{Order: OrderAsc, AfterCursor: after}, "[m05 m06 m07]", "m07"},
+		{"asc before", ListMessagesParams{Order: OrderAsc, BeforeCursor: after}, "[m01 m02 m03]", "m01"},
+		{"desc", ListMessagesParams{Order: OrderDesc}, "[m24 m23 m22]", "m22"},
+	} {
+		tc.p.ChatJID, tc.p.Limit = chat, 3
+		page, err := db.ListMessagesPage(ctx, tc.p)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var got []string
+		for _, m := range page.Messages {
+			got = append(got, m.MsgID)
+		}
+		if fmt.Sprint(got) != tc.want {
+			t.Errorf("%s: page = %v, want %s", tc.name, got, tc.want)
+		}
+		if page.NextCursor == nil || page.NextCursor.MsgID != tc.next {
+			t.Errorf("%s: next cursor = %v, want %s", tc.name, page.NextCursor, tc.next)
+		}
+	}
+
+	if _, err := ParseMessageCursor("not a cursor!"); err == nil {
+		t.Fatalf("expected error for invalid cursor")
+	}
+}
+
+func TestRawExecAndQuery(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, `CREATE TABLE migrations_test (id INTEGER PRIMARY KEY, name TEXT)`); err != nil {
+		t.Fatalf("ExecContext create: %v", err)
+	}
+	res, err := db.ExecContext(ctx, `INSERT INTO migrations_test(name) VALUES (?), (?)`, "a", "b")
+	if err != nil {
+		t.Fatalf("ExecContext insert: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Fatalf("expected 2 rows affected, got %d", n)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name FROM migrations_test ORDER BY id`)
+	if err != nil {
+		t.Fatalf("QueryContext: %v", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("unexpected rows: %v", names)
+	}
+}
+
+func TestWithTxRollback(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	boom := fmt.Errorf("boom")
+	err := db.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`INSERT INTO chats(jid, kind, name) VALUES (?, 'dm', 'Alice')`, "123@s.whatsapp.net"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if err != boom {
+		t.Fatalf("expected fn error to be returned, got %v", err)
+	}
+	if n := countRows(t, db.sql, `SELECT COUNT(*) FROM chats`); n != 0 {
+		t.Fatalf("expected rollback to discard insert, got %d chats", n)
+	}
+
+	if err := db.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO chats(jid, kind, name) VALUES (?, 'dm', 'Alice')`, "123@s.whatsapp.net")
+		return err
+	}); err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if n := countRows(t, db.sql, `SELECT COUNT(*) FROM chats`); n != 1 {
+		t.Fatalf("expected commit to keep insert, got %d chats", n)
+	}
+}
+
+func TestUpsertMessageAdvancesChatTimestamp(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@s.whatsapp.net"
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", t1); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{ChatJID: chat, MsgID: "m1", Timestamp: t2, Text: "hi"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	c, err := db.GetChat(ctx, chat)
+	if err != nil {
+		t.Fatalf("GetChat: %v", err)
+	}
+	if !c.LastMessageTS.Equal(t2) {
+		t.Fatalf("expected LastMessageTS=%s, got %s", t2, c.LastMessageTS)
+	}
+
+	// Older messages must not move it back.
+	if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{ChatJID: chat, MsgID: "m0", Timestamp: t1, Text: "older"}); err != nil {
+		t.Fatalf("UpsertMessage: %v", err)
+	}
+	if c, _ = db.GetChat(ctx, chat); !c.LastMessageTS.Equal(t2) {
+		t.Fatalf("expected LastMessageTS to stay %s, got %s", t2, c.LastMessageTS)
+	}
+}
+
+func TestQueriesHonorContextCancellation(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	chat := "123@s.whatsapp.net"
+	if _, _, err := db.UpsertChat(ctx, chat, "dm", "Alice", "", time.Now()); err != nil {
+		t.Fatalf("UpsertChat: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i+1 FROM n WHERE i < 200000)
+		INSERT INTO messages(chat_jid, msg_id, sender_jid, ts, from_me, text)
+		SELECT ?, 'm' || i, ?, i, 0, 'message number ' || i FROM n
+	`, chat, chat); err != nil {
+		t.Fatalf("seed messages: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := db.ListMessages(cancelled, ListMessagesParams{ChatJID: chat}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ListMessages: expected context.Canceled, got %v", err)
+	}
+	if _, err := db.CountMessages(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("CountMessages: expected context.Canceled, got %v", err)
+	}
+	if _, _, err := db.UpsertChat(cancelled, chat, "dm", "Bob", "", time.Time{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("UpsertChat: expected context.Canceled, got %v", err)
+	}
+
+	// No index covers sender_jid, so this scans every row; the deadline
+	// should interrupt it well before it finishes.
+	short, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := db.ListMessages(short, ListMessagesParams{SenderJID: "nobody@s.whatsapp.net"})
+	if err == nil {
+		t.Skipf("query finished in %s, before the deadline", time.Since(start))
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !strings.Contains(err.Error(), "interrupt") {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	if err := db.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	_ = db.Close()
+	if err := db.HealthCheck(ctx); err == nil {
+		t.Fatalf("expected HealthCheck to fail on a closed DB")
+	}
+}
+
+func TestUpsertReaction(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@g.us"
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+	react := func(sender, reaction string, ts time.Time) {
+		t.Helper()
+		if err := db.UpsertReaction(ctx, UpsertReactionParams{
+			ChatJID: chat, MsgID: "m1", SenderJID: sender, Reaction: reaction, Timestamp: ts,
+		}); err != nil {
+			t.Fatalf("UpsertReaction: %v", err)
+		}
+	}
+
+	react("a@s.whatsapp.net", "👍", t1)
+	react("b@s.whatsapp.net", "👍", t1)
+	react("", "❤️", t1)
+	// A sender's newer reaction replaces the old one; a stale one is ignored.
+	react("b@s.whatsapp.net", "😂", t2)
+	react("b@s.whatsapp.net", "👍", t1)
+
+	got, err := db.ListReactions(ctx, chat, "m1")
+	if err != nil {
+		t.Fatalf("ListReactions: %v", err)
+	}
+	want := []ReactionCount{{"❤️", 1}, {"👍", 1}, {"😂", 1}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	// An empty reaction removes it.
+	react("", "", t2)
+	react("a@s.whatsapp.net", "😂", t2)
+	got, err = db.ListReactions(ctx, chat, "m1")
+	if err != nil {
+		t.Fatalf("ListReactions: %v", err)
+	}
+	if len(got) != 1 || got[0] != (ReactionCount{"😂", 2}) {
+		t.Fatalf("expected one 😂 x2, got %v", got)
+	}
+}
+
+func TestSetLastReadAndUnreadCounts(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	alice, bob := "111@s.whatsapp.net", "222@s.whatsapp.net"
+	for _, jid := range []string{alice, bob} {
+		if _, _, err := db.UpsertChat(ctx, jid, "dm", "", "", base); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+	msg := func(chat, id string, offset int, fromMe bool) {
+		t.Helper()
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{
+			ChatJID: chat, MsgID: id, SenderJID: chat, Timestamp: base.Add(time.Duration(offset) * time.Minute), FromMe: fromMe,
+		}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	msg(alice, "a1", 1, false)
+	msg(alice, "a2", 2, false)
+	msg(alice, "a3", 3, true)
+	msg(alice, "a4", 4, false)
+	msg(bob, "b1", 5, false)
+
+	counts := func() map[string]int {
+		t.Helper()
+		got, err := db.UnreadCounts(ctx)
+		if err != nil {
+			t.Fatalf("UnreadCounts: %v", err)
+		}
+		out := map[string]int{}
+		for _, c := range got {
+			out[c.ChatJID] = c.Count
+		}
+		return out
+	}
+
+	if got := counts(); got[alice] != 3 || got[bob] != 1 {
+		t.Fatalf("expected 3 and 1 unread before marking, got %v", got)
+	}
+
+	if err := db.SetLastRead(ctx, alice, "a2"); err != nil {
+		t.Fatalf("SetLastRead: %v", err)
+	}
+	// Older and unknown messages must not move the pointer back.
+	if err := db.SetLastRead(ctx, alice, "a1"); err != nil {
+		t.Fatalf("SetLastRead older: %v", err)
+	}
+	if err := db.SetLastRead(ctx, alice, "missing"); err != nil {
+		t.Fatalf("SetLastRead missing: %v", err)
+	}
+	if got := counts(); got[alice] != 1 || got[bob] != 1 {
+		t.Fatalf("expected 1 and 1 unread after a2, got %v", got)
+	}
+
+	if err := db.SetLastRead(ctx, bob, "b1"); err != nil {
+		t.Fatalf("SetLastRead: %v", err)
+	}
+	if got := counts(); len(got) != 1 || got[alice] != 1 {
+		t.Fatalf("expected only alice unread, got %v", got)
+	}
+}
+
+func TestMessageCountsTrackInsertsAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	alice, bob := "111@s.whatsapp.net", "222@s.whatsapp.net"
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, jid := range []string{alice, bob} {
+		if _, _, err := db.UpsertChat(ctx, jid, "dm", "", "", base); err != nil {
+			t.Fatalf("UpsertChat: %v", err)
+		}
+	}
+	insert := func(chat, id string) {
+		t.Helper()
+		if _, _, err := db.UpsertMessage(ctx, UpsertMessageParams{ChatJID: chat, MsgID: id, Timestamp: base, Text: id}); err != nil {
+			t.Fatalf("UpsertMessage: %v", err)
+		}
+	}
+	check := func(total, a, b int64) {
+		t.Helper()
+		if n, err := db.CountMessages(ctx); err != nil || n != total {
+			t.Fatalf("CountMessages = %d (err=%v), want %d", n, err, total)
+		}
+		if n, err := db.CountChatMessages(ctx, alice); err != nil || n != a {
+			t.Fatalf("CountChatMessages(alice) = %d (err=%v), want %d", n, err, a)
+		}
+		if n, err := db.CountChatMessages(ctx, bob); err != nil || n != b {
+			t.Fatalf("CountChatMessages(bob) = %d (err=%v), want %d", n, err, b)
+		}
+	}
+
+	check(0, 0, 0)
+	insert(alice, "a1")
+	insert(alice, "a2")
+	insert(bob, "b1")
+	// Re-upserting an existing message must not count it twice.
+	insert(alice, "a1")
+	check(3, 2, 1)
+
+	if _, err := db.sql.ExecContext(ctx, `DELETE FROM messages WHERE chat_jid = ? AND msg_id = ?`, alice, "a2"); err != nil {
+		t.Fatalf("delete message: %v", err)
+	}
+	check(2, 1, 1)
+
+	// Deleting a chat cascades to its messages.
+	if _, err := db.sql.ExecContext(ctx, `DELETE FROM chats WHERE jid = ?`, bob); err != nil {
+		t.Fatalf("delete chat: %v", err)
+	}
+	check(1, 1, 0)
+}
+
+func TestPollResults(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	chat := "123@g.us"
+	if err := db.SavePollOptions(ctx, chat, "p1", []string{"Pizza", "Sushi", "Salad"}); err != nil {
+		t.Fatalf("SavePollOptions: %v", err)
+	}
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	vote := func(voter string, ts time.Time, options ...string) {
+		t.Helper()
+		if err := db.RecordPollVote(ctx, PollVoteParams{
+			ChatJID: chat, MsgID: "p1", VoterJID: voter, Options: options, Timestamp: ts,
+		}); err != nil {
+			t.Fatalf("RecordPollVote: %v", err)
+		}
+	}
+	vote("a@s.whatsapp.net", t1, "Pizza", "Salad")
+	vote("b@s.whatsapp.net", t1, "Pizza")
+	vote("", t1, "Sushi")
+	// A withdrawn vote clears the voter's selection.
+	vote("", t1.Add(time.Minute))
+
+	got, err := db.PollResults(ctx, chat, "p1")
+	if err != nil {
+		t.Fatalf("PollResults: %v", err)
+	}
+	want := []PollOptionCount{{"Pizza", 2}, {"Sushi", 0}, {"Salad", 1}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	if found, err := db.FindPollChat(ctx, "p1"); err != nil || found != chat {
+		t.Fatalf("FindPollChat = %q, %v", found, err)
+	}
+	if got, err := db.PollResults(ctx, chat, "nope"); err != nil || len(got) != 0 {
+		t.Fatalf("unknown poll: %v, %v", got, err)
+	}
+}
+
+func TestScheduledMessages(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	due, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "1@s.whatsapp.net", Message: "due", SendAt: now.Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("UpsertScheduledMessage: %v", err)
+	}
+	later, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "2@s.whatsapp.net", Message: "later", SendAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("UpsertScheduledMessage: %v", err)
+	}
+	if _, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "1@s.whatsapp.net", SendAt: now}); err == nil {
+		t.Fatalf("expected error for empty message")
+	}
+
+	got, err := db.DueScheduledMessages(ctx, now)
+	if err != nil {
+		t.Fatalf("DueScheduledMessages: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != due || got[0].Message != "due" || got[0].Status != ScheduledPending {
+		t.Fatalf("unexpected due messages: %+v", got)
+	}
+
+	// Failures are retried until the limit, then the message is failed.
+	for i := 0; i < 3; i++ {
+		if _, err := db.ClaimScheduledMessage(ctx, due, now); err != nil {
+			t.Fatalf("ClaimScheduledMessage %d: %v", i, err)
+		}
+		if err := db.MarkScheduledFailure(ctx, due, "boom", 3); err != nil {
+			t.Fatalf("MarkScheduledFailure: %v", err)
+		}
+	}
+	m, err := db.GetScheduledMessage(ctx, due)
+	if err != nil {
+		t.Fatalf("GetScheduledMessage: %v", err)
+	}
+	if m.Status != ScheduledFailed || m.Retries != 3 || m.LastError != "boom" {
+		t.Fatalf("expected failed after 3 retries, got %+v", m)
+	}
+	if got, _ := db.DueScheduledMessages(ctx, now); len(got) != 0 {
+		t.Fatalf("failed message is still due: %+v", got)
+	}
+
+	// Updating a pending message replaces its text and time.
+	if _, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{ID: later, To: "2@s.whatsapp.net", Message: "sooner", SendAt: now}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{ID: due, To: "1@s.whatsapp.net", Message: "again", SendAt: now}); !IsNotFound(err) {
+		t.Fatalf("expected not found updating a failed message, got %v", err)
+	}
+	if _, err := db.ClaimScheduledMessage(ctx, later, now); err != nil {
+		t.Fatalf("ClaimScheduledMessage: %v", err)
+	}
+	if err := db.MarkScheduledSent(ctx, later, "m1"); err != nil {
+		t.Fatalf("MarkScheduledSent: %v", err)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, later); m.Status != ScheduledSent || m.MsgID != "m1" || m.Message != "sooner" {
+		t.Fatalf("unexpected sent message: %+v", m)
+	}
+	if err := db.CancelScheduledMessage(ctx, later); !IsNotFound(err) {
+		t.Fatalf("expected not found canceling a sent message, got %v", err)
+	}
+
+	id, _ := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "3@s.whatsapp.net", Message: "cancel me", SendAt: now})
+	if err := db.CancelScheduledMessage(ctx, id); err != nil {
+		t.Fatalf("CancelScheduledMessage: %v", err)
+	}
+	pending, err := db.ListScheduledMessages(ctx, ScheduledPending)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("expected no pending messages, got %+v, %v", pending, err)
+	}
+	if all, _ := db.ListScheduledMessages(ctx, ""); len(all) != 3 {
+		t.Fatalf("expected 3 scheduled messages, got %+v", all)
+	}
+}
+
+func TestClaimScheduledMessage(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	id, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "1@s.whatsapp.net", Message: "old", SendAt: now.Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("UpsertScheduledMessage: %v", err)
+	}
+	// An edit before the claim is what gets sent.
+	if _, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{ID: id, To: "1@s.whatsapp.net", Message: "new", SendAt: now}); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	m, err := db.ClaimScheduledMessage(ctx, id, now)
+	if err != nil {
+		t.Fatalf("ClaimScheduledMessage: %v", err)
+	}
+	if m.Message != "new" || m.Status != ScheduledSending || !m.SendAt.Equal(now) {
+		t.Fatalf("unexpected claimed message: %+v", m)
+	}
+
+	// Once claimed it can't be claimed again, edited or canceled.
+	if _, err := db.ClaimScheduledMessage(ctx, id, now); !IsNotFound(err) {
+		t.Fatalf("expected not found claiming twice, got %v", err)
+	}
+	if _, err := db.UpsertScheduledMessage(ctx, ScheduledMessage{ID: id, To: "1@s.whatsapp.net", Message: "newer", SendAt: now}); !IsNotFound(err) {
+		t.Fatalf("expected not found editing a claimed message, got %v", err)
+	}
+	if err := db.CancelScheduledMessage(ctx, id); !IsNotFound(err) {
+		t.Fatalf("expected not found canceling a claimed message, got %v", err)
+	}
+
+	// Released, it is pending again; a failure also puts it back.
+	if err := db.ReleaseScheduledMessage(ctx, id); err != nil {
+		t.Fatalf("ReleaseScheduledMessage: %v", err)
+	}
+	if _, err := db.ClaimScheduledMessage(ctx, id, now); err != nil {
+		t.Fatalf("claim after release: %v", err)
+	}
+	if err := db.MarkScheduledFailure(ctx, id, "boom", 3); err != nil {
+		t.Fatalf("MarkScheduledFailure: %v", err)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, id); m.Status != ScheduledPending || m.Retries != 1 {
+		t.Fatalf("expected pending after one failure, got %+v", m)
+	}
+
+	// Canceled, or not yet due: nothing to claim.
+	if err := db.CancelScheduledMessage(ctx, id); err != nil {
+		t.Fatalf("CancelScheduledMessage: %v", err)
+	}
+	if _, err := db.ClaimScheduledMessage(ctx, id, now); !IsNotFound(err) {
+		t.Fatalf("expected not found claiming a canceled message, got %v", err)
+	}
+	later, _ := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "2@s.whatsapp.net", Message: "later", SendAt: now.Add(time.Hour)})
+	if _, err := db.ClaimScheduledMessage(ctx, later, now); !IsNotFound(err) {
+		t.Fatalf("expected not found claiming a future message, got %v", err)
+	}
+
+	// A sender that stopped mid-send leaves the row claimed until requeued.
+	soon, _ := db.UpsertScheduledMessage(ctx, ScheduledMessage{To: "3@s.whatsapp.net", Message: "soon", SendAt: now})
+	if _, err := db.ClaimScheduledMessage(ctx, soon, now); err != nil {
+		t.Fatalf("ClaimScheduledMessage: %v", err)
+	}
+	if n, err := db.RequeueSendingScheduled(ctx); err != nil || n != 1 {
+		t.Fatalf("RequeueSendingScheduled = %d, %v", n, err)
+	}
+	if m, _ := db.GetScheduledMessage(ctx, soon); m.Status != ScheduledPending {
+		t.Fatalf("expected pending after requeue, got %+v", m)
+	}
+}
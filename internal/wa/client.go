@@ -3,6 +3,7 @@ package wa
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -85,6 +86,25 @@ func (c *Client) IsConnected() bool {
 	return c.client != nil && c.client.IsConnected()
 }
 
+// Ping sends a keepalive to the WhatsApp server and waits for the
+// acknowledgement, which proves the socket is actually alive rather than
+// merely open.
+func (c *Client) Ping(ctx context.Context) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return fmt.Errorf("not connected")
+	}
+	if ok, _ := cli.DangerousInternals().SendKeepAlive(ctx); !ok {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("keepalive not acknowledged")
+	}
+	return nil
+}
+
 type ConnectOptions struct {
 	AllowQR  bool
 	OnQRCode func(code string)
@@ -197,6 +217,12 @@ func (c *Client) SendProtoMessage(ctx context.Context, to types.JID, msg *waProt
 	return resp.ID, nil
 }
 
+// SendStatus posts msg to our status (status@broadcast), visible to the
+// contacts allowed by the account's status privacy settings.
+func (c *Client) SendStatus(ctx context.Context, msg *waProto.Message) (types.MessageID, error) {
+	return c.SendProtoMessage(ctx, types.StatusBroadcastJID, msg)
+}
+
 func (c *Client) Upload(ctx context.Context, data []byte, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
 	c.mu.Lock()
 	cli := c.client
@@ -217,6 +243,16 @@ func (c *Client) DecryptReaction(ctx context.Context, reaction *events.Message)
 	return cli.DecryptReaction(ctx, reaction)
 }
 
+func (c *Client) DecryptPollVote(ctx context.Context, vote *events.Message) (*waProto.PollVoteMessage, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+	return cli.DecryptPollVote(ctx, vote)
+}
+
 func (c *Client) RequestHistorySyncOnDemand(ctx context.Context, lastKnown types.MessageInfo, count int) (types.MessageID, error) {
 	c.mu.Lock()
 	cli := c.client
@@ -247,6 +283,8 @@ func (c *Client) RequestHistorySyncOnDemand(ctx context.Context, lastKnown types
 	return resp.ID, nil
 }
 
+// ParseUserOrJID parses a send recipient: a JID, or a phone number that is
+// validated and normalised with NormalizePhone.
 func ParseUserOrJID(s string) (types.JID, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -255,13 +293,57 @@ func ParseUserOrJID(s string) (types.JID, error) {
 	if strings.Contains(s, "@") {
 		return types.ParseJID(s)
 	}
-	return types.JID{User: s, Server: types.DefaultUserServer}, nil
+	e164, err := NormalizePhone(s)
+	if err != nil {
+		return types.JID{}, err
+	}
+	return types.JID{User: strings.TrimPrefix(e164, "+"), Server: types.DefaultUserServer}, nil
+}
+
+// ParseChatJID parses a chat to look up in the local store: a JID, or a
+// phone number whose digits become a user JID. Unlike ParseUserOrJID it does
+// not validate the number, so any chat that is already stored can be found.
+func ParseChatJID(s string) (types.JID, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return types.JID{}, fmt.Errorf("chat is required")
+	}
+	if strings.Contains(s, "@") {
+		return types.ParseJID(s)
+	}
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune("+ -.()", r):
+			// Phone number formatting.
+		default:
+			return types.JID{}, fmt.Errorf("invalid chat %q: want a phone number or JID", s)
+		}
+	}
+	if digits.Len() == 0 {
+		return types.JID{}, fmt.Errorf("invalid chat %q: want a phone number or JID", s)
+	}
+	return types.JID{User: digits.String(), Server: types.DefaultUserServer}, nil
 }
 
+// IsGroupJID reports whether jid is a group chat.
 func IsGroupJID(jid types.JID) bool {
 	return jid.Server == types.GroupServer
 }
 
+// IsBroadcastJID reports whether jid is a broadcast list. The status
+// broadcast (status@broadcast) is not a list and is excluded.
+func IsBroadcastJID(jid types.JID) bool {
+	return jid.IsBroadcastList()
+}
+
+// IsNewsletterJID reports whether jid is a channel (newsletter).
+func IsNewsletterJID(jid types.JID) bool {
+	return jid.Server == types.NewsletterServer
+}
+
 func (c *Client) GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error) {
 	c.mu.Lock()
 	cli := c.client
@@ -307,7 +389,7 @@ func BestContactName(info types.ContactInfo) string {
 func (c *Client) ResolveChatName(ctx context.Context, chat types.JID, pushName string) string {
 	fallback := chat.String()
 
-	if chat.Server == types.GroupServer || chat.IsBroadcastList() {
+	if IsGroupJID(chat) || IsBroadcastJID(chat) {
 		info, err := c.GetGroupInfo(ctx, chat)
 		if err == nil && info != nil {
 			if name := strings.TrimSpace(info.GroupName.Name); name != "" {
@@ -339,6 +421,49 @@ func (c *Client) GetGroupInfo(ctx context.Context, jid types.JID) (*types.GroupI
 	return cli.GetGroupInfo(ctx, jid)
 }
 
+// GetProfilePicture returns the URL of a user's or group's photo, or "" if
+// there is none or it is hidden from us. The URL expires after a while.
+func (c *Client) GetProfilePicture(ctx context.Context, jid types.JID) (string, error) {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return "", fmt.Errorf("not connected")
+	}
+	info, err := cli.GetProfilePictureInfo(ctx, jid, nil)
+	if errors.Is(err, whatsmeow.ErrProfilePictureNotSet) || errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized) {
+		return "", nil
+	}
+	if err != nil || info == nil {
+		return "", err
+	}
+	return info.URL, nil
+}
+
+// MarkRead sends read receipts for ids in chat. In groups sender must be
+// the participant who sent them; in direct chats it may be empty.
+func (c *Client) MarkRead(ctx context.Context, chat, sender types.JID, ids []types.MessageID) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return fmt.Errorf("not connected")
+	}
+	return cli.MarkRead(ctx, ids, time.Now(), chat, sender)
+}
+
+// SendChatPresence shows (composing) or clears (paused) our typing
+// indicator in chat.
+func (c *Client) SendChatPresence(ctx context.Context, chat types.JID, state types.ChatPresence) error {
+	c.mu.Lock()
+	cli := c.client
+	c.mu.Unlock()
+	if cli == nil || !cli.IsConnected() {
+		return fmt.Errorf("not connected")
+	}
+	return cli.SendChatPresence(ctx, chat, state, types.ChatPresenceMediaText)
+}
+
 func (c *Client) Logout(ctx context.Context) error {
 	c.mu.Lock()
 	cli := c.client
@@ -1,17 +1,18 @@
 package wa
 
 import (
+	"errors"
 	"testing"
 
 	"go.mau.fi/whatsmeow/types"
 )
 
 func TestParseUserOrJID(t *testing.T) {
-	j, err := ParseUserOrJID("1234567890")
+	j, err := ParseUserOrJID("+1 (415) 555-2671")
 	if err != nil {
 		t.Fatalf("ParseUserOrJID: %v", err)
 	}
-	if j.Server != types.DefaultUserServer || j.User != "1234567890" {
+	if j.Server != types.DefaultUserServer || j.User != "14155552671" {
 		t.Fatalf("unexpected jid: %+v", j)
 	}
 
@@ -24,6 +25,135 @@ func TestParseUserOrJID(t *testing.T) {
 	}
 }
 
+func TestParseChatJID(t *testing.T) {
+	// Lookups accept numbers NormalizePhone would reject.
+	for input, want := range map[string]string{
+		"1234567890":                "1234567890@s.whatsapp.net",
+		"+1 (415) 555-2671":         "14155552671@s.whatsapp.net",
+		"1234567890@s.whatsapp.net": "1234567890@s.whatsapp.net",
+		"123@g.us":                  "123@g.us",
+	} {
+		j, err := ParseChatJID(input)
+		if err != nil {
+			t.Fatalf("ParseChatJID(%q): %v", input, err)
+		}
+		if j.String() != want {
+			t.Fatalf("ParseChatJID(%q) = %s, want %s", input, j, want)
+		}
+	}
+	for _, input := range []string{"", "  ", "alice", "+", "12a34"} {
+		if _, err := ParseChatJID(input); err == nil {
+			t.Fatalf("ParseChatJID(%q): expected an error", input)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		input  string
+		region string
+		want   string
+	}{
+		{input: "14155552671", want: "+14155552671"},
+		{input: "+1 415-555-2671", want: "+14155552671"},
+		{input: "+44 20 7946 0958", want: "+442079460958"},
+		{input: "491701234567", want: "+491701234567"},
+		{input: "0044 20 7946 0958", region: "GB", want: "+442079460958"},
+		{input: "020 7946 0958", region: "GB", want: "+442079460958"},
+		{input: "(415) 555-2671", region: "us", want: "+14155552671"},
+		{input: "0170 1234567", region: "DE", want: "+491701234567"},
+		{input: "+91 98765 43210", region: "US", want: "+919876543210"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Setenv("WACLI_PHONE_REGION", tt.region)
+			got, err := NormalizePhone(tt.input)
+			if err != nil {
+				t.Fatalf("NormalizePhone(%q): %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Fatalf("NormalizePhone(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePhoneInvalid(t *testing.T) {
+	t.Setenv("WACLI_PHONE_REGION", "")
+	for _, input := range []string{"123", "1234567890", "+999 123456", "not-a-number"} {
+		_, err := NormalizePhone(input)
+		var invalid *ErrInvalidPhoneNumber
+		if !errors.As(err, &invalid) {
+			t.Fatalf("NormalizePhone(%q): expected ErrInvalidPhoneNumber, got %v", input, err)
+		}
+	}
+	if _, err := ParseUserOrJID("12345"); err == nil {
+		t.Fatalf("expected ParseUserOrJID to reject an invalid number")
+	}
+}
+
+func TestJIDKindHelpers(t *testing.T) {
+	user := types.NewJID("15551234567", types.DefaultUserServer)
+	group := types.NewJID("120363012345678901", types.GroupServer)
+	list := types.NewJID("1234567890", types.BroadcastServer)
+	newsletter := types.NewJID("120363098765432109", types.NewsletterServer)
+
+	tests := []struct {
+		jid                          types.JID
+		group, broadcast, newsletter bool
+	}{
+		{jid: user},
+		{jid: group, group: true},
+		{jid: list, broadcast: true},
+		{jid: types.StatusBroadcastJID},
+		{jid: newsletter, newsletter: true},
+	}
+	for _, tt := range tests {
+		if got := IsGroupJID(tt.jid); got != tt.group {
+			t.Errorf("IsGroupJID(%s) = %v", tt.jid, got)
+		}
+		if got := IsBroadcastJID(tt.jid); got != tt.broadcast {
+			t.Errorf("IsBroadcastJID(%s) = %v", tt.jid, got)
+		}
+		if got := IsNewsletterJID(tt.jid); got != tt.newsletter {
+			t.Errorf("IsNewsletterJID(%s) = %v", tt.jid, got)
+		}
+	}
+}
+
+func TestJIDToPhone(t *testing.T) {
+	phone, err := JIDToPhone(types.NewJID("15551234567", types.DefaultUserServer))
+	if err != nil || phone != "+15551234567" {
+		t.Fatalf("JIDToPhone user: %q (err=%v)", phone, err)
+	}
+	// Device suffixes are dropped.
+	ad := types.NewADJID("15551234567", 0, 3)
+	if phone, err := JIDToPhone(ad); err != nil || phone != "+15551234567" {
+		t.Fatalf("JIDToPhone device: %q (err=%v)", phone, err)
+	}
+
+	for _, jid := range []types.JID{
+		types.NewJID("120363012345678901", types.GroupServer),
+		types.StatusBroadcastJID,
+		types.NewJID("1234567890", types.BroadcastServer),
+		types.NewJID("123456789012345", types.HiddenUserServer),
+		{},
+	} {
+		if _, err := JIDToPhone(jid); !errors.Is(err, ErrNotAPhone) {
+			t.Fatalf("JIDToPhone(%s): expected ErrNotAPhone, got %v", jid, err)
+		}
+	}
+
+	// Round trip with ParseUserOrJID.
+	j, err := ParseUserOrJID("+14155552671")
+	if err != nil {
+		t.Fatalf("ParseUserOrJID: %v", err)
+	}
+	if phone, err := JIDToPhone(j); err != nil || phone != "+14155552671" {
+		t.Fatalf("round trip: %q (err=%v)", phone, err)
+	}
+}
+
 func TestBestContactName(t *testing.T) {
 	if BestContactName(types.ContactInfo{Found: false, FullName: "x"}) != "" {
 		t.Fatalf("expected empty for not found")
@@ -28,6 +28,19 @@ func (c *Client) SetGroupName(ctx context.Context, jid types.JID, name string) e
 	return cli.SetGroupName(ctx, jid, name)
 }
 
+// ParticipantRole maps a participant's flags to the role stored for it:
+// superadmin, admin or member.
+func ParticipantRole(p types.GroupParticipant) string {
+	switch {
+	case p.IsSuperAdmin:
+		return "superadmin"
+	case p.IsAdmin:
+		return "admin"
+	default:
+		return "member"
+	}
+}
+
 type GroupParticipantAction string
 
 const (
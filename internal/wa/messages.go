@@ -28,12 +28,14 @@ type ParsedMessage struct {
 	Timestamp      time.Time
 	FromMe         bool
 	Text           string
+	DisplayText    string // summary of locations, contacts and polls
 	Media          *Media
 	PushName       string
 	ReplyToID      string
 	ReplyToDisplay string
 	ReactionToID   string
 	ReactionEmoji  string
+	ForwardedScore uint32 // 0 unless forwarded
 }
 
 func ParseLiveMessage(evt *events.Message) ParsedMessage {
@@ -98,6 +100,8 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 		pm.Text = m.GetConversation()
 	case m.GetExtendedTextMessage() != nil:
 		pm.Text = m.GetExtendedTextMessage().GetText()
+	case PollCreation(m) != nil:
+		pm.Text = PollCreation(m).GetName()
 	}
 
 	if img := m.GetImageMessage(); img != nil {
@@ -181,6 +185,11 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 		}
 	}
 
+	if pm.Media == nil && (m.GetLocationMessage() != nil || m.GetContactMessage() != nil ||
+		m.GetContactsArrayMessage() != nil || PollCreation(m) != nil) {
+		pm.DisplayText = displayTextForProto(m)
+	}
+
 	if ctx := contextInfoForMessage(m); ctx != nil {
 		if id := strings.TrimSpace(ctx.GetStanzaID()); id != "" {
 			pm.ReplyToID = id
@@ -188,6 +197,7 @@ func extractWAProto(m *waProto.Message, pm *ParsedMessage) {
 		if quoted := ctx.GetQuotedMessage(); quoted != nil {
 			pm.ReplyToDisplay = strings.TrimSpace(displayTextForProto(quoted))
 		}
+		pm.ForwardedScore = ctx.GetForwardingScore()
 	}
 }
 
@@ -234,6 +244,19 @@ func contextInfoForMessage(m *waProto.Message) *waProto.ContextInfo {
 	return nil
 }
 
+// PollCreation returns the poll in m, whichever version carries it.
+func PollCreation(m *waProto.Message) *waProto.PollCreationMessage {
+	if m == nil {
+		return nil
+	}
+	for _, p := range []*waProto.PollCreationMessage{m.GetPollCreationMessage(), m.GetPollCreationMessageV2(), m.GetPollCreationMessageV3()} {
+		if p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
 func displayTextForProto(m *waProto.Message) string {
 	if m == nil {
 		return ""
@@ -266,6 +289,9 @@ func displayTextForProto(m *waProto.Message) string {
 	if contacts := m.GetContactsArrayMessage(); contacts != nil {
 		return "Sent contacts"
 	}
+	if poll := PollCreation(m); poll != nil {
+		return "Poll: " + strings.TrimSpace(poll.GetName())
+	}
 
 	if text := strings.TrimSpace(m.GetConversation()); text != "" {
 		return text
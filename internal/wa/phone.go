@@ -0,0 +1,70 @@
+package wa
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ErrNotAPhone is returned by JIDToPhone for JIDs that don't belong to a
+// phone number (groups, broadcast lists, LIDs, ...).
+var ErrNotAPhone = errors.New("JID is not a phone number")
+
+// ErrInvalidPhoneNumber is returned when a recipient looks like a phone
+// number but is not a dialable one.
+type ErrInvalidPhoneNumber struct {
+	Input  string
+	Reason string
+}
+
+func (e *ErrInvalidPhoneNumber) Error() string {
+	return fmt.Sprintf("invalid phone number %q: %s", e.Input, e.Reason)
+}
+
+// NormalizePhone validates s and returns it in E.164 form (e.g.
+// "+14155552671"). Numbers without a leading "+" are read as international
+// unless WACLI_PHONE_REGION (e.g. "US") is set, in which case they are read
+// as national numbers of that region.
+func NormalizePhone(s string) (string, error) {
+	input := strings.TrimSpace(s)
+	raw := input
+	region := strings.ToUpper(strings.TrimSpace(os.Getenv("WACLI_PHONE_REGION")))
+	if !strings.HasPrefix(raw, "+") {
+		if region == "" {
+			raw = "+" + raw
+		} else if strings.HasPrefix(raw, "00") {
+			raw = "+" + strings.TrimPrefix(raw, "00")
+		}
+	}
+	if region == "" {
+		region = "ZZ"
+	}
+
+	num, err := libphonenumber.Parse(raw, region)
+	if err != nil {
+		return "", &ErrInvalidPhoneNumber{Input: input, Reason: err.Error()}
+	}
+	if !libphonenumber.IsValidNumber(num) {
+		return "", &ErrInvalidPhoneNumber{Input: input, Reason: "not a valid number"}
+	}
+	return libphonenumber.Format(num, libphonenumber.E164), nil
+}
+
+// JIDToPhone returns the E.164 phone number of a user JID, e.g.
+// 15551234567@s.whatsapp.net -> +15551234567. It is the inverse of
+// ParseUserOrJID for phone numbers.
+func JIDToPhone(jid types.JID) (string, error) {
+	if jid.Server != types.DefaultUserServer || jid.User == "" {
+		return "", ErrNotAPhone
+	}
+	for _, r := range jid.User {
+		if r < '0' || r > '9' {
+			return "", ErrNotAPhone
+		}
+	}
+	return "+" + jid.User, nil
+}